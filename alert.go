@@ -0,0 +1,138 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxAlertTasks bounds the failing tasks listed in the details of an alert
+const maxAlertTasks = 50
+
+// alertFlags holds the flags shared by the notify targets that raise an
+// alert when a gate fails
+type alertFlags struct {
+	inputs   *inputFlags
+	gates    *gateFlags
+	dedupKey *string
+	runURL   *string
+	resolve  *bool
+}
+
+// addAlertFlags registers the alert flags on fs
+func addAlertFlags(fs *flag.FlagSet) *alertFlags {
+	return &alertFlags{
+		inputs:   addInputFlags(fs),
+		gates:    addGateFlags(fs),
+		dedupKey: fs.String("dedup-key", pipelineDedupKey(), "key of the alert, so that the failures of every run of a pipeline update one incident (default mcpchecker/ followed by the repository and workflow or job of the CI pipeline)"),
+		runURL:   fs.String("run-url", pipelineURL(), "link to the run in the alert (default the URL of the CI pipeline)"),
+		resolve:  fs.Bool("resolve", true, "resolve the alert of --dedup-key when the gates pass"),
+	}
+}
+
+// pipelineDedupKey returns the default --dedup-key, which identifies the CI
+// pipeline in GitHub Actions, GitLab CI and Jenkins
+func pipelineDedupKey() string {
+	var pipeline []string
+	switch {
+	case os.Getenv("GITHUB_REPOSITORY") != "":
+		pipeline = []string{os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_WORKFLOW")}
+	case os.Getenv("CI_PROJECT_PATH") != "":
+		pipeline = []string{os.Getenv("CI_PROJECT_PATH"), os.Getenv("CI_JOB_NAME")}
+	case os.Getenv("JOB_NAME") != "":
+		pipeline = []string{os.Getenv("JOB_NAME")}
+	}
+	key := "mcpchecker"
+	for _, part := range pipeline {
+		if part != "" {
+			key += "/" + part
+		}
+	}
+	return key
+}
+
+// pipelineURL returns the default --run-url, the page of the CI pipeline in
+// GitHub Actions, GitLab CI and Jenkins
+func pipelineURL() string {
+	if os.Getenv("GITHUB_RUN_ID") != "" {
+		return fmt.Sprintf("%s/%s/actions/runs/%s", os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"), os.Getenv("GITHUB_RUN_ID"))
+	}
+	return cmp.Or(os.Getenv("CI_PIPELINE_URL"), os.Getenv("BUILD_URL"))
+}
+
+// gateAlert is the outcome of the gates that an alert reports
+type gateAlert struct {
+	dedupKey string
+	runURL   string
+	tests    []publishedTest
+	total    summaryRow
+	// gateErr is the failed gates, or nil when the alert resolves
+	gateErr error
+}
+
+// evaluate checks the gates against the results of the inputs, once the
+// flags of the target named target are parsed
+func (f *alertFlags) evaluate(ctx context.Context, target string, inputs []string) (gateAlert, error) {
+	opts, err := f.inputs.options()
+	if err != nil {
+		return gateAlert{}, err
+	}
+	if *f.gates.failOn == failOnNever && *f.gates.minPassRate == "" && *f.gates.baseline == "" {
+		return gateAlert{}, newUsageError("notify %s requires a gate: --fail-on, --min-pass-rate or --baseline", target)
+	}
+	if *f.dedupKey == "" {
+		return gateAlert{}, newUsageError("--dedup-key must not be empty")
+	}
+	conv, err := newPublishConverter()
+	if err != nil {
+		return gateAlert{}, err
+	}
+	gateOpts, err := f.gates.options(ctx, opts, conv)
+	if err != nil {
+		return gateAlert{}, err
+	}
+	run, tests, err := loadPublishedTests(ctx, inputs, opts, conv)
+	if err != nil {
+		return gateAlert{}, err
+	}
+	report, err := conv.ConvertContext(ctx, run)
+	if err != nil {
+		return gateAlert{}, err
+	}
+	rows := summaryRows(tests)
+	return gateAlert{dedupKey: *f.dedupKey, runURL: *f.runURL, tests: tests, total: rows[len(rows)-1], gateErr: gateOpts.check(report)}, nil
+}
+
+// summary headlines the alert, e.g. "MCP checker gate failed: 18 of 20
+// tasks passed"
+func (a gateAlert) summary() string {
+	return fmt.Sprintf("%s gate failed: %s", defaultPublishName, passedTitle(a.total))
+}
+
+// failingTasks lists the failed and errored tasks, with their outcome, e.g.
+// "create-pod (failure)", and how many more were left out
+func (a gateAlert) failingTasks() []string {
+	var tasks []string
+	for _, test := range a.tests {
+		if test.failureComment() != "" {
+			tasks = append(tasks, fmt.Sprintf("%s (%s)", test.Result.TaskName, test.Outcome))
+		}
+	}
+	if len(tasks) > maxAlertTasks {
+		tasks = append(tasks[:maxAlertTasks], fmt.Sprintf("and %d more", len(tasks)-maxAlertTasks))
+	}
+	return tasks
+}
+
+// description details the alert for people: the failed gates and failing
+// tasks
+func (a gateAlert) description() string {
+	description := a.gateErr.Error()
+	if tasks := a.failingTasks(); len(tasks) > 0 {
+		description += "\n\nFailing tasks:\n  " + strings.Join(tasks, "\n  ")
+	}
+	return description
+}
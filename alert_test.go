@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestPipelineDedupKey(t *testing.T) {
+	for _, tc := range []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"GITHUB_REPOSITORY": "o/r", "GITHUB_WORKFLOW": "nightly"}, "mcpchecker/o/r/nightly"},
+		{map[string]string{"CI_PROJECT_PATH": "group/project", "CI_JOB_NAME": "evals"}, "mcpchecker/group/project/evals"},
+		{map[string]string{"JOB_NAME": "mcp-evals"}, "mcpchecker/mcp-evals"},
+		{nil, "mcpchecker"},
+	} {
+		for _, name := range []string{"GITHUB_REPOSITORY", "GITHUB_WORKFLOW", "CI_PROJECT_PATH", "CI_JOB_NAME", "JOB_NAME"} {
+			t.Setenv(name, tc.env[name])
+		}
+		if got := pipelineDedupKey(); got != tc.want {
+			t.Errorf("pipelineDedupKey with %v = %q, want %q", tc.env, got, tc.want)
+		}
+	}
+}
+
+func TestGateAlertFailingTasks(t *testing.T) {
+	alert := gateAlert{gateErr: gateError{msg: "Tests failed"}}
+	for i := range maxAlertTasks + 2 {
+		alert.tests = append(alert.tests, publishedTest{
+			Result:   converter.MCPTestResult{TaskName: fmt.Sprintf("t%d", i)},
+			TestCase: converter.JUnitTestCase{Error: &converter.JUnitError{Message: "boom"}},
+			Outcome:  outcomeError,
+		})
+	}
+	tasks := alert.failingTasks()
+	if len(tasks) != maxAlertTasks+1 || tasks[0] != "t0 (error)" || tasks[maxAlertTasks] != "and 2 more" {
+		t.Errorf("failingTasks = %q", tasks)
+	}
+	if description := alert.description(); !strings.HasPrefix(description, "Tests failed\n\nFailing tasks:\n  t0 (error)\n") {
+		t.Errorf("description = %q", description)
+	}
+}
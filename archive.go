@@ -0,0 +1,126 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// isArchive reports whether a file name looks like a tar or zip archive
+func isArchive(filename string) bool {
+	name := strings.ToLower(filename)
+	for _, ext := range []string{".tar", ".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// loadArchive parses every results file in a tar, gzip-compressed tar or zip
+// archive, in archive order, into a single run. Members are picked and their
+// format detected exactly as for files in a directory.
+func loadArchive(ctx context.Context, filename string, opts inputOptions) (converter.TestRun, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return converter.TestRun{}, fmt.Errorf("opening file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	var run converter.TestRun
+	found := false
+	add := func(member string, r io.Reader) error {
+		if !isResultsFile(member) {
+			return nil
+		}
+		memberOpts := opts
+		if memberOpts.Format == converter.FormatAuto {
+			memberOpts.Format = inputFormatForFile(member)
+		}
+		start := time.Now()
+		memberRun, err := converter.ParseContext(ctx, r, memberOpts.ParseOptions)
+		if err != nil {
+			return fmt.Errorf("parsing %s:%s: %w", filename, member, err)
+		}
+		memberRun.SetSource(filename + ":" + member)
+		logParsed(filename+":"+member, memberRun, start)
+		run.Merge(memberRun)
+		found = true
+		return nil
+	}
+
+	if strings.HasSuffix(strings.ToLower(filename), ".zip") {
+		err = walkZip(file, add)
+	} else {
+		err = walkTar(file, add)
+	}
+	if err != nil {
+		return run, err
+	}
+	if !found {
+		return run, fmt.Errorf("no results files found in %s", filename)
+	}
+	return run, nil
+}
+
+// walkTar calls fn for every regular file of a tar archive, which may be gzip-compressed
+func walkTar(file *os.File, fn func(member string, r io.Reader) error) error {
+	reader, err := converter.MaybeDecompress(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("reading archive %s: %w", file.Name(), err)
+	}
+
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive %s: %w", file.Name(), err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := fn(path.Clean(header.Name), archive); err != nil {
+			return err
+		}
+	}
+}
+
+// walkZip calls fn for every regular file of a zip archive
+func walkZip(file *os.File, fn func(member string, r io.Reader) error) error {
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("reading archive %s: %w", file.Name(), err)
+	}
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return fmt.Errorf("reading archive %s: %w", file.Name(), err)
+	}
+
+	for _, member := range archive.File {
+		if !member.Mode().IsRegular() {
+			continue
+		}
+		r, err := member.Open()
+		if err != nil {
+			return fmt.Errorf("reading archive %s: %w", file.Name(), err)
+		}
+		err = fn(path.Clean(member.Name), r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
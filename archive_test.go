@@ -0,0 +1,123 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type archiveMember struct {
+	name    string
+	content string
+}
+
+func tarArchive(t *testing.T, members []archiveMember) string {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := tar.NewWriter(&buf)
+	for _, member := range members {
+		if strings.HasSuffix(member.name, "/") {
+			if err := archive.WriteHeader(&tar.Header{Name: member.name, Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		header := &tar.Header{Name: member.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(member.content))}
+		if err := archive.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := archive.Write([]byte(member.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func zipArchive(t *testing.T, members []archiveMember) string {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, member := range members {
+		w, err := archive.Create(member.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(member.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestLoadArchive(t *testing.T) {
+	members := []archiveMember{
+		{name: "shards/"},
+		{name: "shards/1/results.json", content: "[" + resultB + "]"},
+		{name: "shards/2/results.jsonl.gz", content: gzipped(t, resultA)},
+		{name: "shards/2/notes.txt", content: "not results"},
+		{name: "shards/3/results.yaml", content: "taskName: c\n"},
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "tar", file: "results.tar", content: tarArchive(t, members), want: "b,a,c"},
+		{name: "tar.gz", file: "results.tar.gz", content: gzipped(t, tarArchive(t, members)), want: "b,a,c"},
+		{name: "tgz", file: "results.tgz", content: gzipped(t, tarArchive(t, members)), want: "b,a,c"},
+		{name: "zip", file: "results.zip", content: zipArchive(t, members[1:]), want: "b,a,c"},
+		{name: "no results files", file: "empty.tar", content: tarArchive(t, members[3:4]), wantErr: true},
+		{name: "malformed member", file: "bad.zip", content: zipArchive(t, []archiveMember{{name: "x.json", content: "[{"}}), wantErr: true},
+		{name: "not an archive", file: "broken.zip", content: "plain text", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			run, err := loadInput(context.Background(), path, autoInput)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("loadInput() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadInput() error = %v", err)
+			}
+			if got := strings.Join(taskNames(run), ","); got != tt.want {
+				t.Errorf("task names = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadArchiveMemberErrorNamesMember(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.zip")
+	content := zipArchive(t, []archiveMember{{name: "ok.json", content: resultA}, {name: "shard/bad.json", content: "[{"}})
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := loadInput(context.Background(), path, autoInput)
+	if err == nil || !strings.Contains(err.Error(), "results.zip:shard/bad.json") {
+		t.Errorf("error = %v, want it to name the archive member", err)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// badgeColors are the colors of a badge by minimum pass rate, from the
+// highest, as shields.io colors its badges
+var badgeColors = []struct {
+	minRate float64
+	color   string
+}{
+	{0.9, "#4c1"},
+	{0.75, "#97ca00"},
+	{0.6, "#dfb317"},
+	{0.4, "#fe7d37"},
+	{0, "#e05d44"},
+}
+
+// badgeNoDataColor colors badges of reports without tasks that ran
+const badgeNoDataColor = "#9f9f9f"
+
+// badgeTemplate lays out a flat badge: the label on a grey background, the
+// value on a colored one, both with a drop shadow. Its arguments are the
+// total width, the width of the label, the color, the width of the value,
+// the center of the label and of the value, then the label and the value.
+const badgeTemplate = `<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[7]s: %[8]s">
+  <title>%[7]s: %[8]s</title>
+  <linearGradient id="s" x2="0" y2="100%%">
+    <stop offset="0" stop-color="#bbb" stop-opacity=".1"/>
+    <stop offset="1" stop-opacity=".1"/>
+  </linearGradient>
+  <clipPath id="r">
+    <rect width="%[1]d" height="20" rx="3" fill="#fff"/>
+  </clipPath>
+  <g clip-path="url(#r)">
+    <rect width="%[2]d" height="20" fill="#555"/>
+    <rect x="%[2]d" width="%[4]d" height="20" fill="%[3]s"/>
+    <rect width="%[1]d" height="20" fill="url(#s)"/>
+  </g>
+  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+    <text x="%[5]d" y="15" fill="#010101" fill-opacity=".3">%[7]s</text>
+    <text x="%[5]d" y="14">%[7]s</text>
+    <text x="%[6]d" y="15" fill="#010101" fill-opacity=".3">%[8]s</text>
+    <text x="%[6]d" y="14">%[8]s</text>
+  </g>
+</svg>
+`
+
+// runBadge implements the badge command
+func runBadge(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	output := fs.String("output", "", "write the badge to this file, or an s3:// or gs:// URI, instead of stdout")
+	difficulty := fs.String("difficulty", "", "show the pass rate of the tasks of this difficulty level instead of all tasks")
+	label := fs.String("label", "", `text of the left part of the badge (default "pass rate", or "<difficulty> tasks" with --difficulty)`)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	run, err := loadInputs(ctx, fs.Args(), opts)
+	if err != nil {
+		return err
+	}
+	conv, err := converter.New(converter.WithoutSystemOut(), converter.WithoutSystemErr())
+	if err != nil {
+		return err
+	}
+	report, err := conv.ConvertContext(ctx, run)
+	if err != nil {
+		return err
+	}
+
+	text := *label
+	if text == "" {
+		text = "pass rate"
+		if *difficulty != "" {
+			text = *difficulty + " tasks"
+		}
+	}
+	passed, total := passCounts(report)
+	key := strings.ToLower(*difficulty)
+	return writeOutput(ctx, *output, []byte(passRateBadge(text, passed[key], total[key])))
+}
+
+// passRateBadge returns an SVG badge showing passed out of total as a
+// percentage, colored by the pass rate, or "n/a" when total is 0
+func passRateBadge(label string, passed, total int) string {
+	value, color := "n/a", badgeNoDataColor
+	if total > 0 {
+		rate := float64(passed) / float64(total)
+		value = strings.TrimSuffix(fmt.Sprintf("%.1f", rate*100), ".0") + "%"
+		for _, c := range badgeColors {
+			if rate >= c.minRate {
+				color = c.color
+				break
+			}
+		}
+	}
+	labelWidth, valueWidth := badgeTextWidth(label)+10, badgeTextWidth(value)+10
+	return fmt.Sprintf(badgeTemplate, labelWidth+valueWidth, labelWidth, color, valueWidth,
+		labelWidth/2, labelWidth+valueWidth/2, html.EscapeString(label), html.EscapeString(value))
+}
+
+// badgeTextWidth estimates the width in pixels of text in 11px Verdana:
+// narrow characters take 4 pixels, wide ones 10 and the others 7
+func badgeTextWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune(" .,:;!|'ijlft()[]/", r):
+			width += 4
+		case strings.ContainsRune("mwMW%@", r):
+			width += 10
+		default:
+			width += 7
+		}
+	}
+	return width
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPassRateBadge(t *testing.T) {
+	for _, tc := range []struct {
+		passed, total int
+		value, color  string
+	}{
+		{10, 10, "100%", "#4c1"},
+		{9, 10, "90%", "#4c1"},
+		{8, 10, "80%", "#97ca00"},
+		{2, 3, "66.7%", "#dfb317"},
+		{1, 2, "50%", "#fe7d37"},
+		{0, 4, "0%", "#e05d44"},
+		{0, 0, "n/a", badgeNoDataColor},
+	} {
+		badge := passRateBadge("pass rate", tc.passed, tc.total)
+		if !strings.Contains(badge, `aria-label="pass rate: `+tc.value+`"`) || !strings.Contains(badge, `fill="`+tc.color+`"`) {
+			t.Errorf("badge of %d/%d does not show %s in %s:\n%s", tc.passed, tc.total, tc.value, tc.color, badge)
+		}
+	}
+
+	badge := passRateBadge("<hard> & co", 1, 1)
+	var svg struct {
+		XMLName xml.Name `xml:"svg"`
+		Title   string   `xml:"title"`
+	}
+	if err := xml.Unmarshal([]byte(badge), &svg); err != nil {
+		t.Fatalf("badge is not valid XML: %v\n%s", err, badge)
+	}
+	if svg.Title != "<hard> & co: 100%" {
+		t.Errorf("badge title = %q, want the unescaped label and value", svg.Title)
+	}
+}
+
+func TestBadge(t *testing.T) {
+	dir := t.TempDir()
+	results := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(results, []byte(`[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"Hard","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"c","difficulty":"hard","taskPassed":false,"taskError":"boom"},
+		{"taskName":"d","difficulty":"hard","taskSkipped":true}
+	]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, `aria-label="pass rate: 66.7%"`},
+		{[]string{"--difficulty", "hard"}, `aria-label="hard tasks: 50%"`},
+		{[]string{"--difficulty", "medium", "--label", "medium"}, `aria-label="medium: n/a"`},
+	} {
+		output := filepath.Join(dir, "badge.svg")
+		args := append(append([]string{"badge", "--output", output}, tc.args...), results)
+		if err := runCLI(context.Background(), args); err != nil {
+			t.Fatalf("runCLI(%q): %v", args, err)
+		}
+		badge, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(badge), tc.want) {
+			t.Errorf("badge of %q does not contain %s:\n%s", tc.args, tc.want, badge)
+		}
+	}
+}
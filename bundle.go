@@ -0,0 +1,489 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Layout of the --bundle directory
+const (
+	bundleJUnitDir       = "junit"
+	bundleReportFile     = "report.html"
+	bundleSummaryFile    = "summary.json"
+	bundleAttachmentsDir = "attachments"
+	bundleIndexFile      = "index.html"
+)
+
+// bundleFileName matches the characters of suite names replaced in the
+// names of the JUnit files of the bundle
+var bundleFileName = regexp.MustCompile(`[^A-Za-z0-9._]+`)
+
+// attachmentMarker matches the [[ATTACHMENT|path]] markers of system-out
+var attachmentMarker = regexp.MustCompile(`\[\[ATTACHMENT\|([^\]\n]+)\]\]`)
+
+// bundleSummary is the summary.json of a bundle
+type bundleSummary struct {
+	RunID     string `json:"runId,omitempty"`
+	StartedAt string `json:"startedAt,omitempty"`
+	// Environment describes where the run took place, as in summary
+	Environment          []converter.JUnitProperty `json:"environment,omitempty"`
+	Total                bundleSummaryRow          `json:"total"`
+	Difficulties         []bundleSummaryRow        `json:"difficulties"`
+	Failures             []bundleFailedTest        `json:"failures"`
+	TopFailingAssertions []bundleAssertion         `json:"topFailingAssertions,omitempty"`
+	Files                map[string][]string       `json:"files"`
+}
+
+// bundleSummaryRow holds the counts of a difficulty level, or of all tasks,
+// with the LLM usage their results recorded
+type bundleSummaryRow struct {
+	Name     string  `json:"name"`
+	Tests    int     `json:"tests"`
+	Passed   int     `json:"passed"`
+	Failed   int     `json:"failed"`
+	Errors   int     `json:"errors"`
+	Skipped  int     `json:"skipped"`
+	PassRate float64 `json:"passRate"`
+	Tokens   int     `json:"tokens,omitempty"`
+	CostUSD  float64 `json:"costUSD,omitempty"`
+}
+
+// bundleFailedTest is a failed or errored testcase of summary.json
+type bundleFailedTest struct {
+	Suite     string `json:"suite"`
+	Name      string `json:"name"`
+	Classname string `json:"classname"`
+	Outcome   string `json:"outcome"`
+	Message   string `json:"message"`
+	TraceID   string `json:"traceId,omitempty"`
+	TraceURL  string `json:"traceUrl,omitempty"`
+}
+
+// bundleAssertion is an assertion that failed in the most tasks, with the
+// tasks it failed in
+type bundleAssertion struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tasks []string `json:"tasks"`
+}
+
+// bundleRow is a row of the pass rates of the HTML report
+type bundleRow struct {
+	digestRow
+	Tokens int
+	Cost   string
+}
+
+// bundleSuite is a testsuite of the HTML report
+type bundleSuite struct {
+	Name                                   string
+	Tests, Failures, Errors, Skipped, Pass int
+	Tokens                                 int
+	Cost                                   string
+	TestCases                              []bundleTestCase
+}
+
+// bundleTestCase is a testcase of the HTML report
+type bundleTestCase struct {
+	Name, Classname, Outcome, Message, Details, SystemOut string
+	TraceID, TraceURL, Cost                               string
+	Tokens                                                int
+	Attachments                                           []bundleLink
+}
+
+// bundleLink links a file of the bundle, relative to the page linking it
+type bundleLink struct {
+	Name, Href string
+}
+
+// prepareBundle validates --bundle and points the attachments of the
+// conversion to the attachments directory of the bundle
+func prepareBundle(dir string, conversion *convertFlags) error {
+	if isCloudURI(dir) {
+		return newUsageError("--bundle must be a local directory")
+	}
+	if *conversion.attachmentsDir != "" {
+		return newUsageError("--bundle writes attachments to its own %s directory and cannot be combined with --attachments-dir", bundleAttachmentsDir)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("bundle: %w", err)
+	}
+	*conversion.attachmentsDir = filepath.Join(dir, bundleAttachmentsDir)
+	return nil
+}
+
+// writeBundle writes the artifacts of a converted run to dir, so that CI
+// archives a single directory: the JUnit XML of every suite in junit/, an
+// HTML report, summary.json, and an index of the attachments written during
+// the conversion, the copies of the artifact files of the results included.
+// Like summary, the report and summary.json find the result of a testcase,
+// and with it its token usage, cost and failed assertions, by task name.
+func writeBundle(ctx context.Context, dir string, run converter.TestRun, report converter.JUnitTestSuites, conv *converter.Converter) error {
+	junitFiles, err := writeBundleJUnit(ctx, dir, report, conv)
+	if err != nil {
+		return err
+	}
+	attachments, err := bundleAttachments(dir)
+	if err != nil {
+		return err
+	}
+	results := make(map[string]converter.MCPTestResult, len(run.Results))
+	for _, result := range run.Results {
+		results[result.TaskName] = result
+	}
+
+	var testCases []converter.JUnitTestCase
+	for _, suite := range report.Suites {
+		testCases = append(testCases, suite.AllTestCases()...)
+	}
+	rows := difficultyRows(testCases)
+	for _, testCase := range testCases {
+		result, ok := results[testCase.Name]
+		if !ok {
+			continue
+		}
+		difficulty := cmp.Or(testCase.Difficulty(), converter.UnknownGroup)
+		for i := range rows[:len(rows)-1] {
+			if rows[i].name == difficulty {
+				rows[i].addUsage(result)
+			}
+		}
+		rows[len(rows)-1].addUsage(result)
+	}
+	summary := bundleSummary{
+		RunID:       run.RunID,
+		StartedAt:   run.StartedAt,
+		Environment: run.Environment.Properties(),
+		Total:       newBundleSummaryRow(rows[len(rows)-1]),
+		Files: map[string][]string{
+			"junit":       junitFiles,
+			"report":      {bundleReportFile},
+			"attachments": {bundleAttachmentsDir + "/" + bundleIndexFile},
+		},
+		Difficulties: []bundleSummaryRow{},
+		Failures:     []bundleFailedTest{},
+	}
+	for _, row := range rows[:len(rows)-1] {
+		summary.Difficulties = append(summary.Difficulties, newBundleSummaryRow(row))
+	}
+	// assertions maps the name of every failed assertion to the tasks it failed in
+	assertions := make(map[string][]string)
+	var suites []bundleSuite
+	for _, suite := range report.Suites {
+		page := bundleSuite{Name: suite.Name, Tests: suite.Tests, Failures: suite.Failures, Errors: suite.Errors, Skipped: suite.Skipped}
+		page.Pass = page.Tests - page.Failures - page.Errors - page.Skipped
+		usage := summaryRow{}
+		for _, testCase := range suite.AllTestCases() {
+			result, ok := results[testCase.Name]
+			entry := newBundleTestCase(dir, testCase)
+			if ok {
+				entry.Tokens, entry.Cost = bundleUsage(result)
+				usage.addUsage(result)
+			}
+			page.TestCases = append(page.TestCases, entry)
+			if entry.Outcome == outcomeFailure || entry.Outcome == outcomeError {
+				summary.Failures = append(summary.Failures, bundleFailedTest{Suite: suite.Name, Name: testCase.Name,
+					Classname: testCase.Classname, Outcome: entry.Outcome, Message: entry.Message,
+					TraceID: entry.TraceID, TraceURL: entry.TraceURL})
+			}
+			if ok && testCase.Failure != nil {
+				for _, name := range result.FailedAssertions() {
+					assertions[name] = append(assertions[name], result.TaskName)
+				}
+			}
+		}
+		page.Tokens = usage.tokens
+		if usage.cost > 0 {
+			page.Cost = "$" + converter.FormatCost(usage.cost)
+		}
+		suites = append(suites, page)
+	}
+	for _, name := range topAssertionNames(assertions) {
+		summary.TopFailingAssertions = append(summary.TopFailingAssertions,
+			bundleAssertion{Name: name, Count: len(assertions[name]), Tasks: assertions[name]})
+	}
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(ctx, filepath.Join(dir, bundleSummaryFile), append(data, '\n')); err != nil {
+		return err
+	}
+
+	// The usage columns are only shown for results that record it
+	usage := slices.ContainsFunc(run.Results, func(result converter.MCPTestResult) bool {
+		return result.TokenUsage != nil || result.CostUSD > 0
+	})
+	var rowData []bundleRow
+	for _, row := range rows {
+		rowData = append(rowData, bundleRow{digestRow: newDigestRow(row), Tokens: row.tokens, Cost: "$" + converter.FormatCost(row.cost)})
+	}
+	var html strings.Builder
+	if err := bundleReportTemplate.Execute(&html, map[string]interface{}{
+		"Title":       cmp.Or(report.Name, defaultPublishName) + ": " + passedTitle(rows[len(rows)-1]),
+		"RunID":       run.RunID,
+		"StartedAt":   run.StartedAt,
+		"Environment": summary.Environment,
+		"Rows":        rowData,
+		"Usage":       usage,
+		"Assertions":  summary.TopFailingAssertions,
+		"Suites":      suites,
+		"Imported":    len(report.Imported),
+		"JUnit":       junitFiles,
+		"Attachments": bundleAttachmentsDir + "/" + bundleIndexFile,
+	}); err != nil {
+		return err
+	}
+	if err := writeOutput(ctx, filepath.Join(dir, bundleReportFile), []byte(html.String())); err != nil {
+		return err
+	}
+
+	var index strings.Builder
+	if err := bundleIndexTemplate.Execute(&index, attachments); err != nil {
+		return err
+	}
+	return writeOutput(ctx, filepath.Join(dir, bundleAttachmentsDir, bundleIndexFile), []byte(index.String()))
+}
+
+// writeBundleJUnit writes every suite of report, the imported ones
+// included, to a JUnit XML document of its own named TEST-<suite>.xml, and
+// returns their paths relative to dir
+func writeBundleJUnit(ctx context.Context, dir string, report converter.JUnitTestSuites, conv *converter.Converter) ([]string, error) {
+	var docs []converter.JUnitTestSuites
+	var names []string
+	for _, suite := range report.Suites {
+		docs = append(docs, converter.JUnitTestSuites{Name: report.Name, Properties: report.Properties, Suites: []converter.JUnitTestSuite{suite}})
+		names = append(names, suite.Name)
+	}
+	for _, suite := range report.Imported {
+		name := "imported"
+		for _, attr := range suite.Attrs {
+			if attr.Name.Local == "name" {
+				name = attr.Value
+			}
+		}
+		docs = append(docs, converter.JUnitTestSuites{Name: report.Name, Imported: []converter.ImportedSuite{suite}})
+		names = append(names, name)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, bundleJUnitDir), 0o755); err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	used := make(map[string]bool)
+	var files []string
+	for i, doc := range docs {
+		doc.SetAggregates()
+		data, err := conv.Render(doc)
+		if err != nil {
+			return nil, err
+		}
+		name := cmp.Or(strings.Trim(bundleFileName.ReplaceAllString(names[i], "-"), "-."), "suite")
+		unique := name
+		for n := 2; used[unique]; n++ {
+			unique = name + "-" + strconv.Itoa(n)
+		}
+		used[unique] = true
+		file := bundleJUnitDir + "/TEST-" + unique + ".xml"
+		if err := writeOutput(ctx, filepath.Join(dir, file), data); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// newBundleSummaryRow returns the bundleSummaryRow of the counts of row
+func newBundleSummaryRow(row summaryRow) bundleSummaryRow {
+	return bundleSummaryRow{Name: row.name, Tests: row.tests, Passed: row.passed, Failed: row.failed,
+		Errors: row.errored, Skipped: row.skipped, PassRate: row.passRate(), Tokens: row.tokens, CostUSD: row.cost}
+}
+
+// bundleUsage returns the tokens and the formatted cost of a result, "" when
+// it recorded none
+func bundleUsage(result converter.MCPTestResult) (int, string) {
+	row := summaryRow{}
+	row.addUsage(result)
+	if row.cost == 0 {
+		return row.tokens, ""
+	}
+	return row.tokens, "$" + converter.FormatCost(row.cost)
+}
+
+// testCaseProperty returns the value of the named property of testCase, or
+// "" when it has none
+func testCaseProperty(testCase converter.JUnitTestCase, name string) string {
+	if testCase.Properties == nil {
+		return ""
+	}
+	for _, property := range testCase.Properties.Properties {
+		if property.Name == name {
+			return property.Value
+		}
+	}
+	return ""
+}
+
+// newBundleTestCase returns the entry of testCase in the HTML report, with
+// its trace and links to its attachments relative to the bundle directory
+// dir
+func newBundleTestCase(dir string, testCase converter.JUnitTestCase) bundleTestCase {
+	entry := bundleTestCase{Name: testCase.Name, Classname: testCase.Classname, Outcome: historyOutcome(testCase), SystemOut: testCase.SystemOut,
+		TraceID: testCaseProperty(testCase, converter.TraceIDProperty), TraceURL: testCaseProperty(testCase, converter.TraceURLProperty)}
+	switch {
+	case testCase.Error != nil:
+		entry.Message, entry.Details = testCase.Error.Message, strings.TrimSpace(testCase.Error.Content)
+	case testCase.Failure != nil:
+		entry.Message, entry.Details = testCase.Failure.Message, strings.TrimSpace(testCase.Failure.Content)
+	case testCase.Skipped != nil:
+		entry.Message = testCase.Skipped.Message
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return entry
+	}
+	for _, match := range attachmentMarker.FindAllStringSubmatch(testCase.SystemOut, -1) {
+		if rel, err := filepath.Rel(absDir, match[1]); err == nil && filepath.IsLocal(rel) {
+			entry.Attachments = append(entry.Attachments, bundleLink{Name: filepath.Base(rel), Href: filepath.ToSlash(rel)})
+		}
+	}
+	return entry
+}
+
+// bundleAttachments lists the files of the attachments directory of the
+// bundle, per task directory, relative to the attachments directory
+func bundleAttachments(dir string) (map[string][]bundleLink, error) {
+	root := filepath.Join(dir, bundleAttachmentsDir)
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	tasks, err := os.ReadDir(root)
+	if err != nil {
+		return nil, fmt.Errorf("bundle: %w", err)
+	}
+	attachments := make(map[string][]bundleLink)
+	for _, task := range tasks {
+		if !task.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(root, task.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("bundle: %w", err)
+		}
+		for _, file := range files {
+			attachments[task.Name()] = append(attachments[task.Name()], bundleLink{Name: file.Name(), Href: task.Name() + "/" + file.Name()})
+		}
+	}
+	return attachments, nil
+}
+
+// bundleReportTemplate lays out the HTML report of a bundle: the environment,
+// the pass rate of each difficulty level with the usage when recorded, the
+// assertions that failed in the most tasks, then every suite with its
+// testcases, failures expanded
+var bundleReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; }
+pre { background: #f6f8fa; padding: 8px; white-space: pre-wrap; }
+.passed { color: #080; } .failure, .error { color: #c00; } .skipped { color: #888; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .RunID}}
+<p>Run {{.RunID}}{{if .StartedAt}}, started at {{.StartedAt}}{{end}}</p>
+{{- end}}
+{{- if .Environment}}
+<p>Environment: {{range $i, $property := .Environment}}{{if $i}} {{end}}{{$property.Name}}={{$property.Value}}{{end}}</p>
+{{- end}}
+<table>
+<tr><th align="left">Difficulty</th><th>Tests</th><th>Passed</th><th>Failed</th><th>Errors</th><th>Skipped</th><th>Pass rate</th>{{if .Usage}}<th>Tokens</th><th>Cost</th>{{end}}</tr>
+{{- range .Rows}}
+<tr><td>{{.Name}}</td><td align="right">{{.Tests}}</td><td align="right">{{.Passed}}</td><td align="right">{{.Failed}}</td><td align="right">{{.Errors}}</td><td align="right">{{.Skipped}}</td><td align="right">{{.PassRate}}</td>
+{{- if $.Usage}}<td align="right">{{.Tokens}}</td><td align="right">{{.Cost}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- if .Assertions}}
+<h2>Top failing assertions</h2>
+<table>
+<tr><th align="left">Assertion</th><th>Tasks</th><th align="left">Failed in</th></tr>
+{{- range .Assertions}}
+<tr><td>{{.Name}}</td><td align="right">{{.Count}}</td><td>{{range $i, $task := .Tasks}}{{if $i}}, {{end}}{{$task}}{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<p>JUnit XML: {{range $i, $file := .JUnit}}{{if $i}}, {{end}}<a href="{{$file}}">{{$file}}</a>{{end}}.
+Attachments: <a href="{{.Attachments}}">index</a>.{{if .Imported}} {{.Imported}} imported suites are only in the JUnit XML.{{end}}</p>
+{{- range .Suites}}
+<h2>{{.Name}}</h2>
+<p>{{.Tests}} tests: {{.Pass}} passed, {{.Failures}} failed, {{.Errors}} errors, {{.Skipped}} skipped{{if .Tokens}}, {{.Tokens}} tokens{{end}}{{if .Cost}}, {{.Cost}}{{end}}</p>
+{{- range .TestCases}}
+<details{{if or (eq .Outcome "failure") (eq .Outcome "error")}} open{{end}}>
+<summary><span class="{{.Outcome}}">{{.Outcome}}</span> {{.Name}} <small>{{.Classname}}</small></summary>
+{{- if .Message}}
+<p>{{.Message}}</p>
+{{- end}}
+{{- if .Details}}
+<pre>{{.Details}}</pre>
+{{- end}}
+{{- if .TraceURL}}
+<p>Trace: <a href="{{.TraceURL}}">{{or .TraceID .TraceURL}}</a></p>
+{{- else if .TraceID}}
+<p>Trace: {{.TraceID}}</p>
+{{- end}}
+{{- if or .Tokens .Cost}}
+<p>Usage: {{.Tokens}} tokens{{if .Cost}}, {{.Cost}}{{end}}</p>
+{{- end}}
+{{- if .Attachments}}
+<p>Attachments: {{range $i, $link := .Attachments}}{{if $i}}, {{end}}<a href="{{$link.Href}}">{{$link.Name}}</a>{{end}}</p>
+{{- end}}
+{{- if .SystemOut}}
+<details><summary>Output</summary><pre>{{.SystemOut}}</pre></details>
+{{- end}}
+</details>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// bundleIndexTemplate lists the attachments of a bundle per task directory
+var bundleIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Attachments</title>
+</head>
+<body style="font-family: sans-serif">
+<h1>Attachments</h1>
+<p><a href="../report.html">Report</a></p>
+{{- range $task, $files := .}}
+<h3>{{$task}}</h3>
+<ul>
+{{- range $files}}
+<li><a href="{{.Href}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- else}}
+<p>No attachments were written.</p>
+{{- end}}
+</body>
+</html>
+`))
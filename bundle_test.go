@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestBundle(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(filepath.Join(dir, "screenshot.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(input, []byte(`{"runId":"nightly","environment":{"model":"gpt-4o","mcpServers":{"kube":"1.2.0"}},"results":[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,"taskOutput":"pods listed",
+		 "tokenUsage":{"prompt":100,"completion":20},"costUSD":0.01,"artifacts":["screenshot.png","https://ci.example.com/a.log"]},
+		{"taskName":"b <x>","difficulty":"hard","taskPassed":false,"taskError":"boom","traceId":"t-1","tokenUsage":{"total":30}},
+		{"taskName":"c","difficulty":"hard","taskSkipped":true,"skipReason":"no prompts capability"},
+		{"taskName":"d","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false,
+		 "assertionResults":{"pods-ready":{"passed":false},"called":{"passed":true}}}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	bundle := filepath.Join(dir, "out")
+	err := runCLI(context.Background(), []string{"--bundle", bundle, "--min-pass-rate", "0.9",
+		"--trace-url-template", "https://traces.example.com/{{.TraceID}}", input})
+	var gateErr gateError
+	if !errors.As(err, &gateErr) {
+		t.Fatalf("runCLI error = %v, want the failed gate after writing the bundle", err)
+	}
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(bundle, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	var summary bundleSummary
+	if err := json.Unmarshal([]byte(read(bundleSummaryFile)), &summary); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"junit/TEST-MCP-Checker-Tests-easy.xml", "junit/TEST-MCP-Checker-Tests-hard.xml"}; !reflect.DeepEqual(summary.Files["junit"], want) {
+		t.Errorf("junit files = %q, want %q", summary.Files["junit"], want)
+	}
+	if summary.RunID != "nightly" || summary.Total.Tests != 4 || summary.Total.Passed != 1 || summary.Total.PassRate != 1.0/3 || len(summary.Difficulties) != 2 {
+		t.Errorf("summary = %+v", summary)
+	}
+	wantEnvironment := []converter.JUnitProperty{{Name: converter.ModelProperty, Value: "gpt-4o"}, {Name: converter.MCPServerPropertyPrefix + "kube", Value: "1.2.0"}}
+	if !reflect.DeepEqual(summary.Environment, wantEnvironment) {
+		t.Errorf("environment = %+v, want %+v", summary.Environment, wantEnvironment)
+	}
+	if summary.Total.Tokens != 150 || summary.Total.CostUSD != 0.01 || summary.Difficulties[0].Tokens != 120 || summary.Difficulties[1].Tokens != 30 {
+		t.Errorf("usage of %+v and %+v, want the tokens and cost of each difficulty", summary.Total, summary.Difficulties)
+	}
+	if len(summary.Failures) != 2 || summary.Failures[0].Name != "b <x>" || summary.Failures[0].Outcome != outcomeError ||
+		summary.Failures[0].TraceID != "t-1" || summary.Failures[0].TraceURL != "https://traces.example.com/t-1" {
+		t.Errorf("failures = %+v, want the error of b with its trace, and the failure of d", summary.Failures)
+	}
+	if want := []bundleAssertion{{Name: "pods-ready", Count: 1, Tasks: []string{"d"}}}; !reflect.DeepEqual(summary.TopFailingAssertions, want) {
+		t.Errorf("top failing assertions = %+v, want %+v", summary.TopFailingAssertions, want)
+	}
+
+	if hard := read("junit/TEST-MCP-Checker-Tests-hard.xml"); !strings.Contains(hard, `tests="3"`) || strings.Contains(hard, `name="a"`) {
+		t.Errorf("hard suite = %s, want the hard suite only", hard)
+	}
+	report := read(bundleReportFile)
+	for _, want := range []string{
+		"MCP checker: 1 of 3 tasks passed", "<p>Run nightly</p>", "b &lt;x&gt;", `<details open>`,
+		"<p>Environment: model=gpt-4o mcpServer.kube=1.2.0</p>",
+		`<th>Tokens</th><th>Cost</th>`, `<td align="right">150</td><td align="right">$0.0100</td>`,
+		"<tr><td>pods-ready</td><td align=\"right\">1</td><td>d</td></tr>",
+		`<p>Trace: <a href="https://traces.example.com/t-1">t-1</a></p>`,
+		"<p>Usage: 120 tokens, $0.0100</p>",
+		`<a href="attachments/a/task-output.txt">task-output.txt</a>`,
+		`<a href="attachments/a/screenshot.png">screenshot.png</a>`,
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report.html does not contain %q:\n%s", want, report)
+		}
+	}
+	if index := read("attachments/index.html"); !strings.Contains(index, `<a href="a/task-output.txt">task-output.txt</a>`) {
+		t.Errorf("attachments index = %s", index)
+	}
+	if output := read("attachments/a/task-output.txt"); output != "pods listed" {
+		t.Errorf("attachment = %q", output)
+	}
+	// The artifact files, relative to the input, are copied into the bundle,
+	// the links left as they are
+	if screenshot := read("attachments/a/screenshot.png"); screenshot != "png" {
+		t.Errorf("copied artifact = %q", screenshot)
+	}
+	if junit := read("junit/TEST-MCP-Checker-Tests-easy.xml"); !strings.Contains(junit, "https://ci.example.com/a.log") {
+		t.Errorf("easy suite = %s, want the artifact link", junit)
+	}
+
+	var usageErr usageError
+	for _, args := range [][]string{
+		{"--bundle", bundle, "--attachments-dir", filepath.Join(dir, "attachments"), input},
+		{"--bundle", "s3://bucket/out", input},
+		{"--bundle", bundle, "--watch", "--output", filepath.Join(dir, "report.xml"), input},
+	} {
+		if err := runCLI(context.Background(), args); !errors.As(err, &usageErr) {
+			t.Errorf("runCLI(%q) error = %v, want a usageError", args, err)
+		}
+	}
+}
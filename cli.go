@@ -0,0 +1,984 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name string
+	// args is the synopsis of the positional arguments
+	args string
+	// summary is the one-line description shown in the command list
+	summary string
+	// description is the longer text shown by the command's --help
+	description string
+	run         func(ctx context.Context, cmd *command, args []string) error
+}
+
+// defaultCommand runs when the first argument is not a command name, so
+// that "mcpchecker-junit-report results.json" keeps working
+const defaultCommand = "convert"
+
+// commands lists every subcommand, in the order shown by the help
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{
+			name:        "convert",
+			args:        "[file|directory|archive|url...]",
+			summary:     "Convert results to a JUnit XML report (default command)",
+			description: "Converts MCP checker results to a JUnit XML report, or to the output of an external formatter with --format exec:/path/to/formatter. Reads from stdin when no input is given.",
+			run:         runConvert,
+		},
+		{
+			name:        "merge",
+			args:        "run1 run2...",
+			summary:     "Merge several runs, reporting tasks that pass on a rerun as flaky",
+			description: "Merges results by task name, treating later runs as reruns of earlier ones.",
+			run:         runMerge,
+		},
+		{
+			name:        "summary",
+			args:        "[file|directory|archive|url...]",
+			summary:     "Print a table of the results per difficulty and the failing tasks",
+			description: "Prints per-difficulty totals, pass rates and error counts, followed by the failing tasks with their failed assertions or error.",
+			run:         runSummary,
+		},
+		{
+			name:        "stats",
+			args:        "[file|directory|archive|url...]",
+			summary:     "Print tool call and resource read statistics per MCP server",
+			description: "Aggregates the call history of every task: tool call counts and success rates per server and tool, resource reads per URI prefix, and the tools most correlated with failed tasks.",
+			run:         runStats,
+		},
+		{
+			name:        "history",
+			args:        "add [file|directory|archive|url...] | report",
+			summary:     "Record task outcomes in a SQLite database and report on them over runs",
+			description: "history add records the outcome of every task of the given results as a new run of the --db SQLite database. history report prints, for every task, its pass rate over the latest runs and the last run it passed in.",
+			run:         runHistory,
+		},
+		{
+			name:        "diff",
+			args:        "baseline current",
+			summary:     "Compare two runs, task by task or statistically with --stats",
+			description: "Lists the tasks whose outcome differs between the baseline and the current run. With --stats, compares the pass rates per difficulty with confidence intervals instead, counting every sample as a trial. --format html writes the comparison as an HTML page, with the assertions whose outcome changed and the tool calls of each regressed task side by side. Exits with status 5 on regressions, significant ones with --stats.",
+			run:         runDiff,
+		},
+		{
+			name:        "badge",
+			args:        "[file|directory|archive|url...]",
+			summary:     "Write an SVG badge of the pass rate",
+			description: "Writes a shields.io-style SVG badge showing the pass rate of all tasks, or of one difficulty level with --difficulty, colored from red to green.",
+			run:         runBadge,
+		},
+		{
+			name:        "export",
+			args:        "[file|directory|archive|url...]",
+			summary:     "Export pass rates, durations and tool call stats for Grafana",
+			description: "Writes time-stamped metrics of the run, at its startedAt or the current time: outcome counts and pass rates per difficulty, mean phase durations and tool call counts per server and tool, in the InfluxDB line protocol or as Grafana JSON datasource series.",
+			run:         runExport,
+		},
+		{
+			name:        "publish",
+			args:        "testrail|xray|github-check|gitlab-mr|otel|datadog|pushgateway [file|directory|archive|url...]",
+			summary:     "Publish results to a test management or CI service",
+			description: "Sends the outcome of every task to the given target. publish testrail adds the results of the tasks mapped to TestRail cases to a run, created unless --run-id is given. publish xray writes the results of the tasks with a Jira test key as an Xray test execution import, or imports it into Xray Cloud with --url. publish github-check creates a check run on a commit, annotating the task files of failures and concluded by the gate flags. publish gitlab-mr comments a summary on a merge request, compared with the --baseline results of its target branch, and updates that comment on later runs. publish otel sends every task as a span over OTLP/HTTP, with child spans per phase and tool call. publish datadog sends the tasks to Datadog CI Visibility as a test session with a suite per difficulty level. publish pushgateway pushes the metrics of export to a Prometheus Pushgateway.",
+			run:         runPublish,
+		},
+		{
+			name:        "notify",
+			args:        "email|pagerduty|opsgenie [file|directory|archive|url...]",
+			summary:     "Send a digest of results to people, or alert on gate failures",
+			description: "Notifies the given target of the outcome of the run. notify email sends an HTML digest, with the pass rate of each difficulty level and the failing tasks, through an SMTP server to --to and to the owners of difficulty levels and tags routed by --recipients. notify pagerduty and notify opsgenie raise an alert when one of the gate flags fails, keyed by --dedup-key so that the failures of every run of a pipeline update one incident, and resolve it when the gates pass.",
+			run:         runNotify,
+		},
+		{
+			name:        "validate",
+			args:        "[file|directory|archive|url...]",
+			summary:     "Check results for problems without writing a report",
+			description: "Validates results against the result schema and checks them for unknown difficulties, missing assertion maps, empty and duplicate task names. Exits with status 2 if any problem is found.",
+			run:         runValidate,
+		},
+		{
+			name:        "schema",
+			summary:     "Print the JSON Schema of a result",
+			description: "Prints the embedded JSON Schema that results are checked against with --strict and by the validate command. The envelope object is described under $defs of the version 1 schema.",
+			run:         runSchema,
+		},
+		{
+			name:        "serve",
+			summary:     "Serve conversions over HTTP and, optionally, gRPC",
+			description: "Serves POST /convert and GET /healthz, and the gRPC ConverterService with --grpc-addr.",
+			run:         runServe,
+		},
+		{
+			name:        "version",
+			summary:     "Print the version, git commit and build date",
+			description: "Prints the version, git commit and build date of this binary. Same as --version.",
+			run:         runVersion,
+		},
+		{
+			name:        "help",
+			args:        "[command]",
+			summary:     "Show help for a command",
+			description: "Shows the list of commands, or the flags of the given command.",
+			run:         runHelp,
+		},
+	}
+}
+
+// usageError reports invalid flags or arguments
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func newUsageError(format string, args ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// helpOutput receives the usage and help text
+var helpOutput io.Writer = os.Stderr
+
+// stdout receives the output of informational commands such as version
+var stdout io.Writer = os.Stdout
+
+// flagError reports a flag parsing error, already printed along with the usage
+type flagError struct {
+	err error
+}
+
+func (e flagError) Error() string {
+	return e.err.Error()
+}
+
+// programName is the name the binary was invoked with
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
+
+// runCLI dispatches the arguments to a subcommand, falling back to convert.
+// Commands stop early, or shut down in the case of serve and --watch, once
+// ctx is done.
+func runCLI(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "-h", "-help", "--help":
+			printRootUsage(helpOutput)
+			return nil
+		case "-version", "--version":
+			return printVersion(stdout, false)
+		}
+		if cmd := findCommand(args[0]); cmd != nil {
+			return cmd.run(ctx, cmd, args[1:])
+		}
+	}
+	cmd := findCommand(defaultCommand)
+	return cmd.run(ctx, cmd, args)
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// flagSet returns an empty flag set whose --help shows the command's usage
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(helpOutput)
+	fs.Usage = func() {
+		c.printUsage(fs.Output(), fs)
+	}
+	addNetworkFlags(fs)
+	addLogFlags(fs)
+	return fs
+}
+
+// envPrefix prefixes the environment variables mirroring the flags
+const envPrefix = "MCPJUNIT_"
+
+// flagEnvName returns the environment variable mirroring a flag, e.g.
+// MCPJUNIT_INPUT_FORMAT for --input-format
+func flagEnvName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// parseFlags parses the command-line arguments of a command. Flags that are
+// not given on the command line are taken from their MCPJUNIT_<COMMAND>_*
+// or MCPJUNIT_* environment variable, if set, and the logging flags then
+// configure the default logger. It returns flag.ErrHelp after printing the
+// help for -h and --help.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			return flagError{err: err}
+		}
+		return err
+	}
+	skipped, err := applyFlagEnv(fs)
+	if err != nil {
+		return err
+	}
+	if err := setupLogging(fs); err != nil {
+		return err
+	}
+	for _, skip := range skipped {
+		slog.Warn("ignoring an environment variable the command does not accept", "command", fs.Name(), "variable", skip.name, "error", skip.err)
+	}
+	return setupHTTP(fs)
+}
+
+// printUsage writes the command's help, listing the shared input and
+// logging flags separately from the command's own flags
+func (c *command) printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintf(w, "%s\n\nUsage:\n", c.description)
+	synopsis := strings.TrimSpace("[flags] " + c.args)
+	fmt.Fprintf(w, "  %s %s %s\n", programName(), c.name, synopsis)
+	if c.name == defaultCommand {
+		fmt.Fprintf(w, "  %s %s\n", programName(), synopsis)
+	}
+
+	own := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	input := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	network := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	logging := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.VisitAll(func(f *flag.Flag) {
+		target := own
+		if inputFlagNames[f.Name] {
+			target = input
+		} else if networkFlagNames[f.Name] {
+			target = network
+		} else if logFlagNames[f.Name] {
+			target = logging
+		}
+		target.Var(f.Value, f.Name, f.Usage)
+		target.Lookup(f.Name).DefValue = f.DefValue
+	})
+	for _, section := range []struct {
+		title string
+		flags *flag.FlagSet
+	}{{"Flags", own}, {"Input flags", input}, {"Network flags", network}, {"Logging flags", logging}} {
+		if !hasFlags(section.flags) {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		section.flags.SetOutput(w)
+		section.flags.PrintDefaults()
+	}
+	if hasFlags(fs) {
+		fmt.Fprintf(w, "\nEvery flag can also be set with an environment variable named after it,\ne.g. %s for --output, or %s for this command only.\nCommand-line flags take precedence.\n", flagEnvName("output"), commandFlagEnvName(c.name, "output"))
+	}
+}
+
+// envSkip is a shared MCPJUNIT_* variable a command does not accept
+type envSkip struct {
+	name string
+	err  error
+}
+
+// applyFlagEnv sets every flag not given on the command line whose
+// environment variable is set. The variable of the command, e.g.
+// MCPJUNIT_DIFF_FORMAT, wins over the one shared by every command, e.g.
+// MCPJUNIT_FORMAT, and an invalid value for it is an error. A shared value
+// the flag does not accept, e.g. an output format of another command, is
+// skipped and returned instead.
+func applyFlagEnv(fs *flag.FlagSet) ([]envSkip, error) {
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	var skipped []envSkip
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if given[f.Name] || err != nil {
+			return
+		}
+		name := commandFlagEnvName(fs.Name(), f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if setErr := setFlagFromEnv(f, value); setErr != nil {
+				err = newUsageError("invalid value %q for %s: %v", value, name, setErr)
+			}
+			return
+		}
+		name = flagEnvName(f.Name)
+		if value, ok := os.LookupEnv(name); ok {
+			if setErr := setFlagFromEnv(f, value); setErr != nil {
+				skipped = append(skipped, envSkip{name: name, err: setErr})
+			}
+		}
+	})
+	return skipped, err
+}
+
+// setFlagFromEnv sets a flag to the value of its environment variable,
+// checking it first when the flag is only validated after parsing
+func setFlagFromEnv(f *flag.Flag, value string) error {
+	if checked, ok := f.Value.(*checkedString); ok {
+		if err := checked.check(value); err != nil {
+			return err
+		}
+	}
+	return f.Value.Set(value)
+}
+
+// commandFlagEnvName returns the environment variable setting a flag of
+// a single command, e.g. MCPJUNIT_DIFF_FORMAT for the --format of diff
+func commandFlagEnvName(command, name string) string {
+	return flagEnvName(command + "-" + name)
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// printRootUsage writes the list of commands
+func printRootUsage(w io.Writer) {
+	name := programName()
+	fmt.Fprintf(w, "%s converts MCP checker results to JUnit XML.\n\n", name)
+	fmt.Fprintf(w, "Usage:\n  %s <command> [flags] [arguments]\n  %s [flags] [file|directory|archive|url...]    (same as convert)\n\nCommands:\n", name, name)
+
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.name))
+	}
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun '%s help <command>' or '%s <command> --help' for the flags of a command,\nor '%s --version' for the build metadata.\n", name, name, name)
+}
+
+func runHelp(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		printRootUsage(helpOutput)
+		return nil
+	}
+
+	target := findCommand(fs.Arg(0))
+	if target == nil {
+		return newUsageError("unknown command %q, run '%s help' for the list of commands", fs.Arg(0), programName())
+	}
+	// A command's flags are only registered when it runs, so let it print its own help
+	return target.run(ctx, target, []string{"--help"})
+}
+
+// Defaults of the caps on tool call results, generous enough for any result
+// worth reading in a report
+const (
+	defaultMaxToolResultDepth = 32
+	defaultMaxToolResultBytes = 1 << 20
+)
+
+// defaultToolResultLimits caps the tool call results of the inputs of the
+// HTTP and gRPC services, which have no flags for them
+var defaultToolResultLimits = converter.ToolResultLimits{MaxDepth: defaultMaxToolResultDepth, MaxBytes: defaultMaxToolResultBytes}
+
+// inputFlags holds the flags shared by every command that reads results
+type inputFlags struct {
+	format       *string
+	lenient      *bool
+	strict       *bool
+	allowEmpty   *bool
+	httpTokenEnv *string
+	// maxToolResultDepth, maxToolResultBytes and toolResultsDir cap the
+	// tool call results as they are decoded
+	maxToolResultDepth *int
+	maxToolResultBytes *int
+	toolResultsDir     *string
+}
+
+// inputFlagNames lists the flags registered by addInputFlags
+var inputFlagNames = map[string]bool{
+	"input-format":   true,
+	"lenient":        true,
+	"strict":         true,
+	"allow-empty":    true,
+	"http-token-env": true,
+
+	"max-tool-result-depth": true,
+	"max-tool-result-bytes": true,
+	"tool-results-dir":      true,
+}
+
+// addInputFlags registers the input flags on fs
+func addInputFlags(fs *flag.FlagSet) *inputFlags {
+	return &inputFlags{
+		format:       fs.String("input-format", converter.FormatAuto, "input format: auto, json, ndjson, yaml or junit"),
+		lenient:      fs.Bool("lenient", false, "skip malformed entries, reporting them as errored \"parse-error-N\" testcases"),
+		strict:       fs.Bool("strict", false, "validate the input against the embedded result schema and report every violation"),
+		allowEmpty:   fs.Bool("allow-empty", false, "accept inputs without results, writing an empty report with a warning instead of failing"),
+		httpTokenEnv: fs.String("http-token-env", defaultHTTPTokenEnv, "environment variable holding a bearer token for HTTP(S) inputs"),
+
+		maxToolResultDepth: fs.Int("max-tool-result-depth", defaultMaxToolResultDepth, "replace the objects and arrays nested deeper in a tool call result with a placeholder (0 for no limit)"),
+		maxToolResultBytes: fs.Int("max-tool-result-bytes", defaultMaxToolResultBytes, "replace the strings of a tool call result longer than this, and the whole result if still larger, with a placeholder giving their size (0 for no limit)"),
+		toolResultsDir:     fs.String("tool-results-dir", "", "write the full JSON of every capped tool call result to this directory and attach it to its testcase"),
+	}
+}
+
+// options validates the parsed flags and returns the matching inputOptions
+func (f *inputFlags) options() (inputOptions, error) {
+	if *f.maxToolResultDepth < 0 || *f.maxToolResultBytes < 0 {
+		return inputOptions{}, newUsageError("--max-tool-result-depth and --max-tool-result-bytes must not be negative")
+	}
+	return inputOptions{
+		ParseOptions: converter.ParseOptions{
+			Format:     *f.format,
+			Strict:     *f.strict,
+			Lenient:    *f.lenient,
+			AllowEmpty: *f.allowEmpty,
+			ToolResults: converter.ToolResultLimits{
+				MaxDepth: *f.maxToolResultDepth,
+				MaxBytes: *f.maxToolResultBytes,
+				Dir:      *f.toolResultsDir,
+			},
+		},
+		Remote: RemoteOptions{TokenEnv: *f.httpTokenEnv},
+	}, nil
+}
+
+// convertFlags holds the flags shared by every command that writes a report
+type convertFlags struct {
+	groupBy                *string
+	reportName             *string
+	suitePrefix            *string
+	onDuplicate            *string
+	nestedSuites           *bool
+	suiteNameTemplate      *string
+	classnameTemplate      *string
+	classnameStrategy      *string
+	classnameStyle         *string
+	pathPrefix             *string
+	includeTask            *string
+	excludeTask            *string
+	difficulty             *string
+	filterTag              *string
+	explodeAssertions      *bool
+	maxToolOutput          *int
+	maxSystemOutBytes      *int
+	noTruncate             *bool
+	noSystemOut            *bool
+	noSystemErr            *bool
+	systemOutOnFailureOnly *bool
+	properties             *propertyList
+	propertiesFromEnv      *string
+	captureEnv             *string
+	attachmentsDir         *string
+	sort                   *string
+	compact                *bool
+	check                  *bool
+	indent                 *string
+	redactions             *redactionList
+	timeoutPatterns        *patternList
+	noBuiltinRedaction     *bool
+	stripANSI              *bool
+	cdata                  *bool
+	lang                   *string
+	classifyRules          *string
+	phasePolicy            *string
+	samplePolicy           *string
+	systemOutTemplate      *string
+	traceURLTemplate       *string
+}
+
+// addConvertFlags registers the report flags on fs
+func addConvertFlags(fs *flag.FlagSet) *convertFlags {
+	properties := &propertyList{}
+	fs.Var(properties, "property", "add a key=value property to every testsuite; repeatable")
+	redactions := &redactionList{}
+	fs.Var(redactions, "redact", "mask matches of this regular expression in testcase output and messages; repeatable")
+	timeoutPatterns := &patternList{}
+	fs.Var(timeoutPatterns, "timeout-pattern", "report task errors matching this regular expression as timeouts, replacing the built-in patterns; repeatable")
+	return &convertFlags{
+		groupBy:                fs.String("group-by", converter.GroupByDifficulty, "group testcases into suites by "+strings.Join(converter.GroupByValues, ", ")),
+		nestedSuites:           fs.Bool("nested-suites", false, "nest a testsuite per task directory in each testsuite of --group-by"),
+		onDuplicate:            fs.String("on-duplicate", converter.DuplicateSuffix, "handle testcases of a suite sharing a classname and name by "+strings.Join(converter.DuplicatePolicies, ", ")),
+		reportName:             fs.String("report-name", "", "name attribute of the testsuites root element"),
+		suitePrefix:            fs.String("suite-prefix", "", "start of the testsuite names (default the suite name or scenario set of the run, else \"MCP Checker Tests\")"),
+		suiteNameTemplate:      fs.String("suite-name-template", "", "Go template naming each testsuite from the first result of its group, e.g. {{.Group}}"),
+		classnameTemplate:      fs.String("classname-template", "", "Go template for each testcase classname, e.g. {{.Difficulty}}.{{.TaskDir}}"),
+		classnameStrategy:      fs.String("classname-strategy", converter.ClassnameTasksDir, "derive testcase classnames from the task path by "+strings.Join(converter.ClassnameStrategies, ", ")),
+		classnameStyle:         fs.String("classname-style", converter.ClassnameStyleRaw, "rewrite classnames for package grouping: "+strings.Join(converter.ClassnameStyles, ", ")),
+		pathPrefix:             fs.String("path-prefix", "", "strip this prefix from the task paths written to the file attribute of testcases"),
+		includeTask:            fs.String("include-task", "", "only report tasks whose name or path matches this regular expression"),
+		excludeTask:            fs.String("exclude-task", "", "leave out tasks whose name or path matches this regular expression"),
+		difficulty:             fs.String("difficulty", "", "only report tasks of these comma-separated difficulty levels, e.g. easy,medium"),
+		filterTag:              fs.String("filter-tag", "", "only report tasks with one of these comma-separated tags, leaving out those with a tag prefixed with !, e.g. smoke,!flaky"),
+		explodeAssertions:      fs.Bool("explode-assertions", false, "write a testcase per assertion, named task::assertion, rather than per task"),
+		maxToolOutput:          fs.Int("max-tool-output", converter.DefaultMaxToolOutput, "truncate each tool message in system-out to this many bytes, 0 for no limit"),
+		maxSystemOutBytes:      fs.Int("max-system-out-bytes", 0, "truncate the system-out of each testcase to this many bytes, 0 for no limit"),
+		noTruncate:             fs.Bool("no-truncate", false, "never truncate output, overriding --max-tool-output and --max-system-out-bytes"),
+		noSystemOut:            fs.Bool("no-system-out", false, "leave out the system-out section of every testcase"),
+		noSystemErr:            fs.Bool("no-system-err", false, "leave out the system-err section of every testcase"),
+		systemOutOnFailureOnly: fs.Bool("system-out-on-failure-only", false, "only include system-out for failed or errored testcases"),
+		compact:                fs.Bool("compact", false, "write the XML report on a single line, overriding --indent"),
+		check:                  fs.Bool("check", false, "validate the report against the JUnit XML schema before writing it, failing on the first invalid element"),
+		indent:                 fs.String("indent", converter.DefaultIndent, "indentation of nested XML elements, made of spaces and tabs"),
+		sort:                   fs.String("sort", converter.SortSorted, "order of suites and testcases: sorted (difficulty, then name) or original (input order)"),
+		properties:             properties,
+		attachmentsDir:         fs.String("attachments-dir", "", "write the full tool calls and task output of each testcase to this directory, referenced from system-out"),
+		propertiesFromEnv:      fs.String("properties-from-env", "", "add a property for every environment variable with this prefix, named without it"),
+		captureEnv:             fs.String("capture-env", "", "record these comma-separated environment variables, e.g. GIT_SHA,MODEL_NAME, as properties of the report and of every testsuite"),
+		redactions:             redactions,
+		timeoutPatterns:        timeoutPatterns,
+		noBuiltinRedaction:     fs.Bool("no-builtin-redaction", false, "do not mask AWS keys, bearer tokens and URL passwords"),
+		cdata:                  fs.Bool("cdata", true, "write testcase output, failure and error content in CDATA sections rather than escaping it"),
+		stripANSI:              fs.Bool("strip-ansi", false, "remove ANSI escape sequences such as colors from testcase output and messages"),
+		lang:                   fs.String("lang", converter.LangEnglish, "language of testcase output labels and failure messages: "+strings.Join(converter.Languages, ", ")),
+		systemOutTemplate:      fs.String("system-out-template", "", "Go template file laying out the system-out of each testcase from its result"),
+		traceURLTemplate:       fs.String("trace-url-template", "", "Go template of the link to the trace of each task with a traceId, e.g. https://smith.langchain.com/public/{{.TraceID}}/r"),
+		phasePolicy:            fs.String("phase-policy", "", "report phase failures as error, failure, warn (a property only) or ignore, e.g. verify=failure,cleanup=warn"),
+		samplePolicy:           fs.String("sample-policy", converter.SamplePolicyAny, "pass tasks sampled several times when "+strings.Join(converter.SamplePolicies, ", ")+" of their samples passed"),
+		classifyRules:          fs.String("classify-rules", "", "YAML file of rules overriding the status of results by task error or failed assertion"),
+	}
+}
+
+// newConverter validates the parsed flags and returns the Converter they configure
+func (f *convertFlags) newConverter() (*converter.Converter, error) {
+	if !slices.Contains(converter.GroupByValues, *f.groupBy) {
+		return nil, newUsageError("--group-by must be one of %s", strings.Join(converter.GroupByValues, ", "))
+	}
+	if !slices.Contains(converter.DuplicatePolicies, *f.onDuplicate) {
+		return nil, newUsageError("--on-duplicate must be one of %s", strings.Join(converter.DuplicatePolicies, ", "))
+	}
+	if !slices.Contains(converter.ClassnameStyles, *f.classnameStyle) {
+		return nil, newUsageError("--classname-style must be one of %s", strings.Join(converter.ClassnameStyles, ", "))
+	}
+	if !slices.Contains(converter.SamplePolicies, *f.samplePolicy) {
+		return nil, newUsageError("--sample-policy must be one of %s", strings.Join(converter.SamplePolicies, ", "))
+	}
+	if !slices.Contains(converter.SortValues, *f.sort) {
+		return nil, newUsageError("--sort must be one of %s", strings.Join(converter.SortValues, ", "))
+	}
+	if !slices.Contains(converter.Languages, *f.lang) {
+		return nil, newUsageError("--lang must be one of %s", strings.Join(converter.Languages, ", "))
+	}
+	if strings.Trim(*f.indent, " \t") != "" {
+		return nil, newUsageError("--indent must only contain spaces and tabs")
+	}
+	if *f.maxToolOutput < 0 || *f.maxSystemOutBytes < 0 {
+		return nil, newUsageError("--max-tool-output and --max-system-out-bytes must not be negative")
+	}
+
+	indent := *f.indent
+	if *f.compact {
+		indent = ""
+	}
+	maxToolOutput, maxSystemOut := *f.maxToolOutput, *f.maxSystemOutBytes
+	if *f.noTruncate {
+		maxToolOutput, maxSystemOut = 0, 0
+	}
+	var redactions []converter.Redaction
+	if !*f.noBuiltinRedaction {
+		redactions = append(redactions, converter.BuiltinRedactions...)
+	}
+	redactions = append(redactions, *f.redactions...)
+	captured := capturedEnv(splitList(*f.captureEnv))
+	properties := slices.Concat(captured, propertiesFromEnv(*f.propertiesFromEnv), *f.properties)
+
+	opts := []converter.Option{
+		converter.WithGroupBy(*f.groupBy),
+		converter.WithReportName(*f.reportName),
+		converter.WithSuitePrefix(*f.suitePrefix),
+		converter.WithOnDuplicate(*f.onDuplicate),
+		converter.WithPathPrefix(*f.pathPrefix),
+		converter.WithSort(*f.sort),
+		converter.WithClassnameStyle(*f.classnameStyle),
+		converter.WithSamplePolicy(*f.samplePolicy),
+		converter.WithLang(*f.lang),
+		converter.WithIndent(indent),
+		converter.WithTruncation(maxToolOutput, maxSystemOut),
+		converter.WithRedactions(redactions...),
+		converter.WithProperties(properties...),
+		converter.WithReportProperties(captured...),
+	}
+	if *f.noSystemOut {
+		opts = append(opts, converter.WithoutSystemOut())
+	}
+	if *f.noSystemErr {
+		opts = append(opts, converter.WithoutSystemErr())
+	}
+	if *f.systemOutOnFailureOnly {
+		opts = append(opts, converter.WithSystemOutOnFailureOnly())
+	}
+	if len(*f.timeoutPatterns) > 0 {
+		opts = append(opts, converter.WithTimeoutPatterns(*f.timeoutPatterns...))
+	}
+	if *f.nestedSuites {
+		opts = append(opts, converter.WithNestedSuites())
+	}
+	if *f.explodeAssertions {
+		opts = append(opts, converter.WithExplodedAssertions())
+	}
+	if *f.stripANSI {
+		opts = append(opts, converter.WithStripANSI())
+	}
+	if !*f.cdata {
+		opts = append(opts, converter.WithoutCDATA())
+	}
+	if *f.check {
+		opts = append(opts, converter.WithCheck())
+	}
+	if *f.attachmentsDir != "" {
+		opts = append(opts, converter.WithAttachmentsDir(*f.attachmentsDir))
+	}
+
+	suiteName, err := parseNameTemplate("suite-name-template", *f.suiteNameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	classname, err := parseNameTemplate("classname-template", *f.classnameTemplate)
+	if err != nil {
+		return nil, err
+	}
+	traceURL, err := parseNameTemplate("trace-url-template", *f.traceURLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	strategy, ok := converter.ClassnameStrategyByName(*f.classnameStrategy)
+	if !ok {
+		return nil, newUsageError("--classname-strategy must be one of %s", strings.Join(converter.ClassnameStrategies, ", "))
+	}
+	if *f.classnameStrategy == converter.ClassnameTemplate && classname == nil {
+		return nil, newUsageError("--classname-strategy %s requires --classname-template", converter.ClassnameTemplate)
+	}
+	filter := converter.TaskFilter{Difficulties: splitList(*f.difficulty)}
+	for _, tag := range splitList(*f.filterTag) {
+		if excluded, ok := strings.CutPrefix(tag, "!"); ok {
+			filter.ExcludeTags = append(filter.ExcludeTags, excluded)
+		} else {
+			filter.Tags = append(filter.Tags, tag)
+		}
+	}
+	if filter.Include, err = parseFilterRegexp("include-task", *f.includeTask); err != nil {
+		return nil, err
+	}
+	if filter.Exclude, err = parseFilterRegexp("exclude-task", *f.excludeTask); err != nil {
+		return nil, err
+	}
+	opts = append(opts, converter.WithSuiteNameTemplate(suiteName), converter.WithClassnameStrategy(strategy),
+		converter.WithClassnameTemplate(classname), converter.WithTraceURLTemplate(traceURL), converter.WithFilter(filter))
+	if *f.systemOutTemplate != "" {
+		tmpl, err := loadSystemOutTemplate(*f.systemOutTemplate)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, converter.WithSystemOutTemplate(tmpl))
+	}
+	if *f.phasePolicy != "" {
+		policy, err := converter.ParsePhasePolicy(*f.phasePolicy)
+		if err != nil {
+			return nil, newUsageError("invalid --phase-policy: %v", err)
+		}
+		opts = append(opts, converter.WithPhasePolicy(policy))
+	}
+	if *f.classifyRules != "" {
+		rules, err := loadClassificationRules(*f.classifyRules)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, converter.WithClassifier(converter.RuleClassifier{Rules: rules}))
+	}
+	return converter.New(opts...)
+}
+
+// loadSystemOutTemplate reads the template file given to --system-out-template
+func loadSystemOutTemplate(filename string) (*template.Template, error) {
+	text, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, newUsageError("invalid --system-out-template: %v", err)
+	}
+	tmpl, err := converter.ParseSystemOutTemplate(filepath.Base(filename), string(text))
+	if err != nil {
+		return nil, newUsageError("invalid --system-out-template %s: %v", filename, err)
+	}
+	return tmpl, nil
+}
+
+// loadClassificationRules reads the rules file given to --classify-rules
+func loadClassificationRules(filename string) ([]converter.ClassificationRule, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, newUsageError("invalid --classify-rules: %v", err)
+	}
+	defer file.Close()
+	rules, err := converter.ParseClassificationRules(file)
+	if err != nil {
+		return nil, newUsageError("invalid --classify-rules %s: %v", filename, err)
+	}
+	return rules, nil
+}
+
+// parseNameTemplate parses the value of a template flag
+func parseNameTemplate(flagName, text string) (*template.Template, error) {
+	tmpl, err := converter.ParseNameTemplate(flagName, text)
+	if err != nil {
+		return nil, newUsageError("invalid --%s: %v", flagName, err)
+	}
+	return tmpl, nil
+}
+
+// parseFilterRegexp compiles the value of a filter flag
+func parseFilterRegexp(flagName, expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, newUsageError("invalid --%s: %v", flagName, err)
+	}
+	return re, nil
+}
+
+// checkedString is a string flag whose values the command validates after
+// parsing, with a usage error, and whose shared environment variable is
+// only applied when check accepts it
+type checkedString struct {
+	value *string
+	check func(string) error
+}
+
+// addCheckedString registers a string flag validated by check
+func addCheckedString(fs *flag.FlagSet, name, value, usage string, check func(string) error) *string {
+	p := &checkedString{value: &value, check: check}
+	fs.Var(p, name, usage)
+	return p.value
+}
+
+func (s *checkedString) String() string {
+	if s == nil || s.value == nil {
+		return ""
+	}
+	return *s.value
+}
+
+func (s *checkedString) Set(value string) error {
+	*s.value = value
+	return nil
+}
+
+// oneOf returns a check that the value of the flag name is one of values
+func oneOf(name string, values ...string) func(string) error {
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return newUsageError("--%s must be one of %s", name, strings.Join(values, ", "))
+		}
+		return nil
+	}
+}
+
+// patternList is a repeatable regular expression flag
+type patternList []*regexp.Regexp
+
+func (p *patternList) String() string {
+	if p == nil {
+		return ""
+	}
+	patterns := make([]string, 0, len(*p))
+	for _, pattern := range *p {
+		patterns = append(patterns, pattern.String())
+	}
+	return strings.Join(patterns, ",")
+}
+
+func (p *patternList) Set(value string) error {
+	if value == "" {
+		return errors.New("empty regular expression")
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	*p = append(*p, re)
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// gateFlags holds the flags that turn the test outcome into the exit status
+type gateFlags struct {
+	failOn      *string
+	minPassRate *string
+	baseline    *string
+}
+
+// addGateFlags registers the gate flags on fs
+func addGateFlags(fs *flag.FlagSet) *gateFlags {
+	return &gateFlags{
+		failOn:      fs.String("fail-on", failOnNever, "exit with status 4 after writing the report if it has "+strings.Join(failOnValues, ", ")),
+		minPassRate: fs.String("min-pass-rate", "", "exit with status 5 if the pass rate is below this, e.g. 0.95, or per difficulty, e.g. easy=1.0,hard=0.8"),
+		baseline:    fs.String("baseline", "", "exit with status 5 if tasks that passed in this earlier run of results fail, listing them"),
+	}
+}
+
+// options validates the parsed flags and returns the matching GateOptions,
+// reading the baseline with the input options and converting it with conv
+func (f *gateFlags) options(ctx context.Context, inputs inputOptions, conv *converter.Converter) (GateOptions, error) {
+	if !slices.Contains(failOnValues, *f.failOn) {
+		return GateOptions{}, newUsageError("--fail-on must be one of %s", strings.Join(failOnValues, ", "))
+	}
+	minPassRate, err := parsePassRates(*f.minPassRate)
+	if err != nil {
+		return GateOptions{}, err
+	}
+	baseline, err := loadBaseline(ctx, *f.baseline, inputs, conv)
+	if err != nil {
+		return GateOptions{}, err
+	}
+	return GateOptions{FailOn: *f.failOn, MinPassRate: minPassRate, Baseline: baseline}, nil
+}
+
+// exitOnError logs err, one record per line, followed by a hint for the
+// input errors of the converter package, and exits with exitCode(err).
+// Failed gates are written in color instead when colorLogs is set.
+func exitOnError(err error) {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	var flagErr flagError
+	var gateErr gateError
+	if errors.As(err, &gateErr) && colorLogs {
+		printGateFailure(logOutput, err.Error())
+	} else if !errors.As(err, &flagErr) {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			slog.Error(strings.TrimSpace(line))
+		}
+		if hint := errorHint(err); hint != "" {
+			slog.Info(hint)
+		}
+	}
+	os.Exit(exitCode(err))
+}
+
+// Exit statuses, telling CI scripts why the command failed
+const (
+	// exitCodeUsage is the exit status of invalid flags and arguments
+	exitCodeUsage = 1
+	// exitCodeInput is the exit status when an input cannot be read or
+	// parsed, has no results or, for validate, has problems
+	exitCodeInput = 2
+	// exitCodeConversion is the exit status when converting or writing the
+	// report fails, and of any failure without a status of its own
+	exitCodeConversion = 3
+	// exitCodeTestsFailed is the exit status when --fail-on trips
+	exitCodeTestsFailed = 4
+	// exitCodeGateFailed is the exit status when a --min-pass-rate gate
+	// trips or tasks that passed in the --baseline run fail
+	exitCodeGateFailed = 5
+	// exitCodePublish is the exit status when publishing the results or
+	// notifying about them fails
+	exitCodePublish = 6
+	// exitCodeInterrupted is the exit status of a run interrupted by a
+	// signal, as shells report it
+	exitCodeInterrupted = 130
+)
+
+// inputError marks the errors of reading and parsing inputs
+type inputError struct {
+	err error
+}
+
+func (e inputError) Error() string {
+	return e.err.Error()
+}
+
+func (e inputError) Unwrap() error {
+	return e.err
+}
+
+// publishError marks the errors of the publish and notify targets
+type publishError struct {
+	err error
+}
+
+func (e publishError) Error() string {
+	return e.err.Error()
+}
+
+func (e publishError) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the exit status for err: 0 after printing the help, then
+// by precedence, exitCodeInterrupted, exitCodeUsage for flag errors, already
+// printed with the usage, and usage errors, the code of a failed gate,
+// exitCodeInput, exitCodePublish and exitCodeConversion for anything else.
+// Input errors within a publish target thus keep their own status.
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if errors.Is(err, context.Canceled) {
+		return exitCodeInterrupted
+	}
+	var flagErr flagError
+	var usageErr usageError
+	if errors.As(err, &flagErr) || errors.As(err, &usageErr) {
+		return exitCodeUsage
+	}
+	var gateErr gateError
+	if errors.As(err, &gateErr) {
+		return gateErr.code
+	}
+	var inputErr inputError
+	if errors.As(err, &inputErr) || errors.Is(err, errNoResults) || errors.Is(err, converter.ErrEmptyInput) ||
+		errors.Is(err, converter.ErrInvalidJSON) || errors.Is(err, converter.ErrUnsupportedSchema) {
+		return exitCodeInput
+	}
+	var publishErr publishError
+	if errors.As(err, &publishErr) {
+		return exitCodePublish
+	}
+	return exitCodeConversion
+}
+
+// errorHint suggests a way out of the input errors returned by the
+// converter package, or returns "" for other errors
+func errorHint(err error) string {
+	switch {
+	case errors.Is(err, converter.ErrEmptyInput), errors.Is(err, errNoResults):
+		return "the input has no results; check that the mcpchecker run completed and wrote its output, or use --allow-empty to write an empty report"
+	case errors.Is(err, converter.ErrInvalidJSON):
+		return "use --lenient to convert the readable entries and report the others as parse errors"
+	case errors.Is(err, converter.ErrUnsupportedSchema):
+		return "this version reads result schema versions 1 and 2; a newer mcpchecker-junit-report may support the input"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,517 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func captureHelp(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := helpOutput
+	helpOutput = &buf
+	t.Cleanup(func() { helpOutput = previous })
+	return &buf
+}
+
+func TestRunCLIHelp(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantErr  error
+		want     []string
+		dontWant []string
+	}{
+		{
+			name: "root help",
+			args: []string{"--help"},
+			want: []string{"Commands:", "  convert  ", "  merge    ", "  serve    ", "  help     "},
+		},
+		{
+			name: "help command without topic",
+			args: []string{"help"},
+			want: []string{"Commands:"},
+		},
+		{
+			name:    "help for a command",
+			args:    []string{"help", "merge"},
+			wantErr: flag.ErrHelp,
+			want:    []string{"merge [flags] run1 run2...", "Flags:\n", "-group-by string", "-output string", "Input flags:\n  -allow-empty\n"},
+		},
+		{
+			name:     "command --help",
+			args:     []string{"serve", "--help"},
+			wantErr:  flag.ErrHelp,
+			want:     []string{"serve [flags]\n", "-grpc-addr string"},
+			dontWant: []string{"Input flags:"},
+		},
+		{
+			name:    "default command help shows the alias",
+			args:    []string{"-h"},
+			want:    []string{"Commands:"},
+			wantErr: nil,
+		},
+		{
+			name:    "convert help",
+			args:    []string{"convert", "-h"},
+			wantErr: flag.ErrHelp,
+			want:    []string{"convert [flags] [file|directory|archive|url...]", "-watch", "-input-format string", "Network flags:\n  -ca-cert string", "Logging flags:\n  -color string"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureHelp(t)
+			if err := runCLI(context.Background(), tt.args); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("runCLI() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("help does not contain %q:\n%s", want, out)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(out.String(), dontWant) {
+					t.Errorf("help contains %q:\n%s", dontWant, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRunCLIErrors(t *testing.T) {
+	captureHelp(t)
+
+	var flagErr flagError
+	if err := runCLI(context.Background(), []string{"--bogus"}); !errors.As(err, &flagErr) {
+		t.Errorf("unknown flag error = %v, want a flagError", err)
+	}
+
+	var usageErr usageError
+	for _, args := range [][]string{
+		{"help", "nope"},
+		{"merge"},
+		{"--http-retries", "-1", "results.json"},
+		{"--http-timeout", "0", "results.json"},
+		{"--proxy", "ftp://proxy.example.com", "results.json"},
+		{"--max-tool-result-bytes", "-1", "results.json"},
+		{"--watch", "a.json", "b.json"},
+		{"--group-by", "owner", "results.json"},
+		{"--indent", "--", "results.json"},
+		{"--include-task", "(", "results.json"},
+		{"--classname-template", "{{.Owner}}", "results.json"},
+		{"--classname-strategy", "basename", "results.json"},
+		{"--classname-strategy", "template", "results.json"},
+		{"--classname-style", "kebab", "results.json"},
+		{"--classify-rules", "missing.yaml", "results.json"},
+		{"--phase-policy", "teardown=warn", "results.json"},
+		{"--sample-policy", "best", "results.json"},
+		{"--trace-url-template", "{{.TraceId}}", "results.json"},
+		{"history"},
+		{"history", "purge"},
+		{"history", "report"},
+		{"history", "report", "--db", "runs.db", "--last", "0"},
+		{"stats", "--db", "runs.db", "--last", "0"},
+		{"diff", "results.json"},
+		{"export", "--format", "csv", "results.json"},
+		{"publish"},
+		{"publish", "jira", "results.json"},
+		{"publish", "xray", "results.json"},
+		{"publish", "xray", "--project", "PROJ", "--output", "x.json", "--url", "https://xray", "results.json"},
+		{"publish", "testrail", "results.json"},
+		{"publish", "github-check", "--repo", "", "--sha", "abc123", "results.json"},
+		{"publish", "gitlab-mr", "--project", "1", "--merge-request", "", "results.json"},
+		{"publish", "otel", "--endpoint", "", "results.json"},
+		{"publish", "datadog", "--api-key-env", "MCPJUNIT_TEST_UNSET", "results.json"},
+		{"publish", "pushgateway", "results.json"},
+		{"notify"},
+		{"notify", "email", "--smtp-host", "mail", "--from", "ci@example.com", "results.json"},
+		{"notify", "email", "--smtp-host", "mail", "--from", "ci@example.com", "--to", "qa@example.com", "--tls", "ssl", "results.json"},
+		{"notify", "pagerduty", "--min-pass-rate", "0.9", "--severity", "fatal", "results.json"},
+		{"notify", "opsgenie", "--min-pass-rate", "0.9", "--priority", "P0", "results.json"},
+		{"publish", "testrail", "--url", "https://x", "--user", "u", "--mapping", "m.yaml", "--run-id", "1", "--project-id", "2", "results.json"},
+		{"diff", "--stats", "--confidence", "1", "a.json", "b.json"},
+		{"--on-duplicate", "drop", "results.json"},
+		{"--system-out-template", "missing.tmpl", "results.json"},
+	} {
+		if err := runCLI(context.Background(), args); !errors.As(err, &usageErr) {
+			t.Errorf("runCLI(%q) error = %v, want a usageError", args, err)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.json")
+	if err := os.WriteFile(empty, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte(`[{"taskName":`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	noResults := filepath.Join(dir, "no-results.json")
+	if err := os.WriteFile(noResults, []byte(`[]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	captureHelp(t)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantHint string
+	}{
+		{name: "success", wantCode: 0},
+		{name: "help", err: runCLI(context.Background(), []string{"--help"}), wantCode: 0},
+		{name: "flag error", err: runCLI(context.Background(), []string{"--bogus"}), wantCode: exitCodeUsage},
+		{name: "usage error", err: runCLI(context.Background(), []string{"--group-by", "owner", noResults}), wantCode: exitCodeUsage},
+		{name: "tests failed", err: gateError{code: exitCodeTestsFailed, msg: "Tests failed"}, wantCode: 4},
+		{name: "gate", err: gateError{code: exitCodeGateFailed, msg: "pass rate"}, wantCode: 5},
+		{name: "missing input", err: runCLI(context.Background(), []string{filepath.Join(dir, "missing.json")}), wantCode: exitCodeInput},
+		{name: "empty input", err: runCLI(context.Background(), []string{empty}), wantCode: 2, wantHint: "has no results"},
+		{name: "no results", err: runCLI(context.Background(), []string{noResults}), wantCode: 2, wantHint: "--allow-empty"},
+		{name: "invalid JSON", err: runCLI(context.Background(), []string{broken}), wantCode: 2, wantHint: "--lenient"},
+		{name: "buffered invalid JSON", err: runCLI(context.Background(), []string{"--buffered", broken}), wantCode: 2, wantHint: "--lenient"},
+		{name: "validation", err: runCLI(context.Background(), []string{"validate", broken}), wantCode: exitCodeInput},
+		{name: "conversion", err: fmt.Errorf("rendering report: %w", converter.ErrDuplicateTestCase), wantCode: 3},
+		{name: "publish", err: publishError{err: errors.New("POST https://ci.example.com: unexpected status 502 Bad Gateway")}, wantCode: 6},
+		{name: "publish input", err: runCLI(context.Background(), []string{"publish", "otel", "--endpoint", "http://127.0.0.1:1", broken}), wantCode: exitCodeInput, wantHint: "--lenient"},
+		{name: "interrupted", err: fmt.Errorf("parsing %s: %w", broken, context.Canceled), wantCode: 130},
+		{name: "other", err: errors.New("boom"), wantCode: exitCodeConversion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exitCode(tt.err); got != tt.wantCode {
+				t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.wantCode)
+			}
+			hint := ""
+			if tt.err != nil {
+				hint = errorHint(tt.err)
+			}
+			if (tt.wantHint == "") != (hint == "") || !strings.Contains(hint, tt.wantHint) {
+				t.Errorf("errorHint(%v) = %q, want a hint containing %q", tt.err, hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestRunCLICommands(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte("["+resultA+"]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		command []string
+		inputs  []string
+	}{
+		{name: "convert alias", inputs: []string{input}},
+		{name: "convert", command: []string{"convert"}, inputs: []string{input}},
+		{name: "merge", command: []string{"merge"}, inputs: []string{input, input}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "report.xml")
+			args := append(append(tt.command, "--output", output), tt.inputs...)
+			if err := runCLI(context.Background(), args); err != nil {
+				t.Fatalf("runCLI(%q) error = %v", args, err)
+			}
+			report, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(report), `<testcase name="a"`) {
+				t.Errorf("unexpected report:\n%s", report)
+			}
+		})
+	}
+}
+
+func TestReportFlags(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"results.json":  "[" + resultA + `,{"taskName":"b","taskPassed":false,"taskOutput":"partial","taskError":"\u001b[31mquota\u001b[0m exceeded\u0000"}]`,
+		"out.tmpl":      "{{.TaskName}} took the custom layout",
+		"classify.yaml": "rules:\n  - taskError: quota\n    status: skipped\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(dir, "report.xml")
+	args := []string{"--output", output, "--report-name", "nightly", "--suite-prefix", "nightly-k8s", "--system-out-template", filepath.Join(dir, "out.tmpl"),
+		"--classify-rules", filepath.Join(dir, "classify.yaml"), "--strip-ansi", "--timeout-pattern", "quota", "--path-prefix", "/x/", "--check",
+		"--attachments-dir", filepath.Join(dir, "attachments"), filepath.Join(dir, "results.json")}
+	if err := runCLI(context.Background(), args); err != nil {
+		t.Fatalf("runCLI(%q) error = %v", args, err)
+	}
+	report, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<testsuites name="nightly" tests="2" failures="0" errors="0" skipped="1">`,
+		`<testsuite name="nightly-k8s - easy"`,
+		"<system-out><![CDATA[a took the custom layout]]></system-out>",
+		"<skipped message=",
+		`<testcase name="a" classname="tasks.a" file="tasks/a/task.yaml">`,
+		`<property name="timed_out" value="true"></property>`,
+		"<system-err><![CDATA[quota exceeded\uFFFD]]></system-err>",
+		"b took the custom layout\n[[ATTACHMENT|" + filepath.Join(dir, "attachments", "b", "task-output.txt") + "]]\n",
+	} {
+		if !strings.Contains(string(report), want) {
+			t.Errorf("report does not contain %q:\n%s", want, report)
+		}
+	}
+}
+
+func TestFilterTag(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	results := `[{"taskName":"a","taskPassed":true,"tags":["smoke"]},{"taskName":"b","taskPassed":true,"tags":["smoke","flaky"]},{"taskName":"c","taskPassed":true}]`
+	if err := os.WriteFile(input, []byte(results), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "report.xml")
+
+	if err := runCLI(context.Background(), []string{"--filter-tag", "smoke,!flaky", "--output", output, input}); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(report), `<testcase name="a"`) || strings.Contains(string(report), `<testcase name="b"`) || strings.Contains(string(report), `<testcase name="c"`) {
+		t.Errorf("report does not keep only the smoke task without the flaky tag:\n%s", report)
+	}
+}
+
+func TestAllowEmpty(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{"empty.json": "", "no-results.json": "[]", "envelope.json": `{"runId":"r1","results":[]}`} {
+		t.Run(name, func(t *testing.T) {
+			input := filepath.Join(dir, name)
+			if err := os.WriteFile(input, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			output := filepath.Join(dir, name+".xml")
+			if err := runCLI(context.Background(), []string{"--output", output, input}); err == nil {
+				t.Fatal("converting an input without results succeeded, want an error")
+			}
+			if _, err := os.Stat(output); !os.IsNotExist(err) {
+				t.Errorf("report written for an input without results: %v", err)
+			}
+
+			if err := runCLI(context.Background(), []string{"--allow-empty", "--check", "--output", output, input}); err != nil {
+				t.Fatalf("runCLI(--allow-empty) error = %v", err)
+			}
+			report, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := `<testsuite name="MCP Checker Tests" tests="0" failures="0" errors="0" skipped="0"`; !strings.Contains(string(report), want) {
+				t.Errorf("report does not contain %q:\n%s", want, report)
+			}
+		})
+	}
+}
+
+func TestFlagEnv(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte(`[{"taskName":"a"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	envOutput := filepath.Join(dir, "env.xml")
+	flagOutput := filepath.Join(dir, "flag.xml")
+
+	t.Run("env sets a flag", func(t *testing.T) {
+		t.Setenv("MCPJUNIT_OUTPUT", envOutput)
+		if err := runCLI(context.Background(), []string{input}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(envOutput); err != nil {
+			t.Errorf("report not written to MCPJUNIT_OUTPUT: %v", err)
+		}
+	})
+
+	t.Run("command line wins over env", func(t *testing.T) {
+		t.Setenv("MCPJUNIT_OUTPUT", envOutput)
+		if err := runCLI(context.Background(), []string{"--output", flagOutput, input}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(flagOutput); err != nil {
+			t.Errorf("report not written to --output: %v", err)
+		}
+	})
+
+	t.Run("env sets an input flag", func(t *testing.T) {
+		t.Setenv("MCPJUNIT_OUTPUT", envOutput)
+		t.Setenv("MCPJUNIT_STRICT", "true")
+		var schemaErrs converter.SchemaErrors
+		if err := runCLI(context.Background(), []string{input}); !errors.As(err, &schemaErrs) {
+			t.Errorf("runCLI() error = %v, want schema errors from MCPJUNIT_STRICT", err)
+		}
+		if err := runCLI(context.Background(), []string{"--strict=false", input}); err != nil {
+			t.Errorf("--strict=false did not override MCPJUNIT_STRICT: %v", err)
+		}
+	})
+
+	t.Run("invalid env value", func(t *testing.T) {
+		t.Setenv("MCPJUNIT_CONVERT_HTTP_RETRIES", "many")
+		var usageErr usageError
+		if err := runCLI(context.Background(), []string{input}); !errors.As(err, &usageErr) || !strings.Contains(err.Error(), "MCPJUNIT_CONVERT_HTTP_RETRIES") {
+			t.Errorf("runCLI() error = %v, want a usage error naming the variable", err)
+		}
+	})
+}
+
+func TestFlagEnvPrecedence(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+	var logs bytes.Buffer
+	previousLog := logOutput
+	logOutput = &logs
+	t.Cleanup(func() { logOutput = previousLog })
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	results := `[{"taskName":"a","taskPassed":false,"taskOutput":"env-secret cli-secret","allAssertionsPassed":false}]`
+	if err := os.WriteFile(input, []byte(results), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(dir, "report.xml")
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		// output is the file the command writes, stdout when empty
+		output   string
+		want     []string
+		wantErr  bool
+		wantLogs string
+	}{
+		{
+			name:   "repeatable flag from env",
+			env:    map[string]string{"MCPJUNIT_REDACT": "env-secret"},
+			args:   []string{"--output", report, input},
+			output: report,
+			want:   []string{"[REDACTED] cli-secret"},
+		},
+		{
+			name:   "repeatable flag on the command line replaces env",
+			env:    map[string]string{"MCPJUNIT_REDACT": "env-secret"},
+			args:   []string{"--redact", "cli-secret", "--output", report, input},
+			output: report,
+			want:   []string{"env-secret [REDACTED]"},
+		},
+		{
+			name:   "command variable wins over the shared one",
+			env:    map[string]string{"MCPJUNIT_REDACT": "env-secret", "MCPJUNIT_CONVERT_REDACT": "cli-secret"},
+			args:   []string{"--output", report, input},
+			output: report,
+			want:   []string{"env-secret [REDACTED]"},
+		},
+		{
+			name:     "shared value of another command is skipped",
+			env:      map[string]string{"MCPJUNIT_FORMAT": "html"},
+			args:     []string{"--output", report, input},
+			output:   report,
+			want:     []string{`<testcase name="a"`},
+			wantLogs: "MCPJUNIT_FORMAT",
+		},
+		{
+			name: "shared value the command accepts",
+			env:  map[string]string{"MCPJUNIT_FORMAT": "html"},
+			args: []string{"diff", input, input},
+			want: []string{"<title>Run comparison</title>"},
+		},
+		{
+			name: "command variable of diff",
+			env:  map[string]string{"MCPJUNIT_FORMAT": "html", "MCPJUNIT_DIFF_FORMAT": "json"},
+			args: []string{"diff", input, input},
+			want: []string{"[]"},
+		},
+		{
+			name:    "invalid command variable",
+			env:     map[string]string{"MCPJUNIT_DIFF_FORMAT": "pdf"},
+			args:    []string{"diff", input, input},
+			wantErr: true,
+		},
+		{
+			name:     "invalid shared value",
+			env:      map[string]string{"MCPJUNIT_HTTP_RETRIES": "many"},
+			args:     []string{"--output", report, input},
+			output:   report,
+			want:     []string{`<testcase name="a"`},
+			wantLogs: "MCPJUNIT_HTTP_RETRIES",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			out.Reset()
+			logs.Reset()
+			err := runCLI(context.Background(), tt.args)
+			var usage usageError
+			if tt.wantErr {
+				if !errors.As(err, &usage) {
+					t.Errorf("runCLI() error = %v, want a usage error", err)
+				}
+				return
+			}
+			if err != nil && exitCode(err) == exitCodeUsage {
+				t.Fatalf("runCLI() error = %v", err)
+			}
+			got := out.String()
+			if tt.output != "" {
+				data, err := os.ReadFile(tt.output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = string(data)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("output does not contain %q:\n%s", want, got)
+				}
+			}
+			if !strings.Contains(logs.String(), tt.wantLogs) {
+				t.Errorf("logs = %q, want a warning about %s", logs.String(), tt.wantLogs)
+			}
+		})
+	}
+}
+
+func TestFlagEnvName(t *testing.T) {
+	for name, want := range map[string]string{
+		"output":         "MCPJUNIT_OUTPUT",
+		"input-format":   "MCPJUNIT_INPUT_FORMAT",
+		"http-token-env": "MCPJUNIT_HTTP_TOKEN_ENV",
+	} {
+		if got := flagEnvName(name); got != want {
+			t.Errorf("flagEnvName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
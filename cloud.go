@@ -0,0 +1,279 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// Object storage URI schemes accepted for inputs and outputs
+const (
+	schemeS3  = "s3"
+	schemeGCS = "gs"
+)
+
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// cloudObject is an object addressed by an s3://bucket/key or gs://bucket/key URI
+type cloudObject struct {
+	Scheme string
+	Bucket string
+	Key    string
+}
+
+// isCloudURI reports whether a path refers to S3 or GCS object storage
+func isCloudURI(path string) bool {
+	return strings.HasPrefix(path, schemeS3+"://") || strings.HasPrefix(path, schemeGCS+"://")
+}
+
+func parseCloudURI(uri string) (cloudObject, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return cloudObject{}, err
+	}
+	object := cloudObject{
+		Scheme: parsed.Scheme,
+		Bucket: parsed.Host,
+		Key:    strings.TrimPrefix(parsed.Path, "/"),
+	}
+	if object.Bucket == "" || object.Key == "" {
+		return cloudObject{}, fmt.Errorf("invalid object URI %q, expected %s://bucket/key", uri, object.Scheme)
+	}
+	return object, nil
+}
+
+// loadCloudObject downloads and parses the results stored at uri
+func loadCloudObject(ctx context.Context, uri string, opts inputOptions) (converter.TestRun, error) {
+	start := time.Now()
+	object, err := parseCloudURI(uri)
+	if err != nil {
+		return converter.TestRun{}, err
+	}
+
+	data, err := readCloudObject(ctx, object)
+	if err != nil {
+		return converter.TestRun{}, fmt.Errorf("reading %s: %w", uri, err)
+	}
+
+	if opts.Format == converter.FormatAuto {
+		opts.Format = inputFormatForFile(object.Key)
+	}
+
+	run, err := converter.ParseContext(ctx, bytes.NewReader(data), opts.ParseOptions)
+	if err != nil {
+		return run, fmt.Errorf("parsing %s: %w", uri, err)
+	}
+	run.SetSource(uri)
+	logParsed(uri, run, start)
+	return run, nil
+}
+
+// writeCloudOutput uploads the report to uri
+func writeCloudOutput(ctx context.Context, uri string, data []byte) error {
+	object, err := parseCloudURI(uri)
+	if err != nil {
+		return err
+	}
+	if err := writeCloudObject(ctx, object, data); err != nil {
+		return fmt.Errorf("writing %s: %w", uri, err)
+	}
+	return nil
+}
+
+func readCloudObject(ctx context.Context, object cloudObject) ([]byte, error) {
+	if object.Scheme == schemeGCS {
+		return readGCSObject(ctx, object)
+	}
+
+	client, err := newS3Client(ctx, object.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	out, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(object.Bucket),
+		Key:    aws.String(object.Key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+func writeCloudObject(ctx context.Context, object cloudObject, data []byte) error {
+	if object.Scheme == schemeGCS {
+		return writeGCSObject(ctx, object, data)
+	}
+
+	client, err := newS3Client(ctx, object.Bucket)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(object.Bucket),
+		Key:         aws.String(object.Key),
+		Body:        bytes.NewReader(data),
+		ContentType: aws.String(contentTypeFor(object.Key)),
+	})
+	return err
+}
+
+// defaultS3Region is used when the bucket region cannot be looked up and
+// none is configured in the environment, shared config or instance metadata
+const defaultS3Region = "us-east-1"
+
+// s3RegionEndpoint answers bucket region lookups for any bucket
+var s3RegionEndpoint = "https://s3.amazonaws.com"
+
+// newS3Client loads the ambient AWS configuration (environment, shared
+// config files, web identity or instance roles) and points the client at
+// the bucket's own region, so buckets outside the configured region work
+// instead of failing with a redirect
+func newS3Client(ctx context.Context, bucket string) (*s3.Client, error) {
+	transport, err := httpSettings.transport()
+	if err != nil {
+		return nil, err
+	}
+	// The SDK retries on its own, so it only shares the proxy, CA
+	// certificates, timeout and retry count of the shared client
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithEC2IMDSRegion(),
+		config.WithHTTPClient(&http.Client{Transport: transport, Timeout: httpSettings.Timeout}),
+		config.WithRetryMaxAttempts(httpSettings.Retries+1))
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS configuration: %w", err)
+	}
+
+	// Custom endpoints (MinIO, localstack, ...) use the configured region as is
+	if cfg.BaseEndpoint == nil {
+		if region, err := lookupBucketRegion(ctx, bucket); err == nil {
+			cfg.Region = region
+		} else if cfg.Region == "" {
+			return nil, fmt.Errorf("finding region of bucket %s: %w", bucket, err)
+		}
+	}
+	if cfg.Region == "" {
+		cfg.Region = defaultS3Region
+	}
+	return s3.NewFromConfig(cfg), nil
+}
+
+// lookupBucketRegion asks S3 for a bucket's region. The region header is
+// returned on anonymous requests even when access is denied or the bucket
+// lives in another region, so no credentials are needed.
+func lookupBucketRegion(ctx context.Context, bucket string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s3RegionEndpoint+"/"+url.PathEscape(bucket), nil)
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	client := &http.Client{
+		Transport: httpClient.Transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	region := resp.Header.Get("X-Amz-Bucket-Region")
+	if region == "" {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return region, nil
+}
+
+// newGCSClient returns an HTTP client authorized with Application Default
+// Credentials (GOOGLE_APPLICATION_CREDENTIALS, gcloud login or the metadata
+// server), sending its requests, token ones included, with the shared client
+func newGCSClient(ctx context.Context) (*http.Client, error) {
+	client, err := google.DefaultClient(context.WithValue(ctx, oauth2.HTTPClient, httpClient), gcsScope)
+	if err != nil {
+		return nil, fmt.Errorf("loading Google credentials: %w", err)
+	}
+	return client, nil
+}
+
+// gcsEndpoint is the Cloud Storage JSON API base URL
+var gcsEndpoint = "https://storage.googleapis.com"
+
+func readGCSObject(ctx context.Context, object cloudObject) ([]byte, error) {
+	client, err := newGCSClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return getGCSObject(ctx, client, object)
+}
+
+func writeGCSObject(ctx context.Context, object cloudObject, data []byte) error {
+	client, err := newGCSClient(ctx)
+	if err != nil {
+		return err
+	}
+	return putGCSObject(ctx, client, object, data)
+}
+
+func getGCSObject(ctx context.Context, client *http.Client, object cloudObject) ([]byte, error) {
+	endpoint := fmt.Sprintf("%s/storage/v1/b/%s/o/%s?alt=media",
+		gcsEndpoint, url.PathEscape(object.Bucket), url.PathEscape(object.Key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func putGCSObject(ctx context.Context, client *http.Client, object cloudObject, data []byte) error {
+	endpoint := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
+		gcsEndpoint, url.PathEscape(object.Bucket), url.QueryEscape(object.Key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeFor(object.Key))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// contentTypeFor picks the uploaded object's content type from its key
+func contentTypeFor(key string) string {
+	if strings.HasSuffix(strings.ToLower(key), ".xml") {
+		return "application/xml"
+	}
+	return "application/octet-stream"
+}
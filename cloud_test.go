@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseCloudURI(t *testing.T) {
+	tests := []struct {
+		uri     string
+		want    cloudObject
+		wantErr bool
+	}{
+		{uri: "s3://bucket/results.json", want: cloudObject{Scheme: schemeS3, Bucket: "bucket", Key: "results.json"}},
+		{uri: "gs://bucket/run 1/results.json.gz", want: cloudObject{Scheme: schemeGCS, Bucket: "bucket", Key: "run 1/results.json.gz"}},
+		{uri: "s3://bucket", wantErr: true},
+		{uri: "gs:///key", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCloudURI(tt.uri)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCloudURI(%q) succeeded, want an error", tt.uri)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseCloudURI(%q) = %+v, %v, want %+v", tt.uri, got, err, tt.want)
+		}
+	}
+
+	for path, want := range map[string]bool{"s3://b/k": true, "gs://b/k": true, "results.json": false, "https://b/k": false} {
+		if got := isCloudURI(path); got != want {
+			t.Errorf("isCloudURI(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestLookupBucketRegion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		switch r.URL.Path {
+		case "/eu-bucket":
+			// S3 answers with a redirect for buckets in other regions
+			w.Header().Set("X-Amz-Bucket-Region", "eu-west-1")
+			w.WriteHeader(http.StatusMovedPermanently)
+		case "/private-bucket":
+			w.Header().Set("X-Amz-Bucket-Region", "us-west-2")
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	previous := s3RegionEndpoint
+	s3RegionEndpoint = server.URL
+	t.Cleanup(func() { s3RegionEndpoint = previous })
+
+	for bucket, want := range map[string]string{"eu-bucket": "eu-west-1", "private-bucket": "us-west-2"} {
+		if got, err := lookupBucketRegion(context.Background(), bucket); err != nil || got != want {
+			t.Errorf("lookupBucketRegion(%q) = %q, %v, want %q", bucket, got, err, want)
+		}
+	}
+	if _, err := lookupBucketRegion(context.Background(), "missing"); err == nil {
+		t.Error("lookupBucketRegion() for a missing bucket succeeded, want an error")
+	}
+}
+
+func TestGCSObjectRequests(t *testing.T) {
+	var uploaded, contentType, uploadName string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.EscapedPath() == "/storage/v1/b/bucket/o/run%2Fresults.json":
+			w.Write([]byte("[" + resultA + "]"))
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/bucket/o":
+			body, _ := io.ReadAll(r.Body)
+			uploaded = string(body)
+			contentType = r.Header.Get("Content-Type")
+			uploadName = r.URL.Query().Get("name")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	previous := gcsEndpoint
+	gcsEndpoint = server.URL
+	t.Cleanup(func() { gcsEndpoint = previous })
+
+	ctx := context.Background()
+	data, err := getGCSObject(ctx, server.Client(), cloudObject{Scheme: schemeGCS, Bucket: "bucket", Key: "run/results.json"})
+	if err != nil || string(data) != "["+resultA+"]" {
+		t.Errorf("getGCSObject() = %q, %v", data, err)
+	}
+	if _, err := getGCSObject(ctx, server.Client(), cloudObject{Scheme: schemeGCS, Bucket: "bucket", Key: "missing.json"}); err == nil {
+		t.Error("getGCSObject() for a missing object succeeded, want an error")
+	}
+
+	if err := putGCSObject(ctx, server.Client(), cloudObject{Scheme: schemeGCS, Bucket: "bucket", Key: "out/report.xml"}, []byte("<testsuites/>")); err != nil {
+		t.Fatal(err)
+	}
+	if uploaded != "<testsuites/>" || contentType != "application/xml" || uploadName != "out/report.xml" {
+		t.Errorf("upload = %q (%s) named %q", uploaded, contentType, uploadName)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Supported values for the --color flag
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+var colorValues = []string{colorAuto, colorAlways, colorNever}
+
+// ANSI colors of the console output
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiBold   = "\x1b[1m"
+	ansiReset  = "\x1b[0m"
+)
+
+// useColor resolves a --color value for output written to w. In auto mode,
+// color is used when w is a terminal and NO_COLOR is not set.
+func useColor(mode string, w io.Writer) bool {
+	switch mode {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorMode is the --color value of the command, set by setupLogging
+var colorMode = colorAuto
+
+// outcomeColor returns the color of an outcome recorded by history: green
+// when passed, yellow on a failure and red on an error
+func outcomeColor(outcome string) string {
+	switch outcome {
+	case outcomePassed:
+		return ansiGreen
+	case outcomeFailure:
+		return ansiYellow
+	case outcomeError:
+		return ansiRed
+	default:
+		return ""
+	}
+}
+
+// printGateFailure writes the message of a failed gate to w in color: red
+// for the gates that failed and yellow for the tasks listed under them
+func printGateFailure(w io.Writer, msg string) {
+	for _, line := range strings.Split(msg, "\n") {
+		color := ansiBold + ansiRed
+		if strings.HasPrefix(line, "  ") {
+			color = ansiYellow
+		}
+		fmt.Fprintln(w, color+line+ansiReset)
+	}
+}
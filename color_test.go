@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestUseColor(t *testing.T) {
+	var buf bytes.Buffer
+	if !useColor(colorAlways, &buf) || useColor(colorNever, os.Stdout) || useColor(colorAuto, &buf) {
+		t.Error("unexpected color decision for always, never or a non-terminal writer")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if useColor(colorAuto, os.Stdout) {
+		t.Error("NO_COLOR did not disable color")
+	}
+}
+
+func TestPrintGateFailure(t *testing.T) {
+	var out bytes.Buffer
+	printGateFailure(&out, "Pass-rate gate failed: all tasks passed 50.0% (1/2)\nBaseline regressions: 1 tasks that passed in the baseline fail now:\n  create-pod (failure)")
+	want := ansiBold + ansiRed + "Pass-rate gate failed: all tasks passed 50.0% (1/2)" + ansiReset + "\n" +
+		ansiBold + ansiRed + "Baseline regressions: 1 tasks that passed in the baseline fail now:" + ansiReset + "\n" +
+		ansiYellow + "  create-pod (failure)" + ansiReset + "\n"
+	if out.String() != want {
+		t.Errorf("printGateFailure() = %q, want %q", out.String(), want)
+	}
+}
+
+func TestColorFlag(t *testing.T) {
+	captureLog(t)
+	if err := runCLI(context.Background(), []string{"version", "--color", "always"}); err != nil {
+		t.Fatal(err)
+	}
+	if colorMode != colorAlways || !colorLogs {
+		t.Errorf("--color always: mode = %q, colored logs = %v", colorMode, colorLogs)
+	}
+	if err := runCLI(context.Background(), []string{"version", "--color", "always", "--log-format", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	if colorLogs {
+		t.Error("JSON logs are colored")
+	}
+	var usageErr usageError
+	if err := runCLI(context.Background(), []string{"version", "--color", "rainbow"}); !errors.As(err, &usageErr) {
+		t.Errorf("--color rainbow error = %v, want a usage error", err)
+	}
+}
@@ -0,0 +1,50 @@
+package converter
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// artifactMarkers lists the artifacts of a result under a header, one per
+// line: files as [[ATTACHMENT|path]] markers, referencing their copy in
+// copies when the attachments directory has one, and URLs as they are, for
+// the test report pages to link
+func artifactMarkers(test MCPTestResult, msg *messages, copies map[string]string) string {
+	if len(test.Artifacts) == 0 {
+		return ""
+	}
+	var markers strings.Builder
+	markers.WriteString(msg.Artifacts + ":\n")
+	for _, artifact := range test.Artifacts {
+		if isArtifactURL(artifact) {
+			markers.WriteString(artifact + "\n")
+			continue
+		}
+		fmt.Fprintf(&markers, "[[ATTACHMENT|%s]]\n", cmp.Or(copies[artifact], artifactPath(test, artifact)))
+	}
+	return markers.String()
+}
+
+// isArtifactURL reports whether an artifact is a link rather than a file
+func isArtifactURL(artifact string) bool {
+	return strings.Contains(artifact, "://")
+}
+
+// artifactPath returns the absolute path of an artifact file, as the markers
+// require. A relative path is taken from the directory of the input file the
+// result was read from, where the checker left the artifacts, or from the
+// working directory when the result was not read from a local file.
+func artifactPath(test MCPTestResult, artifact string) string {
+	if !filepath.IsAbs(artifact) && test.SourceFile != "" {
+		if info, err := os.Stat(test.SourceFile); err == nil && info.Mode().IsRegular() {
+			artifact = filepath.Join(filepath.Dir(test.SourceFile), artifact)
+		}
+	}
+	if abs, err := filepath.Abs(artifact); err == nil {
+		return abs
+	}
+	return artifact
+}
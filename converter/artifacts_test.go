@@ -0,0 +1,101 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArtifacts(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := mustParse(t, `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+		 "artifacts":["out/screenshot.png","/var/log/verify.log","https://ci.example.com/artifacts/a.tar.gz"]},
+		{"task_name":"b","difficulty":"easy","task_passed":true,"all_assertions_passed":true,"artifacts":["b.log"]},
+		{"taskName":"c","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+
+	tests := []struct {
+		name string
+		opts options
+		want []string
+	}{
+		{
+			name: "english",
+			opts: options{Sort: SortSorted},
+			want: []string{
+				"\nArtifacts:\n[[ATTACHMENT|" + filepath.Join(wd, "out", "screenshot.png") + "]]\n[[ATTACHMENT|/var/log/verify.log]]\nhttps://ci.example.com/artifacts/a.tar.gz\n",
+				"\nArtifacts:\n[[ATTACHMENT|" + filepath.Join(wd, "b.log") + "]]\n",
+				"",
+			},
+		},
+		{
+			name: "spanish",
+			opts: options{Sort: SortSorted, Lang: LangSpanish},
+			want: []string{
+				"\nArtefactos:\n[[ATTACHMENT|" + filepath.Join(wd, "out", "screenshot.png") + "]]\n[[ATTACHMENT|/var/log/verify.log]]\nhttps://ci.example.com/artifacts/a.tar.gz\n",
+				"\nArtefactos:\n[[ATTACHMENT|" + filepath.Join(wd, "b.log") + "]]\n",
+				"",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := mustConvert(t, run, tt.opts)
+			for i, testCase := range report.Suites[0].TestCases {
+				if tt.want[i] == "" {
+					if strings.Contains(testCase.SystemOut, "ATTACHMENT") {
+						t.Errorf("%s: system-out = %q, want no artifacts", testCase.Name, testCase.SystemOut)
+					}
+					continue
+				}
+				if !strings.HasSuffix(testCase.SystemOut, tt.want[i]) {
+					t.Errorf("%s: system-out = %q, want it to end with %q", testCase.Name, testCase.SystemOut, tt.want[i])
+				}
+			}
+		})
+	}
+
+	// Testcases without system-out have nowhere to list artifacts
+	report := mustConvert(t, run, options{Sort: SortSorted, NoSystemOut: true})
+	if got := report.Suites[0].TestCases[0].SystemOut; got != "" {
+		t.Errorf("system-out = %q, want none with NoSystemOut", got)
+	}
+}
+
+func TestArtifactPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte("[]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		source   string
+		artifact string
+		want     string
+	}{
+		{name: "relative to the input", source: input, artifact: "out/screenshot.png", want: filepath.Join(dir, "out", "screenshot.png")},
+		{name: "absolute", source: input, artifact: "/var/log/verify.log", want: "/var/log/verify.log"},
+		{name: "no source", artifact: "b.log", want: filepath.Join(wd, "b.log")},
+		{name: "stdin", source: "stdin", artifact: "b.log", want: filepath.Join(wd, "b.log")},
+		{name: "remote input", source: "https://ci.example.com/results.json", artifact: "b.log", want: filepath.Join(wd, "b.log")},
+		{name: "archive member", source: input + ":results.json", artifact: "b.log", want: filepath.Join(wd, "b.log")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := artifactPath(MCPTestResult{SourceFile: tt.source}, tt.artifact); got != tt.want {
+				t.Errorf("artifactPath(%q) = %q, want %q", tt.artifact, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxDiffLines bounds the lines of the expected and actual values compared
+// line by line; longer values are shown one after the other
+const maxDiffLines = 1000
+
+// assertionDetails explains a failed assertion: its message, if any, then
+// its expected and actual values, as a line diff when either spans several
+// lines. Every line is indented with indent.
+func assertionDetails(assertion Assertion, msg *messages, indent string) string {
+	expected, actual := formatAssertionValue(assertion.Expected), formatAssertionValue(assertion.Actual)
+	var lines []string
+	switch {
+	case assertion.Expected == nil && assertion.Actual == nil:
+	case assertion.Expected != nil && assertion.Actual != nil && (strings.Contains(expected, "\n") || strings.Contains(actual, "\n")):
+		lines = append(lines, "--- "+msg.Expected, "+++ "+msg.Actual)
+		lines = append(lines, lineDiff(strings.Split(expected, "\n"), strings.Split(actual, "\n"))...)
+	default:
+		width := max(utf8.RuneCountInString(msg.Expected), utf8.RuneCountInString(msg.Actual)) + 1
+		if assertion.Expected != nil {
+			lines = append(lines, fmt.Sprintf("%-*s %s", width, msg.Expected+":", strings.ReplaceAll(expected, "\n", "\n"+indent)))
+		}
+		if assertion.Actual != nil {
+			lines = append(lines, fmt.Sprintf("%-*s %s", width, msg.Actual+":", strings.ReplaceAll(actual, "\n", "\n"+indent)))
+		}
+	}
+
+	var details strings.Builder
+	for _, line := range lines {
+		details.WriteString(indent + line + "\n")
+	}
+	return details.String()
+}
+
+// formatAssertionValue renders an expected or actual value: strings as they
+// are, anything else as indented JSON
+func formatAssertionValue(value interface{}) string {
+	if text, ok := value.(string); ok {
+		return text
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
+
+// lineDiff compares expected and actual line by line, marking the lines
+// only in expected with "- ", those only in actual with "+ " and the common
+// ones with two spaces
+func lineDiff(expected, actual []string) []string {
+	if len(expected) > maxDiffLines || len(actual) > maxDiffLines {
+		var lines []string
+		for _, line := range expected {
+			lines = append(lines, "- "+line)
+		}
+		for _, line := range actual {
+			lines = append(lines, "+ "+line)
+		}
+		return lines
+	}
+
+	// common[i][j] is the length of the longest common subsequence of
+	// expected[i:] and actual[j:]
+	common := make([][]int, len(expected)+1)
+	for i := range common {
+		common[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var lines []string
+	i, j := 0, 0
+	for i < len(expected) && j < len(actual) {
+		switch {
+		case expected[i] == actual[j]:
+			lines = append(lines, "  "+expected[i])
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, "- "+expected[i])
+			i++
+		default:
+			lines = append(lines, "+ "+actual[j])
+			j++
+		}
+	}
+	for ; i < len(expected); i++ {
+		lines = append(lines, "- "+expected[i])
+	}
+	for ; j < len(actual); j++ {
+		lines = append(lines, "+ "+actual[j])
+	}
+	return lines
+}
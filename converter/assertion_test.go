@@ -0,0 +1,63 @@
+package converter
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLineDiff(t *testing.T) {
+	got := lineDiff(strings.Split("a\nb\nc\nd", "\n"), strings.Split("a\nc\nx\nd", "\n"))
+	want := []string{"  a", "- b", "  c", "+ x", "  d"}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lineDiff() = %q, want %q", got, want)
+	}
+
+	// Past the size limit the values are shown whole
+	long := strings.Split(strings.Repeat("x\n", maxDiffLines+1), "\n")
+	if got := lineDiff(long, []string{"y"}); len(got) != len(long)+1 || got[len(got)-1] != "+ y" {
+		t.Errorf("lineDiff() of long values = %d lines ending with %q", len(got), got[len(got)-1])
+	}
+}
+
+func TestAssertionExpectedActual(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{
+			"toolWasCalled":{"passed":false,"message":"tool create was not called","expected":"create","actual":"delete"},
+			"minToolCalls":{"passed":false,"expected":2},
+			"output":{"passed":false,"expected":"line 1\nline 2\nline 3","actual":"line 1\nline two\nline 3"},
+			"toolsUsed":{"passed":true,"expected":"x","actual":"y"}}},
+		{"task_name":"b","task_passed":true,"all_assertions_passed":false,"assertion_results":{
+			"args":{"passed":false,"details":{"message":"wrong arguments","expected":{"replicas":3},"actual":{"replicas":1}}}}}
+	]`)
+
+	a := convertTestCase(run.Results[0], options{})
+	for _, want := range []string{
+		"  - minToolCalls\n      expected: 2\n",
+		"  - output\n      --- expected\n      +++ actual\n        line 1\n      - line 2\n      + line two\n        line 3\n",
+		"  - toolWasCalled: tool create was not called\n      expected: create\n      actual:   delete\n",
+	} {
+		if !strings.Contains(a.Failure.Content, want) {
+			t.Errorf("failure content = %q, want it to contain %q", a.Failure.Content, want)
+		}
+	}
+	if strings.Contains(a.Failure.Content, "toolsUsed") {
+		t.Errorf("failure content mentions a passed assertion:\n%s", a.Failure.Content)
+	}
+
+	b := convertTestCase(run.Results[1], options{Lang: LangPortuguese})
+	want := "  - args: wrong arguments\n      --- esperado\n      +++ obtido\n        {\n      -   \"replicas\": 3\n      +   \"replicas\": 1\n        }\n"
+	if !strings.Contains(b.Failure.Content, want) {
+		t.Errorf("failure content = %q, want it to contain %q", b.Failure.Content, want)
+	}
+
+	// Exploded assertions carry the same details
+	exploded := explodeAssertions(run.Results[0], a, options{})
+	i := slices.IndexFunc(exploded, func(tc JUnitTestCase) bool { return tc.Name == "a::toolWasCalled" })
+	if i < 0 {
+		t.Fatalf("no exploded testcase a::toolWasCalled in %+v", exploded)
+	}
+	if want := "tool create was not called\nexpected: create\nactual:   delete\n"; exploded[i].Failure.Content != want {
+		t.Errorf("exploded failure content = %q, want %q", exploded[i].Failure.Content, want)
+	}
+}
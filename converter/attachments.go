@@ -0,0 +1,206 @@
+package converter
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Names of the files written for each testcase in the attachments directory
+const (
+	ToolCallsAttachment     = "tool-calls.json"
+	ResourceReadsAttachment = "resource-reads.txt"
+	TaskOutputAttachment    = "task-output.txt"
+	ConversationAttachment  = "conversation.txt"
+)
+
+// attachmentName matches the characters that are replaced in the directory
+// name derived from a task name
+var attachmentName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// attachmentWriter writes the full artifacts of each result to a directory
+// of its own, which the testcase references with [[ATTACHMENT|path]]
+// markers. The Jenkins JUnit Attachments plugin and Allure both pick these up.
+type attachmentWriter struct {
+	dir        string
+	redactions []Redaction
+	// used holds the directory names given so far, so that tasks whose
+	// names only differ in replaced characters, or repeat, do not collide
+	used map[string]bool
+}
+
+// attachment is a file to write for a result
+type attachment struct {
+	name    string
+	content string
+}
+
+// newAttachmentWriter creates dir and returns a writer storing attachments
+// under its absolute path, which the markers require
+func newAttachmentWriter(dir string, redactions []Redaction) (*attachmentWriter, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("attachments directory: %w", err)
+	}
+	if err := os.MkdirAll(abs, 0o755); err != nil {
+		return nil, fmt.Errorf("attachments directory: %w", err)
+	}
+	return &attachmentWriter{dir: abs, redactions: redactions, used: map[string]bool{}}, nil
+}
+
+// attach writes the tool calls and task output of a result, redacted like
+// the rest of the testcase, and copies its artifact files next to them. It
+// returns the markers referencing the files written, one per line, and the
+// paths of the copies by artifact.
+func (w *attachmentWriter) attach(test MCPTestResult) (string, map[string]string, error) {
+	var files []attachment
+	if len(test.CallHistory.ToolCalls) > 0 || len(test.CallHistory.ResourceReads) > 0 {
+		calls, err := json.MarshalIndent(test.CallHistory, "", "  ")
+		if err != nil {
+			return "", nil, fmt.Errorf("task %s: attaching tool calls: %w", test.TaskName, err)
+		}
+		files = append(files, attachment{ToolCallsAttachment, string(calls) + "\n"})
+	}
+	if content := resourceContents(test.CallHistory.ResourceReads); content != "" {
+		files = append(files, attachment{ResourceReadsAttachment, content})
+	}
+	if test.TaskOutput != "" {
+		files = append(files, attachment{TaskOutputAttachment, test.TaskOutput})
+	}
+	if len(test.Conversation) > 0 {
+		files = append(files, attachment{ConversationAttachment, formatConversation(test.Conversation)})
+	}
+	artifacts := slices.DeleteFunc(slices.Clone(test.Artifacts), isArtifactURL)
+	if len(files) == 0 && len(artifacts) == 0 {
+		return "", nil, nil
+	}
+
+	dir := filepath.Join(w.dir, w.dirName(test.TaskName))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", nil, fmt.Errorf("task %s: %w", test.TaskName, err)
+	}
+	used := make(map[string]bool)
+	var markers strings.Builder
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := os.WriteFile(path, []byte(redactText(file.content, w.redactions)), 0o644); err != nil {
+			return "", nil, fmt.Errorf("task %s: %w", test.TaskName, err)
+		}
+		used[file.name] = true
+		fmt.Fprintf(&markers, "[[ATTACHMENT|%s]]\n", path)
+	}
+	copies := make(map[string]string)
+	for _, artifact := range artifacts {
+		path, err := copyArtifact(dir, artifactPath(test, artifact), used)
+		if errors.Is(err, fs.ErrNotExist) {
+			// A missing artifact is still listed, where the task said it is
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("task %s: copying artifact: %w", test.TaskName, err)
+		}
+		copies[artifact] = path
+	}
+	return markers.String(), copies, nil
+}
+
+// copyArtifact copies an artifact file, as it is, to dir under its base
+// name, suffixed when one of the names in used already took it, and returns
+// the path of the copy
+func copyArtifact(dir, artifact string, used map[string]bool) (string, error) {
+	src, err := os.Open(artifact)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	ext := filepath.Ext(artifact)
+	base := strings.TrimSuffix(filepath.Base(artifact), ext)
+	name := base + ext
+	for n := 2; used[name]; n++ {
+		name = base + "-" + strconv.Itoa(n) + ext
+	}
+	used[name] = true
+	path := filepath.Join(dir, name)
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	return path, dst.Close()
+}
+
+// resourceContents returns the full content of the resource reads that
+// returned any, each under a header naming the resource
+func resourceContents(reads []ResourceRead) string {
+	var contents strings.Builder
+	for _, read := range reads {
+		if read.Content == "" {
+			continue
+		}
+		if contents.Len() > 0 {
+			contents.WriteString("\n")
+		}
+		header := read.ServerName + "::" + read.URI
+		if read.MimeType != "" {
+			header += " (" + read.MimeType + ")"
+		}
+		fmt.Fprintf(&contents, "=== %s ===\n%s", header, read.Content)
+		if !strings.HasSuffix(read.Content, "\n") {
+			contents.WriteString("\n")
+		}
+	}
+	return contents.String()
+}
+
+// formatConversation renders a transcript as the content of each turn
+// under a header naming its role
+func formatConversation(turns []ConversationTurn) string {
+	var transcript strings.Builder
+	for i, turn := range turns {
+		if i > 0 {
+			transcript.WriteString("\n")
+		}
+		fmt.Fprintf(&transcript, "=== %s ===\n%s\n", cmp.Or(turn.Role, "unknown"), strings.TrimRight(turn.Content, "\n"))
+	}
+	return transcript.String()
+}
+
+// appendMarkers appends attachment markers to the system-out of a testcase
+// on lines of their own. Testcases left without system-out have nowhere to
+// reference attachments from.
+func appendMarkers(testCase *JUnitTestCase, markers string) {
+	if markers == "" || testCase.SystemOut == "" {
+		return
+	}
+	if !strings.HasSuffix(testCase.SystemOut, "\n") {
+		testCase.SystemOut += "\n"
+	}
+	testCase.SystemOut += markers
+}
+
+// dirName returns the directory name of the next result of a task
+func (w *attachmentWriter) dirName(taskName string) string {
+	name := strings.Trim(attachmentName.ReplaceAllString(taskName, "-"), "-.")
+	if name == "" {
+		name = "task"
+	}
+	unique := name
+	for n := 2; w.used[unique]; n++ {
+		unique = name + "-" + strconv.Itoa(n)
+	}
+	w.used[unique] = true
+	return unique
+}
@@ -0,0 +1,157 @@
+package converter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAttachments(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "attachments")
+	run := mustParse(t, `[
+		{"taskName":"scale deployment","taskPassed":true,"allAssertionsPassed":true,"taskOutput":"token: Bearer abc.def",
+			"callHistory":{"ToolCalls":[{"serverName":"k8s","name":"scale","success":true,"result":{"content":"scaled"}}],
+				"ResourceReads":[{"serverName":"k8s","uri":"k8s://deployments/web","success":true,"mimeType":"application/json","content":"{\"replicas\": 3}"},
+					{"serverName":"k8s","uri":"k8s://pods","success":false}]}},
+		{"taskName":"scale/deployment","taskPassed":false,"taskOutput":"second"},
+		{"taskName":"quiet","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+
+	report := mustConvert(t, run, options{AttachmentsDir: dir, Redactions: BuiltinRedactions})
+	cases := report.Suites[0].TestCases
+
+	first := filepath.Join(dir, "scale-deployment")
+	for _, want := range []string{
+		"[[ATTACHMENT|" + filepath.Join(first, ToolCallsAttachment) + "]]\n",
+		"[[ATTACHMENT|" + filepath.Join(first, ResourceReadsAttachment) + "]]\n",
+		"[[ATTACHMENT|" + filepath.Join(first, TaskOutputAttachment) + "]]\n",
+	} {
+		if !strings.Contains(cases[0].SystemOut, want) {
+			t.Errorf("system-out does not reference %s:\n%s", want, cases[0].SystemOut)
+		}
+	}
+	if !strings.HasSuffix(cases[1].SystemOut, "[[ATTACHMENT|"+filepath.Join(dir, "scale-deployment-2", TaskOutputAttachment)+"]]\n") {
+		t.Errorf("colliding task name system-out:\n%s", cases[1].SystemOut)
+	}
+	if strings.Contains(cases[2].SystemOut, "[[ATTACHMENT|") {
+		t.Errorf("task without artifacts references attachments:\n%s", cases[2].SystemOut)
+	}
+
+	calls, err := os.ReadFile(filepath.Join(first, ToolCallsAttachment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(calls), `"content": "scaled"`) {
+		t.Errorf("tool calls attachment = %s", calls)
+	}
+	resources, err := os.ReadFile(filepath.Join(first, ResourceReadsAttachment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "=== k8s::k8s://deployments/web (application/json) ===\n{\"replicas\": 3}\n"; string(resources) != want {
+		t.Errorf("resource reads attachment = %q, want %q", resources, want)
+	}
+	output, err := os.ReadFile(filepath.Join(first, TaskOutputAttachment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(output) != "token: Bearer "+RedactedText {
+		t.Errorf("task output attachment = %q, want it redacted", output)
+	}
+}
+
+func TestArtifactCopies(t *testing.T) {
+	src := t.TempDir()
+	for name, content := range map[string]string{"task-output.txt": "log", "screenshot.png": "png"} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dir := filepath.Join(t.TempDir(), "attachments")
+	missing := filepath.Join(src, "missing.log")
+	run := mustParse(t, `[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"taskOutput":"Bearer abc.def",
+		"artifacts":["`+filepath.Join(src, "screenshot.png")+`","`+filepath.Join(src, "task-output.txt")+`","`+missing+`","https://ci.example.com/a.log"]}]`)
+
+	tests := []struct {
+		name   string
+		opts   options
+		want   string
+		copies map[string]string
+	}{
+		{
+			name: "copied to the attachments directory",
+			opts: options{AttachmentsDir: dir, Redactions: BuiltinRedactions},
+			want: "Artifacts:\n[[ATTACHMENT|" + filepath.Join(dir, "a", "screenshot.png") + "]]\n" +
+				"[[ATTACHMENT|" + filepath.Join(dir, "a", "task-output-2.txt") + "]]\n" +
+				"[[ATTACHMENT|" + missing + "]]\nhttps://ci.example.com/a.log\n",
+			// Artifacts are copied as they are, not redacted
+			copies: map[string]string{"screenshot.png": "png", "task-output-2.txt": "log", TaskOutputAttachment: "Bearer " + RedactedText},
+		},
+		{
+			name: "referenced where they are",
+			opts: options{},
+			want: "Artifacts:\n[[ATTACHMENT|" + filepath.Join(src, "screenshot.png") + "]]\n" +
+				"[[ATTACHMENT|" + filepath.Join(src, "task-output.txt") + "]]\n" +
+				"[[ATTACHMENT|" + missing + "]]\nhttps://ci.example.com/a.log\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := mustConvert(t, run, tt.opts)
+			if got := report.Suites[0].TestCases[0].SystemOut; !strings.HasSuffix(got, tt.want) {
+				t.Errorf("system-out = %q, want it to end with %q", got, tt.want)
+			}
+			for name, want := range tt.copies {
+				data, err := os.ReadFile(filepath.Join(dir, "a", name))
+				if err != nil || string(data) != want {
+					t.Errorf("%s = %q, %v, want %q", name, data, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConversationAttachment(t *testing.T) {
+	dir := t.TempDir()
+	run := mustParse(t, `[
+		{"taskName":"a","taskPassed":false,"conversation":[
+			{"role":"user","content":"Scale web to 3 replicas"},
+			{"role":"assistant","content":"Calling pods_list with token Bearer abcdefghijklmnop\n"},
+			{"role":"","content":"done"}]},
+		{"task_name":"b","task_passed":false,"conversation":[{"role":"user","content":"Delete the namespace"}]}
+	]`)
+	report := mustConvert(t, run, options{AttachmentsDir: dir, Redactions: BuiltinRedactions})
+
+	tests := []struct {
+		task, want string
+	}{
+		{task: "a", want: "=== user ===\nScale web to 3 replicas\n\n=== assistant ===\nCalling pods_list with token Bearer " + RedactedText + "\n\n=== unknown ===\ndone\n"},
+		{task: "b", want: "=== user ===\nDelete the namespace\n"},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, tt.task, ConversationAttachment)
+		if !strings.Contains(report.Suites[0].TestCases[i].SystemOut, "[[ATTACHMENT|"+path+"]]\n") {
+			t.Errorf("system-out of %s does not reference %s", tt.task, path)
+		}
+		transcript, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(transcript) != tt.want {
+			t.Errorf("transcript of %s = %q, want %q", tt.task, transcript, tt.want)
+		}
+	}
+}
+
+func TestAttachmentsWithoutSystemOut(t *testing.T) {
+	dir := t.TempDir()
+	run := mustParse(t, `[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"taskOutput":"out"}]`)
+	report := mustConvert(t, run, options{AttachmentsDir: dir, SystemOutOnFailureOnly: true})
+	if out := report.Suites[0].TestCases[0].SystemOut; out != "" {
+		t.Errorf("system-out = %q, want none", out)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("attachments written for a testcase without system-out: %v", entries)
+	}
+}
@@ -0,0 +1,103 @@
+package converter
+
+import "encoding/xml"
+
+// MarshalXML writes the testcase, with its output, failure, error and
+// rerun content in CDATA sections when it was converted with the CDATA
+// option. encoding/xml splits any "]]>" in the content across sections.
+func (tc JUnitTestCase) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	// plain has the fields and tags of JUnitTestCase without this method
+	type plain JUnitTestCase
+	if !tc.cdata {
+		return e.EncodeElement(plain(tc), start)
+	}
+
+	out := cdataTestCase{
+		Name:          tc.Name,
+		Classname:     tc.Classname,
+		Time:          tc.Time,
+		File:          tc.File,
+		Line:          tc.Line,
+		Properties:    tc.Properties,
+		Skipped:       tc.Skipped,
+		RerunFailures: cdataReruns(tc.RerunFailures),
+		RerunErrors:   cdataReruns(tc.RerunErrors),
+		FlakyFailures: cdataReruns(tc.FlakyFailures),
+		FlakyErrors:   cdataReruns(tc.FlakyErrors),
+		SystemOut:     newCDATAText(tc.SystemOut),
+		SystemErr:     newCDATAText(tc.SystemErr),
+	}
+	if tc.Failure != nil {
+		out.Failure = &cdataFault{Message: tc.Failure.Message, Type: tc.Failure.Type, Content: tc.Failure.Content}
+	}
+	if tc.Error != nil {
+		out.Error = &cdataFault{Message: tc.Error.Message, Type: tc.Error.Type, Content: tc.Error.Content}
+	}
+	return e.EncodeElement(out, start)
+}
+
+// cdataTestCase mirrors JUnitTestCase, element for element, with its text
+// content written as CDATA
+type cdataTestCase struct {
+	Name          string           `xml:"name,attr"`
+	Classname     string           `xml:"classname,attr"`
+	Time          string           `xml:"time,attr,omitempty"`
+	File          string           `xml:"file,attr,omitempty"`
+	Line          int              `xml:"line,attr,omitempty"`
+	Properties    *JUnitProperties `xml:"properties,omitempty"`
+	Skipped       *JUnitSkipped    `xml:"skipped,omitempty"`
+	Failure       *cdataFault      `xml:"failure,omitempty"`
+	Error         *cdataFault      `xml:"error,omitempty"`
+	RerunFailures []cdataRerun     `xml:"rerunFailure,omitempty"`
+	RerunErrors   []cdataRerun     `xml:"rerunError,omitempty"`
+	FlakyFailures []cdataRerun     `xml:"flakyFailure,omitempty"`
+	FlakyErrors   []cdataRerun     `xml:"flakyError,omitempty"`
+	SystemOut     *cdataText       `xml:"system-out,omitempty"`
+	SystemErr     *cdataText       `xml:"system-err,omitempty"`
+}
+
+// cdataFault mirrors JUnitFailure and JUnitError
+type cdataFault struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",cdata"`
+}
+
+type cdataRerun struct {
+	Message    string     `xml:"message,attr"`
+	Type       string     `xml:"type,attr"`
+	StackTrace *cdataText `xml:"stackTrace,omitempty"`
+	SystemOut  *cdataText `xml:"system-out,omitempty"`
+	SystemErr  *cdataText `xml:"system-err,omitempty"`
+}
+
+// cdataText is the content of an element holding only text
+type cdataText struct {
+	Text string `xml:",cdata"`
+}
+
+// newCDATAText returns nil for empty text, leaving the element out as the
+// omitempty string fields of JUnitTestCase do
+func newCDATAText(text string) *cdataText {
+	if text == "" {
+		return nil
+	}
+	return &cdataText{Text: text}
+}
+
+func cdataReruns(reruns []JUnitRerun) []cdataRerun {
+	if reruns == nil {
+		return nil
+	}
+	out := make([]cdataRerun, len(reruns))
+	for i, rerun := range reruns {
+		out[i] = cdataRerun{
+			Message:    rerun.Message,
+			Type:       rerun.Type,
+			StackTrace: newCDATAText(rerun.StackTrace),
+			SystemOut:  newCDATAText(rerun.SystemOut),
+			SystemErr:  newCDATAText(rerun.SystemErr),
+		}
+	}
+	return out
+}
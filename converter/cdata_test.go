@@ -0,0 +1,70 @@
+package converter
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCDATA(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPassed":false,"taskError":"if a < b && c > d { x[y[0]]> }","taskOutput":"<html>"},
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"x":{"passed":false}}}
+	]`)
+	run = MergeReruns([]TestRun{run})
+
+	conv, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := conv.Convert(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := conv.Render(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<error message="Test execution failed" type="ExecutionError"><![CDATA[if a < b && c > d { x[y[0]]]]><![CDATA[> }]]></error>`,
+		`<rerunFailure message="Assertion failures: x" type="AssertionFailure">`,
+		`<system-err><![CDATA[if a < b && c > d`,
+		`<stackTrace><![CDATA[Failed Assertions:`,
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("report does not contain %s:\n%s", want, out)
+		}
+	}
+
+	// The content reads back unchanged
+	var decoded struct {
+		Suites []struct {
+			TestCases []JUnitTestCase `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(out, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	got, want := decoded.Suites[0].TestCases[0], report.Suites[0].TestCases[0]
+	got.difficulty, got.cdata = want.difficulty, want.cdata
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded testcase = %+v, want %+v", got, want)
+	}
+
+	conv, err = New(WithoutCDATA())
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = conv.Convert(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = conv.Render(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "CDATA") || !strings.Contains(string(out), "if a &lt; b &amp;&amp; c &gt; d") {
+		t.Errorf("report rendered WithoutCDATA() is not escaped:\n%s", out)
+	}
+}
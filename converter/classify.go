@@ -0,0 +1,205 @@
+package converter
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Status is the outcome a result is reported with
+type Status string
+
+// Statuses a Classifier can return
+const (
+	StatusPassed  Status = "passed"
+	StatusFailure Status = "failure"
+	StatusError   Status = "error"
+	StatusSkipped Status = "skipped"
+)
+
+// Statuses lists every Status
+var Statuses = []Status{StatusPassed, StatusFailure, StatusError, StatusSkipped}
+
+// Classifier decides the outcome of a result. The testcase keeps the
+// failure or error content derived from the result; a Classifier only
+// changes which element carries it.
+type Classifier interface {
+	Classify(result MCPTestResult) Status
+}
+
+// ClassifierFunc adapts a function to the Classifier interface
+type ClassifierFunc func(MCPTestResult) Status
+
+// Classify returns f(result)
+func (f ClassifierFunc) Classify(result MCPTestResult) Status {
+	return f(result)
+}
+
+// DefaultClassifier reports a skipped task as skipped, a task that did not
+// pass as an error, a task with failed assertions as a failure, and a task
+// that passed despite a phase error as an error
+var DefaultClassifier Classifier = ClassifierFunc(func(result MCPTestResult) Status {
+	switch {
+	case result.TaskSkipped:
+		return StatusSkipped
+	case !result.TaskPassed:
+		return StatusError
+	case !result.AllAssertionsPassed:
+		return StatusFailure
+	case hasPhaseError(result):
+		return StatusError
+	default:
+		return StatusPassed
+	}
+})
+
+// hasPhaseError reports whether any phase of a result failed
+func hasPhaseError(result MCPTestResult) bool {
+	for _, phase := range []PhaseOutput{result.SetupOutput, result.AgentOutput, result.VerifyOutput, result.CleanupOutput} {
+		if phase.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassificationRule overrides the status of the results it matches. A
+// rule with both patterns needs both to match; a rule with neither matches
+// every result.
+type ClassificationRule struct {
+	// TaskError matches the task error of the result
+	TaskError *regexp.Regexp
+	// FailedAssertion matches the name of any failed assertion
+	FailedAssertion *regexp.Regexp
+	Status          Status
+}
+
+// matches reports whether the rule applies to a result
+func (r ClassificationRule) matches(result MCPTestResult) bool {
+	if r.TaskError != nil && !r.TaskError.MatchString(result.TaskError) {
+		return false
+	}
+	if r.FailedAssertion != nil && !slices.ContainsFunc(result.FailedAssertions(), r.FailedAssertion.MatchString) {
+		return false
+	}
+	return true
+}
+
+// RuleClassifier applies the first rule matching a result, and the
+// Fallback classifier, DefaultClassifier when nil, to the others
+type RuleClassifier struct {
+	Rules    []ClassificationRule
+	Fallback Classifier
+}
+
+// Classify returns the status of the first matching rule
+func (c RuleClassifier) Classify(result MCPTestResult) Status {
+	for _, rule := range c.Rules {
+		if rule.matches(result) {
+			return rule.Status
+		}
+	}
+	if c.Fallback != nil {
+		return c.Fallback.Classify(result)
+	}
+	return DefaultClassifier.Classify(result)
+}
+
+// ParseClassificationRules reads rules from YAML (or JSON) such as
+//
+//	rules:
+//	  - taskError: "connection refused|quota exceeded"
+//	    status: skipped
+//	  - failedAssertion: "^toolsUsed$"
+//	    status: error
+func ParseClassificationRules(r io.Reader) ([]ClassificationRule, error) {
+	var file struct {
+		Rules []struct {
+			TaskError       string `yaml:"taskError"`
+			FailedAssertion string `yaml:"failedAssertion"`
+			Status          Status `yaml:"status"`
+		} `yaml:"rules"`
+	}
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	rules := make([]ClassificationRule, 0, len(file.Rules))
+	for i, raw := range file.Rules {
+		if !slices.Contains(Statuses, raw.Status) {
+			return nil, fmt.Errorf("rule %d: status must be one of %s", i, joinStatuses())
+		}
+		rule := ClassificationRule{Status: raw.Status}
+		var err error
+		if rule.TaskError, err = compileRulePattern(raw.TaskError); err != nil {
+			return nil, fmt.Errorf("rule %d: taskError: %w", i, err)
+		}
+		if rule.FailedAssertion, err = compileRulePattern(raw.FailedAssertion); err != nil {
+			return nil, fmt.Errorf("rule %d: failedAssertion: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// compileRulePattern compiles a rule pattern; an empty pattern is nil
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+func joinStatuses() string {
+	names := make([]string, len(Statuses))
+	for i, status := range Statuses {
+		names[i] = string(status)
+	}
+	return strings.Join(names, ", ")
+}
+
+// applyStatus moves the failure, error or skipped content of a testcase to
+// the element of status. A testcase that had none gets a message saying it
+// was reclassified.
+func applyStatus(testCase *JUnitTestCase, status Status, msg *messages) {
+	message, kind, content := "", "", ""
+	switch {
+	case testCase.Skipped != nil:
+		message, kind = testCase.Skipped.Message, "Skipped"
+	case testCase.Error != nil:
+		message, kind, content = testCase.Error.Message, testCase.Error.Type, testCase.Error.Content
+	case testCase.Failure != nil:
+		message, kind, content = testCase.Failure.Message, testCase.Failure.Type, testCase.Failure.Content
+	default:
+		message, kind = fmt.Sprintf(msg.Reclassified, status), "Classified"
+	}
+
+	if status != StatusSkipped {
+		testCase.Skipped = nil
+	}
+	switch status {
+	case StatusPassed:
+		testCase.Failure, testCase.Error = nil, nil
+	case StatusFailure:
+		if testCase.Failure == nil {
+			testCase.Failure = &JUnitFailure{Message: message, Type: kind, Content: content}
+		}
+		testCase.Error = nil
+	case StatusError:
+		if testCase.Error == nil {
+			testCase.Error = &JUnitError{Message: message, Type: kind, Content: content}
+		}
+		testCase.Failure = nil
+	case StatusSkipped:
+		if testCase.Skipped == nil {
+			testCase.Skipped = &JUnitSkipped{Message: message}
+		}
+		testCase.Failure, testCase.Error = nil, nil
+	}
+}
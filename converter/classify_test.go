@@ -0,0 +1,114 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultClassifier(t *testing.T) {
+	tests := []struct {
+		name   string
+		result MCPTestResult
+		want   Status
+	}{
+		{name: "passed", result: MCPTestResult{TaskPassed: true, AllAssertionsPassed: true}, want: StatusPassed},
+		{name: "skipped", result: MCPTestResult{TaskSkipped: true}, want: StatusSkipped},
+		{name: "task failed", result: MCPTestResult{AllAssertionsPassed: true}, want: StatusError},
+		{name: "assertions failed", result: MCPTestResult{TaskPassed: true}, want: StatusFailure},
+		{name: "phase error", result: MCPTestResult{TaskPassed: true, AllAssertionsPassed: true, CleanupOutput: PhaseOutput{Error: "boom"}}, want: StatusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DefaultClassifier.Classify(tt.result); got != tt.want {
+				t.Errorf("Classify() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleClassifier(t *testing.T) {
+	rules, err := ParseClassificationRules(strings.NewReader(`
+rules:
+  - taskError: "quota exceeded"
+    status: skipped
+  - failedAssertion: "^toolsUsed$"
+    status: error
+  - taskError: "^$"
+    failedAssertion: "^replicas$"
+    status: passed
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := mustParse(t, `[
+		{"taskName":"quota","taskPassed":false,"taskError":"quota exceeded for model"},
+		{"taskName":"tools","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"toolsUsed":{"passed":false}}},
+		{"taskName":"replicas","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"replicas":{"passed":false}}},
+		{"taskName":"other","taskPassed":false,"taskError":"timeout"}
+	]`)
+
+	conv, err := New(WithGroupBy(GroupByNone), WithSort(SortOriginal), WithClassifier(RuleClassifier{Rules: rules}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := conv.Convert(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suite := report.Suites[0]
+	if suite.Tests != 4 || suite.Failures != 0 || suite.Errors != 2 || suite.Skipped != 1 {
+		t.Errorf("suite counts tests=%d failures=%d errors=%d skipped=%d, want 4, 0, 2, 1", suite.Tests, suite.Failures, suite.Errors, suite.Skipped)
+	}
+
+	cases := suite.TestCases
+	if cases[0].Skipped == nil || cases[0].Error != nil || cases[0].Skipped.Message == "" {
+		t.Errorf("quota testcase = %+v, want skipped", cases[0])
+	}
+	if cases[1].Error == nil || cases[1].Failure != nil || !strings.Contains(cases[1].Error.Message, "toolsUsed") {
+		t.Errorf("tools testcase = %+v, want an error carrying the assertion failure", cases[1])
+	}
+	if cases[2].Error != nil || cases[2].Failure != nil || cases[2].Skipped != nil {
+		t.Errorf("replicas testcase = %+v, want passed", cases[2])
+	}
+	if cases[3].Error == nil {
+		t.Errorf("other testcase = %+v, want the default error", cases[3])
+	}
+}
+
+func TestReclassifyPassed(t *testing.T) {
+	classifier := ClassifierFunc(func(MCPTestResult) Status { return StatusFailure })
+	conv, err := New(WithClassifier(classifier), WithLang(LangSpanish))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCase := conv.ConvertResult(MCPTestResult{TaskName: "a", TaskPassed: true, AllAssertionsPassed: true})
+	if testCase.Failure == nil || testCase.Failure.Message != "Reclasificado como failure" || testCase.Failure.Type != "Classified" {
+		t.Errorf("testcase = %+v, want a Classified failure", testCase)
+	}
+}
+
+func TestParseClassificationRulesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "unknown status", input: "rules:\n  - status: flaky\n", want: "rule 0: status must be one of passed, failure, error, skipped"},
+		{name: "bad pattern", input: "rules:\n  - taskError: \"(\"\n    status: error\n", want: "rule 0: taskError"},
+		{name: "unknown field", input: "rules:\n  - message: x\n    status: error\n", want: "field message not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseClassificationRules(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseClassificationRules() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	if rules, err := ParseClassificationRules(strings.NewReader("")); err != nil || len(rules) != 0 {
+		t.Errorf("ParseClassificationRules(\"\") = %v, %v, want no rules", rules, err)
+	}
+}
@@ -0,0 +1,161 @@
+package converter
+
+import (
+	"path"
+	"strings"
+	"unicode"
+)
+
+// Built-in classname strategies, see ClassnameStrategyByName. The template
+// strategy is not a ClassnameStrategy: it stands for a classname template
+// set with WithClassnameTemplate.
+const (
+	ClassnameTasksDir        = "tasks-dir"
+	ClassnameLastTwoSegments = "last-two-segments"
+	ClassnameFullPathDots    = "full-path-dots"
+	ClassnameTemplate        = "template"
+)
+
+// ClassnameStrategies lists the supported classname strategy names
+var ClassnameStrategies = []string{ClassnameTasksDir, ClassnameLastTwoSegments, ClassnameFullPathDots, ClassnameTemplate}
+
+// Classname styles, see WithClassnameStyle
+const (
+	// ClassnameStyleRaw keeps classnames as derived, the default
+	ClassnameStyleRaw = "raw"
+	// ClassnameStyleNormalized lowercases classnames, replaces the characters
+	// other than letters, digits, underscores and dots with underscores and
+	// collapses repeated dots, e.g. "tasks.create_function"
+	ClassnameStyleNormalized = "normalized"
+	// ClassnameStyleJava also turns the last segment into a class name, e.g.
+	// "tasks.CreateFunction", and prefixes segments starting with a digit
+	// with an underscore
+	ClassnameStyleJava = "java"
+)
+
+// ClassnameStyles lists the supported classname styles
+var ClassnameStyles = []string{ClassnameStyleRaw, ClassnameStyleNormalized, ClassnameStyleJava}
+
+// ClassnameStrategy derives the classname of a testcase from its result
+type ClassnameStrategy interface {
+	Classname(result MCPTestResult) string
+}
+
+// ClassnameFunc adapts a function to the ClassnameStrategy interface
+type ClassnameFunc func(MCPTestResult) string
+
+// Classname returns f(result)
+func (f ClassnameFunc) Classname(result MCPTestResult) string {
+	return f(result)
+}
+
+// TasksDirClassname names testcases after the directory that follows a
+// "tasks" segment of the task path, e.g. "tasks.create-function" for
+// /repo/tasks/create-function/task.yaml. It is the default strategy.
+var TasksDirClassname ClassnameStrategy = ClassnameFunc(func(result MCPTestResult) string {
+	return extractClassname(result.TaskPath, result.Difficulty)
+})
+
+// LastTwoSegmentsClassname names testcases after the last two directories of
+// the task path, e.g. "scenarios.create-function" for
+// /repo/scenarios/create-function/task.yaml
+var LastTwoSegmentsClassname ClassnameStrategy = ClassnameFunc(func(result MCPTestResult) string {
+	segments := taskDirSegments(result.TaskPath)
+	if len(segments) == 0 {
+		return result.Difficulty
+	}
+	return strings.Join(segments[max(len(segments)-2, 0):], ".")
+})
+
+// FullPathDotsClassname names testcases after the whole directory of the
+// task path with dots for separators, e.g. "repo.benchmarks.create-function"
+// for /repo/benchmarks/create-function/task.yaml
+var FullPathDotsClassname ClassnameStrategy = ClassnameFunc(func(result MCPTestResult) string {
+	segments := taskDirSegments(result.TaskPath)
+	if len(segments) == 0 {
+		return result.Difficulty
+	}
+	return strings.Join(segments, ".")
+})
+
+// ClassnameStrategyByName returns the built-in strategy of one of
+// ClassnameStrategies. The template strategy yields TasksDirClassname, which
+// classname templates see as {{.Classname}}.
+func ClassnameStrategyByName(name string) (ClassnameStrategy, bool) {
+	switch name {
+	case ClassnameTasksDir, ClassnameTemplate:
+		return TasksDirClassname, true
+	case ClassnameLastTwoSegments:
+		return LastTwoSegmentsClassname, true
+	case ClassnameFullPathDots:
+		return FullPathDotsClassname, true
+	default:
+		return nil, false
+	}
+}
+
+// taskDirSegments splits the directory of a task path into its non-empty
+// segments, leaving out "." and ".."
+func taskDirSegments(taskPath string) []string {
+	if taskPath == "" {
+		return nil
+	}
+	var segments []string
+	for _, segment := range strings.Split(path.Dir(slashPath(taskPath)), "/") {
+		if segment != "" && segment != "." && segment != ".." {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// styleClassname rewrites a classname in one of ClassnameStyles, so that
+// Jenkins groups testcases into packages by its dots; "" is raw
+func styleClassname(classname, style string) string {
+	if style != ClassnameStyleNormalized && style != ClassnameStyleJava {
+		return classname
+	}
+	var segments []string
+	for _, segment := range strings.Split(classname, ".") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	for i, segment := range segments {
+		if style == ClassnameStyleJava && i == len(segments)-1 {
+			segment = className(segment)
+		} else {
+			segment = strings.Map(func(r rune) rune {
+				if isIdentifierRune(r) {
+					return unicode.ToLower(r)
+				}
+				return '_'
+			}, segment)
+		}
+		if style == ClassnameStyleJava && unicode.IsDigit([]rune(segment)[0]) {
+			segment = "_" + segment
+		}
+		segments[i] = segment
+	}
+	return strings.Join(segments, ".")
+}
+
+// className joins the words of a classname segment into an upper camel case
+// Java class name, e.g. "CreateFunction" for "create-function"
+func className(segment string) string {
+	var name strings.Builder
+	for _, word := range strings.FieldsFunc(segment, func(r rune) bool { return !isIdentifierRune(r) || r == '_' }) {
+		runes := []rune(word)
+		name.WriteRune(unicode.ToUpper(runes[0]))
+		name.WriteString(string(runes[1:]))
+	}
+	if name.Len() == 0 {
+		return "_"
+	}
+	return name.String()
+}
+
+// isIdentifierRune reports whether r may appear in a classname segment
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,114 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassnameStrategies(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPath":"/repo/tasks/create-function/task.yaml","difficulty":"easy"},
+		{"taskName":"b","taskPath":"/repo/scenarios/delete-pod/task.yaml","difficulty":"hard"},
+		{"taskName":"c","taskPath":"benchmarks\\scale\\task.yaml","difficulty":"medium"},
+		{"taskName":"d","taskPath":"task.yaml","difficulty":"easy"},
+		{"taskName":"e","difficulty":"easy"}
+	]`)
+
+	tests := []struct {
+		strategy string
+		want     []string
+	}{
+		{strategy: ClassnameTasksDir, want: []string{"tasks.create-function", "hard", "medium", "easy", "easy"}},
+		{strategy: ClassnameLastTwoSegments, want: []string{"tasks.create-function", "scenarios.delete-pod", "benchmarks.scale", "easy", "easy"}},
+		{strategy: ClassnameFullPathDots, want: []string{"repo.tasks.create-function", "repo.scenarios.delete-pod", "benchmarks.scale", "easy", "easy"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			strategy, ok := ClassnameStrategyByName(tt.strategy)
+			if !ok {
+				t.Fatalf("ClassnameStrategyByName(%q) found no strategy", tt.strategy)
+			}
+			var got []string
+			for _, result := range run.Results {
+				got = append(got, strategy.Classname(result))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("classnames = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := ClassnameStrategyByName("basename"); ok {
+		t.Error("ClassnameStrategyByName(\"basename\") found a strategy")
+	}
+}
+
+func TestClassnameStrategyOption(t *testing.T) {
+	run := mustParse(t, `[{"taskName":"a","taskPath":"/repo/scenarios/create-function/task.yaml","difficulty":"easy"}]`)
+	tmpl, err := ParseNameTemplate("classname-template", "{{.Difficulty}}.{{.Classname}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "default", want: "easy"},
+		{name: "strategy", opts: []Option{WithClassnameStrategy(LastTwoSegmentsClassname)}, want: "scenarios.create-function"},
+		{
+			name: "template sees the strategy classname",
+			opts: []Option{WithClassnameStrategy(LastTwoSegmentsClassname), WithClassnameTemplate(tmpl)},
+			want: "easy.scenarios.create-function",
+		},
+		{
+			name: "style applies to the template output",
+			opts: []Option{WithClassnameStrategy(LastTwoSegmentsClassname), WithClassnameTemplate(tmpl), WithClassnameStyle(ClassnameStyleJava)},
+			want: "easy.scenarios.CreateFunction",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv, err := New(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := conv.Convert(run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := report.Suites[0].TestCases[0].Classname; got != tt.want {
+				t.Errorf("classname = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStyleClassname(t *testing.T) {
+	tests := []struct {
+		classname, style, want string
+	}{
+		{"Tasks.Create Function", ClassnameStyleRaw, "Tasks.Create Function"},
+		{"Tasks.Create Function", "", "Tasks.Create Function"},
+		{"Tasks.Create Function", ClassnameStyleNormalized, "tasks.create_function"},
+		{".tasks..k8s/pods.create-pod.", ClassnameStyleNormalized, "tasks.k8s_pods.create_pod"},
+		{"Tasks.Über-Tool", ClassnameStyleNormalized, "tasks.über_tool"},
+		{"tasks.create-function", ClassnameStyleJava, "tasks.CreateFunction"},
+		{"My Tasks.2024.scale_up deployment", ClassnameStyleJava, "my_tasks._2024.ScaleUpDeployment"},
+		{"tasks.3d-render", ClassnameStyleJava, "tasks._3dRender"},
+		{"easy", ClassnameStyleJava, "Easy"},
+		{"tasks.--", ClassnameStyleJava, "tasks._"},
+	}
+	for _, tt := range tests {
+		if got := styleClassname(tt.classname, tt.style); got != tt.want {
+			t.Errorf("styleClassname(%q, %q) = %q, want %q", tt.classname, tt.style, got, tt.want)
+		}
+	}
+
+	if _, err := New(WithClassnameStyle("kebab")); err == nil {
+		t.Error("New() accepted an unknown classname style")
+	}
+}
@@ -0,0 +1,1008 @@
+// Package converter parses MCP checker results and converts them into JUnit
+// XML reports. It is the library behind the mcpchecker-junit-report command.
+package converter
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/junit"
+)
+
+// MCPTestResult represents a single test result from the MCP checker
+type MCPTestResult struct {
+	TaskName string `json:"taskName"`
+	TaskPath string `json:"taskPath"`
+	// TaskLine, when known, is the line of the task definition in TaskPath
+	TaskLine   int    `json:"taskLine,omitempty"`
+	TaskPassed bool   `json:"taskPassed"`
+	TaskOutput string `json:"taskOutput"`
+	TaskError  string `json:"taskError,omitempty"`
+	// TaskSkipped is set for tasks mcpchecker did not run, e.g. for lack of
+	// a server capability, with the reason in SkipReason
+	TaskSkipped bool     `json:"taskSkipped,omitempty"`
+	SkipReason  string   `json:"skipReason,omitempty"`
+	Difficulty  string   `json:"difficulty"`
+	Tags        []string `json:"tags,omitempty"`
+	// TraceID and PromptID correlate the task with its trace, e.g. in
+	// LangSmith or an OpenTelemetry backend, and the prompt it ran
+	TraceID             string               `json:"traceId,omitempty"`
+	PromptID            string               `json:"promptId,omitempty"`
+	AssertionResults    map[string]Assertion `json:"assertionResults"`
+	AllAssertionsPassed bool                 `json:"allAssertionsPassed"`
+	CallHistory         CallHistory          `json:"callHistory"`
+	SetupOutput         PhaseOutput          `json:"setupOutput"`
+	AgentOutput         PhaseOutput          `json:"agentOutput"`
+	VerifyOutput        PhaseOutput          `json:"verifyOutput"`
+	CleanupOutput       PhaseOutput          `json:"cleanupOutput"`
+	// ExpectedCalls is the tool call sequence the task definition expects,
+	// diffed against CallHistory.ToolCalls in the failure content
+	ExpectedCalls []ExpectedCall `json:"expectedCalls,omitempty"`
+	// TokenUsage and CostUSD are the LLM tokens the agent used and what
+	// they cost, when the checker records them
+	TokenUsage *TokenUsage `json:"tokenUsage,omitempty"`
+	CostUSD    float64     `json:"costUSD,omitempty"`
+	// Conversation is the message transcript of the agent, when recorded
+	Conversation []ConversationTurn `json:"conversation,omitempty"`
+	// Artifacts are the files, by path, and links, by URL, a task left as
+	// evidence, e.g. screenshots and logs of its verify phase
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	// Attempts holds earlier runs of the same task, oldest first: the
+	// retries mcpchecker reports in the attempts array of a result, and the
+	// results of earlier runs when runs are merged
+	Attempts []MCPTestResult `json:"attempts,omitempty"`
+	// Samples holds the runs of a task sampled several times to measure
+	// pass@k, reported as one testcase following the sample policy
+	Samples []MCPTestResult `json:"samples,omitempty"`
+	// SourceFile is the input the result was read from
+	SourceFile string `json:"-"`
+	// parseErr is set on the placeholder result of a malformed entry
+	parseErr error
+}
+
+// ParseError returns the error that made the entry malformed when the
+// result is the placeholder of an entry skipped in lenient mode, nil otherwise
+func (r MCPTestResult) ParseError() error {
+	return r.parseErr
+}
+
+// FailedAssertions returns the names of the failed assertions, sorted
+func (r MCPTestResult) FailedAssertions() []string {
+	return getFailedAssertions(r.AssertionResults)
+}
+
+// Assertion represents an individual assertion result
+type Assertion struct {
+	Passed bool `json:"passed"`
+
+	// Message explains the outcome, and Expected and Actual are the values
+	// the assertion compared, of any JSON type; checkers may leave them out
+	Message  string      `json:"message,omitempty"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+// TokenUsage counts the LLM tokens of the agent phase of a task
+type TokenUsage struct {
+	Prompt     int `json:"prompt"`
+	Completion int `json:"completion"`
+	// Total is left out by checkers that only count prompt and completion
+	// tokens, see Sum
+	Total int `json:"total,omitempty"`
+}
+
+// ConversationTurn is a message of the agent transcript, such as a user
+// prompt or an assistant reply
+type ConversationTurn struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// CallHistory represents the history of tool and resource calls
+type CallHistory struct {
+	ToolCalls     []ToolCall     `json:"ToolCalls"`
+	ResourceReads []ResourceRead `json:"ResourceReads"`
+}
+
+// ToolCall represents a single tool invocation
+type ToolCall struct {
+	ServerName string                 `json:"serverName"`
+	Success    bool                   `json:"success"`
+	Name       string                 `json:"name"`
+	Result     map[string]interface{} `json:"result"`
+	// Arguments are those the agent passed to the tool and DurationMs how
+	// long the call took, in milliseconds; both are left out by older checkers
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+	DurationMs float64                `json:"durationMs,omitempty"`
+}
+
+// ResourceRead represents a single resource read operation
+type ResourceRead struct {
+	ServerName string `json:"serverName"`
+	Success    bool   `json:"success"`
+	URI        string `json:"uri"`
+	// Content is what the read returned and MimeType its media type; both
+	// are left out by older checkers
+	Content  string `json:"content,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// PhaseOutput represents output from a test phase
+type PhaseOutput struct {
+	Success bool   `json:"Success"`
+	Error   string `json:"Error"`
+	// Output is the log of the phase and DurationMs how long it ran, in
+	// milliseconds; both are left out by older checkers
+	Output     string  `json:"Output,omitempty"`
+	DurationMs float64 `json:"DurationMs,omitempty"`
+}
+
+// JUnit XML structures
+type JUnitTestSuites struct {
+	XMLName xml.Name `xml:"testsuites" json:"-"`
+	Name    string   `xml:"name,attr,omitempty" json:"name,omitempty"`
+	// Aggregates over every suite, including the imported ones, for the
+	// consumers that read totals and the time from the root element
+	Tests    int    `xml:"tests,attr" json:"tests"`
+	Failures int    `xml:"failures,attr" json:"failures"`
+	Errors   int    `xml:"errors,attr" json:"errors"`
+	Skipped  int    `xml:"skipped,attr" json:"skipped"`
+	Time     string `xml:"time,attr,omitempty" json:"time,omitempty"`
+	// Properties describe the whole report, see WithReportProperties
+	Properties *JUnitProperties `xml:"properties,omitempty" json:"properties,omitempty"`
+
+	Suites   []JUnitTestSuite `json:"testsuites"`
+	Imported []ImportedSuite  `xml:",any" json:"imported,omitempty"`
+}
+
+type JUnitTestSuite struct {
+	XMLName  xml.Name `xml:"testsuite" json:"-"`
+	Name     string   `xml:"name,attr" json:"name"`
+	Tests    int      `xml:"tests,attr" json:"tests"`
+	Failures int      `xml:"failures,attr" json:"failures"`
+	Errors   int      `xml:"errors,attr" json:"errors"`
+	Skipped  int      `xml:"skipped,attr" json:"skipped"`
+	// Time is the total time of the testcases in seconds, left out when
+	// none of them reported durations
+	Time       string           `xml:"time,attr,omitempty" json:"time,omitempty"`
+	Timestamp  string           `xml:"timestamp,attr,omitempty" json:"timestamp,omitempty"`
+	Properties *JUnitProperties `xml:"properties,omitempty" json:"properties,omitempty"`
+	// Suites are the nested suites of a suite written with the NestedSuites
+	// option, which then has no testcases of its own
+	Suites    []JUnitTestSuite `xml:"testsuite,omitempty" json:"testsuites,omitempty"`
+	TestCases []JUnitTestCase  `xml:"testcase" json:"testcases"`
+}
+
+// AllTestCases returns the testcases of the suite and of its nested suites
+func (s JUnitTestSuite) AllTestCases() []JUnitTestCase {
+	testCases := slices.Clip(s.TestCases)
+	for _, child := range s.Suites {
+		testCases = append(testCases, child.AllTestCases()...)
+	}
+	return testCases
+}
+
+type JUnitProperties struct {
+	Properties []JUnitProperty `xml:"property" json:"property"`
+}
+
+type JUnitProperty = junit.Property
+
+type JUnitTestCase struct {
+	Name      string `xml:"name,attr" json:"name"`
+	Classname string `xml:"classname,attr" json:"classname"`
+	// Time is how long the task ran in seconds, the total of the durations
+	// of its phases, left out when it reported none
+	Time string `xml:"time,attr,omitempty" json:"time,omitempty"`
+	// File and Line locate the task definition, for the test report UIs
+	// that link failures back to it
+	File string `xml:"file,attr,omitempty" json:"file,omitempty"`
+	Line int    `xml:"line,attr,omitempty" json:"line,omitempty"`
+	// Properties annotate the testcase, e.g. with timed_out
+	Properties *JUnitProperties `xml:"properties,omitempty" json:"properties,omitempty"`
+	Skipped    *JUnitSkipped    `xml:"skipped,omitempty" json:"skipped,omitempty"`
+	Failure    *JUnitFailure    `xml:"failure,omitempty" json:"failure,omitempty"`
+	Error      *JUnitError      `xml:"error,omitempty" json:"error,omitempty"`
+
+	// Surefire rerun elements: rerun* for further failed attempts of a
+	// failing test, flaky* for failed attempts of a test that eventually passed
+	RerunFailures []JUnitRerun `xml:"rerunFailure,omitempty" json:"rerunFailure,omitempty"`
+	RerunErrors   []JUnitRerun `xml:"rerunError,omitempty" json:"rerunError,omitempty"`
+	FlakyFailures []JUnitRerun `xml:"flakyFailure,omitempty" json:"flakyFailure,omitempty"`
+	FlakyErrors   []JUnitRerun `xml:"flakyError,omitempty" json:"flakyError,omitempty"`
+
+	SystemOut string `xml:"system-out,omitempty" json:"systemOut,omitempty"`
+	SystemErr string `xml:"system-err,omitempty" json:"systemErr,omitempty"`
+
+	// difficulty is the level of the task, used by the pass-rate gates
+	difficulty string
+	// cdata writes the text content in CDATA sections, see MarshalXML
+	cdata bool
+}
+
+// addProperties appends properties to the testcase
+func (tc *JUnitTestCase) addProperties(properties ...JUnitProperty) {
+	if len(properties) == 0 {
+		return
+	}
+	if tc.Properties == nil {
+		tc.Properties = &JUnitProperties{}
+	}
+	tc.Properties.Properties = append(tc.Properties.Properties, properties...)
+}
+
+// Difficulty returns the difficulty level of the task the testcase was
+// converted from
+func (tc JUnitTestCase) Difficulty() string {
+	return tc.difficulty
+}
+
+type JUnitSkipped struct {
+	Message string `xml:"message,attr,omitempty" json:"message,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr" json:"message"`
+	Type    string `xml:"type,attr" json:"type"`
+	Content string `xml:",chardata" json:"content"`
+}
+
+type JUnitError struct {
+	Message string `xml:"message,attr" json:"message"`
+	Type    string `xml:"type,attr" json:"type"`
+	Content string `xml:",chardata" json:"content"`
+}
+
+type JUnitRerun struct {
+	Message    string `xml:"message,attr" json:"message"`
+	Type       string `xml:"type,attr" json:"type"`
+	StackTrace string `xml:"stackTrace,omitempty" json:"stackTrace,omitempty"`
+	SystemOut  string `xml:"system-out,omitempty" json:"systemOut,omitempty"`
+	SystemErr  string `xml:"system-err,omitempty" json:"systemErr,omitempty"`
+}
+
+// Converter turns parsed results into a JUnit document. Create one with
+// New; a Converter is safe for concurrent use.
+type Converter struct {
+	opts options
+}
+
+// New returns a Converter configured by opts. Without options it groups
+// testcases by difficulty, sorts them, truncates tool messages to
+// DefaultMaxToolOutput bytes, masks the BuiltinRedactions and writes English
+// labels and CDATA sections, like the command line does by default.
+func New(opts ...Option) (*Converter, error) {
+	c := &Converter{opts: defaultOptions()}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	if err := c.opts.validate(); err != nil {
+		return nil, err
+	}
+	if err := c.opts.bindSystemOutTemplate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Convert turns a run into a JUnit document, one testsuite per group
+func (c *Converter) Convert(run TestRun) (JUnitTestSuites, error) {
+	return c.ConvertContext(context.Background(), run)
+}
+
+// ConvertContext is Convert with a context: once ctx is done, conversion
+// stops and ctx.Err() is returned.
+func (c *Converter) ConvertContext(ctx context.Context, run TestRun) (JUnitTestSuites, error) {
+	return convertToJUnit(ctx, run, c.opts)
+}
+
+// ConvertResult turns a single result, together with its earlier attempts,
+// into a testcase
+func (c *Converter) ConvertResult(result MCPTestResult) JUnitTestCase {
+	testCase := convertWithAttempts(result, c.opts)
+	testCase.difficulty = result.Difficulty
+	return testCase
+}
+
+// Keep reports whether result passes the task filter of the converter, see
+// WithFilter
+func (c *Converter) Keep(result MCPTestResult) bool {
+	return c.opts.Filter.keep(result)
+}
+
+// Render marshals a JUnit document with its XML header, indented as
+// configured with WithIndent and, with WithCheck, validated against the
+// JUnit schema
+func (c *Converter) Render(report JUnitTestSuites) ([]byte, error) {
+	output, err := renderReport(report, c.opts.Indent)
+	if err != nil || !c.opts.Check {
+		return output, err
+	}
+	if err := junit.Validate(output); err != nil {
+		return nil, fmt.Errorf("checking the report: %w", err)
+	}
+	return output, nil
+}
+
+// MarshalRun encodes run as JSON in the envelope schema the way the
+// converter sees it: with only the results its task filter keeps, and every
+// string of them masked by its redactions. It is what is safe to hand to
+// other tools.
+func (c *Converter) MarshalRun(run TestRun) ([]byte, error) {
+	run.Results = c.opts.Filter.apply(run.Results)
+	data, err := json.Marshal(run)
+	if err != nil || len(c.opts.Redactions) == 0 {
+		return data, err
+	}
+	return redactJSON(data, c.opts.Redactions)
+}
+
+// renderReport marshals the JUnit document with its XML header, indenting
+// nested elements with indent or, when it is empty, on a single line
+func renderReport(junitXML JUnitTestSuites, indent string) ([]byte, error) {
+	output, err := xml.MarshalIndent(junitXML, "", indent)
+	if err != nil {
+		return nil, fmt.Errorf("generating XML: %w", err)
+	}
+	return []byte(xml.Header + string(output) + "\n"), nil
+}
+
+// truncateText cuts text to at most limit bytes, on a UTF-8 boundary, and
+// notes how many bytes were elided after sep. A limit of 0 keeps the text whole.
+func truncateText(text string, limit int, sep string) string {
+	if limit <= 0 || len(text) <= limit {
+		return text
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s%s… (%d bytes elided)", text[:cut], sep, len(text)-cut)
+}
+
+// formatMilliseconds formats a duration given in milliseconds, e.g. 1.2s
+func formatMilliseconds(ms float64) string {
+	return time.Duration(ms * float64(time.Millisecond)).Round(time.Millisecond).String()
+}
+
+func convertToJUnit(ctx context.Context, run TestRun, opts options) (JUnitTestSuites, error) {
+	suites := JUnitTestSuites{}
+	properties := runProperties(run, opts.Properties)
+	sanitizeProperties(properties, opts.StripANSI)
+	timestamp := formatTimestamp(run.StartedAt)
+	if timestamp == "" && opts.Clock != nil {
+		timestamp = opts.Clock().UTC().Format(junitTimestampLayout)
+	}
+
+	var attachments *attachmentWriter
+	if opts.AttachmentsDir != "" {
+		var err error
+		if attachments, err = newAttachmentWriter(opts.AttachmentsDir, opts.Redactions); err != nil {
+			return suites, err
+		}
+	}
+
+	// Create a test suite for each group, by difficulty unless configured otherwise
+	groups := groupResults(opts.Filter.apply(run.Results), opts.GroupBy)
+	if opts.Sort == SortSorted {
+		sortGroups(groups, opts.GroupBy)
+	}
+	for _, group := range groups {
+		tests := group.results
+		name := suiteName(suitePrefix(run, opts), group.key, opts.GroupBy)
+		if opts.SuiteNameTemplate != nil {
+			var err error
+			name, err = executeNameTemplate(opts.SuiteNameTemplate, newTemplateData(tests[0], group.key, opts))
+			if err != nil {
+				return suites, err
+			}
+		}
+		suite := JUnitTestSuite{
+			Name:       sanitizeText(name, opts.StripANSI),
+			Failures:   0,
+			Errors:     0,
+			Skipped:    0,
+			Timestamp:  timestamp,
+			Properties: withDurationProperties(withTagsProperty(withUsageProperties(properties, tests), tests), tests),
+		}
+
+		if !opts.NestedSuites {
+			if err := convertGroup(ctx, &suite, group.key, tests, opts, attachments); err != nil {
+				return suites, err
+			}
+			suites.Suites = append(suites.Suites, suite)
+			continue
+		}
+
+		// Nest a suite per task directory in the suite of the group
+		children := groupResults(tests, GroupByTaskDir)
+		if opts.Sort == SortSorted {
+			sortGroups(children, GroupByTaskDir)
+		}
+		for _, child := range children {
+			childSuite := JUnitTestSuite{
+				Name:      sanitizeText(nestedSuiteName(child.key), opts.StripANSI),
+				Timestamp: timestamp,
+			}
+			if err := convertGroup(ctx, &childSuite, group.key, child.results, opts, attachments); err != nil {
+				return suites, err
+			}
+			suite.Tests += childSuite.Tests
+			suite.Failures += childSuite.Failures
+			suite.Errors += childSuite.Errors
+			suite.Skipped += childSuite.Skipped
+			suite.Time = addTime(suite.Time, childSuite.Time)
+			suite.Suites = append(suite.Suites, childSuite)
+		}
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	// Some CI parsers reject a report without any testsuite, so a run
+	// without results gets a single empty one
+	if len(suites.Suites) == 0 && len(run.ImportedSuites) == 0 {
+		suites.Suites = append(suites.Suites, JUnitTestSuite{
+			Name:       suiteName(suitePrefix(run, opts), "", GroupByNone),
+			Timestamp:  timestamp,
+			Properties: properties,
+			TestCases:  []JUnitTestCase{},
+		})
+	}
+
+	suites.Imported = run.ImportedSuites
+	suites.Name = opts.ReportName
+	if reportProperties := slices.Concat(run.Environment.Properties(), opts.ReportProperties); len(reportProperties) > 0 {
+		suites.Properties = &JUnitProperties{Properties: reportProperties}
+		sanitizeProperties(suites.Properties, opts.StripANSI)
+	}
+	suites.SetAggregates()
+	return suites, nil
+}
+
+// convertGroup converts the results of a group into the testcases of suite,
+// counting their outcomes. groupKey is the key of the top-level group, for
+// the classname template.
+func convertGroup(ctx context.Context, suite *JUnitTestSuite, groupKey string, tests []MCPTestResult, opts options, attachments *attachmentWriter) error {
+	// sources holds the result each testcase was converted from
+	var testCases []JUnitTestCase
+	var sources []MCPTestResult
+	for _, test := range tests {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		converted, err := convertOne(test, groupKey, opts, attachments)
+		if err != nil {
+			return err
+		}
+		for range converted {
+			sources = append(sources, test)
+		}
+		testCases = append(testCases, converted...)
+	}
+
+	testCases, sources, err := resolveDuplicates(testCases, sources, suite.Name, opts.OnDuplicate)
+	if err != nil {
+		return err
+	}
+	for i, testCase := range testCases {
+		suite.TestCases = append(suite.TestCases, testCase)
+		countTestCase(suite, testCase, sources[i])
+	}
+	suite.Tests = len(suite.TestCases)
+	return nil
+}
+
+// convertOne converts a result into its testcases, a single one unless
+// assertions are exploded, writing its attachments. groupKey is the key of
+// the top-level group, for the classname template.
+func convertOne(test MCPTestResult, groupKey string, opts options, attachments *attachmentWriter) ([]JUnitTestCase, error) {
+	testCase := convertWithAttempts(test, opts)
+	testCase.difficulty = test.Difficulty
+	if opts.ClassnameTemplate != nil {
+		classname, err := executeNameTemplate(opts.ClassnameTemplate, newTemplateData(test, groupKey, opts))
+		if err != nil {
+			return nil, err
+		}
+		testCase.Classname = styleClassname(sanitizeText(classname, opts.StripANSI), opts.ClassnameStyle)
+	}
+	converted := []JUnitTestCase{testCase}
+	if opts.ExplodeAssertions {
+		converted = explodeAssertions(test, testCase, opts)
+	}
+	var copies map[string]string
+	if attachments != nil && slices.ContainsFunc(converted, func(tc JUnitTestCase) bool { return tc.SystemOut != "" }) {
+		markers, artifacts, err := attachments.attach(test)
+		if err != nil {
+			return nil, err
+		}
+		copies = artifacts
+		for i := range converted {
+			appendMarkers(&converted[i], markers)
+		}
+	}
+	if markers := artifactMarkers(test, catalog(opts.Lang), copies); markers != "" {
+		for i := range converted {
+			appendMarkers(&converted[i], markers)
+		}
+	}
+	return converted, nil
+}
+
+// countTestCase counts the outcome of a testcase of suite, converted from
+// test, in the counts and the time of the suite
+func countTestCase(suite *JUnitTestSuite, testCase JUnitTestCase, test MCPTestResult) {
+	suite.Time = addTime(suite.Time, testCase.Time)
+	outcome := "passed"
+	if testCase.Skipped != nil {
+		suite.Skipped++
+		outcome = "skipped"
+	}
+	if testCase.Failure != nil {
+		suite.Failures++
+		outcome = "failure: " + testCase.Failure.Type
+	}
+	if testCase.Error != nil {
+		suite.Errors++
+		outcome = "error: " + testCase.Error.Type
+	}
+	slog.Debug("converted task", "task", test.TaskName, "suite", suite.Name, "classname", testCase.Classname,
+		"outcome", outcome, "attempts", len(test.Attempts)+1)
+}
+
+// SetAggregates sets the counts and total time of the root element from
+// every suite, converted and imported. Without any suite carrying a time,
+// Time is left empty.
+func (s *JUnitTestSuites) SetAggregates() {
+	s.Tests, s.Failures, s.Errors = s.Totals()
+	s.Skipped = 0
+	s.Time = ""
+	for _, suite := range s.Suites {
+		s.Skipped += suite.Skipped
+		s.Time = addTime(s.Time, suite.Time)
+	}
+	for _, suite := range s.Imported {
+		s.Skipped += suite.intAttr("skipped")
+		if seconds, ok := suite.floatAttr("time"); ok {
+			s.Time = addTime(s.Time, strconv.FormatFloat(seconds, 'f', 3, 64))
+		}
+	}
+}
+
+// Totals sums the tests, failures and errors of every suite, including the
+// imported ones
+func (s JUnitTestSuites) Totals() (tests, failures, errors int) {
+	for _, suite := range s.Suites {
+		tests += suite.Tests
+		failures += suite.Failures
+		errors += suite.Errors
+	}
+	for _, suite := range s.Imported {
+		tests += suite.intAttr("tests")
+		failures += suite.intAttr("failures")
+		errors += suite.intAttr("errors")
+	}
+	return tests, failures, errors
+}
+
+// runProperties returns the run metadata, followed by the custom properties,
+// to attach to every testsuite
+func runProperties(run TestRun, custom []JUnitProperty) *JUnitProperties {
+	var properties []JUnitProperty
+	if run.RunID != "" {
+		properties = append(properties, JUnitProperty{Name: "runId", Value: run.RunID})
+	}
+	if run.StartedAt != "" {
+		properties = append(properties, JUnitProperty{Name: "startedAt", Value: run.StartedAt})
+	}
+	properties = append(properties, custom...)
+	if len(properties) == 0 {
+		return nil
+	}
+	return &JUnitProperties{Properties: properties}
+}
+
+// junitTimestampLayout is the format of the testsuite timestamp attribute
+const junitTimestampLayout = "2006-01-02T15:04:05"
+
+// formatTimestamp converts an RFC 3339 time to the JUnit timestamp format,
+// passing through values it cannot parse
+func formatTimestamp(value string) string {
+	if value == "" {
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return value
+	}
+	return t.UTC().Format(junitTimestampLayout)
+}
+
+func convertTestCase(test MCPTestResult, opts options) JUnitTestCase {
+	msg := catalog(opts.Lang)
+	testCase := JUnitTestCase{
+		Name:      test.TaskName,
+		Classname: styleClassname(opts.classname(test), opts.ClassnameStyle),
+		Time:      testCaseTime(test),
+		File:      opts.taskFile(test.TaskPath),
+		Line:      test.TaskLine,
+		// Redact and sanitize before truncating, so that neither a secret nor
+		// an escape sequence is cut into a part the patterns miss
+		SystemOut: truncateText(sanitizeText(redactText(formatSystemOut(test, opts), opts.Redactions), opts.StripANSI), opts.MaxSystemOutBytes, "\n"),
+	}
+
+	// Determine if test failed and why
+	if !test.TaskPassed {
+		// Test execution failed
+		testCase.Error = &JUnitError{
+			Message: msg.ExecutionFailed,
+			Type:    "ExecutionError",
+			Content: test.TaskError,
+		}
+		// Timeouts need different triage than the failures of the agent
+		if isTimeout(test.TaskError, opts.TimeoutPatterns) {
+			testCase.Error.Type = TimeoutErrorType
+			testCase.addProperties(JUnitProperty{Name: TimedOutProperty, Value: "true"})
+		}
+		if test.TaskError != "" {
+			testCase.SystemErr = test.TaskError
+		}
+	} else if !test.AllAssertionsPassed {
+		// Assertions failed
+		failedAssertions := getFailedAssertions(test.AssertionResults)
+		testCase.Failure = &JUnitFailure{
+			Message: fmt.Sprintf(msg.AssertionFailures, strings.Join(failedAssertions, ", ")),
+			Type:    "AssertionFailure",
+			Content: buildFailureContent(test, failedAssertions, msg),
+		}
+	}
+
+	// Check phase failures
+	phases := collectPhaseErrors(test, msg, opts.PhasePolicy)
+	if phases.content != "" {
+		if testCase.Error != nil {
+			testCase.Error.Content += "\n\n" + msg.PhaseErrors + ":\n" + phases.content
+		} else if testCase.Failure != nil {
+			testCase.Failure.Content += "\n\n" + msg.PhaseErrors + ":\n" + phases.content
+		} else if phases.asError {
+			// Phase failed but test reported as passed - treat as error
+			testCase.Error = &JUnitError{
+				Message: msg.PhaseFailed,
+				Type:    "PhaseError",
+				Content: phases.content,
+			}
+		} else {
+			testCase.Failure = &JUnitFailure{
+				Message: msg.PhaseFailed,
+				Type:    "PhaseFailure",
+				Content: phases.content,
+			}
+		}
+		if testCase.SystemErr == "" {
+			testCase.SystemErr = phases.content
+		} else {
+			testCase.SystemErr += "\n\n" + phases.content
+		}
+	}
+	testCase.addProperties(phases.warnings...)
+	testCase.addProperties(traceProperties(test, opts)...)
+
+	// A skipped task did not run, whatever its other fields say
+	if test.TaskSkipped {
+		message := test.SkipReason
+		if message == "" {
+			message = msg.TaskSkipped
+		}
+		testCase.Skipped = &JUnitSkipped{Message: message}
+		testCase.Failure, testCase.Error = nil, nil
+	}
+
+	if opts.Classifier != nil {
+		// The classifier only overrides the outcome where it departs from
+		// DefaultClassifier, which knows nothing of the phase policy
+		seen := opts.PhasePolicy.withoutQuietPhases(test)
+		if status := opts.Classifier.Classify(seen); status != DefaultClassifier.Classify(seen) {
+			applyStatus(&testCase, status, msg)
+		}
+	}
+
+	redactTestCase(&testCase, opts.Redactions)
+	sanitizeTestCase(&testCase, opts.StripANSI)
+
+	testCase.cdata = opts.CDATA
+	passed := testCase.Failure == nil && testCase.Error == nil
+	if opts.NoSystemOut || (opts.SystemOutOnFailureOnly && passed) {
+		testCase.SystemOut = ""
+	}
+	if opts.NoSystemErr {
+		testCase.SystemErr = ""
+	}
+
+	return testCase
+}
+
+func extractClassname(taskPath string, difficulty string) string {
+	if taskPath == "" {
+		return difficulty
+	}
+	// Extract meaningful parts from path
+	// e.g., "/home/.../tasks/create-function/create-function.yaml" -> "tasks.create-function"
+	parts := strings.Split(taskPath, "/")
+	for i, part := range parts {
+		if part == "tasks" && i+1 < len(parts) {
+			return fmt.Sprintf("tasks.%s", parts[i+1])
+		}
+	}
+	return difficulty
+}
+
+// getFailedAssertions returns the names of the failed assertions, sorted
+func getFailedAssertions(assertions map[string]Assertion) []string {
+	var failed []string
+	for name, assertion := range assertions {
+		if !assertion.Passed {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+	return failed
+}
+
+func buildFailureContent(test MCPTestResult, failedAssertions []string, msg *messages) string {
+	var content strings.Builder
+
+	content.WriteString(msg.FailedAssertions + ":\n")
+	for _, name := range failedAssertions {
+		assertion := test.AssertionResults[name]
+		if assertion.Message != "" {
+			content.WriteString(fmt.Sprintf("  - %s: %s\n", name, assertion.Message))
+		} else {
+			content.WriteString(fmt.Sprintf("  - %s\n", name))
+		}
+		content.WriteString(assertionDetails(assertion, msg, "      "))
+	}
+
+	if diff := callSequenceDiff(test, msg); diff != "" {
+		content.WriteString("\n" + msg.ToolCallSequence + ":\n")
+		content.WriteString(diff)
+	}
+
+	if test.TaskError != "" {
+		content.WriteString("\n" + msg.ErrorDetails + ":\n")
+		content.WriteString(test.TaskError)
+	}
+
+	return content.String()
+}
+
+func formatHumanReadableOutput(test MCPTestResult, opts options) string {
+	var output strings.Builder
+	msg := catalog(opts.Lang)
+
+	// Header with test status
+	output.WriteString(fmt.Sprintf("%s: %s\n", msg.Task, test.TaskName))
+	output.WriteString(fmt.Sprintf("%s: %s\n", msg.Path, test.TaskPath))
+	output.WriteString(fmt.Sprintf("%s: %s\n", msg.Difficulty, test.Difficulty))
+	if len(test.Tags) > 0 {
+		output.WriteString(fmt.Sprintf("%s: %s\n", msg.Tags, strings.Join(test.Tags, ", ")))
+	}
+	if test.TraceID != "" {
+		output.WriteString(fmt.Sprintf("%s: %s\n", msg.Trace, traceLine(test, opts)))
+	}
+	if test.PromptID != "" {
+		output.WriteString(fmt.Sprintf("%s: %s\n", msg.Prompt, test.PromptID))
+	}
+
+	status := msg.Passed
+	switch {
+	case test.TaskSkipped && test.SkipReason != "":
+		status = fmt.Sprintf("%s (%s)", msg.Skipped, test.SkipReason)
+	case test.TaskSkipped:
+		status = msg.Skipped
+	case !test.TaskPassed:
+		status = msg.Failed
+	}
+	output.WriteString(fmt.Sprintf("%s: %s\n", msg.Status, status))
+
+	// Assertions summary
+	passedCount := countPassedAssertions(test.AssertionResults)
+	totalCount := len(test.AssertionResults)
+	output.WriteString(fmt.Sprintf(msg.Assertions+"\n", passedCount, totalCount))
+	writeUsage(&output, test, msg)
+	writeDurations(&output, test, msg)
+
+	// Call history summary
+	if test.CallHistory.ToolCalls != nil || test.CallHistory.ResourceReads != nil {
+		toolCount := len(test.CallHistory.ToolCalls)
+		resourceCount := len(test.CallHistory.ResourceReads)
+
+		toolsByServer := groupToolCallsByServer(test.CallHistory.ToolCalls)
+		var serverSummaries []string
+		for server, count := range toolsByServer {
+			serverSummaries = append(serverSummaries, fmt.Sprintf("%s:%d %s", server, count, msg.ToolOK))
+		}
+		sort.Strings(serverSummaries)
+
+		if toolCount > 0 || resourceCount > 0 {
+			output.WriteString(fmt.Sprintf("%s: tools=%d", msg.CallHistory, toolCount))
+			if len(serverSummaries) > 0 {
+				output.WriteString(fmt.Sprintf(" (%s)", strings.Join(serverSummaries, ", ")))
+			}
+			if resourceCount > 0 {
+				output.WriteString(fmt.Sprintf(" resources=%d", resourceCount))
+			}
+			output.WriteString("\n")
+		}
+
+		// Tool outputs
+		if len(test.CallHistory.ToolCalls) > 0 {
+			output.WriteString(fmt.Sprintf("  %s:\n", msg.ToolOutput))
+			for _, toolCall := range test.CallHistory.ToolCalls {
+				statusMarker := msg.ToolOK
+				if !toolCall.Success {
+					statusMarker = msg.ToolFailed
+				}
+				if toolCall.DurationMs > 0 {
+					statusMarker += ", " + formatMilliseconds(toolCall.DurationMs)
+				}
+				output.WriteString(fmt.Sprintf("    • %s::%s (%s)\n", toolCall.ServerName, toolCall.Name, statusMarker))
+				writeToolArguments(&output, toolCall, msg, opts)
+
+				// Extract structured content if available
+				if message := structuredMessage(toolCall); message != "" {
+					message = redactText(message, opts.Redactions)
+					// Truncate long messages
+					if limit := opts.MaxToolOutput; limit > 0 && len(message) > limit {
+						lines := strings.Split(message, "\n")
+						if first := strings.TrimSpace(lines[0]); len(lines) > 3 && len(first) <= limit {
+							output.WriteString(fmt.Sprintf("      %s\n", first))
+							output.WriteString(fmt.Sprintf("      … (+%d lines, %d bytes elided)\n", len(lines)-1, len(message)-len(lines[0])))
+						} else {
+							truncated := truncateText(strings.TrimSpace(message), limit, " ")
+							output.WriteString(fmt.Sprintf("      %s\n", strings.ReplaceAll(truncated, "\n", "\n      ")))
+						}
+					} else {
+						// Show full message for short outputs
+						formattedMsg := strings.ReplaceAll(strings.TrimSpace(message), "\n", "\n      ")
+						output.WriteString(fmt.Sprintf("      %s\n", formattedMsg))
+					}
+				}
+			}
+		}
+
+		// Resource reads
+		if len(test.CallHistory.ResourceReads) > 0 {
+			output.WriteString(fmt.Sprintf("  %s:\n", msg.ResourceReads))
+			for _, read := range test.CallHistory.ResourceReads {
+				writeResourceRead(&output, read, msg, opts)
+			}
+		}
+	}
+
+	// Timeline (from taskOutput - split into bullet points)
+	if test.TaskOutput != "" {
+		output.WriteString(msg.Timeline + ":\n")
+
+		// Split output into paragraphs/sentences
+		lines := strings.Split(test.TaskOutput, "\n")
+		for _, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			// Wrap long lines
+			if len(line) > 100 {
+				wrapped := wrapText(line, 100)
+				for i, wrappedLine := range wrapped {
+					if i == 0 {
+						output.WriteString(fmt.Sprintf("  - %s: %s\n", msg.Note, wrappedLine))
+					} else {
+						output.WriteString(fmt.Sprintf("    %s\n", wrappedLine))
+					}
+				}
+			} else {
+				output.WriteString(fmt.Sprintf("  - %s: %s\n", msg.Note, line))
+			}
+		}
+	}
+
+	writePhaseSections(&output, test, msg, opts.Redactions)
+
+	// Error details if test failed
+	if test.TaskError != "" {
+		output.WriteString("\n" + msg.Error + ":\n")
+		errorLines := strings.Split(test.TaskError, "\n")
+		for _, line := range errorLines {
+			if line != "" {
+				output.WriteString(fmt.Sprintf("  %s\n", line))
+			}
+		}
+	}
+
+	return output.String()
+}
+
+func countPassedAssertions(assertions map[string]Assertion) int {
+	count := 0
+	for _, assertion := range assertions {
+		if assertion.Passed {
+			count++
+		}
+	}
+	return count
+}
+
+// writeToolArguments writes the arguments of a tool call as indented JSON,
+// truncated like the tool messages
+func writeToolArguments(output *strings.Builder, call ToolCall, msg *messages, opts options) {
+	if len(call.Arguments) == 0 {
+		return
+	}
+	arguments, err := json.MarshalIndent(call.Arguments, "", "  ")
+	if err != nil {
+		return
+	}
+	text := truncateText(redactText(string(arguments), opts.Redactions), opts.MaxToolOutput, " ")
+	output.WriteString(fmt.Sprintf("      %s: %s\n", msg.ToolArguments, strings.ReplaceAll(text, "\n", "\n      ")))
+}
+
+// writeResourceRead writes the outcome and media type of a resource read,
+// followed by a preview of its content truncated like the tool messages
+func writeResourceRead(output *strings.Builder, read ResourceRead, msg *messages, opts options) {
+	status := msg.ToolOK
+	if !read.Success {
+		status = msg.ToolFailed
+	}
+	if read.MimeType != "" {
+		status += ", " + read.MimeType
+	}
+	output.WriteString(fmt.Sprintf("    • %s::%s (%s)\n", read.ServerName, read.URI, status))
+	if content := strings.TrimSpace(redactText(read.Content, opts.Redactions)); content != "" {
+		preview := truncateText(content, opts.MaxToolOutput, " ")
+		output.WriteString(fmt.Sprintf("      %s\n", strings.ReplaceAll(preview, "\n", "\n      ")))
+	}
+}
+
+func groupToolCallsByServer(toolCalls []ToolCall) map[string]int {
+	groups := make(map[string]int)
+	for _, call := range toolCalls {
+		if call.Success {
+			groups[call.ServerName]++
+		}
+	}
+	return groups
+}
+
+func wrapText(text string, maxWidth int) []string {
+	var lines []string
+	words := strings.Fields(text)
+
+	if len(words) == 0 {
+		return lines
+	}
+
+	currentLine := words[0]
+	for _, word := range words[1:] {
+		if len(currentLine)+1+len(word) <= maxWidth {
+			currentLine += " " + word
+		} else {
+			lines = append(lines, currentLine)
+			currentLine = word
+		}
+	}
+
+	if currentLine != "" {
+		lines = append(lines, currentLine)
+	}
+
+	return lines
+}
@@ -0,0 +1,321 @@
+package converter
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{name: "no limit", text: "abcdef", limit: 0, want: "abcdef"},
+		{name: "fits", text: "abcdef", limit: 6, want: "abcdef"},
+		{name: "cut", text: "abcdef", limit: 4, want: "abcd … (2 bytes elided)"},
+		{name: "utf-8 boundary", text: "añb", limit: 2, want: "a … (3 bytes elided)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncateText(tt.text, tt.limit, " "); got != tt.want {
+				t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutputTruncation(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	multiline := "first line\n" + strings.Repeat("more\n", 5)
+	run := mustParse(t, `[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"callHistory":{"ToolCalls":[
+		{"serverName":"s","name":"long","success":true,"result":{"structuredContent":{"message":"`+long+`"}}},
+		{"serverName":"s","name":"lines","success":true,"result":{"structuredContent":{"message":"`+strings.ReplaceAll(multiline, "\n", `\n`)+`"}}}
+	]}}]`)
+
+	tests := []struct {
+		name     string
+		opts     options
+		want     []string
+		dontWant []string
+		maxLen   int
+	}{
+		{
+			name: "default tool output limit",
+			opts: defaultOptions(),
+			want: []string{strings.Repeat("x", 200) + " … (100 bytes elided)", "first line\n      more\n"},
+		},
+		{
+			name:     "custom tool output limit",
+			opts:     options{MaxToolOutput: 20},
+			want:     []string{strings.Repeat("x", 20) + " … (280 bytes elided)", "first line\n      … (+6 lines, 26 bytes elided)"},
+			dontWant: []string{strings.Repeat("x", 21)},
+		},
+		{
+			name: "no limit",
+			opts: options{},
+			want: []string{long + "\n", "first line\n      more\n"},
+		},
+		{
+			name:   "system-out limit",
+			opts:   options{MaxSystemOutBytes: 50},
+			want:   []string{"Task: a\n", "\n… ("},
+			maxLen: 50 + len("\n… (999 bytes elided)"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := mustConvert(t, run, tt.opts).Suites[0].TestCases[0].SystemOut
+			for _, want := range tt.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("system-out does not contain %q:\n%s", want, out)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(out, dontWant) {
+					t.Errorf("system-out contains %q:\n%s", dontWant, out)
+				}
+			}
+			if tt.maxLen > 0 && len(out) > tt.maxLen {
+				t.Errorf("system-out is %d bytes, want at most %d:\n%s", len(out), tt.maxLen, out)
+			}
+		})
+	}
+}
+
+func TestToolCallDetails(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPassed":true,"difficulty":"easy","callHistory":{"ToolCalls":[
+			{"serverName":"k8s","name":"pods_list","success":true,"durationMs":1234.4,
+				"arguments":{"namespace":"demo","labels":["app=web"]}},
+			{"serverName":"k8s","name":"login","success":false,"arguments":{"token":"Bearer abcdefghijklmnop"}},
+			{"serverName":"k8s","name":"events_list","success":true}]}},
+		{"task_name":"b","task_passed":true,"call_history":{"tool_calls":[
+			{"server_name":"fs","name":"read","success":true,"duration_ms":42,"arguments":{"path":"/tmp/x"}}]}}
+	]`)
+
+	a := convertTestCase(run.Results[0], options{Redactions: BuiltinRedactions})
+	for _, want := range []string{
+		"    • k8s::pods_list (ok, 1.234s)\n      Arguments: {\n        \"labels\": [\n          \"app=web\"\n        ],\n        \"namespace\": \"demo\"\n      }\n",
+		"    • k8s::login (failed)\n      Arguments: {\n        \"token\": \"Bearer [REDACTED]\"\n      }\n",
+		"    • k8s::events_list (ok)\n",
+	} {
+		if !strings.Contains(a.SystemOut, want) {
+			t.Errorf("system-out = %q, want it to contain %q", a.SystemOut, want)
+		}
+	}
+	if strings.Count(a.SystemOut, "Arguments:") != 2 {
+		t.Errorf("system-out lists arguments for a call without any:\n%s", a.SystemOut)
+	}
+
+	b := convertTestCase(run.Results[1], options{Lang: LangPortuguese, MaxToolOutput: 10})
+	if want := "    • fs::read (ok, 42ms)\n      Argumentos: {\n        \"path\" … (12 bytes elided)\n"; !strings.Contains(b.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", b.SystemOut, want)
+	}
+}
+
+func TestResourceReads(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPassed":true,"difficulty":"easy","callHistory":{"ResourceReads":[
+			{"serverName":"k8s","uri":"k8s://configmaps/app","success":true,"mimeType":"text/plain",
+				"content":"\nreplicas: 2\ntoken: Bearer abcdefghijklmnop\n"},
+			{"serverName":"k8s","uri":"k8s://secrets/app","success":false}]}},
+		{"task_name":"b","task_passed":true,"call_history":{"resource_reads":[
+			{"server_name":"fs","uri":"file:///tmp/x","success":true,"mime_type":"text/plain","content":"0123456789abcdef"}]}}
+	]`)
+
+	a := convertTestCase(run.Results[0], options{Redactions: BuiltinRedactions})
+	want := "  Resource reads:\n    • k8s::k8s://configmaps/app (ok, text/plain)\n      replicas: 2\n      token: Bearer [REDACTED]\n" +
+		"    • k8s::k8s://secrets/app (failed)\n"
+	if !strings.Contains(a.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", a.SystemOut, want)
+	}
+
+	b := convertTestCase(run.Results[1], options{Lang: LangSpanish, MaxToolOutput: 10})
+	if want := "  Lecturas de recursos:\n    • fs::file:///tmp/x (ok, text/plain)\n      0123456789 … (6 bytes elided)\n"; !strings.Contains(b.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", b.SystemOut, want)
+	}
+}
+
+func TestSystemOutSuppression(t *testing.T) {
+	run := mustParse(t, `[`+resultA+`,{"taskName":"b","taskPassed":false,"taskError":"boom","difficulty":"easy"}]`)
+
+	tests := []struct {
+		name string
+		opts options
+		// wantOut and wantErr say whether testcases a (passed) and b (errored)
+		// keep their system-out and system-err
+		wantOut [2]bool
+		wantErr [2]bool
+	}{
+		{name: "default", wantOut: [2]bool{true, true}, wantErr: [2]bool{false, true}},
+		{name: "no system-out", opts: options{NoSystemOut: true}, wantOut: [2]bool{false, false}, wantErr: [2]bool{false, true}},
+		{name: "no system-err", opts: options{NoSystemErr: true}, wantOut: [2]bool{true, true}, wantErr: [2]bool{false, false}},
+		{name: "system-out on failure only", opts: options{SystemOutOnFailureOnly: true}, wantOut: [2]bool{false, true}, wantErr: [2]bool{false, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCases := mustConvert(t, run, tt.opts).Suites[0].TestCases
+			for i, testCase := range testCases {
+				if got := testCase.SystemOut != ""; got != tt.wantOut[i] {
+					t.Errorf("%s has system-out = %v, want %v", testCase.Name, got, tt.wantOut[i])
+				}
+				if got := testCase.SystemErr != ""; got != tt.wantErr[i] {
+					t.Errorf("%s has system-err = %v, want %v", testCase.Name, got, tt.wantErr[i])
+				}
+			}
+		})
+	}
+
+	t.Run("failed attempts of a flaky task keep their output", func(t *testing.T) {
+		merged := MergeReruns([]TestRun{
+			mustParse(t, `[{"taskName":"b","taskPassed":false,"taskError":"boom"}]`),
+			mustParse(t, `[{"taskName":"b","taskPassed":true,"allAssertionsPassed":true}]`),
+		})
+		testCase := mustConvert(t, merged, options{SystemOutOnFailureOnly: true}).Suites[0].TestCases[0]
+		if testCase.SystemOut != "" || len(testCase.FlakyErrors) != 1 || testCase.FlakyErrors[0].SystemOut == "" {
+			t.Errorf("unexpected flaky testcase %+v", testCase)
+		}
+	})
+}
+
+func TestSkippedResults(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskSkipped":true,"skipReason":"server lacks sampling","difficulty":"easy","setupOutput":{"error":"no sampling"}},
+		{"task_name":"b","task_skipped":true,"difficulty":"easy"},
+		`+resultA+`
+	]`)
+	report := mustConvert(t, run, options{Sort: SortOriginal})
+	suite := report.Suites[0]
+	if suite.Tests != 3 || suite.Skipped != 2 || suite.Failures != 0 || suite.Errors != 0 || report.Skipped != 2 {
+		t.Errorf("suite counts tests=%d skipped=%d failures=%d errors=%d, root skipped=%d", suite.Tests, suite.Skipped, suite.Failures, suite.Errors, report.Skipped)
+	}
+
+	a, b := suite.TestCases[0], suite.TestCases[1]
+	if a.Skipped == nil || a.Skipped.Message != "server lacks sampling" || a.Error != nil {
+		t.Errorf("testcase a = %+v, want skipped with its reason", a)
+	}
+	if !strings.Contains(a.SystemOut, "Status: SKIPPED (server lacks sampling)") || !strings.Contains(a.SystemErr, "no sampling") {
+		t.Errorf("testcase a output = %q, %q", a.SystemOut, a.SystemErr)
+	}
+	if b.Skipped == nil || b.Skipped.Message != "Task skipped" {
+		t.Errorf("testcase b = %+v, want skipped with the default message", b)
+	}
+
+	out, err := renderReport(report, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `<testcase name="a" classname="easy"><skipped message="server lacks sampling"></skipped>`) {
+		t.Errorf("report does not contain the skipped element:\n%s", out)
+	}
+
+	// A classifier can still report a skipped task otherwise
+	classified := convertTestCase(run.Results[0], options{Classifier: ClassifierFunc(func(MCPTestResult) Status { return StatusFailure })})
+	if classified.Skipped != nil || classified.Failure == nil || classified.Failure.Message != "server lacks sampling" {
+		t.Errorf("reclassified testcase = %+v, want a failure", classified)
+	}
+}
+
+func TestEmptyReport(t *testing.T) {
+	run := TestRun{RunID: "r1", StartedAt: "2025-03-01T10:00:00Z"}
+	report := mustConvert(t, run, options{GroupBy: GroupByDifficulty})
+	out, err := renderReport(report, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<testsuites tests="0" failures="0" errors="0" skipped="0"><testsuite name="MCP Checker Tests" tests="0" failures="0" errors="0" skipped="0" timestamp="2025-03-01T10:00:00">` +
+		`<properties><property name="runId" value="r1"></property>`
+	if !strings.Contains(string(out), want) {
+		t.Errorf("empty report = %s, want it to contain %s", out, want)
+	}
+
+	// Imported suites are enough of a report
+	run.ImportedSuites = []ImportedSuite{{XMLName: xml.Name{Local: "testsuite"}}}
+	if report := mustConvert(t, run, options{}); len(report.Suites) != 0 {
+		t.Errorf("report with imported suites has %d generated suites, want 0", len(report.Suites))
+	}
+}
+
+func TestReportProperties(t *testing.T) {
+	run := mustParse(t, `[`+resultA+`]`)
+	report := mustConvert(t, run, options{NoSystemOut: true, StripANSI: true,
+		ReportProperties: []JUnitProperty{{Name: "GIT_SHA", Value: "abc123"}, {Name: "MODEL_NAME", Value: "\x1b[1mgpt-5\x1b[0m"}}})
+	out, err := renderReport(report, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<testsuites tests="1" failures="0" errors="0" skipped="0"><properties><property name="GIT_SHA" value="abc123"></property>` +
+		`<property name="MODEL_NAME" value="gpt-5"></property></properties><testsuite `
+	if !strings.Contains(string(out), want) {
+		t.Errorf("report = %s, want it to contain %s", out, want)
+	}
+
+	if report := mustConvert(t, run, options{}); report.Properties != nil {
+		t.Errorf("report properties = %+v, want none", report.Properties)
+	}
+}
+
+func TestRenderReportIndent(t *testing.T) {
+	report := mustConvert(t, mustParse(t, `[`+resultA+`]`), options{NoSystemOut: true})
+
+	tests := []struct {
+		name   string
+		indent string
+		want   string
+	}{
+		{name: "default", indent: DefaultIndent, want: "<testsuites tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"0\">\n  <testsuite name=\"MCP Checker Tests - easy\" tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"0\">\n    <testcase"},
+		{name: "tabs", indent: "\t", want: "<testsuites tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"0\">\n\t<testsuite name=\"MCP Checker Tests - easy\" tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"0\">\n\t\t<testcase"},
+		{name: "compact", indent: "", want: "<testsuites tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"0\"><testsuite name=\"MCP Checker Tests - easy\" tests=\"1\" failures=\"0\" errors=\"0\" skipped=\"0\"><testcase"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := renderReport(report, tt.indent)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(out), xml.Header) || !strings.Contains(string(out), tt.want) {
+				t.Errorf("report does not contain %q:\n%s", tt.want, out)
+			}
+			if tt.indent == "" && strings.Count(string(out), "\n") != 2 {
+				t.Errorf("compact report spans more than one line:\n%s", out)
+			}
+		})
+	}
+}
+
+func TestTestCaseFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   MCPTestResult
+		prefix   string
+		wantFile string
+		wantLine int
+	}{
+		{name: "absolute", result: MCPTestResult{TaskPath: "/repo/tasks/a/task.yaml", TaskLine: 3}, wantFile: "/repo/tasks/a/task.yaml", wantLine: 3},
+		{name: "prefix", result: MCPTestResult{TaskPath: "/repo/tasks/a/task.yaml"}, prefix: "/repo", wantFile: "tasks/a/task.yaml"},
+		{name: "windows", result: MCPTestResult{TaskPath: `C:\repo\tasks\a\task.yaml`}, prefix: `C:\repo\`, wantFile: "tasks/a/task.yaml"},
+		{name: "other prefix", result: MCPTestResult{TaskPath: "/src/a.yaml"}, prefix: "/repo", wantFile: "/src/a.yaml"},
+		{name: "no path", result: MCPTestResult{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCase := convertTestCase(tt.result, options{PathPrefix: tt.prefix})
+			if testCase.File != tt.wantFile || testCase.Line != tt.wantLine {
+				t.Errorf("file, line = %q, %d, want %q, %d", testCase.File, testCase.Line, tt.wantFile, tt.wantLine)
+			}
+		})
+	}
+
+	run := mustParse(t, `{"task_name":"a","task_path":"/repo/a.yaml","task_line":7,"task_passed":true,"attempts":[{"task_passed":false}]}`)
+	if attempt := run.Results[0].Attempts[0]; attempt.TaskLine != 7 {
+		t.Errorf("attempt line = %d, want the line of the result", attempt.TaskLine)
+	}
+}
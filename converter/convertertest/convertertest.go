@@ -0,0 +1,213 @@
+// Package convertertest checks conversions against checked-in pairs of
+// inputs and expected reports, known as golden files, so that changes to the
+// JUnit output show up as test failures. It guards the converter package
+// itself, and programs embedding the converter can use it to notice when an
+// upgrade changes their reports:
+//
+//	func TestReports(t *testing.T) {
+//		convertertest.Run(t, "testdata", converter.WithGroupBy(converter.GroupByServer))
+//	}
+//
+// Running the tests with MCPJUNIT_UPDATE_GOLDEN=1 writes the golden files
+// from the current output, to create them or accept a change.
+package convertertest
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// UpdateEnv names the environment variable that, when set to a non-empty
+// value, makes Check and Run write the golden files instead of comparing
+// the reports with them
+const UpdateEnv = "MCPJUNIT_UPDATE_GOLDEN"
+
+// GoldenSuffix replaces the extension of an input to name its golden file,
+// e.g. basic.golden.xml for basic.json
+const GoldenSuffix = ".golden.xml"
+
+// normalizedTime replaces the times of a report, in the JUnit timestamp
+// layout or in RFC 3339
+var normalizedTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	timestampAttr = regexp.MustCompile(`timestamp="[^"]*"`)
+	rfc3339Time   = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+)
+
+// inputExtensions lists the extensions of the inputs Run picks up, with the
+// format each is parsed as
+var inputExtensions = map[string]string{
+	".json":   converter.FormatAuto,
+	".jsonl":  converter.FormatNDJSON,
+	".ndjson": converter.FormatNDJSON,
+	".yaml":   converter.FormatYAML,
+	".yml":    converter.FormatYAML,
+	".xml":    converter.FormatJUnit,
+}
+
+// Normalize replaces the times of a report, which change from one run to
+// the next, with a fixed one: the timestamp attributes of the testsuites
+// and RFC 3339 times anywhere else, such as in properties
+func Normalize(report []byte) []byte {
+	report = timestampAttr.ReplaceAll(report, fmt.Appendf(nil, `timestamp="%s"`, normalizedTime.Format("2006-01-02T15:04:05")))
+	return rfc3339Time.ReplaceAll(report, []byte(normalizedTime.Format(time.RFC3339)))
+}
+
+// Convert parses the input file, in the format of its extension, converts it
+// with opts and returns the rendered report, normalized. Runs without a
+// start time are stamped with a fixed clock, so that their testsuites have a
+// timestamp as they do outside of tests.
+func Convert(input string, opts ...converter.Option) ([]byte, error) {
+	file, err := os.Open(input)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	format, ok := inputExtensions[filepath.Ext(strings.TrimSuffix(input, ".gz"))]
+	if !ok {
+		format = converter.FormatAuto
+	}
+	run, err := converter.Parse(file, converter.ParseOptions{Format: format, AllowEmpty: true})
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", input, err)
+	}
+	conv, err := converter.New(append([]converter.Option{converter.WithClock(func() time.Time { return normalizedTime })}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	report, err := conv.Convert(run)
+	if err != nil {
+		return nil, fmt.Errorf("converting %s: %w", input, err)
+	}
+	data, err := conv.Render(report)
+	if err != nil {
+		return nil, fmt.Errorf("rendering %s: %w", input, err)
+	}
+	return Normalize(data), nil
+}
+
+// Check converts the input file with opts and compares the report with the
+// golden file, or writes it there when UpdateEnv is set
+func Check(t testing.TB, input, golden string, opts ...converter.Option) {
+	t.Helper()
+	got, err := Convert(input, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(golden)
+	if os.IsNotExist(err) {
+		t.Fatalf("golden file %s is missing, set %s=1 to write it", golden, UpdateEnv)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if line, gotLine, wantLine, differ := firstDifference(got, Normalize(want)); differ {
+		t.Errorf("report of %s differs from %s at line %d\n got: %s\nwant: %s\nset %s=1 to accept the new report",
+			input, golden, line, gotLine, wantLine, UpdateEnv)
+	}
+}
+
+// Run checks every input of dir and its subdirectories against its golden
+// file, next to it and named after it with GoldenSuffix, each in a subtest
+// named after the path of the input relative to dir, e.g. v2/basic.json.
+// Inputs are the files with a JSON, JSON Lines, YAML or JUnit XML extension,
+// gzipped or not, other than the golden files.
+func Run(t *testing.T, dir string, opts ...converter.Option) {
+	t.Helper()
+	inputs, err := Inputs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatalf("no inputs in %s", dir)
+	}
+	for _, input := range inputs {
+		name, err := filepath.Rel(dir, input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			Check(t, input, GoldenFile(input), opts...)
+		})
+	}
+}
+
+// Inputs returns the inputs of dir and its subdirectories that Run checks,
+// sorted by path. Inputs that only differ in their extension, such as
+// basic.json and basic.yaml, would share a golden file and are an error.
+func Inputs(dir string) ([]string, error) {
+	var inputs []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, GoldenSuffix) {
+			return nil
+		}
+		if _, ok := inputExtensions[filepath.Ext(strings.TrimSuffix(name, ".gz"))]; ok {
+			inputs = append(inputs, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(inputs)
+	goldens := make(map[string]string, len(inputs))
+	for _, input := range inputs {
+		golden := GoldenFile(input)
+		if other, ok := goldens[golden]; ok {
+			return nil, fmt.Errorf("inputs %s and %s share the golden file %s", other, input, golden)
+		}
+		goldens[golden] = input
+	}
+	return inputs, nil
+}
+
+// GoldenFile returns the golden file of an input
+func GoldenFile(input string) string {
+	base := strings.TrimSuffix(input, ".gz")
+	return strings.TrimSuffix(base, filepath.Ext(base)) + GoldenSuffix
+}
+
+// firstDifference returns the first line, counted from 1, that differs
+// between got and want, with its content in each
+func firstDifference(got, want []byte) (int, string, string, bool) {
+	if bytes.Equal(got, want) {
+		return 0, "", "", false
+	}
+	gotLines := strings.Split(string(got), "\n")
+	wantLines := strings.Split(string(want), "\n")
+	for i := range max(len(gotLines), len(wantLines)) {
+		gotLine, wantLine := "<end of report>", "<end of report>"
+		if i < len(gotLines) {
+			gotLine = gotLines[i]
+		}
+		if i < len(wantLines) {
+			wantLine = wantLines[i]
+		}
+		if gotLine != wantLine {
+			return i + 1, gotLine, wantLine, true
+		}
+	}
+	return 0, "", "", false
+}
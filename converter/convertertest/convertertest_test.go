@@ -0,0 +1,145 @@
+package convertertest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// TestGolden guards the reports of the converter with the default options
+func TestGolden(t *testing.T) {
+	Run(t, "testdata")
+}
+
+func TestNormalize(t *testing.T) {
+	report := `<testsuite timestamp="2026-10-16T18:06:52"><property name="startedAt" value="2026-10-16T18:06:52.123+02:00"></property></testsuite>`
+	want := `<testsuite timestamp="2000-01-01T00:00:00"><property name="startedAt" value="2000-01-01T00:00:00Z"></property></testsuite>`
+	if got := string(Normalize([]byte(report))); got != want {
+		t.Errorf("Normalize() = %s, want %s", got, want)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json.gz")
+	if err := os.WriteFile(input, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	golden := GoldenFile(input)
+	if golden != filepath.Join(dir, "results.golden.xml") {
+		t.Errorf("GoldenFile() = %s", golden)
+	}
+
+	t.Setenv(UpdateEnv, "1")
+	Check(t, input, golden, converter.WithReportName("nightly"))
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `name="nightly"`) {
+		t.Errorf("golden file written with the update variable set = %s", data)
+	}
+
+	// A report that no longer matches is reported at its first changed line
+	t.Setenv(UpdateEnv, "")
+	recorder := &recordingTB{TB: t}
+	Check(recorder, input, golden, converter.WithReportName("weekly"))
+	if !strings.Contains(recorder.failure, "at line 2") || !strings.Contains(recorder.failure, `name="weekly"`) {
+		t.Errorf("failure = %q", recorder.failure)
+	}
+
+	inputs, err := Inputs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) != 1 || inputs[0] != input {
+		t.Errorf("Inputs() = %v, want the input without its golden file", inputs)
+	}
+}
+
+func TestInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "subdirectories",
+			files: []string{"results.json", "v1/results.json", "v2/results.json.gz", "v2/results.golden.xml", "v2/notes.txt"},
+			want:  []string{"results.json", "v1/results.json", "v2/results.json.gz"},
+		},
+		{name: "same name in one directory", files: []string{"v1/tasks.json", "v1/tasks.yaml"}, wantErr: true},
+		{name: "no inputs", files: []string{"README.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tt.files {
+				path := filepath.Join(dir, filepath.FromSlash(file))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			inputs, err := Inputs(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Inputs() error = %v, want an error: %v", err, tt.wantErr)
+			}
+			var got []string
+			for _, input := range inputs {
+				rel, err := filepath.Rel(dir, input)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Inputs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSubdirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"v1", "v2"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, sub, "results.json"), []byte(`{"runId":"`+sub+`","results":[]}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The fixtures of each directory get a golden file of their own
+	t.Setenv(UpdateEnv, "1")
+	Run(t, dir)
+	for _, sub := range []string{"v1", "v2"} {
+		data, err := os.ReadFile(filepath.Join(dir, sub, "results.golden.xml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), sub) {
+			t.Errorf("golden file of %s = %s", sub, data)
+		}
+	}
+}
+
+// recordingTB records the failures of Check instead of failing the test
+type recordingTB struct {
+	testing.TB
+	failure string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.failure = fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,190 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ways to handle testcases of a suite that share a classname and name, see
+// WithOnDuplicate
+const (
+	DuplicateError  = "error"
+	DuplicateSuffix = "suffix"
+	DuplicateMerge  = "merge"
+)
+
+// DuplicatePolicies lists the duplicate handling values
+var DuplicatePolicies = []string{DuplicateError, DuplicateSuffix, DuplicateMerge}
+
+// ErrDuplicateTestCase is returned by Convert for testcases that share a
+// classname and name within a suite, with DuplicateError
+var ErrDuplicateTestCase = errors.New("duplicate testcase")
+
+// resolveDuplicates handles the testcases of a suite that share a classname
+// and name, which JUnit consumers silently merge or overwrite. sources holds
+// the result of each testcase and is kept in step. An empty policy leaves
+// duplicates alone.
+func resolveDuplicates(testCases []JUnitTestCase, sources []MCPTestResult, suiteName, policy string) ([]JUnitTestCase, []MCPTestResult, error) {
+	if policy == "" {
+		return testCases, sources, nil
+	}
+	index := make(map[string][]int)
+	var keys []string
+	for i, testCase := range testCases {
+		key := testCase.Classname + "\x00" + testCase.Name
+		if _, ok := index[key]; !ok {
+			keys = append(keys, key)
+		}
+		index[key] = append(index[key], i)
+	}
+	if len(keys) == len(testCases) {
+		return testCases, sources, nil
+	}
+
+	switch policy {
+	case DuplicateError:
+		for _, key := range keys {
+			if members := index[key]; len(members) > 1 {
+				first := testCases[members[0]]
+				return nil, nil, fmt.Errorf("%w: suite %q has %d testcases named %q with classname %q",
+					ErrDuplicateTestCase, suiteName, len(members), first.Name, first.Classname)
+			}
+		}
+	case DuplicateSuffix:
+		for _, key := range keys {
+			if members := index[key]; len(members) > 1 {
+				for i, suffix := range duplicateSuffixes(members, sources) {
+					testCases[members[i]].Name += " [" + suffix + "]"
+				}
+			}
+		}
+	case DuplicateMerge:
+		merged := make([]JUnitTestCase, 0, len(keys))
+		mergedSources := make([]MCPTestResult, 0, len(keys))
+		for _, key := range keys {
+			members := index[key]
+			duplicates := make([]JUnitTestCase, len(members))
+			for i, member := range members {
+				duplicates[i] = testCases[member]
+			}
+			merged = append(merged, mergeTestCases(duplicates))
+			mergedSources = append(mergedSources, sources[members[0]])
+		}
+		return merged, mergedSources, nil
+	}
+	return testCases, sources, nil
+}
+
+// duplicateSuffixes tells duplicates apart by the MCP server each called
+// most when those differ, by a hash of their task paths when those differ,
+// and by their position otherwise
+func duplicateSuffixes(members []int, sources []MCPTestResult) []string {
+	servers := make([]string, len(members))
+	paths := make([]string, len(members))
+	for i, member := range members {
+		servers[i] = dominantServer(sources[member])
+		paths[i] = sources[member].TaskPath
+	}
+	if allDistinct(servers) {
+		return servers
+	}
+	if allDistinct(paths) {
+		hashes := make([]string, len(paths))
+		for i, path := range paths {
+			sum := sha256.Sum256([]byte(path))
+			hashes[i] = hex.EncodeToString(sum[:4])
+		}
+		return hashes
+	}
+	positions := make([]string, len(members))
+	for i := range members {
+		positions[i] = strconv.Itoa(i + 1)
+	}
+	return positions
+}
+
+// allDistinct reports whether values are non-empty and all different
+func allDistinct(values []string) bool {
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		if value == "" || seen[value] {
+			return false
+		}
+		seen[value] = true
+	}
+	return true
+}
+
+// outcomeRank orders testcases from skipped to errored
+func outcomeRank(testCase JUnitTestCase) int {
+	switch {
+	case testCase.Error != nil:
+		return 3
+	case testCase.Failure != nil:
+		return 2
+	case testCase.Skipped != nil:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// mergeTestCases combines duplicates into one testcase with the worst
+// outcome among them. The output of every duplicate is kept, as is the
+// failure or error content of those with the same outcome.
+func mergeTestCases(duplicates []JUnitTestCase) JUnitTestCase {
+	worst := 0
+	for i, testCase := range duplicates {
+		if outcomeRank(testCase) > outcomeRank(duplicates[worst]) {
+			worst = i
+		}
+	}
+	merged := duplicates[worst]
+	if merged.Failure != nil {
+		failure := *merged.Failure
+		merged.Failure = &failure
+	}
+	if merged.Error != nil {
+		junitErr := *merged.Error
+		merged.Error = &junitErr
+	}
+
+	var systemOut, systemErr []string
+	for i, testCase := range duplicates {
+		if testCase.SystemOut != "" {
+			systemOut = append(systemOut, testCase.SystemOut)
+		}
+		if testCase.SystemErr != "" {
+			systemErr = append(systemErr, testCase.SystemErr)
+		}
+		if i == worst {
+			continue
+		}
+		if merged.Error != nil && testCase.Error != nil {
+			merged.Error.Content = joinContent(merged.Error.Content, testCase.Error.Content)
+		}
+		if merged.Failure != nil && testCase.Failure != nil {
+			merged.Failure.Content = joinContent(merged.Failure.Content, testCase.Failure.Content)
+		}
+		merged.Time = addTime(merged.Time, testCase.Time)
+		merged.RerunFailures = append(merged.RerunFailures, testCase.RerunFailures...)
+		merged.RerunErrors = append(merged.RerunErrors, testCase.RerunErrors...)
+		merged.FlakyFailures = append(merged.FlakyFailures, testCase.FlakyFailures...)
+		merged.FlakyErrors = append(merged.FlakyErrors, testCase.FlakyErrors...)
+	}
+	merged.SystemOut = strings.Join(systemOut, "\n")
+	merged.SystemErr = strings.Join(systemErr, "\n\n")
+	return merged
+}
+
+// joinContent joins two failure contents with a blank line
+func joinContent(a, b string) string {
+	if a == "" || b == "" {
+		return a + b
+	}
+	return a + "\n\n" + b
+}
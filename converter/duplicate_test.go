@@ -0,0 +1,72 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOnDuplicate(t *testing.T) {
+	call := func(server string) string {
+		return `"callHistory":{"ToolCalls":[{"serverName":"` + server + `","name":"x","success":true}]}`
+	}
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name: "server suffix",
+			input: `[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,` + call("k8s") + `},
+				{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,` + call("openshift") + `},
+				{"taskName":"b","taskPassed":true,"allAssertionsPassed":true}]`,
+			want: "a [k8s],a [openshift],b",
+		},
+		{
+			name: "path hash suffix",
+			input: `[{"taskName":"a","taskPath":"/x/one/a/task.yaml","taskPassed":true,"allAssertionsPassed":true},
+				{"taskName":"a","taskPath":"/x/two/a/task.yaml","taskPassed":true,"allAssertionsPassed":true}]`,
+			want: "a [cbfe675a],a [99fa4f3f]",
+		},
+		{
+			name:  "position suffix",
+			input: `[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true},{"taskName":"a","taskPassed":false}]`,
+			want:  "a [1],a [2]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := mustConvert(t, mustParse(t, tt.input), options{OnDuplicate: DuplicateSuffix})
+			var names []string
+			for _, testCase := range report.Suites[0].TestCases {
+				names = append(names, testCase.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("testcases = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	run := mustParse(t, `[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"taskOutput":"first"},
+		{"taskName":"a","taskPassed":false,"taskError":"boom"},
+		{"taskName":"a","taskPassed":false,"taskError":"bang"}]`)
+
+	report := mustConvert(t, run, options{OnDuplicate: DuplicateMerge})
+	suite := report.Suites[0]
+	if suite.Tests != 1 || suite.Errors != 1 {
+		t.Fatalf("merged suite tests=%d errors=%d, want 1/1", suite.Tests, suite.Errors)
+	}
+	merged := suite.TestCases[0]
+	if merged.Error.Content != "boom\n\nbang" || !strings.Contains(merged.SystemOut, "first") || merged.SystemErr != "boom\n\nbang" {
+		t.Errorf("merged testcase = %+v", merged)
+	}
+
+	if _, err := convertToJUnit(context.Background(), run, options{OnDuplicate: DuplicateError}); !errors.Is(err, ErrDuplicateTestCase) {
+		t.Errorf("Convert() error = %v, want ErrDuplicateTestCase", err)
+	}
+	if report := mustConvert(t, run, options{}); report.Suites[0].Tests != 3 {
+		t.Errorf("duplicates without a policy = %d testcases, want 3", report.Suites[0].Tests)
+	}
+}
@@ -0,0 +1,106 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// PhaseSecondsProperty names the suite property holding the total time its
+// results spent in a phase, in seconds, e.g. "agentSeconds"
+func PhaseSecondsProperty(phase string) string {
+	return phase + "Seconds"
+}
+
+// PhaseDurations returns the milliseconds each phase of the result took,
+// keyed by phase, leaving out the phases that reported no duration
+func (r MCPTestResult) PhaseDurations() map[string]float64 {
+	durations := make(map[string]float64)
+	for phase, output := range phaseOutputs(&r) {
+		if output.DurationMs > 0 {
+			durations[phase] = output.DurationMs
+		}
+	}
+	return durations
+}
+
+// withDurationProperties returns properties followed by the time results
+// spent in each phase, or properties itself when none reported durations
+func withDurationProperties(properties *JUnitProperties, results []MCPTestResult) *JUnitProperties {
+	totals := make(map[string]float64)
+	for _, result := range results {
+		for phase, ms := range result.PhaseDurations() {
+			totals[phase] += ms
+		}
+	}
+	if len(totals) == 0 {
+		return properties
+	}
+	withDurations := &JUnitProperties{}
+	if properties != nil {
+		withDurations.Properties = slices.Clone(properties.Properties)
+	}
+	for _, phase := range Phases {
+		if ms, ok := totals[phase]; ok {
+			withDurations.Properties = append(withDurations.Properties,
+				JUnitProperty{Name: PhaseSecondsProperty(phase), Value: formatSeconds(ms)})
+		}
+	}
+	return withDurations
+}
+
+// writeDurations writes the duration of each phase of a result and their
+// total, e.g. "Durations: setup=1.2s agent=30s total=31.2s", if it
+// reported any
+func writeDurations(output *strings.Builder, test MCPTestResult, msg *messages) {
+	durations := test.PhaseDurations()
+	if len(durations) == 0 {
+		return
+	}
+	var total float64
+	fmt.Fprintf(output, "%s:", msg.Durations)
+	for _, phase := range Phases {
+		if ms, ok := durations[phase]; ok {
+			fmt.Fprintf(output, " %s=%s", phase, formatMilliseconds(ms))
+			total += ms
+		}
+	}
+	fmt.Fprintf(output, " total=%s\n", formatMilliseconds(total))
+}
+
+// formatSeconds formats milliseconds as the seconds of a JUnit time
+// attribute
+func formatSeconds(ms float64) string {
+	return strconv.FormatFloat(ms/1000, 'f', 3, 64)
+}
+
+// testCaseTime returns the time attribute of the testcase of a result, the
+// total of its phase durations, or "" when it reported none
+func testCaseTime(test MCPTestResult) string {
+	durations := test.PhaseDurations()
+	if len(durations) == 0 {
+		return ""
+	}
+	var total float64
+	for _, ms := range durations {
+		total += ms
+	}
+	return formatSeconds(total)
+}
+
+// addTime adds two time attributes, either of which may be empty
+func addTime(a, b string) string {
+	if a == "" {
+		return b
+	}
+	if b == "" {
+		return a
+	}
+	x, errA := strconv.ParseFloat(a, 64)
+	y, errB := strconv.ParseFloat(b, 64)
+	if errA != nil || errB != nil {
+		return a
+	}
+	return strconv.FormatFloat(x+y, 'f', 3, 64)
+}
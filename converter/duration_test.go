@@ -0,0 +1,136 @@
+package converter
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDurations(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+		 "setupOutput":{"Success":true,"DurationMs":1200},"agentOutput":{"Success":true,"DurationMs":30000},
+		 "verifyOutput":{"Success":true,"DurationMs":2000},"cleanupOutput":{"Success":true,"DurationMs":500}},
+		{"task_name":"b","difficulty":"easy","task_passed":true,"all_assertions_passed":true,
+		 "agent_output":{"success":true,"duration_ms":1500.5}},
+		{"taskName":"c","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+
+	report := mustConvert(t, run, options{Sort: SortSorted, Lang: LangEnglish})
+	easy, hard := report.Suites[0], report.Suites[1]
+	var properties []JUnitProperty
+	for _, property := range easy.Properties.Properties {
+		if strings.HasSuffix(property.Name, "Seconds") {
+			properties = append(properties, property)
+		}
+	}
+	want := []JUnitProperty{
+		{Name: "setupSeconds", Value: "1.200"},
+		{Name: "agentSeconds", Value: "31.500"},
+		{Name: "verifySeconds", Value: "2.000"},
+		{Name: "cleanupSeconds", Value: "0.500"},
+	}
+	if !reflect.DeepEqual(properties, want) {
+		t.Errorf("easy suite properties = %v, want %v", properties, want)
+	}
+	if hard.Properties != nil && slices.ContainsFunc(hard.Properties.Properties, func(p JUnitProperty) bool { return strings.HasSuffix(p.Name, "Seconds") }) {
+		t.Errorf("hard suite properties = %v, want no durations", hard.Properties.Properties)
+	}
+
+	for i, want := range []string{
+		"Durations: setup=1.2s agent=30s verify=2s cleanup=500ms total=33.7s\n",
+		"Durations: agent=1.501s total=1.501s\n",
+	} {
+		if got := easy.TestCases[i].SystemOut; !strings.Contains(got, want) {
+			t.Errorf("%s: system-out = %q, want it to contain %q", easy.TestCases[i].Name, got, want)
+		}
+	}
+	if got := hard.TestCases[0].SystemOut; strings.Contains(got, "Durations:") {
+		t.Errorf("system-out = %q, want no durations", got)
+	}
+
+	pt := convertTestCase(run.Results[1], options{Lang: LangPortuguese})
+	if want := "Durações: agent=1.501s total=1.501s\n"; !strings.Contains(pt.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", pt.SystemOut, want)
+	}
+}
+
+func TestTimeAttributes(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+		 "setupOutput":{"Success":true,"DurationMs":1200},"agentOutput":{"Success":true,"DurationMs":30000}},
+		{"taskName":"b","taskPath":"/x/other/b/task.yaml","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+		 "agentOutput":{"Success":true,"DurationMs":1500.5}},
+		{"taskName":"b","taskPath":"/x/other/b/task.yaml","difficulty":"easy","taskPassed":false,"allAssertionsPassed":false,
+		 "agentOutput":{"Success":true,"DurationMs":250}},
+		{"taskName":"c","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+
+	tests := []struct {
+		name string
+		opts options
+		// suites and testCases map names to their time
+		suites    map[string]string
+		testCases map[string]string
+	}{
+		{
+			name:      "suffixed duplicates",
+			opts:      options{Sort: SortSorted, OnDuplicate: DuplicateSuffix, Lang: LangEnglish},
+			suites:    map[string]string{"MCP Checker Tests - easy": "32.950", "MCP Checker Tests - hard": ""},
+			testCases: map[string]string{"a": "31.200", "b [1]": "1.500", "b [2]": "0.250", "c": ""},
+		},
+		{
+			name:      "merged duplicates",
+			opts:      options{Sort: SortSorted, OnDuplicate: DuplicateMerge, Lang: LangEnglish},
+			suites:    map[string]string{"MCP Checker Tests - easy": "32.950"},
+			testCases: map[string]string{"a": "31.200", "b": "1.750"},
+		},
+		{
+			name:   "nested suites",
+			opts:   options{Sort: SortSorted, OnDuplicate: DuplicateSuffix, NestedSuites: true, Lang: LangEnglish},
+			suites: map[string]string{"MCP Checker Tests - easy": "32.950", "a": "31.200", "b": "1.750", "MCP Checker Tests - hard": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suites, testCases := make(map[string]string), make(map[string]string)
+			var walk func([]JUnitTestSuite)
+			walk = func(children []JUnitTestSuite) {
+				for _, suite := range children {
+					suites[suite.Name] = suite.Time
+					for _, testCase := range suite.TestCases {
+						testCases[testCase.Name] = testCase.Time
+					}
+					walk(suite.Suites)
+				}
+			}
+			walk(mustConvert(t, run, tt.opts).Suites)
+			for name, want := range tt.suites {
+				if suites[name] != want {
+					t.Errorf("time of suite %s = %q, want %q (suites %v)", name, suites[name], want, suites)
+				}
+			}
+			for name, want := range tt.testCases {
+				if testCases[name] != want {
+					t.Errorf("time of testcase %s = %q, want %q", name, testCases[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestAddTime(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{},
+		{a: "1.500", want: "1.500"},
+		{b: "0.250", want: "0.250"},
+		{a: "1.500", b: "0.250", want: "1.750"},
+		{a: "1.5", b: "x", want: "1.5"},
+	}
+	for _, tt := range tests {
+		if got := addTime(tt.a, tt.b); got != tt.want {
+			t.Errorf("addTime(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package converter
+
+import (
+	"cmp"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// Names of the testsuites properties describing the environment of a run;
+// the version of each MCP server is written as MCPServerPropertyPrefix
+// followed by the server name
+const (
+	ModelProperty             = "model"
+	TemperatureProperty       = "temperature"
+	MCPCheckerVersionProperty = "mcpcheckerVersion"
+	MCPServerPropertyPrefix   = "mcpServer."
+)
+
+// Environment describes the model and tools a run was produced with, as
+// recorded in the environment object of an envelope
+type Environment struct {
+	Model string `json:"model,omitempty"`
+	// Temperature is nil when the checker did not record it, 0 being a
+	// meaningful setting
+	Temperature       *float64 `json:"temperature,omitempty"`
+	MCPCheckerVersion string   `json:"mcpcheckerVersion,omitempty"`
+	// MCPServers maps the name of each MCP server to its version
+	MCPServers map[string]string `json:"mcpServers,omitempty"`
+}
+
+// UnmarshalJSON accepts the keys of both schema versions, e.g.
+// mcpcheckerVersion and mcpchecker_version
+func (e *Environment) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Model                  string            `json:"model"`
+		Temperature            *float64          `json:"temperature"`
+		MCPCheckerVersion      string            `json:"mcpcheckerVersion"`
+		MCPCheckerVersionSnake string            `json:"mcpchecker_version"`
+		MCPServers             map[string]string `json:"mcpServers"`
+		MCPServersSnake        map[string]string `json:"mcp_servers"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	*e = Environment{
+		Model:             fields.Model,
+		Temperature:       fields.Temperature,
+		MCPCheckerVersion: cmp.Or(fields.MCPCheckerVersion, fields.MCPCheckerVersionSnake),
+		MCPServers:        fields.MCPServers,
+	}
+	if e.MCPServers == nil {
+		e.MCPServers = fields.MCPServersSnake
+	}
+	return nil
+}
+
+// Properties returns the recorded settings as properties, the MCP servers
+// sorted by name
+func (e *Environment) Properties() []JUnitProperty {
+	if e == nil {
+		return nil
+	}
+	var properties []JUnitProperty
+	if e.Model != "" {
+		properties = append(properties, JUnitProperty{Name: ModelProperty, Value: e.Model})
+	}
+	if e.Temperature != nil {
+		properties = append(properties, JUnitProperty{Name: TemperatureProperty, Value: strconv.FormatFloat(*e.Temperature, 'f', -1, 64)})
+	}
+	if e.MCPCheckerVersion != "" {
+		properties = append(properties, JUnitProperty{Name: MCPCheckerVersionProperty, Value: e.MCPCheckerVersion})
+	}
+	for _, server := range slices.Sorted(maps.Keys(e.MCPServers)) {
+		properties = append(properties, JUnitProperty{Name: MCPServerPropertyPrefix + server, Value: e.MCPServers[server]})
+	}
+	return properties
+}
@@ -0,0 +1,66 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEnvironment(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []JUnitProperty
+	}{
+		{
+			name: "camel case",
+			input: `{"runId":"r1","environment":{"model":"gpt-5","temperature":0.2,"mcpcheckerVersion":"0.4.0",
+				"mcpServers":{"kubernetes":"1.2.0","filesystem":"0.3.1"}},"results":[` + resultA + `]}`,
+			want: []JUnitProperty{{Name: ModelProperty, Value: "gpt-5"}, {Name: TemperatureProperty, Value: "0.2"},
+				{Name: MCPCheckerVersionProperty, Value: "0.4.0"}, {Name: "mcpServer.filesystem", Value: "0.3.1"},
+				{Name: "mcpServer.kubernetes", Value: "1.2.0"}},
+		},
+		{
+			name:  "snake case with a zero temperature",
+			input: `{"schema_version":2,"environment":{"model":"claude","temperature":0,"mcpchecker_version":"0.5.0","mcp_servers":{"k8s":"2.0"}},"results":[]}`,
+			want: []JUnitProperty{{Name: ModelProperty, Value: "claude"}, {Name: TemperatureProperty, Value: "0"},
+				{Name: MCPCheckerVersionProperty, Value: "0.5.0"}, {Name: "mcpServer.k8s", Value: "2.0"}},
+		},
+		{
+			name:  "none",
+			input: `{"runId":"r1","results":[` + resultA + `]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := Parse(strings.NewReader(tt.input), ParseOptions{Strict: true, AllowEmpty: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := run.Environment.Properties(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Properties() = %+v, want %+v", got, tt.want)
+			}
+
+			report := mustConvert(t, run, options{ReportProperties: []JUnitProperty{{Name: "GIT_SHA", Value: "abc123"}}})
+			want := append(tt.want, JUnitProperty{Name: "GIT_SHA", Value: "abc123"})
+			if got := report.Properties.Properties; !reflect.DeepEqual(got, want) {
+				t.Errorf("report properties = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestEnvironmentMerge(t *testing.T) {
+	first := mustParse(t, `{"results":[`+resultA+`]}`)
+	second := mustParse(t, `{"environment":{"model":"gpt-5"},"results":[`+resultB+`]}`)
+	third := mustParse(t, `{"environment":{"model":"claude"},"results":[`+resultC+`]}`)
+
+	if merged := MergeReruns([]TestRun{first, second, third}); merged.Environment == nil || merged.Environment.Model != "gpt-5" {
+		t.Errorf("MergeReruns() environment = %+v, want the first one recorded", merged.Environment)
+	}
+	first.Merge(second)
+	first.Merge(third)
+	if first.Environment == nil || first.Environment.Model != "gpt-5" {
+		t.Errorf("Merge() environment = %+v, want the first one recorded", first.Environment)
+	}
+}
@@ -0,0 +1,96 @@
+package converter
+
+import (
+	"fmt"
+	"sort"
+)
+
+// AssertionSeparator joins the task and assertion names of the testcases
+// written with the ExplodeAssertions option
+const AssertionSeparator = "::"
+
+// explodeAssertions turns the testcase of a task into one testcase per
+// assertion, named task::assertion, so that the stability of each assertion
+// can be tracked. Failed assertions carry the output of the final result of
+// the task. A task whose final result errored or was skipped, or has no
+// assertions, keeps its single testcase, since its assertions say nothing
+// on their own.
+//
+// Earlier attempts become rerun elements of each assertion that failed in
+// them: flakyFailure when the assertion passed in the end, rerunFailure
+// otherwise.
+func explodeAssertions(test MCPTestResult, taskCase JUnitTestCase, opts options) []JUnitTestCase {
+	// The testcase of a task with attempts may be the one of an earlier
+	// failed attempt, see convertWithAttempts
+	final := taskCase
+	if len(test.Attempts) > 0 {
+		final = convertTestCase(test, opts)
+	}
+	if final.Error != nil || final.Skipped != nil || len(test.AssertionResults) == 0 {
+		return []JUnitTestCase{taskCase}
+	}
+	msg := catalog(opts.Lang)
+
+	names := make([]string, 0, len(test.AssertionResults))
+	for name := range test.AssertionResults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	testCases := make([]JUnitTestCase, 0, len(names))
+	for _, name := range names {
+		testCase := JUnitTestCase{
+			Name:       taskCase.Name + AssertionSeparator + sanitizeText(name, opts.StripANSI),
+			Classname:  taskCase.Classname,
+			File:       taskCase.File,
+			Line:       taskCase.Line,
+			difficulty: taskCase.difficulty,
+			cdata:      taskCase.cdata,
+		}
+		if failure := assertionFailure(test, name, msg, opts); failure != nil {
+			testCase.Failure = failure
+			testCase.SystemOut, testCase.SystemErr = final.SystemOut, final.SystemErr
+		}
+		for _, attempt := range test.Attempts {
+			failure := assertionFailure(attempt, name, msg, opts)
+			if failure == nil {
+				continue
+			}
+			rerun := JUnitRerun{Message: failure.Message, Type: failure.Type, StackTrace: failure.Content}
+			if testCase.Failure == nil {
+				testCase.FlakyFailures = append(testCase.FlakyFailures, rerun)
+			} else {
+				testCase.RerunFailures = append(testCase.RerunFailures, rerun)
+			}
+		}
+		testCases = append(testCases, testCase)
+	}
+	return testCases
+}
+
+// assertionFailure returns the failure of an assertion of a result, with
+// its message and expected and actual values as content, or nil when it
+// passed or the result does not have it
+func assertionFailure(test MCPTestResult, name string, msg *messages, opts options) *JUnitFailure {
+	assertion, ok := test.AssertionResults[name]
+	if !ok || assertion.Passed {
+		return nil
+	}
+	return &JUnitFailure{
+		Message: sanitizeText(fmt.Sprintf(msg.AssertionFailed, name), opts.StripANSI),
+		Type:    "AssertionFailure",
+		Content: sanitizeText(redactText(assertionContent(assertion, msg), opts.Redactions), opts.StripANSI),
+	}
+}
+
+// assertionContent is the failure content of a single assertion
+func assertionContent(assertion Assertion, msg *messages) string {
+	details := assertionDetails(assertion, msg, "")
+	if assertion.Message == "" {
+		return details
+	}
+	if details == "" {
+		return assertion.Message
+	}
+	return assertion.Message + "\n" + details
+}
@@ -0,0 +1,46 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplodeAssertions(t *testing.T) {
+	run := mustParse(t, `{"schemaVersion":2,"results":[
+		{"task_name":"scale","difficulty":"easy","task_passed":true,"all_assertions_passed":false,"task_output":"scaled",
+			"assertion_results":{"replicas":{"passed":false,"details":{"message":"want 3, got 2"}},"toolsUsed":{"passed":true},"minToolCalls":{"passed":false}},
+			"attempts":[{"task_passed":true,"all_assertions_passed":false,"assertion_results":{"replicas":{"passed":true},"toolsUsed":{"passed":false}}},
+				{"task_passed":true,"all_assertions_passed":false,"assertion_results":{"minToolCalls":{"passed":false}}}]},
+		{"task_name":"broken","difficulty":"easy","task_passed":false,"task_error":"timeout","assertion_results":{"replicas":{"passed":false}}},
+		{"task_name":"bare","difficulty":"easy","task_passed":true,"all_assertions_passed":true}
+	]}`)
+
+	report := mustConvert(t, run, options{ExplodeAssertions: true, Sort: SortOriginal, Lang: LangEnglish})
+	suite := report.Suites[0]
+	if suite.Tests != 5 || suite.Failures != 2 || suite.Errors != 1 {
+		t.Errorf("suite counts tests=%d failures=%d errors=%d, want 5/2/1", suite.Tests, suite.Failures, suite.Errors)
+	}
+
+	var names []string
+	for _, testCase := range suite.TestCases {
+		names = append(names, testCase.Name)
+	}
+	if got := strings.Join(names, ","); got != "scale::minToolCalls,scale::replicas,scale::toolsUsed,broken,bare" {
+		t.Errorf("testcases = %s", got)
+	}
+
+	minToolCalls, replicas, toolsUsed := suite.TestCases[0], suite.TestCases[1], suite.TestCases[2]
+	if replicas.Failure == nil || replicas.Failure.Message != "Assertion replicas failed" || replicas.Failure.Content != "want 3, got 2" ||
+		!strings.Contains(replicas.SystemOut, "Task: scale") || replicas.Difficulty() != "easy" {
+		t.Errorf("replicas testcase = %+v", replicas)
+	}
+	if len(minToolCalls.RerunFailures) != 1 || len(minToolCalls.FlakyFailures) != 0 {
+		t.Errorf("minToolCalls testcase = %+v, want a rerun failure", minToolCalls)
+	}
+	if toolsUsed.Failure != nil || toolsUsed.SystemOut != "" || len(toolsUsed.FlakyFailures) != 1 {
+		t.Errorf("toolsUsed testcase = %+v, want passed with a flaky failure", toolsUsed)
+	}
+	if broken := suite.TestCases[3]; broken.Error == nil {
+		t.Errorf("broken testcase = %+v, want the error of the task", broken)
+	}
+}
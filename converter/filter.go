@@ -0,0 +1,77 @@
+package converter
+
+import (
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TaskFilter selects the results that make it into the report. The zero
+// value keeps every result.
+type TaskFilter struct {
+	// Include keeps only the results whose task name or path matches
+	Include *regexp.Regexp
+	// Exclude drops the results whose task name or path matches, even if
+	// they match Include
+	Exclude *regexp.Regexp
+	// Difficulties keeps only the results of these difficulty levels;
+	// "unknown" stands for results without one
+	Difficulties []string
+	// Tags keeps only the results with at least one of these tags, and
+	// ExcludeTags drops those with any of them, even if they match Tags
+	Tags        []string
+	ExcludeTags []string
+}
+
+// keep reports whether a result passes the filter
+func (f TaskFilter) keep(result MCPTestResult) bool {
+	matches := func(re *regexp.Regexp) bool {
+		return re.MatchString(result.TaskName) || (result.TaskPath != "" && re.MatchString(result.TaskPath))
+	}
+	if f.Include != nil && !matches(f.Include) {
+		return false
+	}
+	if f.Exclude != nil && matches(f.Exclude) {
+		return false
+	}
+	if len(f.Tags) > 0 && !hasTag(result, f.Tags) {
+		return false
+	}
+	if hasTag(result, f.ExcludeTags) {
+		return false
+	}
+	if len(f.Difficulties) > 0 {
+		difficulty := result.Difficulty
+		if difficulty == "" {
+			difficulty = UnknownGroup
+		}
+		return slices.ContainsFunc(f.Difficulties, func(d string) bool {
+			return strings.EqualFold(d, difficulty)
+		})
+	}
+	return true
+}
+
+// hasTag reports whether a result has any of tags, compared case-insensitively
+func hasTag(result MCPTestResult, tags []string) bool {
+	return slices.ContainsFunc(result.Tags, func(tag string) bool {
+		return slices.ContainsFunc(tags, func(t string) bool { return strings.EqualFold(t, tag) })
+	})
+}
+
+// apply returns the results that pass the filter, in their original order
+func (f TaskFilter) apply(results []MCPTestResult) []MCPTestResult {
+	if f.Include == nil && f.Exclude == nil && len(f.Difficulties) == 0 && len(f.Tags) == 0 && len(f.ExcludeTags) == 0 {
+		return results
+	}
+	var kept []MCPTestResult
+	for _, result := range results {
+		if f.keep(result) {
+			kept = append(kept, result)
+		} else {
+			slog.Debug("filtered out task", "task", result.TaskName)
+		}
+	}
+	return kept
+}
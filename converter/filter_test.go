@@ -0,0 +1,55 @@
+package converter
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestTaskFilter(t *testing.T) {
+	run := mustParse(t, `[`+resultA+`,`+resultB+`,`+resultC+`,{"taskName":"d","taskPath":"/x/team-b/d/task.yaml","tags":["Smoke","flaky"]},
+		{"taskName":"e","tags":["smoke"]}]`)
+
+	tests := []struct {
+		name   string
+		filter TaskFilter
+		want   []string
+	}{
+		{name: "no filter", want: []string{"a", "b", "c", "d", "e"}},
+		{name: "include by name", filter: TaskFilter{Include: regexp.MustCompile(`^[ac]$`)}, want: []string{"a", "c"}},
+		{name: "include by path", filter: TaskFilter{Include: regexp.MustCompile(`/team-b/`)}, want: []string{"d"}},
+		{name: "exclude", filter: TaskFilter{Exclude: regexp.MustCompile(`/tasks/`)}, want: []string{"c", "d", "e"}},
+		{
+			name:   "exclude wins over include",
+			filter: TaskFilter{Include: regexp.MustCompile(`/x/`), Exclude: regexp.MustCompile(`^b$`)},
+			want:   []string{"a", "d"},
+		},
+		{name: "difficulty", filter: TaskFilter{Difficulties: []string{"Easy", "medium"}}, want: []string{"a", "c"}},
+		{name: "unknown difficulty", filter: TaskFilter{Difficulties: []string{"unknown"}}, want: []string{"d", "e"}},
+		{name: "tag", filter: TaskFilter{Tags: []string{"smoke"}}, want: []string{"d", "e"}},
+		{name: "excluded tag", filter: TaskFilter{ExcludeTags: []string{"FLAKY"}}, want: []string{"a", "b", "c", "e"}},
+		{name: "excluded tag wins", filter: TaskFilter{Tags: []string{"smoke"}, ExcludeTags: []string{"flaky"}}, want: []string{"e"}},
+		{name: "nothing left", filter: TaskFilter{Difficulties: []string{"expert"}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, result := range tt.filter.apply(run.Results) {
+				got = append(got, result.TaskName)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterDifficulties(t *testing.T) {
+	run := mustParse(t, `[`+resultA+`,`+resultB+`,`+resultC+`]`)
+	report := mustConvert(t, run, options{Filter: TaskFilter{Difficulties: []string{"hard", "Medium"}}})
+	want := []string{"MCP Checker Tests - hard", "MCP Checker Tests - medium"}
+	if got := suiteNames(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("suites = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,190 @@
+package converter
+
+import (
+	"cmp"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Ways to group testcases into testsuites, see WithGroupBy
+const (
+	GroupByDifficulty = "difficulty"
+	GroupByTaskDir    = "task-dir"
+	GroupByServer     = "server"
+	GroupByNone       = "none"
+	GroupByFile       = "file"
+	GroupByTag        = "tag"
+)
+
+// GroupByValues lists the grouping values in the order shown by the help
+var GroupByValues = []string{GroupByDifficulty, GroupByTaskDir, GroupByServer, GroupByNone, GroupByFile, GroupByTag}
+
+// UnknownGroup names the suite of results that lack the grouping field
+const UnknownGroup = "unknown"
+
+// resultGroup is the set of results that form one testsuite
+type resultGroup struct {
+	key     string
+	results []MCPTestResult
+}
+
+// groupResults splits results into suites by the given grouping value,
+// keeping groups and the results within them in input order
+func groupResults(results []MCPTestResult, groupBy string) []resultGroup {
+	var groups []resultGroup
+	index := make(map[string]int)
+	for _, result := range results {
+		key := groupKey(result, groupBy)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, resultGroup{key: key})
+		}
+		groups[i].results = append(groups[i].results, result)
+	}
+	return groups
+}
+
+// groupKey returns the suite a result belongs to
+func groupKey(result MCPTestResult, groupBy string) string {
+	var key string
+	switch groupBy {
+	case GroupByNone:
+		return ""
+	case GroupByTaskDir:
+		if result.TaskPath != "" {
+			key = path.Dir(slashPath(result.TaskPath))
+		}
+	case GroupByServer:
+		key = dominantServer(result)
+	case GroupByFile:
+		key = result.SourceFile
+	case GroupByTag:
+		if len(result.Tags) > 0 {
+			key = result.Tags[0]
+		}
+	default:
+		key = result.Difficulty
+	}
+	if key == "" {
+		return UnknownGroup
+	}
+	return key
+}
+
+// Orders of testsuites and testcases, see WithSort
+const (
+	SortSorted   = "sorted"
+	SortOriginal = "original"
+)
+
+// SortValues lists the orders in the order shown by the help
+var SortValues = []string{SortSorted, SortOriginal}
+
+// difficultyRank orders the known difficulty levels before any other
+var difficultyRank = map[string]int{"easy": 1, "medium": 2, "hard": 3}
+
+// CompareDifficulty orders difficulty levels the way suites grouped by
+// difficulty are sorted: the known levels from easy to hard, then the
+// others by name
+func CompareDifficulty(a, b string) int {
+	rankA, knownA := difficultyRank[a]
+	rankB, knownB := difficultyRank[b]
+	switch {
+	case knownA && knownB:
+		return cmp.Compare(rankA, rankB)
+	case knownA:
+		return -1
+	case knownB:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// sortGroups orders the groups by key, the known difficulty levels first
+// from easy to hard when grouping by difficulty, and the results within each
+// group by task name
+func sortGroups(groups []resultGroup, groupBy string) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		return compareGroupKeys(groups[i].key, groups[j].key, groupBy) < 0
+	})
+	for _, group := range groups {
+		sort.SliceStable(group.results, func(i, j int) bool {
+			return group.results[i].TaskName < group.results[j].TaskName
+		})
+	}
+}
+
+// compareGroupKeys orders the keys of groups: the known difficulty levels
+// first when grouping by difficulty, by name otherwise
+func compareGroupKeys(a, b, groupBy string) int {
+	if groupBy == GroupByDifficulty || groupBy == "" {
+		return CompareDifficulty(a, b)
+	}
+	return strings.Compare(a, b)
+}
+
+// DefaultSuitePrefix starts the testsuite names of runs without a suite
+// name, unless WithSuitePrefix sets another one
+const DefaultSuitePrefix = "MCP Checker Tests"
+
+// suitePrefix returns the start of the testsuite names of run: the prefix
+// set with WithSuitePrefix, the suite name of the run or DefaultSuitePrefix
+func suitePrefix(run TestRun, opts options) string {
+	return cmp.Or(opts.SuitePrefix, run.SuiteName, DefaultSuitePrefix)
+}
+
+// suiteName names the testsuite of a group, e.g. "nightly-k8s - easy"
+func suiteName(prefix, key, groupBy string) string {
+	if groupBy == GroupByNone {
+		return prefix
+	}
+	return prefix + " - " + key
+}
+
+// nestedSuiteName names a suite nested per task directory by the name of
+// the directory, since the enclosing suite already says where it belongs
+func nestedSuiteName(key string) string {
+	if key == UnknownGroup {
+		return key
+	}
+	return path.Base(key)
+}
+
+// slashPath converts the separators of a task path, which may come from a
+// Windows machine, to forward slashes
+func slashPath(p string) string {
+	return strings.ReplaceAll(p, "\\", "/")
+}
+
+// dominantServer returns the MCP server a task used most, counting tool calls
+// and resource reads; ties go to the alphabetically first server
+func dominantServer(result MCPTestResult) string {
+	counts := make(map[string]int)
+	for _, call := range result.CallHistory.ToolCalls {
+		if call.ServerName != "" {
+			counts[call.ServerName]++
+		}
+	}
+	for _, read := range result.CallHistory.ResourceReads {
+		if read.ServerName != "" {
+			counts[read.ServerName]++
+		}
+	}
+
+	servers := make([]string, 0, len(counts))
+	for server := range counts {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+
+	dominant := ""
+	for _, server := range servers {
+		if dominant == "" || counts[server] > counts[dominant] {
+			dominant = server
+		}
+	}
+	return dominant
+}
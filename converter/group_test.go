@@ -0,0 +1,200 @@
+package converter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func suiteNames(suites JUnitTestSuites) []string {
+	names := make([]string, 0, len(suites.Suites))
+	for _, suite := range suites.Suites {
+		names = append(names, suite.Name)
+	}
+	return names
+}
+
+func TestGroupBy(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","difficulty":"easy","callHistory":{"ToolCalls":[{"serverName":"kube"},{"serverName":"git"},{"serverName":"kube"}]}},
+		{"taskName":"b","taskPath":"/x/other/b/task.yaml","difficulty":"hard","callHistory":{"ToolCalls":[{"serverName":"git"}],"ResourceReads":[{"serverName":"docs"}]}},
+		{"taskName":"c","difficulty":"easy"},
+		{"taskName":"d","taskPath":"/x/tasks/a/task.yaml"}
+	]`)
+
+	tests := []struct {
+		groupBy string
+		want    []string
+	}{
+		{groupBy: "", want: []string{"MCP Checker Tests - easy", "MCP Checker Tests - hard", "MCP Checker Tests - unknown"}},
+		{groupBy: GroupByDifficulty, want: []string{"MCP Checker Tests - easy", "MCP Checker Tests - hard", "MCP Checker Tests - unknown"}},
+		{groupBy: GroupByTaskDir, want: []string{"MCP Checker Tests - /x/tasks/a", "MCP Checker Tests - /x/other/b", "MCP Checker Tests - unknown"}},
+		{groupBy: GroupByServer, want: []string{"MCP Checker Tests - kube", "MCP Checker Tests - docs", "MCP Checker Tests - unknown"}},
+		{groupBy: GroupByNone, want: []string{"MCP Checker Tests"}},
+		{groupBy: GroupByFile, want: []string{"MCP Checker Tests - unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.groupBy, func(t *testing.T) {
+			report := mustConvert(t, run, options{GroupBy: tt.groupBy})
+			if got := suiteNames(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suites = %q, want %q", got, tt.want)
+			}
+			tests, _, _ := report.Totals()
+			if tests != len(run.Results) {
+				t.Errorf("report has %d tests, want %d", tests, len(run.Results))
+			}
+		})
+	}
+}
+
+func TestSuitePrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  options
+		want  []string
+	}{
+		{name: "unnamed run", input: "[" + resultA + "]", want: []string{"MCP Checker Tests - easy"}},
+		{name: "suite name", input: `{"suiteName":"nightly-k8s","results":[` + resultA + `]}`, want: []string{"nightly-k8s - easy"}},
+		{name: "scenario set", input: `{"results":[` + resultA + `],"scenario_set":"smoke"}`, want: []string{"smoke - easy"}},
+		{name: "suite name before scenario set", input: `{"scenarioSet":"smoke","suite_name":"nightly-k8s","results":[` + resultA + `]}`, want: []string{"nightly-k8s - easy"}},
+		{name: "single suite", input: `{"suiteName":"nightly-k8s","results":[` + resultA + `]}`, opts: options{GroupBy: GroupByNone}, want: []string{"nightly-k8s"}},
+		{name: "prefix override", input: `{"suiteName":"nightly-k8s","results":[` + resultA + `]}`, opts: options{SuitePrefix: "release"}, want: []string{"release - easy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := mustConvert(t, mustParse(t, tt.input), tt.opts)
+			if got := suiteNames(report); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("suites = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupByFile(t *testing.T) {
+	var run TestRun
+	for _, input := range []struct{ source, results string }{
+		{"first.json", "[" + resultA + "," + resultB + "]"},
+		{"second.json", "[" + resultC + "]"},
+	} {
+		inputRun := mustParse(t, input.results)
+		inputRun.SetSource(input.source)
+		run.Merge(inputRun)
+	}
+
+	report := mustConvert(t, run, options{GroupBy: GroupByFile})
+	want := []string{"MCP Checker Tests - first.json", "MCP Checker Tests - second.json"}
+	if got := suiteNames(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("suites = %q, want %q", got, want)
+	}
+}
+
+func TestSortGroups(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"z","difficulty":"hard"},
+		{"taskName":"y","difficulty":"expert"},
+		{"taskName":"x"},
+		{"taskName":"w","difficulty":"easy"},
+		{"taskName":"v","difficulty":"hard"},
+		{"taskName":"u","difficulty":"medium"},
+		{"taskName":"t","difficulty":"basic"}
+	]`)
+
+	tests := []struct {
+		name       string
+		opts       options
+		wantSuites []string
+		wantTests  [][]string
+	}{
+		{
+			name:       "sorted by difficulty",
+			opts:       options{Sort: SortSorted},
+			wantSuites: []string{"easy", "medium", "hard", "basic", "expert", "unknown"},
+			wantTests:  [][]string{{"w"}, {"u"}, {"v", "z"}, {"t"}, {"y"}, {"x"}},
+		},
+		{
+			name:       "original",
+			opts:       options{Sort: SortOriginal},
+			wantSuites: []string{"hard", "expert", "unknown", "easy", "medium", "basic"},
+			wantTests:  [][]string{{"z", "v"}, {"y"}, {"x"}, {"w"}, {"u"}, {"t"}},
+		},
+		{
+			name:       "sorted alphabetically for other groupings",
+			opts:       options{Sort: SortSorted, GroupBy: GroupByNone},
+			wantSuites: []string{""},
+			wantTests:  [][]string{{"t", "u", "v", "w", "x", "y", "z"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := groupResults(run.Results, tt.opts.GroupBy)
+			if tt.opts.Sort == SortSorted {
+				sortGroups(groups, tt.opts.GroupBy)
+			}
+			var suites []string
+			var names [][]string
+			for _, group := range groups {
+				suites = append(suites, group.key)
+				var tests []string
+				for _, result := range group.results {
+					tests = append(tests, result.TaskName)
+				}
+				names = append(names, tests)
+			}
+			if !reflect.DeepEqual(suites, tt.wantSuites) || !reflect.DeepEqual(names, tt.wantTests) {
+				t.Errorf("groups = %q %q, want %q %q", suites, names, tt.wantSuites, tt.wantTests)
+			}
+		})
+	}
+}
+
+func TestSortedFailedAssertions(t *testing.T) {
+	run := mustParse(t, `[{"taskName":"a","taskPassed":true,"allAssertionsPassed":false,
+		"assertionResults":{"delta":{"passed":false},"alpha":{"passed":false},"charlie":{"passed":true},"bravo":{"passed":false}}}]`)
+	for range 5 {
+		failure := mustConvert(t, run, options{}).Suites[0].TestCases[0].Failure
+		if failure.Message != "Assertion failures: alpha, bravo, delta" {
+			t.Fatalf("failure message = %q", failure.Message)
+		}
+	}
+}
+
+func TestNestedSuites(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"b","taskPath":"/x/tasks/b/task.yaml","difficulty":"easy","taskPassed":false},
+		{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"a2","taskPath":"/x/tasks/a/task2.yaml","difficulty":"easy","taskPassed":true,"allAssertionsPassed":false},
+		{"taskName":"c","taskPath":"/x/tasks/c/task.yaml","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+	report := mustConvert(t, run, options{GroupBy: GroupByDifficulty, Sort: SortSorted, NestedSuites: true})
+
+	easy := report.Suites[0]
+	if easy.Tests != 3 || easy.Failures != 1 || easy.Errors != 1 || len(easy.TestCases) != 0 {
+		t.Errorf("easy suite tests=%d failures=%d errors=%d testcases=%d, want 3/1/1 and no testcases of its own",
+			easy.Tests, easy.Failures, easy.Errors, len(easy.TestCases))
+	}
+	var children []string
+	for _, child := range easy.Suites {
+		children = append(children, fmt.Sprintf("%s:%d", child.Name, child.Tests))
+	}
+	if got := strings.Join(children, ","); got != "a:2,b:1" {
+		t.Errorf("nested suites = %s", got)
+	}
+	if len(easy.AllTestCases()) != 3 {
+		t.Errorf("AllTestCases() = %d testcases, want 3", len(easy.AllTestCases()))
+	}
+	if tests, failures, errors := report.Totals(); tests != 4 || failures != 1 || errors != 1 {
+		t.Errorf("Totals() = %d, %d, %d, want 4, 1, 1", tests, failures, errors)
+	}
+
+	out, err := renderReport(report, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `<testsuite name="MCP Checker Tests - easy" tests="3" failures="1" errors="1" skipped="0"><testsuite name="a" tests="2"`) {
+		t.Errorf("report does not nest the suites:\n%s", out)
+	}
+}
@@ -0,0 +1,296 @@
+package converter
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ResultIterator reads results one at a time, holding a single result in
+// memory however large the input. It accepts what Parse accepts in auto
+// mode, except JUnit XML: a JSON array, JSON Lines, single result objects
+// and envelopes, optionally gzip-compressed. Arrays, including the results
+// of an envelope, are streamed element by element.
+type ResultIterator struct {
+	reader  *bufio.Reader
+	decoder *json.Decoder
+	started bool
+	err     error
+
+	// pending is a result decoded while looking for an envelope
+	pending *MCPTestResult
+	// inArray is set while the elements of an array are being read, and
+	// inEnvelope while the keys of an envelope are
+	inArray    bool
+	inEnvelope bool
+	// version is the schema version declared by the current envelope
+	version int
+	count   int
+	limits  ToolResultLimits
+
+	runID       string
+	startedAt   string
+	suiteName   string
+	scenarioSet string
+	environment *Environment
+}
+
+// NewResultIterator returns an iterator over the results read from r
+func NewResultIterator(r io.Reader) *ResultIterator {
+	return &ResultIterator{reader: bufio.NewReader(r)}
+}
+
+// SetToolResultLimits caps the tool call results of the results returned
+// from then on, like ParseOptions.ToolResults
+func (it *ResultIterator) SetToolResultLimits(limits ToolResultLimits) {
+	it.limits = limits
+}
+
+// Next returns the next result, or io.EOF once the input is exhausted.
+// Errors wrap the same sentinels as Parse and are sticky: after the first
+// one, Next keeps returning it.
+func (it *ResultIterator) Next() (MCPTestResult, error) {
+	if it.err != nil {
+		return MCPTestResult{}, it.err
+	}
+	result, err := it.next()
+	if err != nil {
+		if err != io.EOF {
+			err = markInvalidJSON(err)
+		}
+		it.err = err
+		return MCPTestResult{}, err
+	}
+	return result, nil
+}
+
+// RunID returns the runId of the envelope being read. Keys following the
+// results array are only known once Next has returned io.EOF.
+func (it *ResultIterator) RunID() string {
+	return it.runID
+}
+
+// StartedAt returns the startedAt of the envelope being read, with the same
+// caveat as RunID
+func (it *ResultIterator) StartedAt() string {
+	return it.startedAt
+}
+
+// SuiteName returns the suiteName of the envelope being read, or else its
+// scenarioSet, with the same caveat as RunID
+func (it *ResultIterator) SuiteName() string {
+	return cmp.Or(it.suiteName, it.scenarioSet)
+}
+
+// Environment returns the environment of the envelope being read, with the
+// same caveat as RunID
+func (it *ResultIterator) Environment() *Environment {
+	return it.environment
+}
+
+func (it *ResultIterator) next() (MCPTestResult, error) {
+	if !it.started {
+		if err := it.start(); err != nil {
+			return MCPTestResult{}, err
+		}
+	}
+
+	for {
+		if it.pending != nil {
+			result := *it.pending
+			it.pending = nil
+			return result, nil
+		}
+
+		switch {
+		case it.inArray:
+			if it.decoder.More() {
+				var element json.RawMessage
+				if err := it.decoder.Decode(&element); err != nil {
+					return MCPTestResult{}, fmt.Errorf("result %d: %w", it.count, err)
+				}
+				return it.decodeResult(element)
+			}
+			if _, err := it.decoder.Token(); err != nil {
+				return MCPTestResult{}, err
+			}
+			it.inArray = false
+		case it.inEnvelope:
+			if err := it.readEnvelopeKeys(); err != nil {
+				return MCPTestResult{}, err
+			}
+		default:
+			token, err := it.decoder.Token()
+			if err != nil {
+				return MCPTestResult{}, err
+			}
+			it.version = 0
+			switch token {
+			case json.Delim('['):
+				it.inArray = true
+			case json.Delim('{'):
+				if err := it.readObject(); err != nil {
+					return MCPTestResult{}, err
+				}
+			default:
+				return MCPTestResult{}, fmt.Errorf("result %d: %w: expected an object or array, found %v", it.count, ErrInvalidJSON, token)
+			}
+		}
+	}
+}
+
+// start unwraps gzip input and rejects empty and JUnit XML input
+func (it *ResultIterator) start() error {
+	it.started = true
+	reader, err := MaybeDecompress(it.reader)
+	if err != nil {
+		return err
+	}
+	first, err := peekFirstNonSpace(reader)
+	if err == io.EOF {
+		return ErrEmptyInput
+	} else if err != nil {
+		return err
+	}
+	if first == '<' {
+		return errors.New("JUnit XML input holds no results to iterate over")
+	}
+	it.decoder = json.NewDecoder(reader)
+	return nil
+}
+
+// readObject reads the keys of a top-level object whose opening brace has
+// been consumed. An object with a "results" key is an envelope, whose
+// results are then streamed; any other object is a single result.
+func (it *ResultIterator) readObject() error {
+	fields := map[string]json.RawMessage{}
+	for it.decoder.More() {
+		key, err := it.readKey()
+		if err != nil {
+			return err
+		}
+		if key == "results" {
+			if err := it.applyEnvelopeFields(fields); err != nil {
+				return err
+			}
+			it.inEnvelope = true
+			return it.openResults()
+		}
+		var value json.RawMessage
+		if err := it.decoder.Decode(&value); err != nil {
+			return fmt.Errorf("result %d: %w", it.count, err)
+		}
+		fields[key] = value
+	}
+	if _, err := it.decoder.Token(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	result, err := it.decodeResult(data)
+	if err != nil {
+		return err
+	}
+	it.pending = &result
+	return nil
+}
+
+// readEnvelopeKeys reads the keys following the results array of an
+// envelope, up to its closing brace
+func (it *ResultIterator) readEnvelopeKeys() error {
+	fields := map[string]json.RawMessage{}
+	for it.decoder.More() {
+		key, err := it.readKey()
+		if err != nil {
+			return err
+		}
+		if key == "results" {
+			return fmt.Errorf("%w: envelope has several results arrays", ErrUnsupportedSchema)
+		}
+		var value json.RawMessage
+		if err := it.decoder.Decode(&value); err != nil {
+			return err
+		}
+		fields[key] = value
+	}
+	if _, err := it.decoder.Token(); err != nil {
+		return err
+	}
+	it.inEnvelope = false
+	return it.applyEnvelopeFields(fields)
+}
+
+// readKey reads the next object key
+func (it *ResultIterator) readKey() (string, error) {
+	token, err := it.decoder.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: expected an object key, found %v", ErrInvalidJSON, token)
+	}
+	return key, nil
+}
+
+// openResults consumes the opening bracket of the results of an envelope
+func (it *ResultIterator) openResults() error {
+	token, err := it.decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != json.Delim('[') {
+		return fmt.Errorf("%w: envelope results must be an array", ErrUnsupportedSchema)
+	}
+	it.inArray = true
+	return nil
+}
+
+// applyEnvelopeFields records the run metadata and schema version of an envelope
+func (it *ResultIterator) applyEnvelopeFields(fields map[string]json.RawMessage) error {
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "schemaVersion", "schema_version":
+			it.version, err = parseSchemaVersion(value)
+		case "runId", "run_id":
+			err = json.Unmarshal(value, &it.runID)
+		case "startedAt", "started_at":
+			err = json.Unmarshal(value, &it.startedAt)
+		case "suiteName", "suite_name":
+			err = json.Unmarshal(value, &it.suiteName)
+		case "scenarioSet", "scenario_set":
+			err = json.Unmarshal(value, &it.scenarioSet)
+		case "environment":
+			err = json.Unmarshal(value, &it.environment)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeResult decodes a result of either schema version
+func (it *ResultIterator) decodeResult(data []byte) (MCPTestResult, error) {
+	index := it.count
+	it.count++
+	version, err := detectSchemaVersion(data, it.version)
+	if err != nil {
+		return MCPTestResult{}, fmt.Errorf("result %d: %w", index, err)
+	}
+	result, err := decodeResult(version, data)
+	if err != nil {
+		return MCPTestResult{}, fmt.Errorf("result %d: %w", index, err)
+	}
+	if err := it.limits.apply(&result); err != nil {
+		return MCPTestResult{}, fmt.Errorf("result %d: %w", index, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package converter
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// iterate returns every result of an iterator and the error that stopped it
+func iterate(it *ResultIterator) ([]MCPTestResult, error) {
+	var results []MCPTestResult
+	for {
+		result, err := it.Next()
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+}
+
+func TestResultIterator(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		wantRunID     string
+		wantStartedAt string
+	}{
+		{name: "json array", input: "[" + resultA + "," + resultB + "]"},
+		{name: "json lines", input: resultA + "\n" + resultB + "\n\n" + resultC},
+		{name: "pretty-printed object", input: "{\n  \"taskName\": \"a\",\n  \"taskPassed\": true\n}\n"},
+		{name: "v2 results", input: "[" + resultV2A + "," + resultA + "]"},
+		{name: "json lines mixing arrays and objects", input: resultA + "\n[" + resultB + "," + resultC + "]\n" + resultV2A},
+		{name: "gzip", input: gzipped(t, "["+resultA+","+resultC+"]")},
+		{
+			name:      "envelope",
+			input:     `{"runId":"run-1","results":[` + resultA + `,` + resultB + `]}`,
+			wantRunID: "run-1",
+		},
+		{
+			name:          "envelope with metadata after the results",
+			input:         `{"schemaVersion":2,"results":[{"task_name":"a","task_passed":true}],"started_at":"2025-03-01T10:00:00Z","suite_name":"nightly","environment":{"model":"gpt-5"}}`,
+			wantStartedAt: "2025-03-01T10:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, err := Parse(strings.NewReader(tt.input), ParseOptions{Format: FormatAuto})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			it := NewResultIterator(strings.NewReader(tt.input))
+			got, err := iterate(it)
+			if err != io.EOF {
+				t.Fatalf("Next() error = %v, want io.EOF", err)
+			}
+			if !reflect.DeepEqual(got, want.Results) {
+				t.Errorf("results = %+v, want %+v", got, want.Results)
+			}
+			if it.RunID() != tt.wantRunID || it.StartedAt() != tt.wantStartedAt {
+				t.Errorf("metadata = %q, %q, want %q, %q", it.RunID(), it.StartedAt(), tt.wantRunID, tt.wantStartedAt)
+			}
+			if it.SuiteName() != want.SuiteName {
+				t.Errorf("SuiteName() = %q, want %q", it.SuiteName(), want.SuiteName)
+			}
+			if !reflect.DeepEqual(it.Environment(), want.Environment) {
+				t.Errorf("Environment() = %+v, want %+v", it.Environment(), want.Environment)
+			}
+			if _, err := it.Next(); err != io.EOF {
+				t.Errorf("Next() after the end error = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestResultIteratorErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantCount int
+		want      error
+	}{
+		{name: "empty", input: "  \n", want: ErrEmptyInput},
+		{name: "truncated array", input: "[" + resultA + `,{"taskName":`, wantCount: 1, want: ErrInvalidJSON},
+		{name: "truncated envelope", input: `{"results":[` + resultA, wantCount: 1, want: ErrInvalidJSON},
+		{name: "wrong type", input: resultA + "\n" + `{"taskPassed":"yes"}`, wantCount: 1, want: ErrInvalidJSON},
+		{name: "scalar", input: `42`, want: ErrInvalidJSON},
+		{name: "results not an array", input: `{"results":{}}`, want: ErrUnsupportedSchema},
+		{name: "unsupported schema version", input: `{"schemaVersion":9,"results":[` + resultA + `]}`, want: ErrUnsupportedSchema},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it := NewResultIterator(strings.NewReader(tt.input))
+			got, err := iterate(it)
+			if len(got) != tt.wantCount {
+				t.Errorf("read %d results before the error, want %d", len(got), tt.wantCount)
+			}
+			if !errors.Is(err, tt.want) {
+				t.Errorf("Next() error = %v, want %v", err, tt.want)
+			}
+			if _, again := it.Next(); again != err {
+				t.Errorf("Next() after an error = %v, want the same error %v", again, err)
+			}
+		})
+	}
+
+	if _, err := NewResultIterator(strings.NewReader(`<testsuites/>`)).Next(); err == nil || err == io.EOF {
+		t.Errorf("Next() on JUnit XML error = %v, want an error", err)
+	}
+}
@@ -0,0 +1,117 @@
+package converter
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// ImportedSuite is a testsuite read from an existing JUnit XML report. Its
+// attributes and content are kept verbatim so that reports produced by other
+// tools survive the round trip unchanged.
+type ImportedSuite struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Inner   string     `xml:",innerxml"`
+}
+
+// MarshalJSON renders an imported suite as its attributes and raw XML content
+func (s ImportedSuite) MarshalJSON() ([]byte, error) {
+	attrs := make(map[string]string, len(s.Attrs))
+	for _, attr := range s.Attrs {
+		attrs[attr.Name.Local] = attr.Value
+	}
+	return json.Marshal(struct {
+		Attributes map[string]string `json:"attributes"`
+		Content    string            `json:"content"`
+	}{attrs, s.Inner})
+}
+
+// intAttr returns the integer value of an attribute, or 0 when it is missing or invalid
+func (s ImportedSuite) intAttr(name string) int {
+	for _, attr := range s.Attrs {
+		if attr.Name.Local == name {
+			value, _ := strconv.Atoi(attr.Value)
+			return value
+		}
+	}
+	return 0
+}
+
+// floatAttr returns the value of a decimal attribute, such as time, and
+// whether it is present and valid
+func (s ImportedSuite) floatAttr(name string) (float64, bool) {
+	for _, attr := range s.Attrs {
+		if attr.Name.Local == name {
+			value, err := strconv.ParseFloat(attr.Value, 64)
+			return value, err == nil
+		}
+	}
+	return 0, false
+}
+
+// parseJUnit reads the testsuites of a JUnit XML report whose root is either
+// <testsuites> or a single <testsuite>
+func (d *resultDecoder) parseJUnit(reader io.Reader) error {
+	decoder := xml.NewDecoder(reader)
+	root, err := nextStartElement(decoder)
+	if err != nil {
+		return fmt.Errorf("reading JUnit XML: %w", err)
+	}
+
+	switch root.Name.Local {
+	case "testsuite":
+		return d.addImportedSuite(decoder, root)
+	case "testsuites":
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				return fmt.Errorf("reading JUnit XML: %w", err)
+			}
+			switch element := token.(type) {
+			case xml.StartElement:
+				if element.Name.Local != "testsuite" {
+					if err := decoder.Skip(); err != nil {
+						return fmt.Errorf("reading JUnit XML: %w", err)
+					}
+					continue
+				}
+				if err := d.addImportedSuite(decoder, element); err != nil {
+					return err
+				}
+			case xml.EndElement:
+				return nil
+			}
+		}
+	default:
+		return fmt.Errorf("reading JUnit XML: unexpected root element <%s>", root.Name.Local)
+	}
+}
+
+func (d *resultDecoder) addImportedSuite(decoder *xml.Decoder, start xml.StartElement) error {
+	var suite ImportedSuite
+	if err := decoder.DecodeElement(&suite, &start); err != nil {
+		return fmt.Errorf("reading JUnit XML: %w", err)
+	}
+	suite.XMLName = xml.Name{Local: "testsuite"}
+	d.run.ImportedSuites = append(d.run.ImportedSuites, suite)
+	return nil
+}
+
+// nextStartElement skips the prolog, comments and whitespace before the root element
+func nextStartElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return xml.StartElement{}, io.ErrUnexpectedEOF
+			}
+			return xml.StartElement{}, err
+		}
+		if element, ok := token.(xml.StartElement); ok {
+			return element, nil
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+const goTestReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1">
+	<testsuite name="pkg/a" tests="1" failures="0" time="0.010">
+		<properties><property name="go.version" value="go1.25"></property></properties>
+		<testcase name="TestA" classname="pkg/a" time="0.010"><skipped message="later"></skipped></testcase>
+	</testsuite>
+	<!-- a comment between suites -->
+	<testsuite name="pkg/b" tests="1" failures="1">
+		<testcase name="TestB" classname="pkg/b"><failure message="b &amp; c">want 1</failure></testcase>
+	</testsuite>
+</testsuites>
+`
+
+func TestParseJUnit(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "testsuites root", format: FormatAuto, input: goTestReport, want: []string{"pkg/a", "pkg/b"}},
+		{name: "testsuite root", format: FormatJUnit, input: `<testsuite name="only"><testcase name="x"/></testsuite>`, want: []string{"only"}},
+		{name: "gzip", format: FormatAuto, input: gzipped(t, goTestReport), want: []string{"pkg/a", "pkg/b"}},
+		{name: "empty testsuites", format: FormatAuto, input: `<testsuites></testsuites>`},
+		{name: "unexpected root", format: FormatAuto, input: `<html></html>`, wantErr: true},
+		{name: "truncated", format: FormatAuto, input: goTestReport[:200], wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := Parse(strings.NewReader(tt.input), ParseOptions{Format: tt.format})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Parse() succeeded, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			var names []string
+			for _, suite := range run.ImportedSuites {
+				for _, attr := range suite.Attrs {
+					if attr.Name.Local == "name" {
+						names = append(names, attr.Value)
+					}
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("suite names = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportedSuitesInReport(t *testing.T) {
+	run := mustParse(t, "["+resultA+"]")
+	run.Merge(mustParse(t, goTestReport))
+	report, err := renderReport(mustConvert(t, run, options{ReportName: "nightly"}), DefaultIndent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := string(report)
+	for _, want := range []string{
+		`<testsuites name="nightly" tests="3" failures="1" errors="0" skipped="0" time="0.010">`,
+		`<testsuite name="MCP Checker Tests - easy"`,
+		`<testsuite name="pkg/a" tests="1" failures="0" time="0.010">`,
+		`<testcase name="TestA" classname="pkg/a" time="0.010"><skipped message="later"></skipped></testcase>`,
+		`<failure message="b &amp; c">want 1</failure>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report does not contain %s:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "MCP Checker Tests") > strings.Index(out, "pkg/a") {
+		t.Error("imported suites should follow the generated ones")
+	}
+}
+
+func TestSetAggregatesTime(t *testing.T) {
+	timed := `{"taskName":"t","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,"agentOutput":{"Success":true,"DurationMs":1500}}`
+	tests := []struct {
+		name     string
+		inputs   []string
+		wantTime string
+	}{
+		{name: "no durations", inputs: []string{"[" + resultA + "]"}},
+		{name: "converted suites", inputs: []string{"[" + resultA + "," + timed + "]"}, wantTime: "1.500"},
+		{name: "imported suites", inputs: []string{"[" + resultA + "]", goTestReport}, wantTime: "0.010"},
+		{name: "converted and imported suites", inputs: []string{"[" + timed + "]", goTestReport}, wantTime: "1.510"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var run TestRun
+			for _, input := range tt.inputs {
+				run.Merge(mustParse(t, input))
+			}
+			if got := mustConvert(t, run, options{}).Time; got != tt.wantTime {
+				t.Errorf("time = %q, want %q", got, tt.wantTime)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package converter
+
+// Languages of the testcase output, see WithLang
+const (
+	LangEnglish    = "en"
+	LangPortuguese = "pt-BR"
+	LangSpanish    = "es"
+)
+
+// Languages lists the supported languages
+var Languages = []string{LangEnglish, LangPortuguese, LangSpanish}
+
+// messages holds the labels of the human-readable testcase output and the
+// failure and error messages. The failure and error types stay in English,
+// as CI tools match on them.
+type messages struct {
+	Task, Path, Difficulty, Status string
+	Tags, Trace, Prompt            string
+	Passed, Failed, Skipped        string
+	// Assertions is formatted with the passed and total assertion counts,
+	// and Samples with the passed and total sample counts
+	Assertions, Samples string
+	// TokenUsage and Cost label the LLM usage of a task
+	TokenUsage, Cost string
+	CallHistory      string
+	ToolOutput       string
+	// ResourceReads titles the list of resource reads
+	ResourceReads string
+	// ToolOK and ToolFailed mark the outcome of each tool call
+	ToolOK, ToolFailed string
+	// ToolArguments labels the arguments of each tool call
+	ToolArguments string
+	Timeline      string
+	Note          string
+	Error         string
+
+	// ExecutionFailed, AssertionFailures and PhaseFailed are the messages of
+	// the failure and error elements; AssertionFailures is formatted with the
+	// failed assertion names
+	ExecutionFailed   string
+	AssertionFailures string
+	PhaseFailed       string
+	// AssertionFailed is the message of the testcase of a single failed
+	// assertion, formatted with its name
+	AssertionFailed string
+	// TaskSkipped is the message of a skipped testcase without a reason
+	TaskSkipped string
+	// Reclassified is the message of a passed testcase that a Classifier
+	// reported otherwise, formatted with the status
+	Reclassified     string
+	FailedAssertions string
+	// Expected and Actual label the values a failed assertion compared
+	Expected, Actual  string
+	ErrorDetails      string
+	PhaseErrors       string
+	SetupPhaseError   string
+	AgentPhaseError   string
+	VerifyPhaseError  string
+	CleanupPhaseError string
+	// SetupPhase, AgentPhase, VerifyPhase and CleanupPhase title the
+	// sections of the phase output
+	SetupPhase, AgentPhase, VerifyPhase, CleanupPhase string
+
+	// ToolCallSequence titles the diff of the expected and actual tool
+	// calls, summed up by the calls MissingCalls, UnexpectedCalls and
+	// OutOfOrderCalls list
+	ToolCallSequence                               string
+	MissingCalls, UnexpectedCalls, OutOfOrderCalls string
+	// Artifacts titles the files and links a task left
+	Artifacts string
+	// Durations labels the time the phases of a task took
+	Durations string
+}
+
+var catalogs = map[string]*messages{
+	LangEnglish: {
+		Task:              "Task",
+		Path:              "Path",
+		Difficulty:        "Difficulty",
+		Tags:              "Tags",
+		Trace:             "Trace",
+		Prompt:            "Prompt",
+		Status:            "Status",
+		Passed:            "PASSED",
+		Failed:            "FAILED",
+		Skipped:           "SKIPPED",
+		Assertions:        "Assertions: %d/%d passed",
+		Samples:           "Samples: %d/%d passed",
+		TokenUsage:        "Token usage",
+		Cost:              "Cost",
+		Durations:         "Durations",
+		CallHistory:       "Call history",
+		ToolOutput:        "Tool output",
+		ResourceReads:     "Resource reads",
+		ToolOK:            "ok",
+		ToolFailed:        "failed",
+		ToolArguments:     "Arguments",
+		Timeline:          "Timeline",
+		Note:              "note",
+		Error:             "Error",
+		ExecutionFailed:   "Test execution failed",
+		AssertionFailures: "Assertion failures: %s",
+		AssertionFailed:   "Assertion %s failed",
+		PhaseFailed:       "Phase execution failed",
+		TaskSkipped:       "Task skipped",
+		Reclassified:      "Reclassified as %s",
+		FailedAssertions:  "Failed Assertions",
+		Expected:          "expected",
+		Actual:            "actual",
+		ToolCallSequence:  "Tool Call Sequence",
+		MissingCalls:      "Missing",
+		UnexpectedCalls:   "Unexpected",
+		OutOfOrderCalls:   "Out of order",
+		Artifacts:         "Artifacts",
+		ErrorDetails:      "Error Details",
+		PhaseErrors:       "Phase Errors",
+		SetupPhaseError:   "Setup Phase Error",
+		AgentPhaseError:   "Agent Phase Error",
+		VerifyPhaseError:  "Verify Phase Error",
+		CleanupPhaseError: "Cleanup Phase Error",
+		SetupPhase:        "Setup",
+		AgentPhase:        "Agent",
+		VerifyPhase:       "Verify",
+		CleanupPhase:      "Cleanup",
+	},
+	LangPortuguese: {
+		Task:              "Tarefa",
+		Path:              "Caminho",
+		Difficulty:        "Dificuldade",
+		Tags:              "Tags",
+		Trace:             "Rastreamento",
+		Prompt:            "Prompt",
+		Status:            "Status",
+		Passed:            "APROVADO",
+		Failed:            "REPROVADO",
+		Skipped:           "IGNORADO",
+		Assertions:        "Asserções: %d/%d aprovadas",
+		Samples:           "Amostras: %d/%d aprovadas",
+		TokenUsage:        "Uso de tokens",
+		Cost:              "Custo",
+		Durations:         "Durações",
+		CallHistory:       "Histórico de chamadas",
+		ToolOutput:        "Saída das ferramentas",
+		ResourceReads:     "Leituras de recursos",
+		ToolOK:            "ok",
+		ToolFailed:        "falhou",
+		ToolArguments:     "Argumentos",
+		Timeline:          "Linha do tempo",
+		Note:              "nota",
+		Error:             "Erro",
+		ExecutionFailed:   "Falha na execução do teste",
+		AssertionFailures: "Falhas de asserção: %s",
+		AssertionFailed:   "A asserção %s falhou",
+		PhaseFailed:       "Falha na execução da fase",
+		TaskSkipped:       "Tarefa ignorada",
+		Reclassified:      "Reclassificado como %s",
+		FailedAssertions:  "Asserções com falha",
+		Expected:          "esperado",
+		Actual:            "obtido",
+		ToolCallSequence:  "Sequência de chamadas de ferramentas",
+		MissingCalls:      "Ausentes",
+		UnexpectedCalls:   "Inesperadas",
+		OutOfOrderCalls:   "Fora de ordem",
+		Artifacts:         "Artefatos",
+		ErrorDetails:      "Detalhes do erro",
+		PhaseErrors:       "Erros de fase",
+		SetupPhaseError:   "Erro na fase de preparação",
+		AgentPhaseError:   "Erro na fase do agente",
+		VerifyPhaseError:  "Erro na fase de verificação",
+		CleanupPhaseError: "Erro na fase de limpeza",
+		SetupPhase:        "Preparação",
+		AgentPhase:        "Agente",
+		VerifyPhase:       "Verificação",
+		CleanupPhase:      "Limpeza",
+	},
+	LangSpanish: {
+		Task:              "Tarea",
+		Path:              "Ruta",
+		Difficulty:        "Dificultad",
+		Tags:              "Etiquetas",
+		Trace:             "Traza",
+		Prompt:            "Prompt",
+		Status:            "Estado",
+		Passed:            "APROBADA",
+		Failed:            "FALLIDA",
+		Skipped:           "OMITIDA",
+		Assertions:        "Aserciones: %d/%d aprobadas",
+		Samples:           "Muestras: %d/%d aprobadas",
+		TokenUsage:        "Uso de tokens",
+		Cost:              "Costo",
+		Durations:         "Duraciones",
+		CallHistory:       "Historial de llamadas",
+		ToolOutput:        "Salida de herramientas",
+		ResourceReads:     "Lecturas de recursos",
+		ToolOK:            "ok",
+		ToolFailed:        "falló",
+		ToolArguments:     "Argumentos",
+		Timeline:          "Cronología",
+		Note:              "nota",
+		Error:             "Error",
+		ExecutionFailed:   "Falló la ejecución de la prueba",
+		AssertionFailures: "Fallos de aserción: %s",
+		AssertionFailed:   "La aserción %s falló",
+		PhaseFailed:       "Falló la ejecución de una fase",
+		TaskSkipped:       "Tarea omitida",
+		Reclassified:      "Reclasificado como %s",
+		FailedAssertions:  "Aserciones fallidas",
+		Expected:          "esperado",
+		Actual:            "obtenido",
+		ToolCallSequence:  "Secuencia de llamadas a herramientas",
+		MissingCalls:      "Faltantes",
+		UnexpectedCalls:   "Inesperadas",
+		OutOfOrderCalls:   "Fuera de orden",
+		Artifacts:         "Artefactos",
+		ErrorDetails:      "Detalles del error",
+		PhaseErrors:       "Errores de fase",
+		SetupPhaseError:   "Error en la fase de preparación",
+		AgentPhaseError:   "Error en la fase del agente",
+		VerifyPhaseError:  "Error en la fase de verificación",
+		CleanupPhaseError: "Error en la fase de limpieza",
+		SetupPhase:        "Preparación",
+		AgentPhase:        "Agente",
+		VerifyPhase:       "Verificación",
+		CleanupPhase:      "Limpieza",
+	},
+}
+
+// catalog returns the messages for a language, English for ""
+func catalog(lang string) *messages {
+	if m, ok := catalogs[lang]; ok {
+		return m
+	}
+	return catalogs[LangEnglish]
+}
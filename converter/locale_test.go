@@ -0,0 +1,56 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	for _, lang := range Languages {
+		m := reflect.ValueOf(*catalogs[lang])
+		for i := range m.NumField() {
+			if m.Field(i).String() == "" {
+				t.Errorf("%s catalog has no %s message", lang, m.Type().Field(i).Name)
+			}
+		}
+		if strings.Count(catalogs[lang].Assertions, "%d") != 2 || strings.Count(catalogs[lang].AssertionFailures, "%s") != 1 ||
+			strings.Count(catalogs[lang].AssertionFailed, "%s") != 1 ||
+			strings.Count(catalogs[lang].Reclassified, "%s") != 1 {
+			t.Errorf("%s catalog has wrong format verbs", lang)
+		}
+	}
+	if catalog("") != catalogs[LangEnglish] || catalog("xx") != catalogs[LangEnglish] {
+		t.Error("catalog should fall back to English")
+	}
+}
+
+func TestLocalizedTestCase(t *testing.T) {
+	test := MCPTestResult{
+		TaskName:         "scale",
+		TaskPassed:       true,
+		TaskOutput:       "scaled the deployment",
+		AssertionResults: map[string]Assertion{"replicas": {Passed: false}},
+		VerifyOutput:     PhaseOutput{Error: "timeout"},
+	}
+
+	got := convertTestCase(test, options{Lang: LangPortuguese})
+	if got.Failure.Message != "Falhas de asserção: replicas" || got.Failure.Type != "AssertionFailure" {
+		t.Errorf("failure = %q (%s), want a Portuguese message and the English type", got.Failure.Message, got.Failure.Type)
+	}
+	for _, want := range []string{"Asserções com falha:\n  - replicas", "Erros de fase:\nErro na fase de verificação:\ntimeout"} {
+		if !strings.Contains(got.Failure.Content, want) {
+			t.Errorf("failure content does not contain %q:\n%s", want, got.Failure.Content)
+		}
+	}
+	for _, want := range []string{"Tarefa: scale\n", "Status: APROVADO\n", "Asserções: 0/1 aprovadas\n", "Linha do tempo:\n  - nota: scaled the deployment\n"} {
+		if !strings.Contains(got.SystemOut, want) {
+			t.Errorf("system-out does not contain %q:\n%s", want, got.SystemOut)
+		}
+	}
+
+	test.TaskPassed = false
+	if got := convertTestCase(test, options{Lang: LangSpanish}); got.Error.Message != "Falló la ejecución de la prueba" || got.Error.Type != "ExecutionError" {
+		t.Errorf("error = %q (%s), want a Spanish message and the English type", got.Error.Message, got.Error.Type)
+	}
+}
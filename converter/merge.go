@@ -0,0 +1,115 @@
+package converter
+
+import "slices"
+
+// MergeReruns combines runs by task name. Each task keeps its result from the
+// latest run it appears in, with the results from earlier runs as Attempts.
+// Tasks are ordered by their first appearance and the run metadata is taken
+// from the first run that has it.
+func MergeReruns(runs []TestRun) TestRun {
+	var merged TestRun
+	index := make(map[string]int)
+
+	for _, run := range runs {
+		if merged.RunID == "" {
+			merged.RunID = run.RunID
+		}
+		if merged.StartedAt == "" {
+			merged.StartedAt = run.StartedAt
+		}
+		if merged.SuiteName == "" {
+			merged.SuiteName = run.SuiteName
+		}
+		if merged.Environment == nil {
+			merged.Environment = run.Environment
+		}
+		merged.ParseErrors = append(merged.ParseErrors, run.ParseErrors...)
+
+		for _, result := range run.Results {
+			i, seen := index[result.TaskName]
+			if !seen {
+				index[result.TaskName] = len(merged.Results)
+				merged.Results = append(merged.Results, result)
+				continue
+			}
+			previous := merged.Results[i]
+			attempts := previous.Attempts
+			previous.Attempts = nil
+			attempts = append(attempts, previous)
+			result.Attempts = append(attempts, result.Attempts...)
+			merged.Results[i] = result
+		}
+	}
+	return merged
+}
+
+// convertWithAttempts converts a result together with its earlier attempts
+// following the Surefire rerun conventions. The final attempt decides the
+// outcome. A task whose final attempt passed, or was skipped, is reported
+// as such, with a flakyFailure or flakyError for every failed attempt. A task whose final
+// attempt failed is reported with the failure of its first failed attempt
+// and a rerunFailure or rerunError for every later failed attempt, whatever
+// the attempts it passed in between.
+func convertWithAttempts(test MCPTestResult, opts options) JUnitTestCase {
+	if len(test.Samples) > 0 {
+		return convertSamples(test, opts)
+	}
+	if len(test.Attempts) == 0 {
+		return convertTestCase(test, opts)
+	}
+
+	attempts := make([]JUnitTestCase, 0, len(test.Attempts)+1)
+	for _, attempt := range test.Attempts {
+		attempts = append(attempts, convertTestCase(attempt, opts))
+	}
+	final := convertTestCase(test, opts)
+	attempts = append(attempts, final)
+
+	failed := func(attempt JUnitTestCase) bool {
+		return attempt.Failure != nil || attempt.Error != nil
+	}
+	if !failed(final) {
+		testCase := final
+		for _, attempt := range attempts {
+			if attempt.Failure != nil {
+				testCase.FlakyFailures = append(testCase.FlakyFailures, failureRerun(attempt))
+			}
+			if attempt.Error != nil {
+				testCase.FlakyErrors = append(testCase.FlakyErrors, errorRerun(attempt))
+			}
+		}
+		return testCase
+	}
+
+	first := slices.IndexFunc(attempts, failed)
+	testCase := attempts[first]
+	for _, attempt := range attempts[first+1:] {
+		if attempt.Failure != nil {
+			testCase.RerunFailures = append(testCase.RerunFailures, failureRerun(attempt))
+		}
+		if attempt.Error != nil {
+			testCase.RerunErrors = append(testCase.RerunErrors, errorRerun(attempt))
+		}
+	}
+	return testCase
+}
+
+func failureRerun(attempt JUnitTestCase) JUnitRerun {
+	return JUnitRerun{
+		Message:    attempt.Failure.Message,
+		Type:       attempt.Failure.Type,
+		StackTrace: attempt.Failure.Content,
+		SystemOut:  attempt.SystemOut,
+		SystemErr:  attempt.SystemErr,
+	}
+}
+
+func errorRerun(attempt JUnitTestCase) JUnitRerun {
+	return JUnitRerun{
+		Message:    attempt.Error.Message,
+		Type:       attempt.Error.Type,
+		StackTrace: attempt.Error.Content,
+		SystemOut:  attempt.SystemOut,
+		SystemErr:  attempt.SystemErr,
+	}
+}
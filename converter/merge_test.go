@@ -0,0 +1,178 @@
+package converter
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func mustConvert(t *testing.T, run TestRun, opts options) JUnitTestSuites {
+	t.Helper()
+	report, err := convertToJUnit(context.Background(), run, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func mustParse(t *testing.T, input string) TestRun {
+	t.Helper()
+	run, err := Parse(strings.NewReader(input), ParseOptions{Format: FormatAuto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+func TestMergeReruns(t *testing.T) {
+	run1 := mustParse(t, `{"runId":"run-1","results":[`+resultA+`,`+resultB+`]}`)
+	run2 := mustParse(t, `{"runId":"run-2","results":[`+resultC+`,{"taskName":"b","taskPassed":true,"allAssertionsPassed":true}]}`)
+	run3 := mustParse(t, `[{"taskName":"b","taskPassed":false}]`)
+
+	merged := MergeReruns([]TestRun{run1, run2, run3})
+	if got := strings.Join(taskNames(merged), ","); got != "a,b,c" {
+		t.Errorf("task names = %s, want a,b,c", got)
+	}
+	if merged.RunID != "run-1" {
+		t.Errorf("RunID = %q, want run-1", merged.RunID)
+	}
+
+	b := merged.Results[1]
+	if b.TaskPassed {
+		t.Error("b should keep the result of its latest run")
+	}
+	if len(b.Attempts) != 2 || b.Attempts[0].TaskPassed || !b.Attempts[1].TaskPassed {
+		t.Errorf("b attempts = %+v, want the failed and passed earlier runs in order", b.Attempts)
+	}
+	for _, attempt := range b.Attempts {
+		if len(attempt.Attempts) != 0 {
+			t.Errorf("attempts must not be nested: %+v", attempt)
+		}
+	}
+	if len(merged.Results[0].Attempts) != 0 {
+		t.Errorf("a ran once but has attempts: %+v", merged.Results[0].Attempts)
+	}
+}
+
+func TestConvertWithAttempts(t *testing.T) {
+	failed := MCPTestResult{TaskName: "t", TaskPassed: true, AssertionResults: map[string]Assertion{"x": {Passed: false}}}
+	errored := MCPTestResult{TaskName: "t", TaskPassed: false, TaskError: "boom"}
+	passed := MCPTestResult{TaskName: "t", TaskPassed: true, AllAssertionsPassed: true}
+
+	withAttempts := func(final MCPTestResult, earlier ...MCPTestResult) MCPTestResult {
+		final.Attempts = earlier
+		return final
+	}
+
+	tests := []struct {
+		name              string
+		test              MCPTestResult
+		wantFailure       bool
+		wantError         bool
+		wantFlakyFailures int
+		wantFlakyErrors   int
+		wantRerunFailures int
+		wantRerunErrors   int
+	}{
+		{name: "single run", test: failed, wantFailure: true},
+		{name: "failed then passed", test: withAttempts(passed, failed), wantFlakyFailures: 1},
+		{name: "errored, failed, then passed", test: withAttempts(passed, errored, failed), wantFlakyFailures: 1, wantFlakyErrors: 1},
+		{name: "passed then failed", test: withAttempts(failed, passed), wantFailure: true},
+		{name: "passed then errored", test: withAttempts(errored, passed), wantError: true},
+		{name: "failed, passed, then failed", test: withAttempts(failed, failed, passed), wantFailure: true, wantRerunFailures: 1},
+		{name: "passed, errored, then failed", test: withAttempts(failed, passed, errored), wantError: true, wantRerunFailures: 1},
+		{name: "failed, passed, then passed", test: withAttempts(passed, failed, passed), wantFlakyFailures: 1},
+		{name: "failed every time", test: withAttempts(errored, failed, failed), wantFailure: true, wantRerunFailures: 1, wantRerunErrors: 1},
+		{name: "passed every time", test: withAttempts(passed, passed)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convertWithAttempts(tt.test, options{})
+			if (got.Failure != nil) != tt.wantFailure || (got.Error != nil) != tt.wantError {
+				t.Errorf("failure = %v, error = %v, want %v, %v", got.Failure, got.Error, tt.wantFailure, tt.wantError)
+			}
+			if len(got.FlakyFailures) != tt.wantFlakyFailures || len(got.FlakyErrors) != tt.wantFlakyErrors {
+				t.Errorf("flaky failures/errors = %d/%d, want %d/%d", len(got.FlakyFailures), len(got.FlakyErrors), tt.wantFlakyFailures, tt.wantFlakyErrors)
+			}
+			if len(got.RerunFailures) != tt.wantRerunFailures || len(got.RerunErrors) != tt.wantRerunErrors {
+				t.Errorf("rerun failures/errors = %d/%d, want %d/%d", len(got.RerunFailures), len(got.RerunErrors), tt.wantRerunFailures, tt.wantRerunErrors)
+			}
+		})
+	}
+}
+
+func TestMergedReportCountsFlakyAsPassed(t *testing.T) {
+	run1 := mustParse(t, `[`+resultB+`]`)
+	run2 := mustParse(t, `[{"taskName":"b","taskPassed":true,"difficulty":"hard","allAssertionsPassed":true}]`)
+
+	report := mustConvert(t, MergeReruns([]TestRun{run1, run2}), options{})
+	if len(report.Suites) != 1 {
+		t.Fatalf("got %d suites, want 1", len(report.Suites))
+	}
+	suite := report.Suites[0]
+	if suite.Tests != 1 || suite.Failures != 0 || suite.Errors != 0 {
+		t.Errorf("suite counts tests=%d failures=%d errors=%d, want 1/0/0", suite.Tests, suite.Failures, suite.Errors)
+	}
+
+	out, err := xml.Marshal(suite.TestCases[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `<flakyError message="Test execution failed" type="ExecutionError">`) {
+		t.Errorf("testcase XML has no flakyError element:\n%s", out)
+	}
+}
+
+func TestParseAttempts(t *testing.T) {
+	for _, input := range []string{
+		`{"taskName":"t","taskPath":"/x/tasks/t/task.yaml","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+			"attempts":[{"taskPassed":false,"taskError":"timeout"},{"taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"x":{"passed":false}}}]}`,
+		`{"task_name":"t","task_path":"/x/tasks/t/task.yaml","difficulty":"easy","task_passed":true,"all_assertions_passed":true,
+			"attempts":[{"task_passed":false,"task_error":"timeout"},{"task_passed":true,"all_assertions_passed":false,"assertion_results":{"x":{"passed":false}}}]}`,
+	} {
+		run, err := Parse(strings.NewReader(input), ParseOptions{Format: FormatAuto, Strict: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		attempts := run.Results[0].Attempts
+		if len(attempts) != 2 || attempts[0].TaskName != "t" || attempts[1].Difficulty != "easy" || attempts[0].TaskError != "timeout" {
+			t.Fatalf("attempts = %+v, want two attempts of task t", attempts)
+		}
+
+		report := mustConvert(t, run, options{})
+		suite := report.Suites[0]
+		if suite.Tests != 1 || suite.Failures != 0 || suite.Errors != 0 {
+			t.Errorf("suite counts tests=%d failures=%d errors=%d, want 1/0/0", suite.Tests, suite.Failures, suite.Errors)
+		}
+		out, err := xml.Marshal(suite.TestCases[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{`<flakyFailure message="Assertion failures: x" type="AssertionFailure">`, `<flakyError message="Test execution failed" type="ExecutionError">`} {
+			if !strings.Contains(string(out), want) {
+				t.Errorf("testcase XML does not contain %s:\n%s", want, out)
+			}
+		}
+	}
+
+	if _, err := Parse(strings.NewReader(`{"taskName":"t","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,"attempts":[{"taskError":"x"}]}`),
+		ParseOptions{Format: FormatAuto, Strict: true}); err == nil {
+		t.Error("Parse() accepted an attempt without taskPassed in strict mode")
+	}
+}
+
+func TestMergeRerunsKeepsReportedAttempts(t *testing.T) {
+	run1 := mustParse(t, `[{"taskName":"t","taskPassed":false,"taskError":"first"}]`)
+	run2 := mustParse(t, `[{"taskName":"t","taskPassed":true,"allAssertionsPassed":true,"attempts":[{"taskPassed":false,"taskError":"second"}]}]`)
+
+	merged := MergeReruns([]TestRun{run1, run2})
+	var errors []string
+	for _, attempt := range merged.Results[0].Attempts {
+		errors = append(errors, attempt.TaskError)
+	}
+	if strings.Join(errors, ",") != "first,second" {
+		t.Errorf("attempt errors = %q, want the earlier run before the retries of the later one", errors)
+	}
+}
@@ -0,0 +1,389 @@
+package converter
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Defaults applied by New
+const (
+	DefaultMaxToolOutput = 200
+	DefaultIndent        = "  "
+)
+
+// Option configures a Converter
+type Option func(*options)
+
+// options controls how results are turned into the JUnit document. The zero
+// value groups by difficulty, keeps the input order and truncates nothing.
+type options struct {
+	// GroupBy selects how testcases are grouped into testsuites, one of
+	// GroupByValues; empty groups by difficulty
+	GroupBy string
+	// OnDuplicate is one of DuplicatePolicies and handles the testcases of a
+	// suite that share a classname and name; empty leaves them alone
+	OnDuplicate string
+	// NestedSuites nests a suite per task directory in the suite of each
+	// group
+	NestedSuites bool
+	// ReportName, when set, is the name attribute of the testsuites element
+	ReportName string
+	// SuitePrefix, when set, starts the testsuite names in place of the
+	// suite name of the run or DefaultSuitePrefix
+	SuitePrefix string
+	// ReportProperties are written in the properties of the testsuites element
+	ReportProperties []JUnitProperty
+	// SuiteNameTemplate, when set, names each testsuite from the first
+	// result of its group
+	SuiteNameTemplate *template.Template
+	// Classname derives the classname of each testcase; nil uses
+	// TasksDirClassname
+	Classname ClassnameStrategy
+	// ClassnameTemplate, when set, takes precedence over Classname, which
+	// it sees as {{.Classname}}
+	ClassnameTemplate *template.Template
+	// ClassnameStyle is one of ClassnameStyles and rewrites the classnames
+	// from Classname or ClassnameTemplate; empty keeps them raw
+	ClassnameStyle string
+	// ExplodeAssertions writes a testcase per assertion rather than per task
+	ExplodeAssertions bool
+	// PathPrefix is stripped from the task paths written to the file
+	// attribute of testcases
+	PathPrefix string
+	// Filter selects the results to convert
+	Filter TaskFilter
+	// SystemOutTemplate, when set, lays out the system-out of each testcase
+	// instead of the built-in layout
+	SystemOutTemplate *template.Template
+	// TraceURLTemplate, when set, links each result with a trace ID to its
+	// trace
+	TraceURLTemplate *template.Template
+	// MaxToolOutput caps, in bytes, each tool message shown in system-out;
+	// 0 shows them in full
+	MaxToolOutput int
+	// MaxSystemOutBytes caps the system-out of each testcase; 0 keeps it whole
+	MaxSystemOutBytes int
+	// NoSystemOut and NoSystemErr leave out the system-out and system-err
+	// sections of every testcase
+	NoSystemOut bool
+	NoSystemErr bool
+	// SystemOutOnFailureOnly leaves out the system-out of passing testcases
+	// and passing attempts
+	SystemOutOnFailureOnly bool
+	// AttachmentsDir, when set, receives the full tool calls and task output
+	// of each result, and copies of its artifact files, referenced from its
+	// system-out
+	AttachmentsDir string
+	// Properties are added to the properties of every testsuite, after the
+	// run metadata
+	Properties []JUnitProperty
+	// CDATA writes the system-out, system-err, failure, error and rerun
+	// content of testcases in CDATA sections rather than escaping it
+	CDATA bool
+	// Sort is one of SortValues; empty keeps the input order
+	Sort string
+	// Indent indents the nested elements of the XML report; empty writes
+	// the report on a single line
+	Indent string
+	// Check validates the document Render produces against the JUnit
+	// schema, see junit.Validate
+	Check bool
+	// Redactions mask secrets in the output, failure and error content of
+	// every testcase
+	Redactions []Redaction
+	// StripANSI removes ANSI escape sequences from the strings of the
+	// report. Code points XML 1.0 does not allow are always replaced.
+	StripANSI bool
+	// Lang is one of Languages and selects the language of the testcase
+	// output and the failure and error messages; empty is English
+	Lang string
+	// TimeoutPatterns recognize the task errors of timeouts, which are
+	// reported with the Timeout error type and a timed_out property
+	TimeoutPatterns []*regexp.Regexp
+	// PhasePolicy decides how the failure of each phase is reported; nil
+	// reports every one as an error
+	PhasePolicy PhasePolicy
+	// SamplePolicy decides the outcome of the tasks sampled several times,
+	// one of SamplePolicies; empty takes SamplePolicyAny
+	SamplePolicy string
+	// Classifier, when set, decides the outcome of each result instead of
+	// DefaultClassifier
+	Classifier Classifier
+	// Clock, when set, stamps the testsuites of runs without a start time
+	Clock func() time.Time
+}
+
+// defaultOptions returns the options New starts from, matching the
+// command-line defaults
+func defaultOptions() options {
+	return options{
+		GroupBy:         GroupByDifficulty,
+		MaxToolOutput:   DefaultMaxToolOutput,
+		Sort:            SortSorted,
+		Indent:          DefaultIndent,
+		Redactions:      BuiltinRedactions,
+		CDATA:           true,
+		TimeoutPatterns: DefaultTimeoutPatterns,
+		Lang:            LangEnglish,
+		SamplePolicy:    SamplePolicyAny,
+	}
+}
+
+// validate checks the values that are chosen from a fixed set
+func (o options) validate() error {
+	if o.GroupBy != "" && !slices.Contains(GroupByValues, o.GroupBy) {
+		return fmt.Errorf("invalid group-by %q: must be one of %s", o.GroupBy, strings.Join(GroupByValues, ", "))
+	}
+	if o.OnDuplicate != "" && !slices.Contains(DuplicatePolicies, o.OnDuplicate) {
+		return fmt.Errorf("invalid duplicate policy %q: must be one of %s", o.OnDuplicate, strings.Join(DuplicatePolicies, ", "))
+	}
+	if o.ClassnameStyle != "" && !slices.Contains(ClassnameStyles, o.ClassnameStyle) {
+		return fmt.Errorf("invalid classname style %q: must be one of %s", o.ClassnameStyle, strings.Join(ClassnameStyles, ", "))
+	}
+	if o.Sort != "" && !slices.Contains(SortValues, o.Sort) {
+		return fmt.Errorf("invalid sort order %q: must be one of %s", o.Sort, strings.Join(SortValues, ", "))
+	}
+	if o.SamplePolicy != "" && !slices.Contains(SamplePolicies, o.SamplePolicy) {
+		return fmt.Errorf("invalid sample policy %q: must be one of %s", o.SamplePolicy, strings.Join(SamplePolicies, ", "))
+	}
+	if o.Lang != "" && !slices.Contains(Languages, o.Lang) {
+		return fmt.Errorf("invalid language %q: must be one of %s", o.Lang, strings.Join(Languages, ", "))
+	}
+	if o.MaxToolOutput < 0 || o.MaxSystemOutBytes < 0 {
+		return fmt.Errorf("truncation limits must not be negative")
+	}
+	if strings.Trim(o.Indent, " \t") != "" {
+		return fmt.Errorf("indent must only contain spaces and tabs")
+	}
+	return nil
+}
+
+// classname returns the classname of a result before any template applies
+func (o options) classname(result MCPTestResult) string {
+	if o.Classname != nil {
+		return o.Classname.Classname(result)
+	}
+	return TasksDirClassname.Classname(result)
+}
+
+// taskFile returns the file attribute of the testcase of a task, with
+// forward slashes and without the path prefix
+func (o options) taskFile(taskPath string) string {
+	file := slashPath(taskPath)
+	if o.PathPrefix != "" {
+		if rest, ok := strings.CutPrefix(file, slashPath(o.PathPrefix)); ok {
+			file = strings.TrimPrefix(rest, "/")
+		}
+	}
+	return sanitizeText(file, o.StripANSI)
+}
+
+// WithGroupBy groups testcases into testsuites by one of GroupByValues
+func WithGroupBy(groupBy string) Option {
+	return func(o *options) { o.GroupBy = groupBy }
+}
+
+// WithSort orders testsuites and testcases by one of SortValues
+func WithSort(order string) Option {
+	return func(o *options) { o.Sort = order }
+}
+
+// WithOnDuplicate handles the testcases of a suite that share a classname
+// and name by one of DuplicatePolicies: failing the conversion, suffixing
+// their names, or merging them into one testcase
+func WithOnDuplicate(policy string) Option {
+	return func(o *options) { o.OnDuplicate = policy }
+}
+
+// WithNestedSuites nests a testsuite per task directory in the testsuite
+// of each group, for the consumers that render suite hierarchies
+func WithNestedSuites() Option {
+	return func(o *options) { o.NestedSuites = true }
+}
+
+// WithReportName sets the name attribute of the testsuites element
+func WithReportName(name string) Option {
+	return func(o *options) { o.ReportName = name }
+}
+
+// WithSuitePrefix starts the testsuite names with prefix, e.g. "nightly-k8s"
+// for "nightly-k8s - easy", overriding the suite name of the run. A suite
+// name template takes precedence.
+func WithSuitePrefix(prefix string) Option {
+	return func(o *options) { o.SuitePrefix = prefix }
+}
+
+// WithReportProperties adds properties to the testsuites element, e.g. to
+// describe the environment the whole report was produced in
+func WithReportProperties(properties ...JUnitProperty) Option {
+	return func(o *options) { o.ReportProperties = append(o.ReportProperties, properties...) }
+}
+
+// WithSuiteNameTemplate names each testsuite by executing tmpl with the
+// first result of its group; see ParseNameTemplate
+func WithSuiteNameTemplate(tmpl *template.Template) Option {
+	return func(o *options) { o.SuiteNameTemplate = tmpl }
+}
+
+// WithClassnameTemplate sets each testcase classname by executing tmpl
+// with its result; see ParseNameTemplate
+func WithClassnameTemplate(tmpl *template.Template) Option {
+	return func(o *options) { o.ClassnameTemplate = tmpl }
+}
+
+// WithClassnameStrategy derives each testcase classname from its result
+// with strategy instead of TasksDirClassname
+func WithClassnameStrategy(strategy ClassnameStrategy) Option {
+	return func(o *options) { o.Classname = strategy }
+}
+
+// WithClassnameStyle rewrites every classname in one of ClassnameStyles,
+// e.g. ClassnameStyleNormalized for Jenkins package grouping
+func WithClassnameStyle(style string) Option {
+	return func(o *options) { o.ClassnameStyle = style }
+}
+
+// WithClassnameFunc derives each testcase classname from its result with fn
+// instead of from the task path
+func WithClassnameFunc(fn func(MCPTestResult) string) Option {
+	return WithClassnameStrategy(ClassnameFunc(fn))
+}
+
+// WithFilter converts only the results that pass filter
+func WithFilter(filter TaskFilter) Option {
+	return func(o *options) { o.Filter = filter }
+}
+
+// WithExplodedAssertions writes a testcase per assertion, named
+// task::assertion, rather than one per task, for the tasks that ran
+func WithExplodedAssertions() Option {
+	return func(o *options) { o.ExplodeAssertions = true }
+}
+
+// WithPathPrefix strips prefix from the task paths written to the file
+// attribute of testcases, e.g. the checkout directory, so that they are
+// relative to the repository
+func WithPathPrefix(prefix string) Option {
+	return func(o *options) { o.PathPrefix = prefix }
+}
+
+// WithTruncation caps each tool message shown in system-out to
+// maxToolOutput bytes, and the whole system-out of each testcase to
+// maxSystemOut bytes; 0 lifts a limit
+func WithTruncation(maxToolOutput, maxSystemOut int) Option {
+	return func(o *options) {
+		o.MaxToolOutput = maxToolOutput
+		o.MaxSystemOutBytes = maxSystemOut
+	}
+}
+
+// WithSystemOutTemplate lays out the system-out of each testcase by
+// executing tmpl, parsed with ParseSystemOutTemplate, with its result. A nil
+// tmpl keeps the built-in layout.
+func WithSystemOutTemplate(tmpl *template.Template) Option {
+	return func(o *options) { o.SystemOutTemplate = tmpl }
+}
+
+// WithTraceURLTemplate links each result with a trace ID to its trace, at
+// the URL tmpl renders from the result, e.g. from
+// https://smith.langchain.com/public/{{.TraceID}}/r; see ParseNameTemplate
+func WithTraceURLTemplate(tmpl *template.Template) Option {
+	return func(o *options) { o.TraceURLTemplate = tmpl }
+}
+
+// WithoutSystemOut leaves out the system-out of every testcase
+func WithoutSystemOut() Option {
+	return func(o *options) { o.NoSystemOut = true }
+}
+
+// WithoutSystemErr leaves out the system-err of every testcase
+func WithoutSystemErr() Option {
+	return func(o *options) { o.NoSystemErr = true }
+}
+
+// WithSystemOutOnFailureOnly leaves out the system-out of passing testcases
+func WithSystemOutOnFailureOnly() Option {
+	return func(o *options) { o.SystemOutOnFailureOnly = true }
+}
+
+// WithoutCDATA escapes the text content of testcases instead of writing it
+// in CDATA sections
+func WithoutCDATA() Option {
+	return func(o *options) { o.CDATA = false }
+}
+
+// WithAttachmentsDir writes the full tool calls and task output of each
+// result converted by Convert to files under dir, along with copies of its
+// artifact files, referenced from the system-out of its testcase with
+// [[ATTACHMENT|path]] markers
+func WithAttachmentsDir(dir string) Option {
+	return func(o *options) { o.AttachmentsDir = dir }
+}
+
+// WithProperties adds properties to every testsuite, after the run metadata
+func WithProperties(properties ...JUnitProperty) Option {
+	return func(o *options) { o.Properties = append(o.Properties, properties...) }
+}
+
+// WithIndent indents nested XML elements with indent, made of spaces and
+// tabs; an empty indent renders the report on a single line
+func WithIndent(indent string) Option {
+	return func(o *options) { o.Indent = indent }
+}
+
+// WithCheck makes Render validate the report against the JUnit schema and
+// fail with the offending element, rather than return an invalid document
+func WithCheck() Option {
+	return func(o *options) { o.Check = true }
+}
+
+// WithRedactions replaces the default BuiltinRedactions; pass none to turn
+// redaction off
+func WithRedactions(redactions ...Redaction) Option {
+	return func(o *options) { o.Redactions = redactions }
+}
+
+// WithStripANSI removes ANSI escape sequences, such as the colors of tool
+// output, from the report rather than replacing their escape character
+func WithStripANSI() Option {
+	return func(o *options) { o.StripANSI = true }
+}
+
+// WithLang writes the testcase output labels and the failure and error
+// messages in one of Languages
+func WithLang(lang string) Option {
+	return func(o *options) { o.Lang = lang }
+}
+
+// WithTimeoutPatterns replaces the DefaultTimeoutPatterns recognizing the
+// task errors of timeouts; pass none to report timeouts as other errors
+func WithTimeoutPatterns(patterns ...*regexp.Regexp) Option {
+	return func(o *options) { o.TimeoutPatterns = patterns }
+}
+
+// WithPhasePolicy sets how the failure of each phase is reported
+func WithPhasePolicy(policy PhasePolicy) Option {
+	return func(o *options) { o.PhasePolicy = policy }
+}
+
+// WithSamplePolicy decides the outcome of the tasks sampled several times
+// by one of SamplePolicies
+func WithSamplePolicy(policy string) Option {
+	return func(o *options) { o.SamplePolicy = policy }
+}
+
+// WithClassifier decides the outcome of each result with classifier
+// instead of DefaultClassifier
+func WithClassifier(classifier Classifier) Option {
+	return func(o *options) { o.Classifier = classifier }
+}
+
+// WithClock stamps the testsuites of runs that carry no start time with the
+// time returned by clock. Without it such testsuites have no timestamp.
+func WithClock(clock func() time.Time) Option {
+	return func(o *options) { o.Clock = clock }
+}
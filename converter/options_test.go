@@ -0,0 +1,90 @@
+package converter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	run := mustParse(t, `[`+resultA+`,`+resultB+`]`)
+
+	conv, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := conv.Convert(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := suiteNames(report); strings.Join(got, ",") != "MCP Checker Tests - easy,MCP Checker Tests - hard" {
+		t.Errorf("default suites = %q", got)
+	}
+	if report.Suites[0].Timestamp != "" {
+		t.Errorf("timestamp = %q without a clock or start time", report.Suites[0].Timestamp)
+	}
+
+	clock := func() time.Time { return time.Date(2025, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600)) }
+	conv, err = New(
+		WithGroupBy(GroupByNone),
+		WithClassnameFunc(func(result MCPTestResult) string { return "custom." + result.TaskName }),
+		WithClock(clock),
+		WithIndent(""),
+		// CDATA sections keep the newlines of the output
+		WithoutCDATA(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err = conv.Convert(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suite := report.Suites[0]
+	if suite.Name != "MCP Checker Tests" || suite.Timestamp != "2025-03-01T11:30:00" {
+		t.Errorf("suite %q has timestamp %q", suite.Name, suite.Timestamp)
+	}
+	if got := suite.TestCases[1].Classname; got != "custom.b" {
+		t.Errorf("classname = %q, want custom.b", got)
+	}
+	out, err := conv.Render(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(out), "\n") != 2 {
+		t.Errorf("report rendered with WithIndent(\"\") spans several lines:\n%s", out)
+	}
+
+	testCase := conv.ConvertResult(run.Results[1])
+	if testCase.Error == nil || testCase.Difficulty() != "hard" {
+		t.Errorf("ConvertResult() = %+v, want an errored hard testcase", testCase)
+	}
+}
+
+func TestConvertContext(t *testing.T) {
+	conv, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := conv.ConvertContext(ctx, mustParse(t, `[`+resultA+`]`)); !errors.Is(err, context.Canceled) {
+		t.Errorf("ConvertContext() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"group-by":   WithGroupBy("owner"),
+		"sort":       WithSort("random"),
+		"lang":       WithLang("fr"),
+		"truncation": WithTruncation(-1, 0),
+		"indent":     WithIndent("--"),
+	} {
+		if _, err := New(opt); err == nil {
+			t.Errorf("New() with an invalid %s succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,612 @@
+package converter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Input formats understood by Parse
+const (
+	FormatAuto   = "auto"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatYAML   = "yaml"
+	FormatJUnit  = "junit"
+)
+
+// Errors returned by Parse, wrapped with the position of the offending
+// entry; test for them with errors.Is
+var (
+	// ErrEmptyInput is returned when the input contains no data at all
+	ErrEmptyInput = errors.New("input is empty")
+	// ErrInvalidJSON is returned when JSON or JSON Lines input is malformed,
+	// or holds values of the wrong type for the result model
+	ErrInvalidJSON = errors.New("invalid JSON")
+	// ErrUnsupportedSchema is returned for a schemaVersion this package
+	// does not know, or an envelope whose results are not an array
+	ErrUnsupportedSchema = errors.New("unsupported schema")
+	// ErrInputTooLarge is returned for an input, once decompressed, larger
+	// than ParseOptions.MaxBytes
+	ErrInputTooLarge = errors.New("input too large")
+)
+
+// errTruncated stops decoding a damaged value in lenient mode once the
+// damage has been recorded as a parse error
+var errTruncated = errors.New("truncated value")
+
+// TestRun is the parsed input: the results plus any run-level metadata.
+// Newer mcpchecker builds emit it directly as an envelope object; bare
+// arrays and JSON Lines produce a run without metadata.
+type TestRun struct {
+	RunID     string          `json:"runId"`
+	StartedAt string          `json:"startedAt"`
+	Results   []MCPTestResult `json:"results"`
+	// SuiteName names the run, e.g. after the scenario set the checker was
+	// invoked with, and prefixes the testsuite names in place of
+	// DefaultSuitePrefix
+	SuiteName string `json:"suiteName,omitempty"`
+	// Environment is the model and tooling the run was produced with, nil
+	// when the input does not record it
+	Environment *Environment `json:"environment,omitempty"`
+
+	// ImportedSuites holds testsuites read from existing JUnit XML
+	// reports, appended to the generated report as they are
+	ImportedSuites []ImportedSuite `json:"-"`
+
+	// ParseErrors lists the entries skipped in lenient mode
+	ParseErrors []error `json:"-"`
+}
+
+// ParseOptions controls how the input is read and decoded
+type ParseOptions struct {
+	// Format is one of the Format constants; empty means FormatAuto
+	Format string
+	// Strict validates every result against the embedded input schema
+	Strict bool
+	// Lenient skips malformed entries, recording them as parse errors
+	// instead of failing the whole input
+	Lenient bool
+	// AllowEmpty parses an input without any data as a run without
+	// results rather than failing with ErrEmptyInput
+	AllowEmpty bool
+	// ToolResults caps the tool call results of every result as it is
+	// decoded
+	ToolResults ToolResultLimits
+	// MaxBytes, when positive, caps the size of the input once
+	// decompressed, so that a small gzip input cannot inflate without bound
+	MaxBytes int64
+}
+
+// SetSource records the input the results were read from, keeping a more
+// specific source already set, e.g. by an archive reader
+func (run *TestRun) SetSource(source string) {
+	for i := range run.Results {
+		if run.Results[i].SourceFile == "" {
+			run.Results[i].SourceFile = source
+		}
+	}
+}
+
+// Merge appends the results of other, keeping the first run metadata seen
+func (run *TestRun) Merge(other TestRun) {
+	if run.RunID == "" {
+		run.RunID = other.RunID
+	}
+	if run.StartedAt == "" {
+		run.StartedAt = other.StartedAt
+	}
+	if run.SuiteName == "" {
+		run.SuiteName = other.SuiteName
+	}
+	if run.Environment == nil {
+		run.Environment = other.Environment
+	}
+	run.Results = append(run.Results, other.Results...)
+	run.ImportedSuites = append(run.ImportedSuites, other.ImportedSuites...)
+	run.ParseErrors = append(run.ParseErrors, other.ParseErrors...)
+}
+
+// resultDecoder accumulates results from any of the supported input formats
+type resultDecoder struct {
+	ctx          context.Context
+	opts         ParseOptions
+	format       string
+	run          TestRun
+	count        int
+	schemaErrors SchemaErrors
+	// version is the schema version declared by an envelope, 0 if none
+	version int
+	// scenarioSet names the run when the envelope has no suiteName
+	scenarioSet string
+}
+
+// Parse decodes MCP checker results from r using the given options.
+// In auto mode a leading '[' selects the JSON array format, a leading '<' an
+// existing JUnit XML report, and anything else is treated as JSON Lines, which
+// also covers a single bare result object and the envelope schema.
+func Parse(r io.Reader, opts ParseOptions) (TestRun, error) {
+	return ParseContext(context.Background(), r, opts)
+}
+
+// ParseContext is Parse with a context: once ctx is done, reading and
+// decoding stop and ctx.Err() is returned.
+func ParseContext(ctx context.Context, r io.Reader, opts ParseOptions) (TestRun, error) {
+	reader, err := MaybeDecompress(bufio.NewReader(contextReader{ctx: ctx, r: r}))
+	if err != nil {
+		return TestRun{}, err
+	}
+	var limit *maxBytesReader
+	if opts.MaxBytes > 0 {
+		limit = &maxBytesReader{r: reader, limit: opts.MaxBytes, remaining: opts.MaxBytes}
+		reader = bufio.NewReader(limit)
+	}
+
+	// An empty results file must not turn into an empty, passing report,
+	// unless asked for
+	detected, err := DetectFormat(reader)
+	if err == io.EOF && opts.AllowEmpty {
+		return TestRun{}, nil
+	} else if err == io.EOF {
+		return TestRun{}, ErrEmptyInput
+	} else if err != nil {
+		return TestRun{}, err
+	}
+
+	format := opts.Format
+	if format == FormatAuto || format == "" {
+		format = detected
+	}
+
+	d := &resultDecoder{ctx: ctx, opts: opts, format: format}
+	switch format {
+	case FormatJSON:
+		err = d.parseJSON(reader)
+	case FormatNDJSON:
+		err = d.parseNDJSON(reader)
+	case FormatYAML:
+		err = d.parseYAML(reader)
+	case FormatJUnit:
+		err = d.parseJUnit(reader)
+	default:
+		err = fmt.Errorf("unsupported input format %q", format)
+	}
+	if err != nil && limit.exceeded() {
+		// The YAML decoder does not wrap read errors
+		return TestRun{}, limit.tooLarge()
+	} else if err != nil {
+		return TestRun{}, d.wrapJSONError(err)
+	}
+	if len(d.schemaErrors) > 0 {
+		return TestRun{}, d.schemaErrors
+	}
+	if d.run.SuiteName == "" {
+		d.run.SuiteName = d.scenarioSet
+	}
+	return d.run, nil
+}
+
+// parseJSON decodes a top-level array of results, an envelope object, or a
+// single result object which is wrapped into a one-element slice
+func (d *resultDecoder) parseJSON(reader io.Reader) error {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return d.addValue(data)
+}
+
+// parseNDJSON decodes one result object per line, processing entries as they are read
+func (d *resultDecoder) parseNDJSON(reader io.Reader) error {
+	if d.opts.Lenient {
+		return d.parseNDJSONLenient(bufio.NewReader(reader))
+	}
+
+	decoder := json.NewDecoder(reader)
+	for line := 1; ; line++ {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("entry %d: %w", line, err)
+		}
+		if err := d.addValue(raw); err != nil {
+			return fmt.Errorf("entry %d: %w", line, err)
+		}
+	}
+}
+
+// parseNDJSONLenient reads the input line by line so that a malformed line
+// only costs its own entry. Lines are accumulated until they form a complete
+// value, which keeps pretty-printed objects and envelopes working; a line
+// starting a new top-level value flushes an incomplete one as a parse error.
+func (d *resultDecoder) parseNDJSONLenient(reader *bufio.Reader) error {
+	var pending []byte
+
+	flush := func() error {
+		value := bytes.TrimSpace(pending)
+		pending = nil
+		if len(value) == 0 {
+			return nil
+		}
+		// Invalid values end up as parse errors, a truncated envelope
+		// still yields its complete results
+		return d.addValue(value)
+	}
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		startsValue := len(line) > 0 && (line[0] == '{' || line[0] == '[')
+		if startsValue && len(bytes.TrimSpace(pending)) > 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		pending = append(pending, line...)
+
+		// Only check for a complete value where one can end: a single
+		// compact line, or a closing bracket at the start of a line
+		endsValue := len(line) > 0 && (line[0] == '}' || line[0] == ']')
+		if (startsValue || endsValue) && json.Valid(pending) {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+
+		if readErr == io.EOF {
+			return flush()
+		}
+	}
+}
+
+// parseYAML decodes results from YAML with the same structure as the JSON
+// input. Each document is converted to JSON so that the json field names and
+// envelope detection apply unchanged; multi-document streams are concatenated.
+func (d *resultDecoder) parseYAML(reader io.Reader) error {
+	decoder := yaml.NewDecoder(reader)
+	for doc := 1; ; doc++ {
+		var value interface{}
+		if err := decoder.Decode(&value); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("document %d: %w", doc, err)
+		}
+		if value == nil {
+			continue
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("document %d: %w", doc, err)
+		}
+		if err := d.addValue(data); err != nil {
+			return fmt.Errorf("document %d: %w", doc, err)
+		}
+	}
+}
+
+// addValue adds a top-level JSON value: an array of results, an envelope
+// carrying run metadata, or a single result
+func (d *resultDecoder) addValue(data []byte) error {
+	if err := d.decodeValue(data); err != nil && !errors.Is(err, errTruncated) {
+		return err
+	}
+	return nil
+}
+
+func (d *resultDecoder) decodeValue(data []byte) error {
+	trimmed := bytes.TrimLeftFunc(data, unicode.IsSpace)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		decoder := json.NewDecoder(bytes.NewReader(trimmed))
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		return d.addArray(decoder)
+	}
+
+	if isEnvelope(data) {
+		return d.addEnvelope(data)
+	}
+
+	return d.addResult(data)
+}
+
+// addEnvelope walks the envelope object key by key so that a truncated
+// results array still yields the entries before the damage in lenient mode
+func (d *resultDecoder) addEnvelope(data []byte) error {
+	// A truncated envelope cannot be validated as a whole, its complete
+	// results are still validated one by one
+	if d.opts.Strict && json.Valid(data) {
+		if errs := validateEnvelope(data); len(errs) > 0 {
+			d.schemaErrors = append(d.schemaErrors, errs...)
+			return nil
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if _, err := decoder.Token(); err != nil {
+		return err
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return d.truncated(err)
+		}
+
+		switch token {
+		case "schemaVersion", "schema_version":
+			var raw json.RawMessage
+			if err = decoder.Decode(&raw); err == nil {
+				d.version, err = parseSchemaVersion(raw)
+			}
+		case "runId", "run_id":
+			err = decoder.Decode(&d.run.RunID)
+		case "startedAt", "started_at":
+			err = decoder.Decode(&d.run.StartedAt)
+		case "suiteName", "suite_name":
+			err = decoder.Decode(&d.run.SuiteName)
+		case "scenarioSet", "scenario_set":
+			err = decoder.Decode(&d.scenarioSet)
+		case "environment":
+			err = decoder.Decode(&d.run.Environment)
+		case "results":
+			if token, err = decoder.Token(); err == nil && token != json.Delim('[') {
+				err = fmt.Errorf("%w: envelope results must be an array", ErrUnsupportedSchema)
+			}
+			if err == nil {
+				err = d.addArray(decoder)
+			}
+		default:
+			var skipped json.RawMessage
+			err = decoder.Decode(&skipped)
+		}
+		if err != nil {
+			return d.truncated(err)
+		}
+	}
+	return nil
+}
+
+// addArray decodes the remaining elements of an array whose opening
+// bracket has already been consumed
+func (d *resultDecoder) addArray(decoder *json.Decoder) error {
+	for decoder.More() {
+		var element json.RawMessage
+		if err := decoder.Decode(&element); err != nil {
+			return d.truncated(err)
+		}
+		if err := d.addResult(element); err != nil {
+			return err
+		}
+	}
+	_, err := decoder.Token()
+	return d.truncated(err)
+}
+
+// addResult decodes a single result object of either schema version,
+// validating it first in strict mode. In lenient mode an entry that fails is
+// recorded as a parse error instead.
+func (d *resultDecoder) addResult(data []byte) error {
+	if err := d.ctx.Err(); err != nil {
+		return err
+	}
+	index := d.count
+	d.count++
+
+	version, err := detectSchemaVersion(data, d.version)
+	if err != nil {
+		if d.opts.Lenient {
+			d.addParseError(index, err)
+			return nil
+		}
+		return fmt.Errorf("result %d: %w", index, err)
+	}
+
+	if d.opts.Strict {
+		if errs := validateResult(index, version, data); len(errs) > 0 {
+			if d.opts.Lenient {
+				d.addParseError(index, SchemaErrors(errs))
+			} else {
+				d.schemaErrors = append(d.schemaErrors, errs...)
+			}
+			return nil
+		}
+	}
+
+	result, err := decodeResult(version, data)
+	if err != nil {
+		if d.opts.Lenient {
+			d.addParseError(index, err)
+			return nil
+		}
+		return fmt.Errorf("result %d: %w", index, err)
+	}
+	if err := d.opts.ToolResults.apply(&result); err != nil {
+		return fmt.Errorf("result %d: %w", index, err)
+	}
+	d.run.Results = append(d.run.Results, result)
+	return nil
+}
+
+// truncated handles a syntax error that stops decoding the current value. In
+// lenient mode it is recorded against the next entry and errTruncated unwinds
+// to addValue, keeping the results decoded so far.
+func (d *resultDecoder) truncated(err error) error {
+	if err == nil || !d.opts.Lenient || errors.Is(err, errTruncated) || d.ctx.Err() != nil {
+		return err
+	}
+	d.addParseError(d.count, err)
+	d.count++
+	return errTruncated
+}
+
+// addParseError records a malformed entry as an errored result named "parse-error-N"
+func (d *resultDecoder) addParseError(index int, err error) {
+	err = d.wrapJSONError(err)
+	d.run.ParseErrors = append(d.run.ParseErrors, fmt.Errorf("result %d: %w", index, err))
+	d.run.Results = append(d.run.Results, MCPTestResult{
+		TaskName:   fmt.Sprintf("parse-error-%d", index),
+		TaskPassed: false,
+		TaskError:  fmt.Sprintf("Failed to parse result %d: %v", index, err),
+		parseErr:   err,
+	})
+}
+
+// wrapJSONError marks the decoding errors of JSON input with ErrInvalidJSON.
+// YAML is decoded through JSON too, but its errors are left alone.
+func (d *resultDecoder) wrapJSONError(err error) error {
+	if d.format == FormatYAML {
+		return err
+	}
+	return markInvalidJSON(err)
+}
+
+// markInvalidJSON wraps JSON syntax and type errors with ErrInvalidJSON
+func markInvalidJSON(err error) error {
+	if errors.Is(err, ErrInvalidJSON) {
+		return err
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+	return err
+}
+
+// isEnvelope reports whether a JSON object is the wrapped schema, recognised
+// by a top-level "results" key. Keys are scanned in order, so an envelope
+// truncated after that key is still recognised.
+func isEnvelope(data []byte) bool {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return false
+	}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if token == "results" {
+			return true
+		}
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return false
+		}
+	}
+	return false
+}
+
+// contextReader fails reads with ctx.Err() once ctx is done
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// maxBytesReader fails with ErrInputTooLarge once more than limit bytes
+// are read from r
+type maxBytesReader struct {
+	r         io.Reader
+	limit     int64
+	remaining int64
+}
+
+func (r *maxBytesReader) Read(p []byte) (int, error) {
+	if r.remaining < 0 {
+		return 0, r.tooLarge()
+	}
+	// Read a byte past the limit to tell an input of exactly limit bytes
+	// from a larger one
+	if int64(len(p)) > r.remaining+1 {
+		p = p[:r.remaining+1]
+	}
+	n, err := r.r.Read(p)
+	if int64(n) > r.remaining {
+		n, r.remaining = int(r.remaining), -1
+		return n, r.tooLarge()
+	}
+	r.remaining -= int64(n)
+	return n, err
+}
+
+// exceeded reports whether reading went past the limit of r, which may be nil
+func (r *maxBytesReader) exceeded() bool {
+	return r != nil && r.remaining < 0
+}
+
+func (r *maxBytesReader) tooLarge() error {
+	return fmt.Errorf("%w: more than %d bytes", ErrInputTooLarge, r.limit)
+}
+
+// MaybeDecompress transparently unwraps gzip-compressed input, detected by
+// its magic bytes; Parse applies it to its input
+func MaybeDecompress(reader *bufio.Reader) (*bufio.Reader, error) {
+	magic, err := reader.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		// Too short to be gzip, let the decoder report any real problem
+		return reader, nil
+	}
+
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading gzip input: %w", err)
+	}
+	return bufio.NewReader(gz), nil
+}
+
+// DetectFormat returns the format Parse detects in auto mode from the first
+// non-whitespace byte of an input: FormatJUnit for '<', FormatJSON for '['
+// and FormatNDJSON otherwise. It consumes nothing but leading whitespace, and
+// returns io.EOF for an input holding nothing else.
+func DetectFormat(reader *bufio.Reader) (string, error) {
+	first, err := peekFirstNonSpace(reader)
+	if err != nil {
+		return "", err
+	}
+	switch first {
+	case '[':
+		return FormatJSON, nil
+	case '<':
+		return FormatJUnit, nil
+	default:
+		return FormatNDJSON, nil
+	}
+}
+
+// peekFirstNonSpace returns the first non-whitespace byte without consuming it,
+// or io.EOF when the input holds nothing else
+func peekFirstNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b[0])) {
+			return b[0], nil
+		}
+		if _, err := reader.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+}
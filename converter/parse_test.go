@@ -0,0 +1,364 @@
+package converter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	resultA = `{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true}`
+	resultB = `{"taskName":"b","taskPath":"/x/tasks/b/task.yaml","taskPassed":false,"difficulty":"hard","allAssertionsPassed":false}`
+	resultC = `{"taskName":"c","taskPassed":true,"difficulty":"medium","allAssertionsPassed":true}`
+)
+
+func taskNames(run TestRun) []string {
+	names := make([]string, 0, len(run.Results))
+	for _, result := range run.Results {
+		names = append(names, result.TaskName)
+	}
+	return names
+}
+
+func gzipped(t *testing.T, data string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestParseResultsFormats(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		input     string
+		want      []string
+		wantRunID string
+	}{
+		{
+			name:   "json array",
+			format: FormatAuto,
+			input:  "[" + resultA + "," + resultB + "]",
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "json array with explicit format",
+			format: FormatJSON,
+			input:  "\n  [" + resultA + "]",
+			want:   []string{"a"},
+		},
+		{
+			name:   "single object in auto mode",
+			format: FormatAuto,
+			input:  resultA,
+			want:   []string{"a"},
+		},
+		{
+			name:   "single object with explicit json format",
+			format: FormatJSON,
+			input:  resultA,
+			want:   []string{"a"},
+		},
+		{
+			name:   "json lines",
+			format: FormatAuto,
+			input:  resultA + "\n" + resultB + "\n\n" + resultC + "\n",
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "json lines with explicit format",
+			format: FormatNDJSON,
+			input:  resultA + "\n" + resultB,
+			want:   []string{"a", "b"},
+		},
+		{
+			name:      "envelope",
+			format:    FormatAuto,
+			input:     `{"runId":"run-1","startedAt":"2026-01-02T03:04:05Z","results":[` + resultA + `,` + resultB + `]}`,
+			want:      []string{"a", "b"},
+			wantRunID: "run-1",
+		},
+		{
+			name:      "envelope with explicit json format",
+			format:    FormatJSON,
+			input:     `{"results":[` + resultA + `],"runId":"run-2"}`,
+			want:      []string{"a"},
+			wantRunID: "run-2",
+		},
+		{
+			name:   "gzip json array",
+			format: FormatAuto,
+			input:  gzipped(t, "["+resultA+","+resultC+"]"),
+			want:   []string{"a", "c"},
+		},
+		{
+			name:   "gzip json lines",
+			format: FormatAuto,
+			input:  gzipped(t, resultB+"\n"+resultC),
+			want:   []string{"b", "c"},
+		},
+		{
+			name:   "yaml sequence",
+			format: FormatYAML,
+			input:  "- taskName: a\n  taskPassed: true\n  difficulty: easy\n- taskName: b\n  taskPassed: false\n",
+			want:   []string{"a", "b"},
+		},
+		{
+			name:      "yaml envelope",
+			format:    FormatYAML,
+			input:     "runId: run-3\nstartedAt: 2026-01-02T03:04:05Z\nresults:\n  - taskName: a\n",
+			want:      []string{"a"},
+			wantRunID: "run-3",
+		},
+		{
+			name:   "yaml multi-document stream",
+			format: FormatYAML,
+			input:  "taskName: a\n---\ntaskName: b\n---\n",
+			want:   []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := Parse(strings.NewReader(tt.input), ParseOptions{Format: tt.format})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := taskNames(run); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("task names = %v, want %v", got, tt.want)
+			}
+			if run.RunID != tt.wantRunID {
+				t.Errorf("RunID = %q, want %q", run.RunID, tt.wantRunID)
+			}
+		})
+	}
+}
+
+func TestParseResultsEnvelopeStartedAt(t *testing.T) {
+	input := `{"runId":"run-1","startedAt":"2026-01-02T03:04:05+01:00","results":[]}`
+	run, err := Parse(strings.NewReader(input), ParseOptions{Format: FormatAuto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.StartedAt != "2026-01-02T03:04:05+01:00" {
+		t.Errorf("StartedAt = %q", run.StartedAt)
+	}
+	if got := formatTimestamp(run.StartedAt); got != "2026-01-02T02:04:05" {
+		t.Errorf("formatTimestamp() = %q", got)
+	}
+}
+
+func TestParseResultsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		input  string
+		// want is the sentinel error, if any
+		want error
+	}{
+		{name: "empty", format: FormatAuto, input: "", want: ErrEmptyInput},
+		{name: "whitespace only", format: FormatAuto, input: " \n\t", want: ErrEmptyInput},
+		{name: "empty with explicit json format", format: FormatJSON, input: "", want: ErrEmptyInput},
+		{name: "empty yaml", format: FormatYAML, input: "\n", want: ErrEmptyInput},
+		{name: "empty gzip", format: FormatAuto, input: gzipped(t, ""), want: ErrEmptyInput},
+		{name: "truncated array", format: FormatAuto, input: "[" + resultA + ",", want: ErrInvalidJSON},
+		{name: "wrong field type", format: FormatAuto, input: `[{"taskName":"a","taskPassed":"yes"}]`, want: ErrInvalidJSON},
+		{name: "bad json line", format: FormatNDJSON, input: resultA + "\n{bad\n", want: ErrInvalidJSON},
+		{name: "unknown schema version", format: FormatAuto, input: `[{"schemaVersion":3,"taskName":"a"}]`, want: ErrUnsupportedSchema},
+		{name: "envelope results not an array", format: FormatAuto, input: `{"runId":"r","results":{}}`, want: ErrUnsupportedSchema},
+		{name: "unsupported format", format: "xml", input: resultA},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input), ParseOptions{Format: tt.format})
+			if err == nil {
+				t.Fatal("Parse() succeeded, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMaxBytes(t *testing.T) {
+	array := "[" + resultA + "," + resultB + "]"
+	tests := []struct {
+		name    string
+		input   string
+		opts    ParseOptions
+		wantErr bool
+	}{
+		{name: "exactly the limit", input: array, opts: ParseOptions{MaxBytes: int64(len(array))}},
+		{name: "one byte over", input: array, opts: ParseOptions{MaxBytes: int64(len(array)) - 1}, wantErr: true},
+		{name: "gzip within the limit", input: gzipped(t, array), opts: ParseOptions{MaxBytes: int64(len(array))}},
+		{name: "gzip inflating past the limit", input: gzipped(t, array+strings.Repeat(" ", 1<<20)), opts: ParseOptions{MaxBytes: 1 << 10}, wantErr: true},
+		{name: "lenient json lines", input: gzipped(t, resultA+strings.Repeat("\n", 1<<20)), opts: ParseOptions{Lenient: true, MaxBytes: 1 << 10}, wantErr: true},
+		{name: "yaml", input: "- taskName: y\n" + strings.Repeat("#\n", 1<<10), opts: ParseOptions{Format: FormatYAML, MaxBytes: 1 << 10}, wantErr: true},
+		{name: "no limit", input: gzipped(t, array+strings.Repeat(" ", 1<<20))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := Parse(strings.NewReader(tt.input), tt.opts)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInputTooLarge) {
+					t.Errorf("Parse() error = %v, want %v", err, ErrInputTooLarge)
+				}
+				return
+			}
+			if err != nil || len(run.Results) == 0 {
+				t.Errorf("Parse() = %d results, %v", len(run.Results), err)
+			}
+		})
+	}
+}
+
+func TestParseAllowEmpty(t *testing.T) {
+	for _, input := range []string{"", " \n", gzipped(t, "")} {
+		run, err := Parse(strings.NewReader(input), ParseOptions{AllowEmpty: true})
+		if err != nil || len(run.Results) != 0 {
+			t.Errorf("Parse(%q) = %d results, %v, want an empty run", input, len(run.Results), err)
+		}
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, format := range []string{FormatJSON, FormatNDJSON, FormatYAML, FormatJUnit} {
+		t.Run(format, func(t *testing.T) {
+			_, err := ParseContext(ctx, strings.NewReader("["+resultA+"]"), ParseOptions{Format: format, Lenient: true})
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("ParseContext() error = %v, want %v", err, context.Canceled)
+			}
+		})
+	}
+
+	// Results already read stop decoding too
+	ctx, cancel = context.WithCancel(context.Background())
+	d := &resultDecoder{ctx: ctx, opts: ParseOptions{Lenient: true}}
+	if err := d.addValue([]byte("[" + resultA + "]")); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if err := d.addValue([]byte("[" + resultB + "]")); !errors.Is(err, context.Canceled) {
+		t.Errorf("addValue() error = %v after cancellation, want %v", err, context.Canceled)
+	}
+	if len(d.run.ParseErrors) != 0 {
+		t.Errorf("cancellation recorded as parse errors: %v", d.run.ParseErrors)
+	}
+}
+
+func TestParseResultsLenient(t *testing.T) {
+	envelope := `{
+  "runId": "run-1",
+  "results": [
+    ` + resultA + `,
+    ` + resultB + `
+  ]
+}
+`
+	tests := []struct {
+		name        string
+		format      string
+		input       string
+		strict      bool
+		want        []string
+		parseErrors int
+	}{
+		{
+			name:   "json lines keep decoding after a bad line",
+			format: FormatAuto,
+			input:  resultA + "\n{bad\n" + resultC + "\n",
+			want:   []string{"a", "parse-error-1", "c"},
+		},
+		{
+			name:   "json lines with explicit format and truncated last line",
+			format: FormatNDJSON,
+			input:  resultA + "\n" + resultB + "\n" + resultC[:20],
+			want:   []string{"a", "b", "parse-error-2"},
+		},
+		{
+			name:   "json lines with a wrongly typed entry",
+			format: FormatAuto,
+			input:  resultA + "\n" + `{"taskName":"b","taskPassed":"yes"}` + "\n" + resultC,
+			want:   []string{"a", "parse-error-1", "c"},
+		},
+		{
+			name:   "pretty-printed envelope",
+			format: FormatAuto,
+			input:  envelope,
+			want:   []string{"a", "b"},
+		},
+		{
+			name:   "truncated pretty-printed envelope",
+			format: FormatAuto,
+			input:  envelope[:strings.Index(envelope, resultB)+30],
+			want:   []string{"a", "parse-error-1"},
+		},
+		{
+			name:   "array with a wrongly typed element",
+			format: FormatAuto,
+			input:  `[` + resultA + `,{"taskName":1},` + resultC + `]`,
+			want:   []string{"a", "parse-error-1", "c"},
+		},
+		{
+			name:   "truncated array",
+			format: FormatAuto,
+			input:  `[` + resultA + `,` + resultB[:30],
+			want:   []string{"a", "parse-error-1"},
+		},
+		{
+			name:   "array with a syntax error stops at the damage",
+			format: FormatAuto,
+			input:  `[` + resultA + `,{bad},` + resultC + `]`,
+			want:   []string{"a", "parse-error-1"},
+		},
+		{
+			name:   "strict schema violations become parse errors",
+			format: FormatAuto,
+			input:  `[` + resultA + `,{"taskName":"b"}]`,
+			strict: true,
+			want:   []string{"a", "parse-error-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := ParseOptions{Format: tt.format, Strict: tt.strict, Lenient: true}
+			run, err := Parse(strings.NewReader(tt.input), opts)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := taskNames(run); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("task names = %v, want %v", got, tt.want)
+			}
+
+			parseErrors := 0
+			for _, name := range tt.want {
+				if strings.HasPrefix(name, "parse-error-") {
+					parseErrors++
+				}
+			}
+			if len(run.ParseErrors) != parseErrors {
+				t.Errorf("got %d parse errors, want %d: %v", len(run.ParseErrors), parseErrors, run.ParseErrors)
+			}
+			for _, result := range run.Results {
+				if strings.HasPrefix(result.TaskName, "parse-error-") && (result.TaskPassed || result.TaskError == "") {
+					t.Errorf("%s is not reported as an error: %+v", result.TaskName, result)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Phases of a task, in the order they run
+const (
+	PhaseSetup   = "setup"
+	PhaseAgent   = "agent"
+	PhaseVerify  = "verify"
+	PhaseCleanup = "cleanup"
+)
+
+// Phases lists every phase
+var Phases = []string{PhaseSetup, PhaseAgent, PhaseVerify, PhaseCleanup}
+
+// PhaseAction is how a phase failure is reported
+type PhaseAction string
+
+// PhaseActions a PhasePolicy can give a phase
+const (
+	// PhaseActionError reports the failure as an error, the default
+	PhaseActionError PhaseAction = "error"
+	// PhaseActionFailure reports it as a failure
+	PhaseActionFailure PhaseAction = "failure"
+	// PhaseActionWarn only records it in a <phase>_phase_error property of
+	// the testcase
+	PhaseActionWarn PhaseAction = "warn"
+	// PhaseActionIgnore leaves it out of the report
+	PhaseActionIgnore PhaseAction = "ignore"
+)
+
+// PhaseActions lists every PhaseAction
+var PhaseActions = []PhaseAction{PhaseActionError, PhaseActionFailure, PhaseActionWarn, PhaseActionIgnore}
+
+// PhasePolicy maps phases to the action taken when they fail. Phases it
+// leaves out, and every phase of a nil policy, take PhaseActionError.
+type PhasePolicy map[string]PhaseAction
+
+// action returns the action for a phase
+func (p PhasePolicy) action(phase string) PhaseAction {
+	if action, ok := p[phase]; ok {
+		return action
+	}
+	return PhaseActionError
+}
+
+// ParsePhasePolicy parses comma-separated phase=action pairs, such as
+// setup=error,verify=failure,cleanup=warn
+func ParsePhasePolicy(value string) (PhasePolicy, error) {
+	policy := PhasePolicy{}
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		phase, action, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q is not phase=action", pair)
+		}
+		if !slices.Contains(Phases, phase) {
+			return nil, fmt.Errorf("unknown phase %q: must be one of %s", phase, strings.Join(Phases, ", "))
+		}
+		if !slices.Contains(PhaseActions, PhaseAction(action)) {
+			return nil, fmt.Errorf("invalid action %q for %s: must be one of %s", action, phase, phaseActionNames())
+		}
+		policy[phase] = PhaseAction(action)
+	}
+	return policy, nil
+}
+
+// phaseActionNames returns the PhaseActions as a comma-separated list
+func phaseActionNames() string {
+	names := make([]string, len(PhaseActions))
+	for i, action := range PhaseActions {
+		names[i] = string(action)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Phase returns the output of phase, one of Phases, or a zero PhaseOutput
+// for other names
+func (r MCPTestResult) Phase(phase string) PhaseOutput {
+	if output := phaseOutputs(&r)[phase]; output != nil {
+		return *output
+	}
+	return PhaseOutput{}
+}
+
+// phaseOutputs returns the outputs of the phases of a result, by phase
+func phaseOutputs(test *MCPTestResult) map[string]*PhaseOutput {
+	return map[string]*PhaseOutput{
+		PhaseSetup:   &test.SetupOutput,
+		PhaseAgent:   &test.AgentOutput,
+		PhaseVerify:  &test.VerifyOutput,
+		PhaseCleanup: &test.CleanupOutput,
+	}
+}
+
+// writePhaseSections writes a section per phase that reported its output or
+// duration, titled with its outcome and duration, e.g. "=== Setup (ok, 1.2s) ==="
+func writePhaseSections(output *strings.Builder, test MCPTestResult, msg *messages, redactions []Redaction) {
+	titles := map[string]string{
+		PhaseSetup:   msg.SetupPhase,
+		PhaseAgent:   msg.AgentPhase,
+		PhaseVerify:  msg.VerifyPhase,
+		PhaseCleanup: msg.CleanupPhase,
+	}
+	outputs := phaseOutputs(&test)
+	for _, phase := range Phases {
+		phaseOutput := outputs[phase]
+		if phaseOutput.Output == "" && phaseOutput.DurationMs <= 0 {
+			continue
+		}
+		status := msg.ToolOK
+		if !phaseOutput.Success {
+			status = msg.ToolFailed
+		}
+		if phaseOutput.DurationMs > 0 {
+			status += ", " + formatMilliseconds(phaseOutput.DurationMs)
+		}
+		fmt.Fprintf(output, "\n=== %s (%s) ===\n", titles[phase], status)
+		for _, line := range strings.Split(strings.TrimRight(redactText(phaseOutput.Output, redactions), "\n"), "\n") {
+			if line != "" {
+				fmt.Fprintf(output, "  %s\n", line)
+			}
+		}
+	}
+}
+
+// withoutQuietPhases returns the result as a Classifier should see it, with
+// the errors of the phases the policy warns about or ignores cleared
+func (p PhasePolicy) withoutQuietPhases(test MCPTestResult) MCPTestResult {
+	for phase, output := range phaseOutputs(&test) {
+		if action := p.action(phase); action == PhaseActionWarn || action == PhaseActionIgnore {
+			output.Error = ""
+		}
+	}
+	return test
+}
+
+// phaseFailures are the failed phases of a result, sorted by the policy
+type phaseFailures struct {
+	// content describes the phases reported as errors or failures
+	content string
+	// asError and asFailure tell whether any phase is reported as an error
+	// or as a failure
+	asError, asFailure bool
+	// warnings are the properties of the phases the policy warns about
+	warnings []JUnitProperty
+}
+
+// collectPhaseErrors gathers the failed phases of a result according to
+// the policy
+func collectPhaseErrors(test MCPTestResult, msg *messages, policy PhasePolicy) phaseFailures {
+	var failures phaseFailures
+	var content strings.Builder
+	labels := map[string]string{
+		PhaseSetup:   msg.SetupPhaseError,
+		PhaseAgent:   msg.AgentPhaseError,
+		PhaseVerify:  msg.VerifyPhaseError,
+		PhaseCleanup: msg.CleanupPhaseError,
+	}
+	outputs := phaseOutputs(&test)
+
+	for _, phase := range Phases {
+		output := outputs[phase]
+		if output.Success || output.Error == "" {
+			continue
+		}
+		switch policy.action(phase) {
+		case PhaseActionIgnore:
+			continue
+		case PhaseActionWarn:
+			failures.warnings = append(failures.warnings, JUnitProperty{Name: phase + "_phase_error", Value: output.Error})
+			continue
+		case PhaseActionFailure:
+			failures.asFailure = true
+		default:
+			failures.asError = true
+		}
+		content.WriteString(labels[phase] + ":\n")
+		content.WriteString(output.Error)
+		content.WriteString("\n\n")
+	}
+
+	failures.content = strings.TrimSpace(content.String())
+	return failures
+}
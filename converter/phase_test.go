@@ -0,0 +1,109 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPhasePolicy(t *testing.T) {
+	policy, err := ParsePhasePolicy("setup=error, verify=failure,cleanup=warn,agent=ignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passed := func(phases map[string]string) MCPTestResult {
+		result := MCPTestResult{TaskName: "a", TaskPassed: true, AllAssertionsPassed: true}
+		outputs := phaseOutputs(&result)
+		for phase, message := range phases {
+			outputs[phase].Error = message
+		}
+		return result
+	}
+
+	tests := []struct {
+		name         string
+		result       MCPTestResult
+		wantError    string
+		wantFailure  string
+		wantProperty string
+	}{
+		{name: "error", result: passed(map[string]string{PhaseSetup: "no cluster"}), wantError: "PhaseError"},
+		{name: "failure", result: passed(map[string]string{PhaseVerify: "mismatch"}), wantFailure: "PhaseFailure"},
+		{name: "error wins", result: passed(map[string]string{PhaseSetup: "no cluster", PhaseVerify: "mismatch"}), wantError: "PhaseError"},
+		{name: "warn", result: passed(map[string]string{PhaseCleanup: "namespace stuck"}), wantProperty: "cleanup_phase_error=namespace stuck"},
+		{name: "ignore", result: passed(map[string]string{PhaseAgent: "crashed"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, classifier := range []Classifier{nil, RuleClassifier{}} {
+				testCase := convertTestCase(tt.result, options{PhasePolicy: policy, Classifier: classifier})
+				var gotError, gotFailure, gotProperty string
+				if testCase.Error != nil {
+					gotError = testCase.Error.Type
+				}
+				if testCase.Failure != nil {
+					gotFailure = testCase.Failure.Type
+				}
+				if testCase.Properties != nil {
+					property := testCase.Properties.Properties[0]
+					gotProperty = property.Name + "=" + property.Value
+				}
+				if gotError != tt.wantError || gotFailure != tt.wantFailure || gotProperty != tt.wantProperty {
+					t.Errorf("classifier %v: error %q, failure %q, property %q, want %q, %q, %q",
+						classifier, gotError, gotFailure, gotProperty, tt.wantError, tt.wantFailure, tt.wantProperty)
+				}
+				if tt.name == "ignore" && testCase.SystemErr != "" {
+					t.Errorf("ignored phase in system-err: %q", testCase.SystemErr)
+				}
+			}
+		})
+	}
+
+	// Without a policy every phase failure is an error
+	if testCase := convertTestCase(passed(map[string]string{PhaseCleanup: "stuck"}), options{}); testCase.Error == nil {
+		t.Errorf("testcase = %+v, want a phase error", testCase)
+	}
+}
+
+func TestParsePhasePolicyErrors(t *testing.T) {
+	for value, want := range map[string]string{
+		"cleanup":        `"cleanup" is not phase=action`,
+		"teardown=warn":  `unknown phase "teardown"`,
+		"cleanup=notice": `invalid action "notice" for cleanup: must be one of error, failure, warn, ignore`,
+	} {
+		if _, err := ParsePhasePolicy(value); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParsePhasePolicy(%q) error = %v, want %q", value, err, want)
+		}
+	}
+}
+
+func TestPhaseSections(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPassed":false,"difficulty":"easy",
+			"setupOutput":{"Success":true,"Output":"namespace created\n\nimage pulled\n","DurationMs":1234.4},
+			"agentOutput":{"Success":true},
+			"verifyOutput":{"Success":false,"Error":"not ready","Output":"token=Bearer abcdefghijklmnop"}},
+		{"task_name":"b","task_passed":true,"cleanup_output":{"success":true,"duration_ms":350}}
+	]`)
+
+	a := convertTestCase(run.Results[0], options{Redactions: BuiltinRedactions})
+	want := "\n=== Setup (ok, 1.234s) ===\n  namespace created\n  image pulled\n\n=== Verify (failed) ===\n  token=Bearer [REDACTED]\n"
+	if !strings.Contains(a.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", a.SystemOut, want)
+	}
+	if strings.Contains(a.SystemOut, "=== Agent") {
+		t.Errorf("system-out has a section for a phase without output or duration:\n%s", a.SystemOut)
+	}
+
+	if got := run.Results[0].Phase(PhaseVerify); got.Success || got.Error != "not ready" {
+		t.Errorf("Phase(%q) = %+v", PhaseVerify, got)
+	}
+	if got := run.Results[0].Phase("deploy"); got != (PhaseOutput{}) {
+		t.Errorf("Phase(%q) = %+v, want a zero PhaseOutput", "deploy", got)
+	}
+
+	b := convertTestCase(run.Results[1], options{Lang: LangSpanish})
+	if !strings.Contains(b.SystemOut, "\n=== Limpieza (ok, 350ms) ===\n") {
+		t.Errorf("system-out = %q, want a Spanish cleanup section", b.SystemOut)
+	}
+}
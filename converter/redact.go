@@ -0,0 +1,121 @@
+package converter
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// RedactedText replaces the secrets masked by the redactions
+const RedactedText = "[REDACTED]"
+
+// Redaction masks every match of Pattern. Its Replacement may refer to
+// submatches with $1, ${name}, etc., as in regexp.Regexp.ReplaceAllString.
+type Redaction struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+	// Literals, when set, are lower case strings one of which every match
+	// of Pattern contains, ignoring case. Text containing none of them is
+	// left alone without running Pattern, which is much slower.
+	Literals []string
+}
+
+// BuiltinRedactions masks common credentials: AWS access key IDs and secret
+// keys, bearer tokens, and the user info of URLs with a password. A bearer
+// token must look like one, with a digit, a dot or 16 characters, so that
+// prose such as "the bearer of" is left alone.
+var BuiltinRedactions = []Redaction{
+	{Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), Replacement: RedactedText, Literals: []string{"akia", "asia"}},
+	{
+		Pattern:     regexp.MustCompile(`(?i)(aws_secret_access_key\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`),
+		Replacement: "${1}" + RedactedText,
+		Literals:    []string{"aws_secret_access_key"},
+	},
+	{
+		Pattern:     regexp.MustCompile(`(?i)\b(bearer\s+)(?:[A-Za-z0-9\-._~+/]*[0-9][A-Za-z0-9\-._~+/]*|[A-Za-z0-9\-_~+/]+\.[A-Za-z0-9\-._~+/]*[A-Za-z0-9\-_~+/]|[A-Za-z0-9\-._~+/]{16,})=*`),
+		Replacement: "${1}" + RedactedText,
+		Literals:    []string{"bearer"},
+	},
+	{
+		Pattern:     regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.\-]*://)[^/\s:@]+:[^/\s@]+@`),
+		Replacement: "${1}" + RedactedText + "@",
+		Literals:    []string{"://"},
+	},
+}
+
+// redactText applies every redaction to text, skipping those whose
+// literals text does not contain
+func redactText(text string, redactions []Redaction) string {
+	// folded is text in lower case, for the literals, once one needs it
+	folded, foldedOK := "", false
+	for _, redaction := range redactions {
+		if len(redaction.Literals) > 0 {
+			if !foldedOK {
+				folded, foldedOK = strings.ToLower(text), true
+			}
+			if !slices.ContainsFunc(redaction.Literals, func(literal string) bool { return strings.Contains(folded, literal) }) {
+				continue
+			}
+		}
+		if redacted := redaction.Pattern.ReplaceAllString(text, redaction.Replacement); redacted != text {
+			text, foldedOK = redacted, false
+		}
+	}
+	return text
+}
+
+// redactJSON applies every redaction to the strings of a JSON document,
+// leaving its keys and numbers alone
+func redactJSON(data []byte, redactions []Redaction) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(redactValue(value, redactions))
+}
+
+// redactValue applies every redaction to the strings of a decoded JSON value
+func redactValue(value any, redactions []Redaction) any {
+	switch v := value.(type) {
+	case string:
+		return redactText(v, redactions)
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i], redactions)
+		}
+	case map[string]any:
+		for key := range v {
+			v[key] = redactValue(v[key], redactions)
+		}
+	}
+	return value
+}
+
+// redactTestCase masks the secrets in the system-err, failure and error
+// content of a testcase. Its system-out is left out, convertTestCase having
+// redacted it before truncating, and the rerun elements of merged runs are
+// built from testcases that are already redacted.
+func redactTestCase(testCase *JUnitTestCase, redactions []Redaction) {
+	if len(redactions) == 0 {
+		return
+	}
+	redact := func(text *string) {
+		*text = redactText(*text, redactions)
+	}
+	redact(&testCase.SystemErr)
+	if testCase.Skipped != nil {
+		redact(&testCase.Skipped.Message)
+	}
+	if testCase.Failure != nil {
+		redact(&testCase.Failure.Message)
+		redact(&testCase.Failure.Content)
+	}
+	if testCase.Error != nil {
+		redact(&testCase.Error.Message)
+		redact(&testCase.Error.Content)
+	}
+}
@@ -0,0 +1,231 @@
+package converter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Result schema versions understood by the decoder
+const (
+	schemaVersion1 = 1
+	schemaVersion2 = 2
+)
+
+// resultV2 is a result in the v2 schema, which renames every field to
+// snake_case and nests assertion details
+type resultV2 struct {
+	TaskName            string                 `json:"task_name"`
+	TaskPath            string                 `json:"task_path"`
+	TaskLine            int                    `json:"task_line"`
+	TaskPassed          bool                   `json:"task_passed"`
+	TaskOutput          string                 `json:"task_output"`
+	TaskError           string                 `json:"task_error"`
+	TaskSkipped         bool                   `json:"task_skipped"`
+	SkipReason          string                 `json:"skip_reason"`
+	Difficulty          string                 `json:"difficulty"`
+	Tags                []string               `json:"tags"`
+	TraceID             string                 `json:"trace_id"`
+	PromptID            string                 `json:"prompt_id"`
+	AssertionResults    map[string]assertionV2 `json:"assertion_results"`
+	AllAssertionsPassed bool                   `json:"all_assertions_passed"`
+	CallHistory         struct {
+		ToolCalls []struct {
+			ServerName string                 `json:"server_name"`
+			Success    bool                   `json:"success"`
+			Name       string                 `json:"name"`
+			Result     map[string]interface{} `json:"result"`
+			Arguments  map[string]interface{} `json:"arguments"`
+			DurationMs float64                `json:"duration_ms"`
+		} `json:"tool_calls"`
+		ResourceReads []struct {
+			ServerName string `json:"server_name"`
+			Success    bool   `json:"success"`
+			URI        string `json:"uri"`
+			Content    string `json:"content"`
+			MimeType   string `json:"mime_type"`
+		} `json:"resource_reads"`
+	} `json:"call_history"`
+	ExpectedCalls []struct {
+		ServerName string `json:"server_name"`
+		Name       string `json:"name"`
+	} `json:"expected_calls"`
+	SetupOutput   phaseOutputV2      `json:"setup_output"`
+	AgentOutput   phaseOutputV2      `json:"agent_output"`
+	VerifyOutput  phaseOutputV2      `json:"verify_output"`
+	CleanupOutput phaseOutputV2      `json:"cleanup_output"`
+	TokenUsage    *TokenUsage        `json:"token_usage"`
+	CostUSD       float64            `json:"cost_usd"`
+	Conversation  []ConversationTurn `json:"conversation"`
+	Artifacts     []string           `json:"artifacts"`
+	Attempts      []resultV2         `json:"attempts"`
+	Samples       []resultV2         `json:"samples"`
+}
+
+type assertionV2 struct {
+	Passed  bool `json:"passed"`
+	Details struct {
+		Message  string      `json:"message"`
+		Expected interface{} `json:"expected"`
+		Actual   interface{} `json:"actual"`
+	} `json:"details"`
+}
+
+type phaseOutputV2 struct {
+	Success    bool    `json:"success"`
+	Error      string  `json:"error"`
+	Output     string  `json:"output"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// normalize converts a v2 result into the internal model
+func (r resultV2) normalize() MCPTestResult {
+	result := MCPTestResult{
+		TaskName:            r.TaskName,
+		TaskPath:            r.TaskPath,
+		TaskLine:            r.TaskLine,
+		TaskPassed:          r.TaskPassed,
+		TaskOutput:          r.TaskOutput,
+		TaskError:           r.TaskError,
+		TaskSkipped:         r.TaskSkipped,
+		SkipReason:          r.SkipReason,
+		Difficulty:          r.Difficulty,
+		Tags:                r.Tags,
+		TraceID:             r.TraceID,
+		PromptID:            r.PromptID,
+		AllAssertionsPassed: r.AllAssertionsPassed,
+		SetupOutput:         PhaseOutput(r.SetupOutput),
+		AgentOutput:         PhaseOutput(r.AgentOutput),
+		VerifyOutput:        PhaseOutput(r.VerifyOutput),
+		CleanupOutput:       PhaseOutput(r.CleanupOutput),
+		TokenUsage:          r.TokenUsage,
+		CostUSD:             r.CostUSD,
+		Conversation:        r.Conversation,
+		Artifacts:           r.Artifacts,
+	}
+	for _, call := range r.ExpectedCalls {
+		result.ExpectedCalls = append(result.ExpectedCalls, ExpectedCall(call))
+	}
+	if r.AssertionResults != nil {
+		result.AssertionResults = make(map[string]Assertion, len(r.AssertionResults))
+		for name, assertion := range r.AssertionResults {
+			result.AssertionResults[name] = Assertion{
+				Passed:   assertion.Passed,
+				Message:  assertion.Details.Message,
+				Expected: assertion.Details.Expected,
+				Actual:   assertion.Details.Actual,
+			}
+		}
+	}
+	for _, call := range r.CallHistory.ToolCalls {
+		result.CallHistory.ToolCalls = append(result.CallHistory.ToolCalls, ToolCall(call))
+	}
+	for _, read := range r.CallHistory.ResourceReads {
+		result.CallHistory.ResourceReads = append(result.CallHistory.ResourceReads, ResourceRead(read))
+	}
+	for _, attempt := range r.Attempts {
+		result.Attempts = append(result.Attempts, attempt.normalize())
+	}
+	for _, sample := range r.Samples {
+		result.Samples = append(result.Samples, sample.normalize())
+	}
+	return result
+}
+
+// decodeResult decodes a result of the given schema version into the internal model
+func decodeResult(version int, data []byte) (MCPTestResult, error) {
+	if version == schemaVersion2 {
+		var result resultV2
+		if err := json.Unmarshal(data, &result); err != nil {
+			return MCPTestResult{}, err
+		}
+		return inheritAttempts(result.normalize()), nil
+	}
+
+	var result MCPTestResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return MCPTestResult{}, err
+	}
+	return inheritAttempts(result), nil
+}
+
+// inheritAttempts fills in the task identity the entries of an attempts or
+// samples array usually leave out, and drops the attempts and samples
+// nested in them
+func inheritAttempts(result MCPTestResult) MCPTestResult {
+	for _, entries := range [][]MCPTestResult{result.Attempts, result.Samples} {
+		for i := range entries {
+			entry := &entries[i]
+			if entry.TaskName == "" {
+				entry.TaskName = result.TaskName
+			}
+			if entry.TaskPath == "" {
+				entry.TaskPath, entry.TaskLine = result.TaskPath, result.TaskLine
+			}
+			if entry.Difficulty == "" {
+				entry.Difficulty = result.Difficulty
+			}
+			entry.Attempts, entry.Samples = nil, nil
+		}
+	}
+	return result
+}
+
+// detectSchemaVersion picks the schema version of a raw result. An explicit
+// schemaVersion (or schema_version) field wins, then the version declared by
+// the enclosing envelope, if any; otherwise a result with snake_case task_name
+// and no camelCase taskName is taken as v2.
+func detectSchemaVersion(data []byte, envelopeVersion int) (int, error) {
+	var probe struct {
+		SchemaVersion      json.RawMessage `json:"schemaVersion"`
+		SchemaVersionSnake json.RawMessage `json:"schema_version"`
+		TaskName           json.RawMessage `json:"taskName"`
+		TaskNameSnake      json.RawMessage `json:"task_name"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		// Not an object; leave the error to the decoder
+		return schemaVersion1, nil
+	}
+
+	declared := probe.SchemaVersion
+	if declared == nil {
+		declared = probe.SchemaVersionSnake
+	}
+	if declared != nil {
+		return parseSchemaVersion(declared)
+	}
+	if envelopeVersion != 0 {
+		return envelopeVersion, nil
+	}
+	if probe.TaskNameSnake != nil && probe.TaskName == nil {
+		return schemaVersion2, nil
+	}
+	return schemaVersion1, nil
+}
+
+// parseSchemaVersion accepts a number or a string such as "2" or "2.1",
+// keeping the major version
+func parseSchemaVersion(raw json.RawMessage) (int, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return 0, err
+	}
+
+	var text string
+	switch value := value.(type) {
+	case float64:
+		text = strconv.FormatFloat(value, 'f', -1, 64)
+	case string:
+		text = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	default:
+		return 0, fmt.Errorf("%w: schemaVersion must be a number or a string, got %s", ErrUnsupportedSchema, raw)
+	}
+
+	major, _, _ := strings.Cut(text, ".")
+	version, err := strconv.Atoi(major)
+	if err != nil || version < schemaVersion1 || version > schemaVersion2 {
+		return 0, fmt.Errorf("%w: schemaVersion %s", ErrUnsupportedSchema, raw)
+	}
+	return version, nil
+}
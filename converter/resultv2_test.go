@@ -0,0 +1,143 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const resultV2A = `{"task_name":"a2","task_path":"/x/tasks/a2/task.yaml","task_passed":true,"difficulty":"easy","all_assertions_passed":false,
+	"assertion_results":{"called-tool":{"passed":false,"details":{"message":"tool was never called"}},"ok":{"passed":true}},
+	"call_history":{"tool_calls":[{"server_name":"s","name":"t","success":true}],"resource_reads":[{"server_name":"s","uri":"file:///x","success":false}]},
+	"setup_output":{"success":true},"verify_output":{"success":false,"error":"verify failed"}}`
+
+func TestDetectSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		input           string
+		envelopeVersion int
+		want            int
+		wantErr         bool
+	}{
+		{name: "camelCase", input: resultA, want: schemaVersion1},
+		{name: "snake_case", input: resultV2A, want: schemaVersion2},
+		{name: "explicit number", input: `{"schemaVersion":2,"taskName":"a"}`, want: schemaVersion2},
+		{name: "explicit string with minor", input: `{"schema_version":"2.1"}`, want: schemaVersion2},
+		{name: "explicit v1 wins over envelope", input: `{"schemaVersion":"v1","task_name":"a"}`, envelopeVersion: 2, want: schemaVersion1},
+		{name: "envelope version", input: resultA, envelopeVersion: 2, want: schemaVersion2},
+		{name: "unsupported version", input: `{"schemaVersion":3}`, wantErr: true},
+		{name: "invalid version", input: `{"schemaVersion":true}`, wantErr: true},
+		{name: "not an object", input: `"a"`, want: schemaVersion1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := detectSchemaVersion([]byte(tt.input), tt.envelopeVersion)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("detectSchemaVersion() = %d, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("detectSchemaVersion() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("detectSchemaVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseResultsV2(t *testing.T) {
+	tests := []struct {
+		name      string
+		format    string
+		input     string
+		want      []string
+		wantRunID string
+	}{
+		{name: "json array", format: FormatAuto, input: "[" + resultV2A + "]", want: []string{"a2"}},
+		{name: "mixed json lines", format: FormatAuto, input: resultA + "\n" + strings.ReplaceAll(resultV2A, "\n", "") + "\n" + resultC, want: []string{"a", "a2", "c"}},
+		{
+			name:      "v2 envelope",
+			format:    FormatAuto,
+			input:     `{"schemaVersion":2,"run_id":"run-2","started_at":"2026-01-02T03:04:05Z","results":[{"task_name":"x","task_passed":true}]}`,
+			want:      []string{"x"},
+			wantRunID: "run-2",
+		},
+		{name: "yaml", format: FormatYAML, input: "- task_name: y\n  task_passed: true\n", want: []string{"y"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := Parse(strings.NewReader(tt.input), ParseOptions{Format: tt.format})
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := taskNames(run); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("task names = %v, want %v", got, tt.want)
+			}
+			if run.RunID != tt.wantRunID {
+				t.Errorf("RunID = %q, want %q", run.RunID, tt.wantRunID)
+			}
+		})
+	}
+}
+
+func TestNormalizeV2(t *testing.T) {
+	run, err := Parse(strings.NewReader("["+resultV2A+"]"), ParseOptions{Format: FormatAuto, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := run.Results[0]
+	if result.TaskPath != "/x/tasks/a2/task.yaml" || !result.TaskPassed || result.AllAssertionsPassed || result.Difficulty != "easy" {
+		t.Errorf("task fields not normalized: %+v", result)
+	}
+	if got := result.AssertionResults["called-tool"]; got.Passed || got.Message != "tool was never called" {
+		t.Errorf("assertion = %+v", got)
+	}
+	if len(result.CallHistory.ToolCalls) != 1 || result.CallHistory.ToolCalls[0].ServerName != "s" {
+		t.Errorf("tool calls = %+v", result.CallHistory.ToolCalls)
+	}
+	if len(result.CallHistory.ResourceReads) != 1 || result.CallHistory.ResourceReads[0].URI != "file:///x" {
+		t.Errorf("resource reads = %+v", result.CallHistory.ResourceReads)
+	}
+	if result.VerifyOutput.Success || result.VerifyOutput.Error != "verify failed" || !result.SetupOutput.Success {
+		t.Errorf("phase outputs = %+v %+v", result.SetupOutput, result.VerifyOutput)
+	}
+
+	testCase := convertTestCase(result, options{})
+	if testCase.Failure == nil || !strings.Contains(testCase.Failure.Content, "  - called-tool: tool was never called\n") {
+		t.Errorf("failure content does not include the assertion details: %+v", testCase.Failure)
+	}
+}
+
+func TestParseResultsV2Errors(t *testing.T) {
+	_, err := Parse(strings.NewReader(`[{"task_name":"a","task_passed":"yes"}]`), ParseOptions{Format: FormatAuto, Strict: true})
+	var schemaErrs SchemaErrors
+	if !errors.As(err, &schemaErrs) {
+		t.Fatalf("Parse() error = %v, want SchemaErrors", err)
+	}
+	got := schemaErrs.Error()
+	for _, want := range []string{
+		"result 0: $.task_passed: expected boolean, got string",
+		"result 0: $.difficulty: required field is missing",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("schema errors do not contain %q:\n%s", want, got)
+		}
+	}
+
+	if _, err := Parse(strings.NewReader(`{"schemaVersion":9,"taskName":"a"}`), ParseOptions{Format: FormatAuto}); err == nil {
+		t.Error("unsupported schema version succeeded, want an error")
+	}
+
+	run, err := Parse(strings.NewReader(resultA+"\n"+`{"schemaVersion":9}`), ParseOptions{Format: FormatAuto, Lenient: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(taskNames(run), ","); got != "a,parse-error-1" {
+		t.Errorf("lenient task names = %s", got)
+	}
+}
@@ -0,0 +1,108 @@
+package converter
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Policies deciding the outcome of a task sampled several times, see
+// WithSamplePolicy
+const (
+	// SamplePolicyAny passes a task when any sample passed, as pass@k does
+	SamplePolicyAny = "any"
+	// SamplePolicyAll passes a task only when every sample passed
+	SamplePolicyAll = "all"
+	// SamplePolicyMajority passes a task when more than half its samples passed
+	SamplePolicyMajority = "majority"
+)
+
+// SamplePolicies lists the sample policies in the order shown by the help
+var SamplePolicies = []string{SamplePolicyAny, SamplePolicyAll, SamplePolicyMajority}
+
+// PassAt1Property names the testcase property holding the share of the
+// samples of a task that passed; pass@k, for k the number of samples, is
+// written as "pass@" followed by k
+const PassAt1Property = "pass@1"
+
+// samplesPass reports whether passed of total samples satisfy policy
+func samplesPass(policy string, passed, total int) bool {
+	switch policy {
+	case SamplePolicyAll:
+		return passed == total
+	case SamplePolicyMajority:
+		return 2*passed > total
+	default:
+		return passed > 0
+	}
+}
+
+// convertSamples converts a task sampled several times into one testcase,
+// that of its first sample with the outcome the sample policy decides. The
+// testcase gets the pass@1 and pass@k properties and, in system-out, the
+// outcome of every sample. Skipped samples count toward neither side; a
+// task whose samples were all skipped is reported as its first one.
+func convertSamples(test MCPTestResult, opts options) JUnitTestCase {
+	msg := catalog(opts.Lang)
+	samples := make([]JUnitTestCase, len(test.Samples))
+	ran, passed := 0, 0
+	for i, sample := range test.Samples {
+		samples[i] = convertTestCase(sample, opts)
+		if samples[i].Skipped != nil {
+			continue
+		}
+		ran++
+		if samples[i].Failure == nil && samples[i].Error == nil {
+			passed++
+		}
+	}
+	if ran == 0 {
+		return samples[0]
+	}
+
+	// The testcase shows a sample with the outcome of the task
+	pass := samplesPass(opts.SamplePolicy, passed, ran)
+	testCase := samples[0]
+	for _, sample := range samples {
+		if sample.Skipped == nil && (sample.Failure == nil && sample.Error == nil) == pass {
+			testCase = sample
+			break
+		}
+	}
+
+	passAt1 := strconv.FormatFloat(math.Round(float64(passed)/float64(ran)*1e4)/1e4, 'f', -1, 64)
+	passAtK := "0"
+	if passed > 0 {
+		passAtK = "1"
+	}
+	// With a single sample that ran, pass@k is pass@1
+	rates := fmt.Sprintf("pass@1=%s", passAt1)
+	testCase.addProperties(JUnitProperty{Name: PassAt1Property, Value: passAt1})
+	if ran > 1 {
+		rates += fmt.Sprintf(", pass@%d=%s", ran, passAtK)
+		testCase.addProperties(JUnitProperty{Name: "pass@" + strconv.Itoa(ran), Value: passAtK})
+	}
+
+	if testCase.SystemOut != "" {
+		var section strings.Builder
+		fmt.Fprintf(&section, "\n"+msg.Samples+" (%s)\n", passed, ran, rates)
+		for i, sample := range samples {
+			switch {
+			case sample.Skipped != nil:
+				fmt.Fprintf(&section, "  #%d %s: %s\n", i+1, msg.Skipped, sample.Skipped.Message)
+			case sample.Error != nil:
+				fmt.Fprintf(&section, "  #%d %s: %s\n", i+1, msg.Failed, sample.Error.Message)
+			case sample.Failure != nil:
+				fmt.Fprintf(&section, "  #%d %s: %s\n", i+1, msg.Failed, sample.Failure.Message)
+			default:
+				fmt.Fprintf(&section, "  #%d %s\n", i+1, msg.Passed)
+			}
+		}
+		if !strings.HasSuffix(testCase.SystemOut, "\n") {
+			testCase.SystemOut += "\n"
+		}
+		testCase.SystemOut += section.String()
+	}
+	return testCase
+}
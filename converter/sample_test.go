@@ -0,0 +1,74 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSamples(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","difficulty":"easy","samples":[
+			{"taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"podReady":{"passed":false}}},
+			{"taskPassed":true,"allAssertionsPassed":true},
+			{"taskPassed":false,"taskError":"boom"}]},
+		{"task_name":"b","samples":[{"task_passed":true,"all_assertions_passed":true},{"task_skipped":true,"skip_reason":"quota"}]},
+		{"taskName":"c","samples":[{"taskSkipped":true,"skipReason":"no sampling"}]}
+	]`)
+
+	tests := []struct {
+		policy string
+		// outcomes of a and b: "passed", "failure" or "error"
+		want [2]string
+	}{
+		{policy: SamplePolicyAny, want: [2]string{"passed", "passed"}},
+		{policy: SamplePolicyAll, want: [2]string{"failure", "passed"}},
+		{policy: SamplePolicyMajority, want: [2]string{"failure", "passed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			for i, result := range run.Results[:2] {
+				testCase := convertWithAttempts(result, options{SamplePolicy: tt.policy})
+				got := "passed"
+				if testCase.Failure != nil {
+					got = "failure"
+				} else if testCase.Error != nil {
+					got = "error"
+				}
+				if got != tt.want[i] {
+					t.Errorf("%s is %s, want %s", result.TaskName, got, tt.want[i])
+				}
+				if testCase.Name != result.TaskName {
+					t.Errorf("testcase name = %q, want %q", testCase.Name, result.TaskName)
+				}
+			}
+		})
+	}
+
+	a := convertWithAttempts(run.Results[0], options{})
+	if a.Classname != "tasks.a" || a.File != "/x/tasks/a/task.yaml" {
+		t.Errorf("testcase = %q, %q, want the identity of the task", a.Classname, a.File)
+	}
+	want := "\nSamples: 1/3 passed (pass@1=0.3333, pass@3=1)\n  #1 FAILED: Assertion failures: podReady\n  #2 PASSED\n  #3 FAILED: Test execution failed\n"
+	if !strings.HasSuffix(a.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to end with %q", a.SystemOut, want)
+	}
+	properties := map[string]string{}
+	for _, property := range a.Properties.Properties {
+		properties[property.Name] = property.Value
+	}
+	if properties[PassAt1Property] != "0.3333" || properties["pass@3"] != "1" {
+		t.Errorf("properties = %v, want pass@1=0.3333 and pass@3=1", properties)
+	}
+
+	// Skipped samples count toward neither side
+	b := convertWithAttempts(run.Results[1], options{Lang: LangPortuguese})
+	if len(b.Properties.Properties) != 1 {
+		t.Errorf("properties = %v, want pass@1 alone with a single sample that ran", b.Properties.Properties)
+	}
+	if want := "Amostras: 1/1 aprovadas (pass@1=1)\n  #1 APROVADO\n  #2 IGNORADO: quota\n"; !strings.HasSuffix(b.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to end with %q", b.SystemOut, want)
+	}
+	if c := convertWithAttempts(run.Results[2], options{}); c.Skipped == nil || c.Skipped.Message != "no sampling" {
+		t.Errorf("task with only skipped samples = %+v, want it skipped", c)
+	}
+}
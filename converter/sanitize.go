@@ -0,0 +1,80 @@
+package converter
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ansiEscape matches ANSI escape sequences: CSI sequences such as colors and
+// cursor movement, OSC sequences such as terminal titles and hyperlinks, and
+// the two-byte escapes
+var ansiEscape = regexp.MustCompile("\x1b(?:\\[[0-?]*[ -/]*[@-~]|\\][^\x07\x1b]*(?:\x07|\x1b\\\\)|[@-Z\\\\-_])")
+
+// isXMLChar reports whether r may appear in an XML 1.0 document
+func isXMLChar(r rune) bool {
+	return r == '\t' || r == '\n' || r == '\r' ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= utf8.MaxRune
+}
+
+// sanitizeText replaces the code points XML 1.0 does not allow, such as NUL
+// and most control characters, and invalid UTF-8 with U+FFFD, which JUnit
+// consumers like Jenkins would otherwise reject the whole report for. With
+// stripANSI, ANSI escape sequences are removed first so that colored tool
+// output does not turn into replacement characters.
+func sanitizeText(text string, stripANSI bool) string {
+	if stripANSI && strings.IndexByte(text, '\x1b') >= 0 {
+		text = ansiEscape.ReplaceAllString(text, "")
+	}
+	if utf8.ValidString(text) && strings.IndexFunc(text, func(r rune) bool { return !isXMLChar(r) }) < 0 {
+		return text
+	}
+
+	var sanitized strings.Builder
+	sanitized.Grow(len(text))
+	// Ranging over invalid UTF-8 yields U+FFFD for every bad byte
+	for _, r := range text {
+		if !isXMLChar(r) {
+			r = utf8.RuneError
+		}
+		sanitized.WriteRune(r)
+	}
+	return sanitized.String()
+}
+
+// sanitizeTestCase sanitizes every string of a testcase that ends up in the
+// report. Like redaction, it runs before the rerun elements of merged runs
+// are built from the attempts.
+func sanitizeTestCase(testCase *JUnitTestCase, stripANSI bool) {
+	sanitize := func(text *string) {
+		*text = sanitizeText(*text, stripANSI)
+	}
+	sanitize(&testCase.Name)
+	sanitize(&testCase.Classname)
+	sanitize(&testCase.SystemOut)
+	sanitize(&testCase.SystemErr)
+	if testCase.Skipped != nil {
+		sanitize(&testCase.Skipped.Message)
+	}
+	if testCase.Failure != nil {
+		sanitize(&testCase.Failure.Message)
+		sanitize(&testCase.Failure.Content)
+	}
+	if testCase.Error != nil {
+		sanitize(&testCase.Error.Message)
+		sanitize(&testCase.Error.Content)
+	}
+}
+
+// sanitizeProperties sanitizes the names and values of properties
+func sanitizeProperties(properties *JUnitProperties, stripANSI bool) {
+	if properties == nil {
+		return
+	}
+	for i, property := range properties.Properties {
+		properties.Properties[i].Name = sanitizeText(property.Name, stripANSI)
+		properties.Properties[i].Value = sanitizeText(property.Value, stripANSI)
+	}
+}
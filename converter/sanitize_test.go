@@ -0,0 +1,74 @@
+package converter
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		stripANSI bool
+		want      string
+	}{
+		{name: "clean", input: "línea 1\n\ttab\r\n😀 �", want: "línea 1\n\ttab\r\n😀 �"},
+		{name: "nul and control characters", input: "a\x00b\x08c\x7fd", want: "a�b�c\x7fd"},
+		{name: "invalid utf-8", input: "a\xffb\xc3", want: "a�b�"},
+		{name: "non-characters", input: "a￾b￿", want: "a�b�"},
+		{name: "ansi kept", input: "\x1b[31mred\x1b[0m", want: "�[31mred�[0m"},
+		{name: "ansi stripped", input: "\x1b[1;31mred\x1b[0m \x1b[2K\x1b]0;title\x07\x1b]8;;http://x\x1b\\link\x1b]8;;\x1b\\ \x1bMup", stripANSI: true, want: "red link up"},
+		{name: "lone escape stripped", input: "a\x1b", stripANSI: true, want: "a�"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeText(tt.input, tt.stripANSI); got != tt.want {
+				t.Errorf("sanitizeText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeReport(t *testing.T) {
+	run := mustParse(t, `{"runId":"run\u0001","results":[
+		{"taskName":"a\u0000","taskPassed":false,"taskError":"\u001b[31mboom\u001b[0m\u0007","taskOutput":"out\u000b"},
+		{"taskName":"a\u0000","taskPassed":true,"allAssertionsPassed":true}
+	]}`)
+	for _, stripANSI := range []bool{false, true} {
+		var opts []Option
+		if stripANSI {
+			opts = append(opts, WithStripANSI())
+		}
+		conv, err := New(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := conv.Convert(MergeReruns([]TestRun{run}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := conv.Render(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := xml.Unmarshal(out, &JUnitTestSuites{}); err != nil {
+			t.Errorf("report is not valid XML: %v\n%s", err, out)
+		}
+		if strings.ContainsAny(string(out), "\x00\x01\x07\x0b\x1b") {
+			t.Errorf("report contains control characters:\n%q", out)
+		}
+		wantBoom := "�[31mboom�[0m�"
+		if stripANSI {
+			wantBoom = "boom�"
+		}
+		testCase := report.Suites[0].TestCases[0]
+		if testCase.Name != "a�" || len(testCase.FlakyErrors) != 1 || testCase.FlakyErrors[0].SystemErr != wantBoom {
+			t.Errorf("testcase = %+v, want sanitized name and flaky error", testCase)
+		}
+		if value := report.Suites[0].Properties.Properties[0].Value; value != "run�" {
+			t.Errorf("runId property = %q", value)
+		}
+	}
+}
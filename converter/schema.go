@@ -0,0 +1,247 @@
+package converter
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// resultSchemaJSON is the JSON Schema describing a single MCPTestResult
+//
+//go:embed schema.json
+var resultSchemaJSON []byte
+
+// resultSchemaV2JSON describes a result in the v2 schema
+//
+//go:embed schema_v2.json
+var resultSchemaV2JSON []byte
+
+var (
+	resultSchema   = mustParseSchema(resultSchemaJSON)
+	resultSchemaV2 = mustParseSchema(resultSchemaV2JSON)
+)
+
+// envelopeIndex marks a SchemaError found in the envelope's own fields
+// rather than in one of its results
+const envelopeIndex = -1
+
+// SchemaError describes one violation of the input schema
+type SchemaError struct {
+	// Index is the position of the offending result in the input, or -1
+	// for the envelope's run metadata and for input that cannot be read
+	Index int
+	// Path locates the offending field within the result, e.g. "$.callHistory.ToolCalls[0].success"
+	Path    string
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	if e.Index == envelopeIndex && e.Path == "" {
+		return e.Message
+	}
+	if e.Index == envelopeIndex {
+		return fmt.Sprintf("envelope: %s: %s", e.Path, e.Message)
+	}
+	return fmt.Sprintf("result %d: %s: %s", e.Index, e.Path, e.Message)
+}
+
+// SchemaErrors collects every violation found in the input
+type SchemaErrors []SchemaError
+
+func (errs SchemaErrors) Error() string {
+	lines := make([]string, 0, len(errs)+1)
+	lines = append(lines, fmt.Sprintf("input does not match the result schema (%d problems):", len(errs)))
+	for _, err := range errs {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// jsonSchema is the subset of JSON Schema used by schema.json
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Required             []string               `json:"required"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Ref                  string                 `json:"$ref"`
+	Defs                 map[string]*jsonSchema `json:"$defs"`
+}
+
+// schemaTypes accepts both the string and the array form of the "type" keyword
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = schemaTypes{single}
+		return nil
+	}
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*t = multiple
+	return nil
+}
+
+// Schema returns the embedded JSON Schema of a single result in the given
+// schema version, 1 for the original camelCase fields or 2 for snake_case.
+// The envelope object is described under $defs of the version 1 schema.
+func Schema(version int) ([]byte, error) {
+	switch version {
+	case schemaVersion1:
+		return bytes.Clone(resultSchemaJSON), nil
+	case schemaVersion2:
+		return bytes.Clone(resultSchemaV2JSON), nil
+	default:
+		return nil, fmt.Errorf("%w: schema version %d", ErrUnsupportedSchema, version)
+	}
+}
+
+// Validate checks JSON or JSON Lines input against the embedded schemas,
+// like Parse with ParseOptions.Strict, and returns every violation, or nil
+// when the input is valid. Input that is empty or not valid JSON yields a
+// single SchemaError with Index -1 and no Path.
+func Validate(data []byte) []SchemaError {
+	_, err := Parse(bytes.NewReader(data), ParseOptions{Format: FormatNDJSON, Strict: true})
+	var schemaErrs SchemaErrors
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &schemaErrs):
+		return schemaErrs
+	default:
+		return []SchemaError{{Index: envelopeIndex, Message: err.Error()}}
+	}
+}
+
+func mustParseSchema(data []byte) *jsonSchema {
+	var schema jsonSchema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		panic(fmt.Sprintf("invalid embedded schema: %v", err))
+	}
+	return &schema
+}
+
+// validateResult checks a single raw result against the embedded schema of its version
+func validateResult(index, version int, data []byte) []SchemaError {
+	if version == schemaVersion2 {
+		return validateAgainst(resultSchemaV2, resultSchemaV2, index, data)
+	}
+	return validateAgainst(resultSchema, resultSchema, index, data)
+}
+
+// validateEnvelope checks the run metadata of an envelope object. Its
+// results are validated one by one with validateResult.
+func validateEnvelope(data []byte) []SchemaError {
+	return validateAgainst(resultSchema, resultSchema.Defs["envelope"], envelopeIndex, data)
+}
+
+// validateAgainst checks data against schema, resolving references in root
+func validateAgainst(root, schema *jsonSchema, index int, data []byte) []SchemaError {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []SchemaError{{Index: index, Path: "$", Message: err.Error()}}
+	}
+
+	v := &schemaValidator{root: root, index: index}
+	v.validate(schema, value, "$")
+	return v.errors
+}
+
+type schemaValidator struct {
+	root   *jsonSchema
+	index  int
+	errors []SchemaError
+}
+
+func (v *schemaValidator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, SchemaError{Index: v.index, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(schema *jsonSchema, value interface{}, path string) {
+	if schema.Ref != "" {
+		schema = v.resolve(schema.Ref)
+	}
+
+	actual := jsonTypeOf(value)
+	if len(schema.Type) > 0 && !typeMatches(schema.Type, actual) {
+		v.fail(path, "expected %s, got %s", strings.Join(schema.Type, " or "), actual)
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for _, name := range schema.Required {
+			if _, ok := value[name]; !ok {
+				v.fail(path+"."+name, "required field is missing")
+			}
+		}
+
+		names := make([]string, 0, len(value))
+		for name := range value {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if property, ok := schema.Properties[name]; ok {
+				v.validate(property, value[name], path+"."+name)
+			} else if schema.AdditionalProperties != nil {
+				v.validate(schema.AdditionalProperties, value[name], path+"."+name)
+			}
+		}
+	case []interface{}:
+		if schema.Items != nil {
+			for i, item := range value {
+				v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+// resolve looks up a local "#/$defs/<name>" reference
+func (v *schemaValidator) resolve(ref string) *jsonSchema {
+	name := strings.TrimPrefix(ref, "#/$defs/")
+	if schema, ok := v.root.Defs[name]; ok {
+		return schema
+	}
+	panic(fmt.Sprintf("unresolved schema reference %q", ref))
+}
+
+func jsonTypeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func typeMatches(expected []string, actual string) bool {
+	for _, t := range expected {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,200 @@
+package converter
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateResult(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "valid result",
+			input: resultA,
+		},
+		{
+			name: "valid result with nested fields and nulls",
+			input: `{"taskName":"a","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,
+				"assertionResults":{"x":{"passed":true}},
+				"callHistory":{"ToolCalls":[{"name":"t","success":true,"result":null}],"ResourceReads":null},
+				"setupOutput":{"Success":true,"Error":""},"cleanupOutput":null}`,
+		},
+		{
+			name:  "wrong scalar type",
+			input: `{"taskName":"a","taskPassed":"yes","difficulty":"easy","allAssertionsPassed":true}`,
+			want:  []string{"result 3: $.taskPassed: expected boolean, got string"},
+		},
+		{
+			name:  "missing required fields",
+			input: `{"taskPassed":true,"allAssertionsPassed":true}`,
+			want: []string{
+				"result 3: $.taskName: required field is missing",
+				"result 3: $.difficulty: required field is missing",
+			},
+		},
+		{
+			name: "nested array item",
+			input: `{"taskName":"a","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,
+				"callHistory":{"ToolCalls":[{"name":"t","success":true},{"name":"u","success":1}]}}`,
+			want: []string{"result 3: $.callHistory.ToolCalls[1].success: expected boolean, got integer"},
+		},
+		{
+			name: "additional properties schema",
+			input: `{"taskName":"a","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,
+				"assertionResults":{"x":{"passed":"no"},"y":{}}}`,
+			want: []string{
+				"result 3: $.assertionResults.x.passed: expected boolean, got string",
+				"result 3: $.assertionResults.y.passed: required field is missing",
+			},
+		},
+		{
+			name:  "not an object",
+			input: `"a"`,
+			want:  []string{"result 3: $: expected object, got string"},
+		},
+		{
+			name:  "unknown fields are allowed",
+			input: `{"taskName":"a","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,"extra":[1,2]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateResult(3, schemaVersion1, []byte(tt.input))
+			got := make([]string, 0, len(errs))
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("validateResult() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateEnvelope(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "valid envelope",
+			input: `{"runId":"r","startedAt":"2026-01-01T00:00:00Z","results":[{"anything":1}]}`,
+		},
+		{
+			name:  "wrong metadata types",
+			input: `{"runId":5,"startedAt":true,"results":{}}`,
+			want: []string{
+				"envelope: $.results: expected array, got object",
+				"envelope: $.runId: expected string, got integer",
+				"envelope: $.startedAt: expected string, got boolean",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateEnvelope([]byte(tt.input))
+			got := make([]string, 0, len(errs))
+			for _, err := range errs {
+				got = append(got, err.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("validateEnvelope() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseResultsStrict(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantErrors int
+	}{
+		{name: "valid array", input: "[" + resultA + "," + resultB + "]"},
+		{name: "valid envelope", input: `{"runId":"r","results":[` + resultA + `]}`},
+		{name: "invalid array elements", input: `[` + resultA + `,{"taskPassed":1},{"taskName":2}]`, wantErrors: 8},
+		{name: "invalid envelope metadata", input: `{"runId":1,"results":[` + resultA + `]}`, wantErrors: 1},
+		{name: "invalid json line", input: resultA + "\n" + `{"taskName":"b"}`, wantErrors: 3},
+		{name: "invalid yaml document", input: "- taskName: a\n", wantErrors: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := FormatAuto
+			if strings.HasPrefix(tt.input, "- ") {
+				format = FormatYAML
+			}
+			_, err := Parse(strings.NewReader(tt.input), ParseOptions{Format: format, Strict: true})
+			if tt.wantErrors == 0 {
+				if err != nil {
+					t.Fatalf("Parse() error = %v", err)
+				}
+				return
+			}
+
+			var schemaErrs SchemaErrors
+			if !errors.As(err, &schemaErrs) {
+				t.Fatalf("Parse() error = %v, want SchemaErrors", err)
+			}
+			if len(schemaErrs) != tt.wantErrors {
+				t.Errorf("got %d schema errors, want %d:\n%v", len(schemaErrs), tt.wantErrors, err)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantErrors int
+		// wantMessage is part of the message of the first error
+		wantMessage string
+	}{
+		{name: "valid array", input: "[" + resultA + "," + resultB + "]"},
+		{name: "valid json lines", input: resultA + "\n" + resultB + "\n"},
+		{name: "valid envelope", input: `{"runId":"r","results":[` + resultA + `]}`},
+		{name: "invalid elements", input: `[` + resultA + `,{"taskPassed":1},{"taskName":2}]`, wantErrors: 8},
+		{name: "invalid envelope metadata", input: `{"runId":1,"results":[` + resultA + `]}`, wantErrors: 1},
+		{name: "malformed json", input: `[` + resultA + `,{`, wantErrors: 1, wantMessage: "invalid JSON"},
+		{name: "empty", input: " ", wantErrors: 1, wantMessage: "input is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := Validate([]byte(tt.input))
+			if len(errs) != tt.wantErrors {
+				t.Fatalf("Validate() returned %d errors, want %d: %v", len(errs), tt.wantErrors, errs)
+			}
+			if tt.wantMessage != "" && (!strings.Contains(errs[0].Error(), tt.wantMessage) || errs[0].Index != -1) {
+				t.Errorf("Validate() error = %+v, want an input error containing %q", errs[0], tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	for _, version := range []int{1, 2} {
+		schema, err := Schema(version)
+		if err != nil {
+			t.Fatalf("Schema(%d) error = %v", version, err)
+		}
+		if parsed := mustParseSchema(schema); parsed.Properties == nil {
+			t.Errorf("Schema(%d) has no properties", version)
+		}
+		schema[0] = 'x'
+		if again, _ := Schema(version); again[0] == 'x' {
+			t.Errorf("Schema(%d) returned the embedded bytes rather than a copy", version)
+		}
+	}
+	if _, err := Schema(3); !errors.Is(err, ErrUnsupportedSchema) {
+		t.Errorf("Schema(3) error = %v, want ErrUnsupportedSchema", err)
+	}
+}
@@ -0,0 +1,138 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// ExpectedCall is a tool call the task definition expects the agent to
+// make, in the order of the expectedCalls of the result
+type ExpectedCall struct {
+	// ServerName, when set, is the MCP server the tool must be called on
+	ServerName string `json:"serverName,omitempty"`
+	Name       string `json:"name"`
+}
+
+// callLabels names the expected and actual tool calls the way they are
+// compared: by server and tool when every expected call names its server,
+// otherwise by tool alone
+func callLabels(expected []ExpectedCall, actual []ToolCall) ([]string, []string) {
+	withServer := !slices.ContainsFunc(expected, func(call ExpectedCall) bool { return call.ServerName == "" })
+	label := func(server, name string) string {
+		if withServer {
+			return server + "::" + name
+		}
+		return name
+	}
+	expectedLabels := make([]string, len(expected))
+	for i, call := range expected {
+		expectedLabels[i] = label(call.ServerName, call.Name)
+	}
+	actualLabels := make([]string, len(actual))
+	for i, call := range actual {
+		actualLabels[i] = label(call.ServerName, call.Name)
+	}
+	return expectedLabels, actualLabels
+}
+
+// callSequenceDiff lays out the expected tool calls of a result beside
+// those the agent made, as lineDiff aligns them, then lists the calls that
+// are missing, unexpected or out of order. It returns "" when the result
+// expects no calls or the agent made exactly the expected ones.
+func callSequenceDiff(test MCPTestResult, msg *messages) string {
+	if len(test.ExpectedCalls) == 0 {
+		return ""
+	}
+	expected, actual := callLabels(test.ExpectedCalls, test.CallHistory.ToolCalls)
+	if slices.Equal(expected, actual) {
+		return ""
+	}
+
+	// Rows pair a run of missing calls with the unexpected calls that
+	// follow, so that a call replaced by another shows on one line
+	type row struct{ mark, expected, actual string }
+	var rows []row
+	var removed, added []string
+	flush := func() {
+		for i := range max(len(removed), len(added)) {
+			r := row{mark: "-"}
+			if i < len(removed) {
+				r.expected = removed[i]
+			}
+			if i < len(added) {
+				r.actual = added[i]
+				r.mark = "+"
+				if i < len(removed) {
+					r.mark = "!"
+				}
+			}
+			rows = append(rows, r)
+		}
+		removed, added = nil, nil
+	}
+	for _, line := range lineDiff(expected, actual) {
+		switch mark, call := line[:2], line[2:]; mark {
+		case "- ":
+			if len(added) > 0 {
+				flush()
+			}
+			removed = append(removed, call)
+		case "+ ":
+			added = append(added, call)
+		default:
+			flush()
+			rows = append(rows, row{mark: " ", expected: call, actual: call})
+		}
+	}
+	flush()
+
+	width := utf8.RuneCountInString(msg.Expected)
+	for _, r := range rows {
+		width = max(width, utf8.RuneCountInString(r.expected))
+	}
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "    %-*s | %s\n", width, msg.Expected, msg.Actual)
+	for _, r := range rows {
+		diff.WriteString(strings.TrimRight(fmt.Sprintf("  %s %-*s | %s", r.mark, width, r.expected, r.actual), " ") + "\n")
+	}
+
+	// A call both missing and unexpected was made, but not where expected
+	missing, unexpected := countDiff(expected, actual), countDiff(actual, expected)
+	var outOfOrder []string
+	for _, r := range rows {
+		for _, call := range []string{r.expected, r.actual} {
+			if call != "" && r.mark != " " && !slices.Contains(missing, call) && !slices.Contains(unexpected, call) && !slices.Contains(outOfOrder, call) {
+				outOfOrder = append(outOfOrder, call)
+			}
+		}
+	}
+	for _, summary := range []struct {
+		label string
+		calls []string
+	}{{msg.MissingCalls, missing}, {msg.UnexpectedCalls, unexpected}, {msg.OutOfOrderCalls, outOfOrder}} {
+		if len(summary.calls) > 0 {
+			fmt.Fprintf(&diff, "  %s: %s\n", summary.label, strings.Join(summary.calls, ", "))
+		}
+	}
+	return diff.String()
+}
+
+// countDiff returns the calls of a that b has fewer of, once per call
+// missing from b, in the order of a
+func countDiff(a, b []string) []string {
+	remaining := make(map[string]int)
+	for _, call := range b {
+		remaining[call]++
+	}
+	var diff []string
+	for _, call := range a {
+		if remaining[call] > 0 {
+			remaining[call]--
+			continue
+		}
+		diff = append(diff, call)
+	}
+	return diff
+}
@@ -0,0 +1,101 @@
+package converter
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCallSequenceDiff(t *testing.T) {
+	calls := func(names ...string) []ToolCall {
+		var toolCalls []ToolCall
+		for _, name := range names {
+			toolCalls = append(toolCalls, ToolCall{ServerName: "k8s", Name: name, Success: true})
+		}
+		return toolCalls
+	}
+	expect := func(server string, names ...string) []ExpectedCall {
+		var expected []ExpectedCall
+		for _, name := range names {
+			expected = append(expected, ExpectedCall{ServerName: server, Name: name})
+		}
+		return expected
+	}
+
+	tests := []struct {
+		name     string
+		expected []ExpectedCall
+		actual   []ToolCall
+		want     string
+	}{
+		{
+			name:   "no expected calls",
+			actual: calls("pods_list"),
+			want:   "",
+		},
+		{
+			name:     "same sequence",
+			expected: expect("", "pods_list", "pods_get"),
+			actual:   calls("pods_list", "pods_get"),
+			want:     "",
+		},
+		{
+			name:     "missing and unexpected",
+			expected: expect("", "pods_list", "pods_delete", "pods_get"),
+			actual:   calls("pods_list", "pods_exec", "pods_get", "events_list"),
+			want: "" +
+				"    expected    | actual\n" +
+				"    pods_list   | pods_list\n" +
+				"  ! pods_delete | pods_exec\n" +
+				"    pods_get    | pods_get\n" +
+				"  +             | events_list\n" +
+				"  Missing: pods_delete\n" +
+				"  Unexpected: pods_exec, events_list\n",
+		},
+		{
+			name:     "wrong order",
+			expected: expect("k8s", "pods_list", "pods_get"),
+			actual:   calls("pods_get", "pods_list"),
+			want: "" +
+				"    expected       | actual\n" +
+				"  - k8s::pods_list |\n" +
+				"    k8s::pods_get  | k8s::pods_get\n" +
+				"  +                | k8s::pods_list\n" +
+				"  Out of order: k8s::pods_list\n",
+		},
+		{
+			name:     "wrong server",
+			expected: expect("helm", "pods_list"),
+			actual:   calls("pods_list"),
+			want: "" +
+				"    expected        | actual\n" +
+				"  ! helm::pods_list | k8s::pods_list\n" +
+				"  Missing: helm::pods_list\n" +
+				"  Unexpected: k8s::pods_list\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			test := MCPTestResult{ExpectedCalls: tt.expected, CallHistory: CallHistory{ToolCalls: tt.actual}}
+			if got := callSequenceDiff(test, catalog(LangEnglish)); got != tt.want {
+				t.Errorf("callSequenceDiff() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallSequenceInFailureContent(t *testing.T) {
+	run := mustParse(t, `[
+		{"task_name":"a","difficulty":"easy","task_passed":true,"all_assertions_passed":false,
+		 "assertion_results":{"toolsUsed":{"passed":false}},
+		 "call_history":{"tool_calls":[{"server_name":"k8s","name":"pods_get","success":true}]},
+		 "expected_calls":[{"server_name":"k8s","name":"pods_list"},{"server_name":"k8s","name":"pods_get"}]}
+	]`)
+	testCase := convertTestCase(run.Results[0], options{})
+	if testCase.Failure == nil {
+		t.Fatal("want a failure")
+	}
+	want := "\nTool Call Sequence:\n    expected       | actual\n  - k8s::pods_list |\n    k8s::pods_get  | k8s::pods_get\n  Missing: k8s::pods_list\n"
+	if !strings.Contains(testCase.Failure.Content, want) {
+		t.Errorf("failure content = %q, want it to contain %q", testCase.Failure.Content, want)
+	}
+}
@@ -0,0 +1,449 @@
+package converter
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/junit"
+)
+
+// ResultStream is a source of results read one at a time, such as a
+// ResultIterator. The run metadata is only read once Next has returned
+// io.EOF, since envelopes may give it after their results.
+type ResultStream interface {
+	Next() (MCPTestResult, error)
+	RunID() string
+	StartedAt() string
+	SuiteName() string
+	Environment() *Environment
+}
+
+// Streamable reports whether ConvertStream supports the options of the
+// converter. Nested suites and the schema check need the whole report, so
+// converters configured with WithNestedSuites or WithCheck are not.
+func (c *Converter) Streamable() bool {
+	return !c.opts.NestedSuites && !c.opts.Check
+}
+
+// ConvertStream converts the results of a stream and writes the report to
+// w: the same document as Convert followed by Render, without ever holding
+// more than one result in memory. Testcases are spilled to a temporary file
+// as they are converted, until the counts of every suite are known, and
+// written from there. Tasks whose attachment directories collide are
+// numbered in input order rather than report order.
+//
+// The returned report holds the suites and testcases of the document, for
+// the gates, but the testcases lack their output, reruns and failure
+// content.
+func (c *Converter) ConvertStream(ctx context.Context, results ResultStream, w io.Writer) (JUnitTestSuites, error) {
+	opts := c.opts
+	if !c.Streamable() {
+		return JUnitTestSuites{}, errors.New("nested suites and the schema check need the whole report, convert it instead of streaming it")
+	}
+	var attachments *attachmentWriter
+	if opts.AttachmentsDir != "" {
+		var err error
+		if attachments, err = newAttachmentWriter(opts.AttachmentsDir, opts.Redactions); err != nil {
+			return JUnitTestSuites{}, err
+		}
+	}
+	spill, err := newSpillFile()
+	if err != nil {
+		return JUnitTestSuites{}, err
+	}
+	defer spill.close()
+
+	groups, err := spillGroups(ctx, results, opts, attachments, spill)
+	if err != nil {
+		return JUnitTestSuites{}, err
+	}
+	run := TestRun{RunID: results.RunID(), StartedAt: results.StartedAt(), SuiteName: results.SuiteName(), Environment: results.Environment()}
+	report, err := streamReport(run, groups, opts, spill)
+	if err != nil {
+		return JUnitTestSuites{}, err
+	}
+	if err := writeStreamedReport(ctx, w, report, groups, opts, spill); err != nil {
+		return JUnitTestSuites{}, err
+	}
+	return report, nil
+}
+
+// streamGroup is a group of results whose testcases were spilled
+type streamGroup struct {
+	key string
+	// first is the result the suite name template is executed with, the
+	// first in report order, and stubs the results of the group without
+	// their outputs, for the suite properties
+	first *MCPTestResult
+	stubs []MCPTestResult
+	// entries are the spilled testcases of the group
+	entries []spilledTestCase
+}
+
+// spilledTestCase locates a testcase in the spill file. header is the
+// testcase without its content and source the index of its result in the
+// stubs of its group.
+type spilledTestCase struct {
+	header JUnitTestCase
+	offset int64
+	size   int64
+	source int
+}
+
+// spillGroups reads, filters, groups and converts the results of a stream,
+// spilling their testcases, and returns the groups in report order
+func spillGroups(ctx context.Context, results ResultStream, opts options, attachments *attachmentWriter, spill *spillFile) ([]*streamGroup, error) {
+	var groups []*streamGroup
+	index := make(map[string]*streamGroup)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result, err := results.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if !opts.Filter.keep(result) {
+			continue
+		}
+		key := groupKey(result, opts.GroupBy)
+		group, ok := index[key]
+		if !ok {
+			group = &streamGroup{key: key}
+			index[key] = group
+			groups = append(groups, group)
+		}
+		testCases, err := convertOne(result, key, opts, attachments)
+		if err != nil {
+			return nil, err
+		}
+		for _, testCase := range testCases {
+			entry, err := spill.write(testCase)
+			if err != nil {
+				return nil, err
+			}
+			entry.source = len(group.stubs)
+			group.entries = append(group.entries, entry)
+		}
+		if opts.SuiteNameTemplate != nil && (group.first == nil || opts.Sort == SortSorted && result.TaskName < group.first.TaskName) {
+			group.first = &result
+		}
+		group.stubs = append(group.stubs, resultStub(result))
+	}
+
+	if opts.Sort == SortSorted {
+		slices.SortStableFunc(groups, func(a, b *streamGroup) int {
+			return compareGroupKeys(a.key, b.key, opts.GroupBy)
+		})
+		for _, group := range groups {
+			group.sortEntries()
+		}
+	}
+	return groups, nil
+}
+
+// sortEntries orders the stubs and testcases of a group by task name, like
+// sortGroups orders results
+func (g *streamGroup) sortEntries() {
+	order := make([]int, len(g.stubs))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(g.stubs[a].TaskName, g.stubs[b].TaskName)
+	})
+	rank := make([]int, len(order))
+	stubs := make([]MCPTestResult, len(order))
+	for i, source := range order {
+		rank[source] = i
+		stubs[i] = g.stubs[source]
+	}
+	for i := range g.entries {
+		g.entries[i].source = rank[g.entries[i].source]
+	}
+	slices.SortStableFunc(g.entries, func(a, b spilledTestCase) int {
+		return a.source - b.source
+	})
+	g.stubs = stubs
+}
+
+// resultStub returns what the suite properties and duplicate suffixes need
+// of a result: its name, path, level, tags, usage, phase durations and the
+// servers it called
+func resultStub(result MCPTestResult) MCPTestResult {
+	stub := MCPTestResult{
+		TaskName:      result.TaskName,
+		TaskPath:      result.TaskPath,
+		Difficulty:    result.Difficulty,
+		Tags:          result.Tags,
+		TokenUsage:    result.TokenUsage,
+		CostUSD:       result.CostUSD,
+		SetupOutput:   PhaseOutput{DurationMs: result.SetupOutput.DurationMs},
+		AgentOutput:   PhaseOutput{DurationMs: result.AgentOutput.DurationMs},
+		VerifyOutput:  PhaseOutput{DurationMs: result.VerifyOutput.DurationMs},
+		CleanupOutput: PhaseOutput{DurationMs: result.CleanupOutput.DurationMs},
+		Attempts:      make([]MCPTestResult, len(result.Attempts)),
+	}
+	for _, call := range result.CallHistory.ToolCalls {
+		stub.CallHistory.ToolCalls = append(stub.CallHistory.ToolCalls, ToolCall{ServerName: call.ServerName})
+	}
+	for _, read := range result.CallHistory.ResourceReads {
+		stub.CallHistory.ResourceReads = append(stub.CallHistory.ResourceReads, ResourceRead{ServerName: read.ServerName})
+	}
+	return stub
+}
+
+// streamReport builds the report of the spilled groups, with the headers of
+// their testcases, resolving the duplicates of each suite
+func streamReport(run TestRun, groups []*streamGroup, opts options, spill *spillFile) (JUnitTestSuites, error) {
+	suites := JUnitTestSuites{}
+	properties := runProperties(run, opts.Properties)
+	sanitizeProperties(properties, opts.StripANSI)
+	timestamp := formatTimestamp(run.StartedAt)
+	if timestamp == "" && opts.Clock != nil {
+		timestamp = opts.Clock().UTC().Format(junitTimestampLayout)
+	}
+
+	for _, group := range groups {
+		name := suiteName(suitePrefix(run, opts), group.key, opts.GroupBy)
+		if opts.SuiteNameTemplate != nil {
+			var err error
+			name, err = executeNameTemplate(opts.SuiteNameTemplate, newTemplateData(*group.first, group.key, opts))
+			if err != nil {
+				return suites, err
+			}
+		}
+		suite := JUnitTestSuite{
+			Name:       sanitizeText(name, opts.StripANSI),
+			Timestamp:  timestamp,
+			Properties: withDurationProperties(withTagsProperty(withUsageProperties(properties, group.stubs), group.stubs), group.stubs),
+		}
+		if err := group.resolveDuplicates(suite.Name, opts.OnDuplicate, spill); err != nil {
+			return suites, err
+		}
+		for _, entry := range group.entries {
+			suite.TestCases = append(suite.TestCases, entry.header)
+			countTestCase(&suite, entry.header, group.stubs[entry.source])
+		}
+		suite.Tests = len(suite.TestCases)
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	// Some CI parsers reject a report without any testsuite, so a run
+	// without results gets a single empty one
+	if len(suites.Suites) == 0 {
+		suites.Suites = append(suites.Suites, JUnitTestSuite{
+			Name:       suiteName(suitePrefix(run, opts), "", GroupByNone),
+			Timestamp:  timestamp,
+			Properties: properties,
+			TestCases:  []JUnitTestCase{},
+		})
+	}
+
+	suites.Name = opts.ReportName
+	if reportProperties := slices.Concat(run.Environment.Properties(), opts.ReportProperties); len(reportProperties) > 0 {
+		suites.Properties = &JUnitProperties{Properties: reportProperties}
+		sanitizeProperties(suites.Properties, opts.StripANSI)
+	}
+	suites.SetAggregates()
+	return suites, nil
+}
+
+// resolveDuplicates applies the duplicate policy to the testcases of the
+// group. Only groups that have duplicates are read back from the spill
+// file, and their resolved testcases spilled again.
+func (g *streamGroup) resolveDuplicates(suiteName, policy string, spill *spillFile) error {
+	headers := make([]JUnitTestCase, len(g.entries))
+	sources := make([]MCPTestResult, len(g.entries))
+	for i, entry := range g.entries {
+		headers[i] = entry.header
+		sources[i] = g.stubs[entry.source]
+	}
+	if policy != DuplicateMerge || !hasDuplicates(headers) {
+		// Suffixes only rename testcases, which read takes from the headers
+		resolved, _, err := resolveDuplicates(headers, sources, suiteName, policy)
+		if err != nil {
+			return err
+		}
+		for i := range g.entries {
+			g.entries[i].header = resolved[i]
+		}
+		return nil
+	}
+
+	// Merged testcases keep the result of the first of their duplicates
+	testCases := make([]JUnitTestCase, len(g.entries))
+	var mergedSources []int
+	seen := make(map[string]bool)
+	for i, entry := range g.entries {
+		var err error
+		if testCases[i], err = spill.read(entry); err != nil {
+			return err
+		}
+		if key := entry.header.Classname + "\x00" + entry.header.Name; !seen[key] {
+			seen[key] = true
+			mergedSources = append(mergedSources, entry.source)
+		}
+	}
+	merged, _, err := resolveDuplicates(testCases, sources, suiteName, policy)
+	if err != nil {
+		return err
+	}
+	entries := make([]spilledTestCase, len(merged))
+	for i, testCase := range merged {
+		if entries[i], err = spill.write(testCase); err != nil {
+			return err
+		}
+		entries[i].source = mergedSources[i]
+	}
+	g.entries = entries
+	return nil
+}
+
+// hasDuplicates reports whether testcases share a classname and name
+func hasDuplicates(testCases []JUnitTestCase) bool {
+	seen := make(map[string]bool, len(testCases))
+	for _, testCase := range testCases {
+		key := testCase.Classname + "\x00" + testCase.Name
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+	}
+	return false
+}
+
+// writeStreamedReport writes the report, reading the testcases of each
+// suite back from the spill file
+func writeStreamedReport(ctx context.Context, w io.Writer, report JUnitTestSuites, groups []*streamGroup, opts options, spill *spillFile) error {
+	buffered := bufio.NewWriter(w)
+	writer := junit.NewStreamWriter(buffered, opts.Indent)
+	root := junit.Root{Name: report.Name, Tests: report.Tests, Failures: report.Failures, Errors: report.Errors, Skipped: report.Skipped, Time: report.Time}
+	if report.Properties != nil {
+		root.Properties = report.Properties.Properties
+	}
+	if err := writer.SetRoot(root); err != nil {
+		return err
+	}
+	for i, suite := range report.Suites {
+		start := junit.Suite{Name: suite.Name, Tests: suite.Tests, Failures: suite.Failures, Errors: suite.Errors, Skipped: suite.Skipped, Time: suite.Time, Timestamp: suite.Timestamp}
+		if suite.Properties != nil {
+			start.Properties = suite.Properties.Properties
+		}
+		if err := writer.WriteSuiteStart(start); err != nil {
+			return fmt.Errorf("generating XML: %w", err)
+		}
+		if i < len(groups) {
+			for _, entry := range groups[i].entries {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				testCase, err := spill.read(entry)
+				if err != nil {
+					return err
+				}
+				if err := writer.WriteTestCase(testCase); err != nil {
+					return fmt.Errorf("generating XML: %w", err)
+				}
+			}
+		}
+		if err := writer.WriteSuiteEnd(); err != nil {
+			return fmt.Errorf("generating XML: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("generating XML: %w", err)
+	}
+	return buffered.Flush()
+}
+
+// spillFile is a temporary file holding converted testcases as JSON, removed
+// by close
+type spillFile struct {
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+	// flushed is set while the file holds everything written
+	flushed bool
+}
+
+// newSpillFile creates a spill file in the temporary directory
+func newSpillFile() (*spillFile, error) {
+	file, err := os.CreateTemp("", "mcpchecker-junit-*.spill")
+	if err != nil {
+		return nil, fmt.Errorf("creating the spill file: %w", err)
+	}
+	return &spillFile{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// write appends a testcase, returning where it was written with its header
+func (s *spillFile) write(testCase JUnitTestCase) (spilledTestCase, error) {
+	data, err := json.Marshal(testCase)
+	if err != nil {
+		return spilledTestCase{}, fmt.Errorf("spilling testcase %q: %w", testCase.Name, err)
+	}
+	if _, err := s.writer.Write(data); err != nil {
+		return spilledTestCase{}, fmt.Errorf("spilling testcase %q: %w", testCase.Name, err)
+	}
+	entry := spilledTestCase{header: testCaseHeader(testCase), offset: s.size, size: int64(len(data))}
+	s.size += int64(len(data))
+	s.flushed = false
+	return entry, nil
+}
+
+// read returns the testcase of entry, named as its header since suffixes
+// may have renamed it after it was spilled
+func (s *spillFile) read(entry spilledTestCase) (JUnitTestCase, error) {
+	if !s.flushed {
+		if err := s.writer.Flush(); err != nil {
+			return JUnitTestCase{}, fmt.Errorf("spilling testcases: %w", err)
+		}
+		s.flushed = true
+	}
+	var testCase JUnitTestCase
+	if err := json.NewDecoder(io.NewSectionReader(s.file, entry.offset, entry.size)).Decode(&testCase); err != nil {
+		return JUnitTestCase{}, fmt.Errorf("reading back testcase %q: %w", entry.header.Name, err)
+	}
+	testCase.Name = entry.header.Name
+	testCase.difficulty = entry.header.difficulty
+	testCase.cdata = entry.header.cdata
+	return testCase, nil
+}
+
+// close removes the spill file
+func (s *spillFile) close() {
+	s.file.Close()
+	os.Remove(s.file.Name())
+}
+
+// testCaseHeader returns a testcase without its output, reruns and failure
+// content, which is all the gates and the duplicate policies need
+func testCaseHeader(testCase JUnitTestCase) JUnitTestCase {
+	header := JUnitTestCase{
+		Name:       testCase.Name,
+		Classname:  testCase.Classname,
+		Time:       testCase.Time,
+		File:       testCase.File,
+		Line:       testCase.Line,
+		Properties: testCase.Properties,
+		Skipped:    testCase.Skipped,
+		difficulty: testCase.difficulty,
+		cdata:      testCase.cdata,
+	}
+	if testCase.Failure != nil {
+		header.Failure = &JUnitFailure{Message: testCase.Failure.Message, Type: testCase.Failure.Type}
+	}
+	if testCase.Error != nil {
+		header.Error = &JUnitError{Message: testCase.Error.Message, Type: testCase.Error.Type}
+	}
+	return header
+}
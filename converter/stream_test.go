@@ -0,0 +1,245 @@
+package converter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/metrics"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"text/template"
+	"time"
+)
+
+// streamInput exercises what the suite properties and the duplicate
+// policies need of the results, with the run metadata after the results
+const streamInput = `{"results":[
+	{"taskName":"b","taskPath":"/x/tasks/b/task.yaml","difficulty":"hard","tags":["k8s"],"taskPassed":false,"taskError":"boom","allAssertionsPassed":false,
+	 "tokenUsage":{"prompt":10,"completion":5},"costUSD":0.1,"agentOutput":{"Success":false,"DurationMs":1200},
+	 "attempts":[{"taskName":"b","taskPassed":false,"taskError":"flaky"}]},
+	{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","difficulty":"easy","tags":["smoke"],"taskPassed":true,"allAssertionsPassed":true,"taskOutput":"ok",
+	 "costUSD":0.2,"callHistory":{"ToolCalls":[{"serverName":"kube","name":"get","success":true}]}},
+	{"taskName":"a","taskPath":"/y/tasks/a/task.yaml","difficulty":"easy","taskPassed":false,"allAssertionsPassed":false,
+	 "assertionResults":{"pods-ready":{"passed":false},"called":{"passed":true}},"costUSD":0.3,
+	 "callHistory":{"ToolCalls":[{"serverName":"helm","name":"list","success":true}]}},
+	{"taskName":"c","difficulty":"medium","taskSkipped":true,"skipReason":"no sampling"},
+	{"taskName":"d","taskPassed":true,"allAssertionsPassed":true,"verifyOutput":{"Success":true,"DurationMs":300}}
+],"runId":"run-1","startedAt":"2026-01-02T03:04:05Z","environment":{"model":"m1"}}`
+
+// streamed converts input with ConvertStream
+func streamed(t *testing.T, conv *Converter, input string) (JUnitTestSuites, []byte, error) {
+	t.Helper()
+	var out bytes.Buffer
+	report, err := conv.ConvertStream(context.Background(), NewResultIterator(strings.NewReader(input)), &out)
+	return report, out.Bytes(), err
+}
+
+func TestConvertStreamMatchesRender(t *testing.T) {
+	clock := func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	suiteTemplate := template.Must(ParseNameTemplate("suite", "{{.Group}}-{{.TaskName}}"))
+	tests := []struct {
+		name  string
+		input string
+		opts  []Option
+	}{
+		{name: "defaults", input: streamInput},
+		{name: "suffix duplicates", input: streamInput, opts: []Option{WithOnDuplicate(DuplicateSuffix)}},
+		{name: "merge duplicates", input: streamInput, opts: []Option{WithOnDuplicate(DuplicateMerge), WithoutCDATA()}},
+		{name: "input order on one line", input: streamInput, opts: []Option{WithSort(SortOriginal), WithGroupBy(GroupByNone), WithIndent("")}},
+		{name: "suite name template", input: streamInput, opts: []Option{WithSuiteNameTemplate(suiteTemplate), WithReportName("nightly")}},
+		{name: "exploded assertions", input: streamInput, opts: []Option{WithExplodedAssertions(), WithGroupBy(GroupByTaskDir)}},
+		{name: "filter", input: streamInput, opts: []Option{WithFilter(TaskFilter{Tags: []string{"k8s", "smoke"}}), WithProperties(JUnitProperty{Name: "branch", Value: "main"})}},
+		{name: "no results", input: "[]", opts: []Option{WithClock(clock)}},
+		{name: "named run", input: strings.Replace(streamInput, `"runId"`, `"scenarioSet":"nightly-k8s","runId"`, 1)},
+		{name: "suite prefix", input: streamInput, opts: []Option{WithSuitePrefix("release"), WithGroupBy(GroupByNone)}},
+		{name: "json lines", input: resultA + "\n" + resultB + "\n" + resultC + "\n" + resultV2A},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conv, err := New(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			run, err := Parse(strings.NewReader(tt.input), ParseOptions{AllowEmpty: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			report, err := conv.Convert(run)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := conv.Render(report)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			headers, got, err := streamed(t, conv, tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("streamed report differs from the rendered one\ngot:\n%s\nwant:\n%s", got, want)
+			}
+			tests, failures, errors := headers.Totals()
+			wantTests, wantFailures, wantErrors := report.Totals()
+			if tests != wantTests || failures != wantFailures || errors != wantErrors {
+				t.Errorf("totals = %d, %d, %d, want %d, %d, %d", tests, failures, errors, wantTests, wantFailures, wantErrors)
+			}
+			for i, suite := range headers.Suites {
+				for j, testCase := range suite.TestCases {
+					if testCase.SystemOut != "" || testCase.Difficulty() != report.Suites[i].TestCases[j].Difficulty() {
+						t.Errorf("header of %s = %+v, want it without output and with the difficulty", testCase.Name, testCase)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestConvertStreamErrors(t *testing.T) {
+	conv, err := New(WithOnDuplicate(DuplicateError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := streamed(t, conv, streamInput); !errors.Is(err, ErrDuplicateTestCase) {
+		t.Errorf("err = %v, want %v", err, ErrDuplicateTestCase)
+	}
+	if _, _, err := streamed(t, conv, "["+resultA+",{"); !errors.Is(err, ErrInvalidJSON) {
+		t.Errorf("err = %v, want %v", err, ErrInvalidJSON)
+	}
+
+	nested, err := New(WithNestedSuites())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nested.Streamable() {
+		t.Error("Streamable() = true with nested suites")
+	}
+	if _, _, err := streamed(t, nested, streamInput); err == nil {
+		t.Error("streaming nested suites succeeded, want an error")
+	}
+}
+
+// benchmarkInputMB is the size of the generated input of the benchmarks, in
+// MiB, set with MCPJUNIT_BENCH_INPUT_MB, e.g. to 4096 to convert a 4 GiB run
+func benchmarkInputMB(b *testing.B) int {
+	size := 16
+	if value := os.Getenv("MCPJUNIT_BENCH_INPUT_MB"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil {
+			b.Fatalf("MCPJUNIT_BENCH_INPUT_MB: %v", err)
+		}
+	}
+	return size
+}
+
+// generatedResults is a JSON Lines input of results with 64 KiB of task
+// output each, generated as it is read
+type generatedResults struct {
+	remaining int64
+	count     int
+	line      []byte
+	output    string
+}
+
+// newGeneratedResults returns an input of about size bytes
+func newGeneratedResults(size int64) *generatedResults {
+	return &generatedResults{remaining: size, output: strings.Repeat("log line of the agent\\n", 64<<10/23)}
+}
+
+func (g *generatedResults) Read(p []byte) (int, error) {
+	if len(g.line) == 0 {
+		if g.remaining <= 0 {
+			return 0, io.EOF
+		}
+		g.count++
+		difficulty := []string{"easy", "medium", "hard"}[g.count%3]
+		g.line = fmt.Appendf(nil, `{"taskName":"task-%07d","taskPath":"/x/tasks/%d/task.yaml","difficulty":%q,"taskPassed":%t,"allAssertionsPassed":true,"taskOutput":"%s","costUSD":0.01}`+"\n",
+			g.count, g.count, difficulty, g.count%10 != 0, g.output)
+		g.remaining -= int64(len(g.line))
+	}
+	n := copy(p, g.line)
+	g.line = g.line[n:]
+	return n, nil
+}
+
+// peakHeap samples the bytes of live heap objects until stop is called,
+// which returns the highest sample
+func peakHeap() (stop func() uint64) {
+	var peak atomic.Uint64
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			metrics.Read(sample)
+			if value := sample[0].Value.Uint64(); value > peak.Load() {
+				peak.Store(value)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		<-finished
+		return peak.Load()
+	}
+}
+
+// BenchmarkConvertStream converts a generated run with ConvertStream, whose
+// peak heap stays flat however large the input
+func BenchmarkConvertStream(b *testing.B) {
+	size := int64(benchmarkInputMB(b)) << 20
+	conv, err := New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(size)
+	b.ReportAllocs()
+	for b.Loop() {
+		stop := peakHeap()
+		if _, err := conv.ConvertStream(context.Background(), NewResultIterator(newGeneratedResults(size)), io.Discard); err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(stop())/(1<<20), "peak-heap-MB")
+	}
+}
+
+// BenchmarkConvertBuffered converts the same run with Parse, Convert and
+// Render, whose peak heap grows with the input
+func BenchmarkConvertBuffered(b *testing.B) {
+	size := int64(benchmarkInputMB(b)) << 20
+	conv, err := New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(size)
+	b.ReportAllocs()
+	for b.Loop() {
+		stop := peakHeap()
+		run, err := Parse(newGeneratedResults(size), ParseOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		report, err := conv.Convert(run)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := conv.Render(report); err != nil {
+			b.Fatal(err)
+		}
+		b.ReportMetric(float64(stop())/(1<<20), "peak-heap-MB")
+	}
+}
@@ -0,0 +1,104 @@
+package converter
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"text/template"
+)
+
+// systemOutFuncs are the functions available to system-out templates
+// besides the text/template builtins
+func systemOutFuncs(opts options) template.FuncMap {
+	return template.FuncMap{
+		// toolMessage returns the structured content message of a tool
+		// call, truncated to the configured tool output limit
+		"toolMessage": func(call ToolCall) string {
+			message := redactText(structuredMessage(call), opts.Redactions)
+			return truncateText(strings.TrimSpace(message), opts.MaxToolOutput, " ")
+		},
+		// indent prefixes every line of text but the first with n spaces
+		"indent": func(n int, text string) string {
+			return strings.ReplaceAll(text, "\n", "\n"+strings.Repeat(" ", n))
+		},
+		// wrap breaks text into lines of at most width bytes
+		"wrap": func(width int, text string) []string {
+			return wrapText(text, width)
+		},
+		// lines splits text into lines, without surrounding blank lines
+		"lines": func(text string) []string {
+			return strings.Split(strings.TrimSpace(text), "\n")
+		},
+	}
+}
+
+// ParseSystemOutTemplate parses a template laying out the system-out of
+// each testcase, which it renders from the MCPTestResult, e.g.
+//
+//	Task: {{.TaskName}}
+//	{{range .CallHistory.ToolCalls}}- {{.Name}}: {{toolMessage .}}
+//	{{end}}
+//
+// Besides the builtins, the template can call toolMessage, indent, wrap
+// and lines. The template is checked against an empty result so that
+// unknown fields are reported before any input is read.
+func ParseSystemOutTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(systemOutFuncs(options{})).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// Check a clone, since an executed template can no longer be cloned
+	// to bind the functions to the Converter options
+	check, err := tmpl.Clone()
+	if err != nil {
+		return nil, err
+	}
+	if err := check.Execute(&strings.Builder{}, MCPTestResult{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// bindSystemOutTemplate rebinds the functions of the system-out template,
+// if any, to the final options
+func (o *options) bindSystemOutTemplate() error {
+	if o.SystemOutTemplate == nil {
+		return nil
+	}
+	tmpl, err := o.SystemOutTemplate.Clone()
+	if err != nil {
+		return fmt.Errorf("system-out template: %w", err)
+	}
+	o.SystemOutTemplate = tmpl.Funcs(systemOutFuncs(*o))
+	return nil
+}
+
+// formatSystemOut renders the system-out of a result with the configured
+// template, falling back to the built-in layout when there is none or it
+// fails on this result
+func formatSystemOut(test MCPTestResult, opts options) string {
+	if opts.SystemOutTemplate == nil {
+		return formatHumanReadableOutput(test, opts)
+	}
+	var output strings.Builder
+	err := opts.SystemOutTemplate.Execute(&output, test)
+	if err == nil {
+		return output.String()
+	}
+	slog.Warn("system-out template failed, using the built-in layout", "task", test.TaskName, "error", err)
+	return formatHumanReadableOutput(test, opts)
+}
+
+// structuredMessage returns the structuredContent message of a tool call
+// result, or "" when it has none
+func structuredMessage(call ToolCall) string {
+	if call.Result == nil {
+		return ""
+	}
+	if structuredContent, ok := call.Result["structuredContent"].(map[string]interface{}); ok {
+		if message, ok := structuredContent["message"].(string); ok {
+			return message
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package converter
+
+import (
+	"slices"
+	"strings"
+)
+
+// TagsProperty names the suite property listing the tags of its results
+const TagsProperty = "tags"
+
+// withTagsProperty returns properties followed by the sorted, comma-separated
+// tags of results, or properties itself when none has tags
+func withTagsProperty(properties *JUnitProperties, results []MCPTestResult) *JUnitProperties {
+	var tags []string
+	for _, result := range results {
+		for _, tag := range result.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	if len(tags) == 0 {
+		return properties
+	}
+	slices.Sort(tags)
+	withTags := &JUnitProperties{}
+	if properties != nil {
+		withTags.Properties = slices.Clone(properties.Properties)
+	}
+	withTags.Properties = append(withTags.Properties, JUnitProperty{Name: TagsProperty, Value: strings.Join(tags, ",")})
+	return withTags
+}
@@ -0,0 +1,39 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	run := mustParse(t, `{"runId":"r1","results":[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"difficulty":"easy","tags":["kubernetes","smoke"]},
+		{"task_name":"b","task_passed":true,"all_assertions_passed":true,"difficulty":"easy","tags":["helm","kubernetes"]},
+		{"taskName":"c","taskPassed":true,"allAssertionsPassed":true,"difficulty":"hard"}
+	]}`)
+
+	a := convertTestCase(run.Results[0], options{})
+	if want := "Difficulty: easy\nTags: kubernetes, smoke\nStatus:"; !strings.Contains(a.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", a.SystemOut, want)
+	}
+	if c := convertTestCase(run.Results[2], options{Lang: LangSpanish}); strings.Contains(c.SystemOut, "Etiquetas") {
+		t.Errorf("system-out of an untagged result = %q", c.SystemOut)
+	}
+
+	report := mustConvert(t, run, options{})
+	easy := []JUnitProperty{{Name: "runId", Value: "r1"}, {Name: TagsProperty, Value: "helm,kubernetes,smoke"}}
+	if got := report.Suites[0].Properties.Properties; !reflect.DeepEqual(got, easy) {
+		t.Errorf("easy suite properties = %+v, want %+v", got, easy)
+	}
+	if got := report.Suites[1].Properties.Properties; len(got) != 1 {
+		t.Errorf("untagged suite properties = %+v, want only the runId", got)
+	}
+
+	// Results go to the suite of their first tag
+	report = mustConvert(t, run, options{GroupBy: GroupByTag, Sort: SortSorted})
+	want := []string{"MCP Checker Tests - helm", "MCP Checker Tests - kubernetes", "MCP Checker Tests - unknown"}
+	if got := suiteNames(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("suites = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package converter
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+)
+
+// templateData is what the suite name and classname templates are
+// executed with. Every result field is available, e.g.
+// {{.TaskName}} or {{.Difficulty}}, along with a few derived values.
+type templateData struct {
+	MCPTestResult
+	// Group is the key the result was grouped by
+	Group string
+	// TaskDir is the name of the directory holding the task file, e.g.
+	// "create-function" for tasks/create-function/task.yaml
+	TaskDir string
+	// Server is the MCP server the task called most
+	Server string
+	// Classname is the classname given by the classname strategy, derived
+	// from the task path by default
+	Classname string
+}
+
+// newTemplateData returns the template data of a result in the given group
+func newTemplateData(result MCPTestResult, group string, opts options) templateData {
+	data := templateData{
+		MCPTestResult: result,
+		Group:         group,
+		Server:        dominantServer(result),
+		Classname:     opts.classname(result),
+	}
+	if result.TaskPath != "" {
+		data.TaskDir = path.Base(path.Dir(slashPath(result.TaskPath)))
+	}
+	return data
+}
+
+// ParseNameTemplate parses a suite name or classname template, checking it
+// against an empty result so that unknown fields are reported before any
+// input is read. An empty text yields a nil template.
+func ParseNameTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := executeNameTemplate(tmpl, templateData{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// executeNameTemplate renders a name template, trimming surrounding whitespace
+func executeNameTemplate(tmpl *template.Template, data templateData) (string, error) {
+	var name strings.Builder
+	if err := tmpl.Execute(&name, data); err != nil {
+		return "", fmt.Errorf("executing %s: %w", tmpl.Name(), err)
+	}
+	return strings.TrimSpace(name.String()), nil
+}
@@ -0,0 +1,76 @@
+package converter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNameTemplates(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","taskPath":"/x/tasks/create-function/task.yaml","difficulty":"easy","callHistory":{"ToolCalls":[{"serverName":"kube"}]}},
+		{"taskName":"b","taskPath":"C:\\x\\tasks\\delete-pod\\task.yaml","difficulty":"hard"},
+		{"taskName":"c"}
+	]`)
+
+	tests := []struct {
+		name           string
+		suiteName      string
+		classname      string
+		wantSuites     []string
+		wantClassnames []string
+	}{
+		{
+			name:           "defaults",
+			wantSuites:     []string{"MCP Checker Tests - easy", "MCP Checker Tests - hard", "MCP Checker Tests - unknown"},
+			wantClassnames: []string{"tasks.create-function", "hard", ""},
+		},
+		{
+			name:           "fields and derived values",
+			suiteName:      "{{.Group}} tasks",
+			classname:      "{{.Difficulty}}.{{.TaskDir}}",
+			wantSuites:     []string{"easy tasks", "hard tasks", "unknown tasks"},
+			wantClassnames: []string{"easy.create-function", "hard.delete-pod", "."},
+		},
+		{
+			name:           "default classname and server",
+			classname:      " {{with .Server}}{{.}}.{{end}}{{.Classname}} ",
+			wantSuites:     []string{"MCP Checker Tests - easy", "MCP Checker Tests - hard", "MCP Checker Tests - unknown"},
+			wantClassnames: []string{"kube.tasks.create-function", "hard", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts options
+			var err error
+			if opts.SuiteNameTemplate, err = ParseNameTemplate("suite-name-template", tt.suiteName); err != nil {
+				t.Fatal(err)
+			}
+			if opts.ClassnameTemplate, err = ParseNameTemplate("classname-template", tt.classname); err != nil {
+				t.Fatal(err)
+			}
+
+			report := mustConvert(t, run, opts)
+			var classnames []string
+			for _, suite := range report.Suites {
+				for _, testCase := range suite.TestCases {
+					classnames = append(classnames, testCase.Classname)
+				}
+			}
+			if got := suiteNames(report); !reflect.DeepEqual(got, tt.wantSuites) {
+				t.Errorf("suites = %q, want %q", got, tt.wantSuites)
+			}
+			if !reflect.DeepEqual(classnames, tt.wantClassnames) {
+				t.Errorf("classnames = %q, want %q", classnames, tt.wantClassnames)
+			}
+		})
+	}
+}
+
+func TestParseNameTemplateErrors(t *testing.T) {
+	for _, text := range []string{"{{.Difficulty", "{{.Owner}}"} {
+		if _, err := ParseNameTemplate("classname-template", text); err == nil {
+			t.Errorf("ParseNameTemplate(%q) succeeded, want an error", text)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package converter
+
+import "regexp"
+
+// TimeoutErrorType is the error type of testcases whose task timed out
+const TimeoutErrorType = "Timeout"
+
+// TimedOutProperty is the testcase property set to true on timeouts
+const TimedOutProperty = "timed_out"
+
+// DefaultTimeoutPatterns recognize the task errors of timeouts: Go context
+// deadlines and the usual "timeout", "timed out" and "time-out" wordings
+var DefaultTimeoutPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)deadline exceeded`),
+	regexp.MustCompile(`(?i)\btime(?:d[ -]?out|[ -]?out)\b`),
+}
+
+// isTimeout reports whether a task error matches any of the patterns
+func isTimeout(taskError string, patterns []*regexp.Regexp) bool {
+	if taskError == "" {
+		return false
+	}
+	for _, pattern := range patterns {
+		if pattern.MatchString(taskError) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package converter
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTimeouts(t *testing.T) {
+	tests := []struct {
+		taskError string
+		patterns  []*regexp.Regexp
+		want      bool
+	}{
+		{taskError: "calling tool: context deadline exceeded", patterns: DefaultTimeoutPatterns, want: true},
+		{taskError: "agent timed out after 5m", patterns: DefaultTimeoutPatterns, want: true},
+		{taskError: "Timeout waiting for pod", patterns: DefaultTimeoutPatterns, want: true},
+		{taskError: "time-out", patterns: DefaultTimeoutPatterns, want: true},
+		{taskError: "set timeoutSeconds to 30", patterns: DefaultTimeoutPatterns},
+		{taskError: "agent failed", patterns: DefaultTimeoutPatterns},
+		{taskError: "timed out"},
+		{taskError: "quota exceeded", patterns: []*regexp.Regexp{regexp.MustCompile(`quota`)}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.taskError, func(t *testing.T) {
+			testCase := convertTestCase(MCPTestResult{TaskName: "a", TaskError: tt.taskError}, options{TimeoutPatterns: tt.patterns})
+			timedOut := testCase.Properties != nil && testCase.Properties.Properties[0] == JUnitProperty{Name: TimedOutProperty, Value: "true"}
+			if (testCase.Error.Type == TimeoutErrorType) != tt.want || timedOut != tt.want {
+				t.Errorf("error type = %s, properties = %+v, want timeout %v", testCase.Error.Type, testCase.Properties, tt.want)
+			}
+		})
+	}
+}
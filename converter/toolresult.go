@@ -0,0 +1,201 @@
+package converter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ToolResultLimits caps the tool call results of the results being decoded.
+// Results are untyped and can be huge, e.g. base64 payloads, or deeply
+// nested; capping them as each result is decoded keeps them from piling up
+// in memory and in the report. The zero value sets no limit.
+type ToolResultLimits struct {
+	// MaxDepth is how deeply objects and arrays may nest in a tool call
+	// result, the result itself being at depth 1. Deeper ones are replaced
+	// by a placeholder.
+	MaxDepth int
+	// MaxBytes caps the size of each string of a tool call result, and of
+	// the whole result once its strings are capped, in bytes of JSON. Larger
+	// values are replaced by a placeholder giving their size.
+	MaxBytes int
+	// Dir, when set, receives the full JSON of every capped result in a
+	// file of its own, which is added to the artifacts of the result so
+	// that the testcase links to it
+	Dir string
+}
+
+// enabled reports whether the limits cap anything
+func (l ToolResultLimits) enabled() bool {
+	return l.MaxDepth > 0 || l.MaxBytes > 0
+}
+
+// omittedPlaceholder replaces a value of size bytes
+func omittedPlaceholder(size int) string {
+	return fmt.Sprintf("[omitted: %d bytes]", size)
+}
+
+// apply caps the tool call results of a result and of its attempts and
+// samples, diverting the full ones to Dir
+func (l ToolResultLimits) apply(result *MCPTestResult) error {
+	if !l.enabled() {
+		return nil
+	}
+	if err := l.capCalls(result, result, ""); err != nil {
+		return err
+	}
+	for _, entries := range [][]MCPTestResult{result.Attempts, result.Samples} {
+		for i := range entries {
+			if err := l.capCalls(result, &entries[i], fmt.Sprintf("attempt%d-", i+1)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// capCalls caps the tool call results of entry, listing the diverted files
+// in the artifacts of result
+func (l ToolResultLimits) capCalls(result, entry *MCPTestResult, prefix string) error {
+	for i := range entry.CallHistory.ToolCalls {
+		call := &entry.CallHistory.ToolCalls[i]
+		if call.Result == nil {
+			continue
+		}
+		value, size, capped := l.capValue(call.Result, 1)
+		if l.MaxBytes > 0 && jsonSize(value) > l.MaxBytes {
+			value, capped = map[string]interface{}{"omitted": omittedPlaceholder(size)}, true
+		}
+		if !capped {
+			continue
+		}
+		if l.Dir != "" {
+			path, err := l.divert(entry.TaskName, fmt.Sprintf("%scall%d", prefix, i+1), call.Result)
+			if err != nil {
+				return err
+			}
+			result.Artifacts = append(result.Artifacts, path)
+		}
+		call.Result = value.(map[string]interface{})
+	}
+	return nil
+}
+
+// capValue returns v with its strings over MaxBytes and its objects and
+// arrays deeper than MaxDepth replaced by placeholders, the size of v and
+// whether anything was replaced. Objects and arrays are only copied when
+// something in them is.
+func (l ToolResultLimits) capValue(v interface{}, depth int) (interface{}, int, bool) {
+	switch v := v.(type) {
+	case string:
+		if l.MaxBytes > 0 && len(v) > l.MaxBytes {
+			return omittedPlaceholder(len(v)), len(v) + 2, true
+		}
+		return v, len(v) + 2, false
+	case map[string]interface{}:
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			size := jsonSize(v)
+			return omittedPlaceholder(size), size, true
+		}
+		var capped map[string]interface{}
+		size := 2 + max(len(v)-1, 0)
+		for key, value := range v {
+			child, childSize, changed := l.capValue(value, depth+1)
+			size += len(key) + 3 + childSize
+			if changed && capped == nil {
+				capped = maps.Clone(v)
+			}
+			if changed {
+				capped[key] = child
+			}
+		}
+		if capped == nil {
+			return v, size, false
+		}
+		return capped, size, true
+	case []interface{}:
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			size := jsonSize(v)
+			return omittedPlaceholder(size), size, true
+		}
+		var capped []interface{}
+		size := 2 + max(len(v)-1, 0)
+		for i, value := range v {
+			child, childSize, changed := l.capValue(value, depth+1)
+			size += childSize
+			if changed && capped == nil {
+				capped = slices.Clone(v)
+			}
+			if changed {
+				capped[i] = child
+			}
+		}
+		if capped == nil {
+			return v, size, false
+		}
+		return capped, size, true
+	default:
+		return v, jsonSize(v), false
+	}
+}
+
+// jsonSize estimates the size of the JSON encoding of a decoded value,
+// counting strings without their escapes
+func jsonSize(v interface{}) int {
+	switch v := v.(type) {
+	case nil:
+		return len("null")
+	case bool:
+		return len(strconv.FormatBool(v))
+	case float64:
+		return len(strconv.FormatFloat(v, 'g', -1, 64))
+	case json.Number:
+		return len(v)
+	case string:
+		return len(v) + 2
+	case map[string]interface{}:
+		size := 2 + max(len(v)-1, 0)
+		for key, value := range v {
+			size += len(key) + 3 + jsonSize(value)
+		}
+		return size
+	case []interface{}:
+		size := 2 + max(len(v)-1, 0)
+		for _, value := range v {
+			size += jsonSize(value)
+		}
+		return size
+	default:
+		return len(fmt.Sprint(v))
+	}
+}
+
+// divert writes the full JSON of a tool call result to a file of Dir named
+// after the task, the call and a hash of the result, so that converting the
+// same input again writes the same files, and returns its path
+func (l ToolResultLimits) divert(taskName, call string, result map[string]interface{}) (string, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("writing tool result: %w", err)
+	}
+	if err := os.MkdirAll(l.Dir, 0o755); err != nil {
+		return "", fmt.Errorf("tool results directory: %w", err)
+	}
+	name := strings.Trim(attachmentName.ReplaceAllString(taskName, "-"), "-.")
+	if name == "" {
+		name = "task"
+	}
+	sum := sha256.Sum256(data)
+	path := filepath.Join(l.Dir, fmt.Sprintf("%s-%s-%s.json", name, call, hex.EncodeToString(sum[:4])))
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("writing tool result: %w", err)
+	}
+	return path, nil
+}
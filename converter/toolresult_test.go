@@ -0,0 +1,116 @@
+package converter
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// toolResult decodes the JSON of a tool call result
+func toolResult(t *testing.T, data string) map[string]interface{} {
+	t.Helper()
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestToolResultLimits(t *testing.T) {
+	payload := strings.Repeat("A", 100)
+	tests := []struct {
+		name   string
+		limits ToolResultLimits
+		result string
+		want   string
+	}{
+		{
+			name:   "within the limits",
+			limits: ToolResultLimits{MaxDepth: 3, MaxBytes: 200},
+			result: `{"content":[{"type":"text","text":"ok"}]}`,
+			want:   `{"content":[{"type":"text","text":"ok"}]}`,
+		},
+		{
+			name:   "long string",
+			limits: ToolResultLimits{MaxBytes: 80},
+			result: `{"content":[{"type":"image","data":"` + payload + `"}]}`,
+			want:   `{"content":[{"type":"image","data":"[omitted: 100 bytes]"}]}`,
+		},
+		{
+			name:   "deep nesting",
+			limits: ToolResultLimits{MaxDepth: 2},
+			result: `{"a":{"b":{"c":[1,2]}},"d":[true]}`,
+			want:   `{"a":{"b":"[omitted: 11 bytes]"},"d":[true]}`,
+		},
+		{
+			name:   "large once capped",
+			limits: ToolResultLimits{MaxBytes: 30},
+			result: `{"a":"0123456789","b":"0123456789","c":"0123456789"}`,
+			want:   `{"omitted":"[omitted: 52 bytes]"}`,
+		},
+		{
+			name:   "no limits",
+			result: `{"data":"` + payload + `"}`,
+			want:   `{"data":"` + payload + `"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := toolResult(t, tt.result)
+			result := MCPTestResult{TaskName: "a", CallHistory: CallHistory{ToolCalls: []ToolCall{{Name: "get", Result: original}}}}
+			if err := tt.limits.apply(&result); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := result.CallHistory.ToolCalls[0].Result, toolResult(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("result = %v, want %v", got, want)
+			}
+			if !reflect.DeepEqual(original, toolResult(t, tt.result)) {
+				t.Errorf("capping modified the decoded result: %v", original)
+			}
+		})
+	}
+}
+
+func TestToolResultLimitsDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tool-results")
+	input := `{"taskName":"get pods","taskPassed":true,
+		"callHistory":{"ToolCalls":[{"name":"get","result":{"data":"` + strings.Repeat("x", 64) + `"}},{"name":"list","result":{"ok":true}}]},
+		"attempts":[{"taskPassed":false,"callHistory":{"ToolCalls":[{"name":"get","result":{"data":"` + strings.Repeat("y", 64) + `"}}]}}]}`
+	run, err := Parse(strings.NewReader(input), ParseOptions{ToolResults: ToolResultLimits{MaxBytes: 32, Dir: dir}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := run.Results[0]
+	if got := result.Attempts[0].CallHistory.ToolCalls[0].Result["data"]; got != "[omitted: 64 bytes]" {
+		t.Errorf("result of the attempt = %v, want a placeholder", got)
+	}
+	if len(result.Artifacts) != 2 {
+		t.Fatalf("artifacts = %q, want the two capped results", result.Artifacts)
+	}
+	for i, prefix := range []string{"get-pods-call1-", "get-pods-attempt1-call1-"} {
+		if name := filepath.Base(result.Artifacts[i]); !strings.HasPrefix(name, prefix) {
+			t.Errorf("artifact %d = %s, want a name starting with %s", i, name, prefix)
+		}
+	}
+	data, err := os.ReadFile(result.Artifacts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"data":"` + strings.Repeat("x", 64) + `"}` + "\n"; string(data) != want {
+		t.Errorf("diverted result = %s, want %s", data, want)
+	}
+
+	// The iterator applies the same limits
+	it := NewResultIterator(strings.NewReader(input))
+	it.SetToolResultLimits(ToolResultLimits{MaxBytes: 32})
+	iterated, err := it.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := iterated.CallHistory.ToolCalls[0].Result["data"]; got != "[omitted: 64 bytes]" {
+		t.Errorf("iterated result = %v, want a placeholder", got)
+	}
+}
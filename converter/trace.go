@@ -0,0 +1,48 @@
+package converter
+
+import "cmp"
+
+// Testcase properties correlating a task with its trace and prompt
+const (
+	TraceIDProperty  = "traceId"
+	PromptIDProperty = "promptId"
+	// TraceURLProperty links to the trace, see WithTraceURLTemplate
+	TraceURLProperty = "traceUrl"
+)
+
+// traceURL returns the link to the trace of a result, rendered from the
+// trace URL template, or "" when there is no trace ID or no template. A
+// template that fails on the result yields no link rather than failing
+// the conversion, ParseNameTemplate having checked it up front.
+func traceURL(test MCPTestResult, opts options) string {
+	if test.TraceID == "" || opts.TraceURLTemplate == nil {
+		return ""
+	}
+	url, err := executeNameTemplate(opts.TraceURLTemplate, newTemplateData(test, "", opts))
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// traceProperties returns the trace ID, trace link and prompt ID of a
+// result, those it has
+func traceProperties(test MCPTestResult, opts options) []JUnitProperty {
+	var properties []JUnitProperty
+	if test.TraceID != "" {
+		properties = append(properties, JUnitProperty{Name: TraceIDProperty, Value: test.TraceID})
+		if url := traceURL(test, opts); url != "" {
+			properties = append(properties, JUnitProperty{Name: TraceURLProperty, Value: url})
+		}
+	}
+	if test.PromptID != "" {
+		properties = append(properties, JUnitProperty{Name: PromptIDProperty, Value: test.PromptID})
+	}
+	return properties
+}
+
+// traceLine returns what system-out shows of the trace of a result: its
+// link when there is one, otherwise its ID
+func traceLine(test MCPTestResult, opts options) string {
+	return cmp.Or(traceURL(test, opts), test.TraceID)
+}
@@ -0,0 +1,64 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTraceCorrelation(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,"traceId":"abc 123","promptId":"p-7"},
+		{"task_name":"b","difficulty":"easy","task_passed":true,"all_assertions_passed":true,"trace_id":"def"},
+		{"taskName":"c","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+	tmpl, err := ParseNameTemplate("trace-url-template", "https://traces.example.com/{{urlquery .TraceID}}?task={{.TaskName}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       options
+		properties [][]JUnitProperty
+		lines      []string
+	}{
+		{
+			name: "ids",
+			properties: [][]JUnitProperty{
+				{{Name: TraceIDProperty, Value: "abc 123"}, {Name: PromptIDProperty, Value: "p-7"}},
+				{{Name: TraceIDProperty, Value: "def"}},
+				nil,
+			},
+			lines: []string{"Trace: abc 123\nPrompt: p-7\n", "Trace: def\n", ""},
+		},
+		{
+			name: "links",
+			opts: options{TraceURLTemplate: tmpl},
+			properties: [][]JUnitProperty{
+				{{Name: TraceIDProperty, Value: "abc 123"}, {Name: TraceURLProperty, Value: "https://traces.example.com/abc+123?task=a"}, {Name: PromptIDProperty, Value: "p-7"}},
+				{{Name: TraceIDProperty, Value: "def"}, {Name: TraceURLProperty, Value: "https://traces.example.com/def?task=b"}},
+				nil,
+			},
+			lines: []string{"Trace: https://traces.example.com/abc+123?task=a\nPrompt: p-7\n", "Trace: https://traces.example.com/def?task=b\n", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, result := range run.Results {
+				testCase := convertTestCase(result, tt.opts)
+				var properties []JUnitProperty
+				if testCase.Properties != nil {
+					properties = testCase.Properties.Properties
+				}
+				if !reflect.DeepEqual(properties, tt.properties[i]) {
+					t.Errorf("%s: properties = %v, want %v", result.TaskName, properties, tt.properties[i])
+				}
+				header := "Difficulty: easy\n" + tt.lines[i] + "Status:"
+				if !strings.Contains(testCase.SystemOut, header) {
+					t.Errorf("%s: system-out = %q, want it to contain %q", result.TaskName, testCase.SystemOut, header)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package converter
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Names of the suite properties holding the token usage and cost totals of
+// its results
+const (
+	PromptTokensProperty     = "promptTokens"
+	CompletionTokensProperty = "completionTokens"
+	TotalTokensProperty      = "totalTokens"
+	CostUSDProperty          = "costUSD"
+)
+
+// Sum returns the total number of tokens, adding up the prompt and
+// completion tokens when Total is not set
+func (u TokenUsage) Sum() int {
+	if u.Total > 0 {
+		return u.Total
+	}
+	return u.Prompt + u.Completion
+}
+
+// add accumulates other into u, with its total filled in
+func (u *TokenUsage) add(other TokenUsage) {
+	u.Prompt += other.Prompt
+	u.Completion += other.Completion
+	u.Total += other.Sum()
+}
+
+// usageTotals adds up the token usage and cost of results, and reports
+// whether any of them recorded either
+func usageTotals(results []MCPTestResult) (TokenUsage, float64, bool) {
+	var usage TokenUsage
+	var cost float64
+	recorded := false
+	for _, result := range results {
+		if result.TokenUsage != nil {
+			usage.add(*result.TokenUsage)
+			recorded = true
+		}
+		if result.CostUSD > 0 {
+			cost += result.CostUSD
+			recorded = true
+		}
+	}
+	return usage, cost, recorded
+}
+
+// withUsageProperties returns properties followed by the token usage and
+// cost totals of results, or properties itself when none recorded them
+func withUsageProperties(properties *JUnitProperties, results []MCPTestResult) *JUnitProperties {
+	usage, cost, recorded := usageTotals(results)
+	if !recorded {
+		return properties
+	}
+	withUsage := &JUnitProperties{}
+	if properties != nil {
+		withUsage.Properties = slices.Clone(properties.Properties)
+	}
+	withUsage.Properties = append(withUsage.Properties,
+		JUnitProperty{Name: PromptTokensProperty, Value: strconv.Itoa(usage.Prompt)},
+		JUnitProperty{Name: CompletionTokensProperty, Value: strconv.Itoa(usage.Completion)},
+		JUnitProperty{Name: TotalTokensProperty, Value: strconv.Itoa(usage.Total)},
+		JUnitProperty{Name: CostUSDProperty, Value: strconv.FormatFloat(cost, 'f', -1, 64)},
+	)
+	return withUsage
+}
+
+// writeUsage writes the token usage and cost of a result, if it recorded them
+func writeUsage(output *strings.Builder, test MCPTestResult, msg *messages) {
+	if usage := test.TokenUsage; usage != nil {
+		fmt.Fprintf(output, "%s: prompt=%d completion=%d total=%d\n", msg.TokenUsage, usage.Prompt, usage.Completion, usage.Sum())
+	}
+	if test.CostUSD > 0 {
+		fmt.Fprintf(output, "%s: $%s\n", msg.Cost, FormatCost(test.CostUSD))
+	}
+}
+
+// FormatCost formats an amount in US dollars with four decimals, enough for
+// the cost of a single task
+func FormatCost(usd float64) string {
+	return strconv.FormatFloat(usd, 'f', 4, 64)
+}
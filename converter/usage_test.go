@@ -0,0 +1,40 @@
+package converter
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	run := mustParse(t, `{"runId":"r1","results":[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"difficulty":"easy",
+			"tokenUsage":{"prompt":1000,"completion":200,"total":1200},"costUSD":0.012},
+		{"task_name":"b","task_passed":true,"all_assertions_passed":true,"difficulty":"easy",
+			"token_usage":{"prompt":300,"completion":50},"cost_usd":0.0035},
+		{"taskName":"c","taskPassed":true,"allAssertionsPassed":true,"difficulty":"hard"}
+	]}`)
+
+	a := convertTestCase(run.Results[0], options{})
+	if want := "Assertions: 0/0 passed\nToken usage: prompt=1000 completion=200 total=1200\nCost: $0.0120\n"; !strings.Contains(a.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", a.SystemOut, want)
+	}
+	b := convertTestCase(run.Results[1], options{Lang: LangPortuguese})
+	if want := "Uso de tokens: prompt=300 completion=50 total=350\nCusto: $0.0035\n"; !strings.Contains(b.SystemOut, want) {
+		t.Errorf("system-out = %q, want it to contain %q", b.SystemOut, want)
+	}
+	if c := convertTestCase(run.Results[2], options{}); strings.Contains(c.SystemOut, "Token usage") || strings.Contains(c.SystemOut, "Cost") {
+		t.Errorf("system-out of a result without usage = %q", c.SystemOut)
+	}
+
+	report := mustConvert(t, run, options{})
+	easy := []JUnitProperty{{Name: "runId", Value: "r1"}, {Name: PromptTokensProperty, Value: "1300"},
+		{Name: CompletionTokensProperty, Value: "250"}, {Name: TotalTokensProperty, Value: "1550"}, {Name: CostUSDProperty, Value: "0.0155"}}
+	if got := report.Suites[0].Properties.Properties; !reflect.DeepEqual(got, easy) {
+		t.Errorf("easy suite properties = %+v, want %+v", got, easy)
+	}
+	hard := []JUnitProperty{{Name: "runId", Value: "r1"}}
+	if got := report.Suites[1].Properties.Properties; !reflect.DeepEqual(got, hard) {
+		t.Errorf("hard suite properties = %+v, want %+v", got, hard)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Defaults of publish datadog
+const (
+	defaultDatadogSite    = "datadoghq.com"
+	defaultDatadogKeyEnv  = "DD_API_KEY"
+	defaultDatadogService = "mcpchecker"
+	defaultDatadogEnv     = "ci"
+	// datadogModule is the test module of every suite, as a test framework
+	datadogModule = "mcpchecker"
+)
+
+// Test statuses of CI Visibility
+const (
+	datadogPass = "pass"
+	datadogFail = "fail"
+	datadogSkip = "skip"
+)
+
+// datadogEvent is an event of the CI Visibility test cycle intake: a test,
+// or the end of a suite, module or session
+type datadogEvent struct {
+	Type    string         `json:"type"`
+	Version int            `json:"version"`
+	Content datadogContent `json:"content"`
+}
+
+// datadogContent is the span of an event; times are Unix nanoseconds
+type datadogContent struct {
+	TraceID   uint64            `json:"trace_id,omitempty"`
+	SpanID    uint64            `json:"span_id,omitempty"`
+	ParentID  uint64            `json:"parent_id"`
+	SessionID uint64            `json:"test_session_id"`
+	ModuleID  uint64            `json:"test_module_id,omitempty"`
+	SuiteID   uint64            `json:"test_suite_id,omitempty"`
+	Name      string            `json:"name"`
+	Resource  string            `json:"resource"`
+	Service   string            `json:"service"`
+	Type      string            `json:"type"`
+	Start     int64             `json:"start"`
+	Duration  int64             `json:"duration"`
+	Error     int               `json:"error"`
+	Meta      map[string]string `json:"meta"`
+}
+
+// publishDatadog implements publish datadog: it sends the tests of the
+// results to Datadog CI Visibility as a test session of one module, with a
+// suite per difficulty level
+func publishDatadog(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	site := fs.String("site", cmp.Or(os.Getenv("DD_SITE"), defaultDatadogSite), "Datadog site, e.g. datadoghq.eu (default $DD_SITE or datadoghq.com)")
+	intakeURL := fs.String("intake-url", "", "URL of the test cycle intake, instead of the one of --site, e.g. for a proxy")
+	keyEnv := fs.String("api-key-env", defaultDatadogKeyEnv, "environment variable holding the Datadog API key")
+	service := fs.String("service", cmp.Or(os.Getenv("DD_SERVICE"), defaultDatadogService), "service of the tests (default $DD_SERVICE or mcpchecker)")
+	env := fs.String("env", cmp.Or(os.Getenv("DD_ENV"), defaultDatadogEnv), "environment of the tests (default $DD_ENV or ci)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	key, err := secretFromEnv("api-key-env", *keyEnv)
+	if err != nil {
+		return err
+	}
+	conv, err := newPublishConverter()
+	if err != nil {
+		return err
+	}
+	run, tests, err := loadPublishedTests(ctx, fs.Args(), opts, conv)
+	if err != nil {
+		return err
+	}
+	if len(tests) == 0 {
+		return fmt.Errorf("no task to publish")
+	}
+
+	tags := parseDatadogTags(os.Getenv("DD_TAGS"))
+	tags["env"] = *env
+	events := datadogEvents(run, tests, runTime(run, time.Now), *service, tags, randomUint64)
+	payload := map[string]interface{}{
+		"version": 1,
+		"metadata": map[string]interface{}{"*": map[string]string{
+			"language": "go", "library_version": currentBuildInfo().Version, "runtime-id": randomID(16),
+		}},
+		"events": events,
+	}
+	header := http.Header{}
+	header.Set("DD-API-KEY", key)
+	endpoint := cmp.Or(*intakeURL, "https://citestcycle-intake."+*site+"/api/v2/citestcycle")
+	if err := callAPI(ctx, http.MethodPost, endpoint, header, payload, nil); err != nil {
+		return fmt.Errorf("sending to Datadog CI Visibility: %w", err)
+	}
+	slog.Info("published results to Datadog CI Visibility", "service", *service, "tests", len(tests))
+	return nil
+}
+
+// parseDatadogTags parses the key:value pairs of DD_TAGS, separated by
+// commas or spaces
+func parseDatadogTags(value string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if key, value, ok := strings.Cut(tag, ":"); ok && key != "" {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// randomUint64 returns a random non-zero ID of a test, suite, module or
+// session
+func randomUint64() uint64 {
+	var id [8]byte
+	for {
+		rand.Read(id[:])
+		if n := binary.BigEndian.Uint64(id[:]) >> 1; n != 0 {
+			return n
+		}
+	}
+}
+
+// datadogEvents returns the events of tests starting at start: every test,
+// then the end of each suite, of the module and of the session, tagged with
+// tags. Tests are laid out one after the other, as results only report
+// durations. newID returns the IDs of the events.
+func datadogEvents(run converter.TestRun, tests []publishedTest, start time.Time, service string, tags map[string]string, newID func() uint64) []datadogEvent {
+	sessionID, moduleID := newID(), newID()
+	command := "mcpchecker " + cmp.Or(run.RunID, start.UTC().Format(time.RFC3339))
+	meta := func(status string, extra map[string]string) map[string]string {
+		m := map[string]string{"test.command": command, "test.framework": datadogModule, "test.module": datadogModule, "test.status": status}
+		for key, value := range tags {
+			m[key] = value
+		}
+		for key, value := range extra {
+			m[key] = value
+		}
+		return m
+	}
+	errorFlag := func(status string) int {
+		if status == datadogFail {
+			return 1
+		}
+		return 0
+	}
+
+	type suite struct {
+		id         uint64
+		start, end time.Time
+		status     string
+	}
+	suites := make(map[string]*suite)
+	var events []datadogEvent
+	sessionStatus := datadogSkip
+	at := start
+	for _, test := range tests {
+		name := cmp.Or(test.Result.Difficulty, converter.UnknownGroup)
+		s := suites[name]
+		if s == nil {
+			s = &suite{id: newID(), start: at, status: datadogSkip}
+			suites[name] = s
+		}
+		status := datadogFail
+		switch test.Outcome {
+		case outcomePassed:
+			status = datadogPass
+		case outcomeSkipped:
+			status = datadogSkip
+		}
+		s.status = worseDatadogStatus(s.status, status)
+		sessionStatus = worseDatadogStatus(sessionStatus, status)
+
+		extra := map[string]string{"test.name": test.Result.TaskName, "test.suite": name, "test.type": "test", "span.kind": "test"}
+		if test.TestCase.File != "" {
+			extra["test.source.file"] = test.TestCase.File
+		}
+		if comment := test.failureComment(); comment != "" {
+			extra["error.type"] = test.Outcome
+			extra["error.message"], extra["error.stack"], _ = strings.Cut(comment, "\n\n")
+		}
+		if status == datadogSkip && test.TestCase.Skipped != nil {
+			extra["test.skip_reason"] = test.TestCase.Skipped.Message
+		}
+		duration := test.duration()
+		events = append(events, datadogEvent{Type: "test", Version: 2, Content: datadogContent{
+			TraceID: newID(), SpanID: newID(), SessionID: sessionID, ModuleID: moduleID, SuiteID: s.id,
+			Name: "mcpchecker.test", Resource: name + "." + test.Result.TaskName, Service: service, Type: "test",
+			Start: at.UnixNano(), Duration: duration.Nanoseconds(), Error: errorFlag(status), Meta: meta(status, extra),
+		}})
+		at = at.Add(duration)
+		s.end = at
+	}
+
+	for _, name := range slices.SortedFunc(maps.Keys(suites), converter.CompareDifficulty) {
+		s := suites[name]
+		events = append(events, datadogEvent{Type: "test_suite_end", Version: 1, Content: datadogContent{
+			SessionID: sessionID, ModuleID: moduleID, SuiteID: s.id,
+			Name: "mcpchecker.test_suite", Resource: name, Service: service, Type: "test_suite_end",
+			Start: s.start.UnixNano(), Duration: s.end.Sub(s.start).Nanoseconds(), Error: errorFlag(s.status),
+			Meta: meta(s.status, map[string]string{"test.suite": name}),
+		}})
+	}
+	events = append(events, datadogEvent{Type: "test_module_end", Version: 1, Content: datadogContent{
+		SessionID: sessionID, ModuleID: moduleID, Name: "mcpchecker.test_module", Resource: datadogModule, Service: service,
+		Type: "test_module_end", Start: start.UnixNano(), Duration: at.Sub(start).Nanoseconds(), Error: errorFlag(sessionStatus),
+		Meta: meta(sessionStatus, nil),
+	}}, datadogEvent{Type: "test_session_end", Version: 1, Content: datadogContent{
+		SessionID: sessionID, Name: "mcpchecker.test_session", Resource: command, Service: service,
+		Type: "test_session_end", Start: start.UnixNano(), Duration: at.Sub(start).Nanoseconds(), Error: errorFlag(sessionStatus),
+		Meta: meta(sessionStatus, nil),
+	}})
+	return events
+}
+
+// worseDatadogStatus returns the status of a suite or session holding tests
+// of both statuses: fail over pass over skip
+func worseDatadogStatus(a, b string) string {
+	rank := map[string]int{datadogSkip: 0, datadogPass: 1, datadogFail: 2}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
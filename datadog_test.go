@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublishDatadog(t *testing.T) {
+	var payload struct {
+		Events []datadogEvent `json:"events"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("DD-API-KEY") != "dd-key" {
+			http.Error(w, `{"errors":["Forbidden"]}`, http.StatusForbidden)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Setenv("DD_API_KEY", "dd-key")
+	t.Setenv("DD_TAGS", "team:evals,git.branch:main")
+
+	input := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(input, []byte(`[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"hard","taskPassed":false,"taskError":"boom"}
+	]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	publish := func() error {
+		return runCLI(context.Background(), []string{"publish", "datadog", "--intake-url", server.URL, "--env", "staging", input})
+	}
+	if err := publish(); err != nil {
+		t.Fatal(err)
+	}
+	var types []string
+	for _, event := range payload.Events {
+		types = append(types, event.Type)
+	}
+	if want := []string{"test", "test", "test_suite_end", "test_suite_end", "test_module_end", "test_session_end"}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("event types = %q, want %q", types, want)
+	}
+	b := payload.Events[1].Content
+	if b.Meta["test.status"] != datadogFail || !strings.Contains(b.Meta["error.stack"], "boom") || b.Meta["team"] != "evals" || b.Meta["env"] != "staging" || b.Error != 1 {
+		t.Errorf("test b = %+v", b)
+	}
+
+	t.Setenv("DD_API_KEY", "wrong")
+	if err := publish(); err == nil || !strings.Contains(err.Error(), "403 Forbidden") {
+		t.Errorf("publish with a wrong key error = %v, want the 403 response", err)
+	}
+}
+
+func TestDatadogEvents(t *testing.T) {
+	run := mustParse(t, `{"runId":"nightly","results":[
+		{"taskName":"a","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true,"agentOutput":{"Success":true,"DurationMs":2000}},
+		{"taskName":"b","difficulty":"easy","taskSkipped":true,"skipReason":"no prompts capability"},
+		{"taskName":"c","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false}},
+			"agentOutput":{"Success":true,"DurationMs":1000}}
+	]}`)
+	conv := mustNewPublishConverter(t)
+	var tests []publishedTest
+	for _, result := range run.Results {
+		testCase := conv.ConvertResult(result)
+		tests = append(tests, publishedTest{Result: result, TestCase: testCase, Outcome: historyOutcome(testCase)})
+	}
+	var ids uint64
+	newID := func() uint64 {
+		ids++
+		return ids
+	}
+	start := time.Unix(1000, 0)
+	events := datadogEvents(run, tests, start, "mcp", map[string]string{"env": "ci"}, newID)
+
+	type event struct {
+		kind, resource, status string
+		suite                  uint64
+		start, duration        int64
+	}
+	second := time.Second.Nanoseconds()
+	want := []event{
+		{"test", "hard.a", datadogPass, 3, start.UnixNano(), 2 * second},
+		{"test", "easy.b", datadogSkip, 6, start.UnixNano() + 2*second, 0},
+		{"test", "hard.c", datadogFail, 3, start.UnixNano() + 2*second, second},
+		{"test_suite_end", "easy", datadogSkip, 6, start.UnixNano() + 2*second, 0},
+		{"test_suite_end", "hard", datadogFail, 3, start.UnixNano(), 3 * second},
+		{"test_module_end", "mcpchecker", datadogFail, 0, start.UnixNano(), 3 * second},
+		{"test_session_end", "mcpchecker nightly", datadogFail, 0, start.UnixNano(), 3 * second},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %d", events, len(want))
+	}
+	for i, e := range events {
+		c := e.Content
+		if got := (event{e.Type, c.Resource, c.Meta["test.status"], c.SuiteID, c.Start, c.Duration}); got != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, got, want[i])
+		}
+		if c.SessionID != 1 || c.Service != "mcp" || c.Meta["env"] != "ci" {
+			t.Errorf("event %d = %+v, want session 1 of service mcp in env ci", i, c)
+		}
+	}
+	if reason := events[1].Content.Meta["test.skip_reason"]; reason != "no prompts capability" {
+		t.Errorf("skip reason = %q", reason)
+	}
+	if message := events[2].Content.Meta["error.message"]; !strings.Contains(message, "pods-ready") {
+		t.Errorf("error message = %q, want the failed assertion", message)
+	}
+}
+
+func TestParseDatadogTags(t *testing.T) {
+	got := parseDatadogTags("team:evals, git.branch:main region:eu-west-1 nocolon")
+	want := map[string]string{"team": "evals", "git.branch": "main", "region": "eu-west-1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDatadogTags = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// defaultConfidence is the confidence level of the intervals of diff --stats
+const defaultConfidence = 0.95
+
+// taskChange is a task whose outcome differs between the two runs of diff
+type taskChange struct {
+	Task       string `json:"task"`
+	Difficulty string `json:"difficulty"`
+	// Baseline and Current are the outcomes of the task in each run, as
+	// recorded by history, or empty when the run does not have it
+	Baseline   string `json:"baseline"`
+	Current    string `json:"current"`
+	Regression bool   `json:"regression"`
+}
+
+// passInterval is the pass rate of a set of trials with its Wilson score
+// interval
+type passInterval struct {
+	Passed int     `json:"passed"`
+	Trials int     `json:"trials"`
+	Rate   float64 `json:"passRate"`
+	Lower  float64 `json:"lower"`
+	Upper  float64 `json:"upper"`
+}
+
+// rateDelta compares the pass rates of one difficulty level, or of all
+// tasks under "Total", between the two runs of diff --stats
+type rateDelta struct {
+	Difficulty string       `json:"difficulty"`
+	Baseline   passInterval `json:"baseline"`
+	Current    passInterval `json:"current"`
+	// Delta is the current pass rate minus the baseline one, between Lower
+	// and Upper at the confidence level of the comparison
+	Delta float64 `json:"delta"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	// Significant is set when the interval of Delta excludes 0
+	Significant bool `json:"significant"`
+}
+
+// runDiff implements the diff command, comparing a baseline run with a
+// current one
+func runDiff(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	conversion := addConvertFlags(fs)
+	withStats := fs.Bool("stats", false, "compare the pass rates per difficulty, with every sample as a trial, and only report significant regressions")
+	confidence := fs.Float64("confidence", defaultConfidence, "confidence level of the --stats intervals, between 0 and 1")
+	checkFormat := oneOf("format", diffFormats...)
+	format := addCheckedString(fs, "format", diffFormatText, "output format: "+strings.Join(diffFormats, ", "), checkFormat)
+	asJSON := fs.Bool("json", false, "print the comparison as JSON, like --format json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	if *confidence <= 0 || *confidence >= 1 {
+		return newUsageError("--confidence must be between 0 and 1, exclusive")
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	if *asJSON {
+		if *format != diffFormatText && *format != diffFormatJSON {
+			return newUsageError("--json cannot be combined with --format %s", *format)
+		}
+		*format = diffFormatJSON
+	}
+	if fs.NArg() != 2 {
+		return newUsageError("diff requires a baseline and a current input")
+	}
+	conv, err := conversion.newConverter()
+	if err != nil {
+		return err
+	}
+	var runs [2]converter.TestRun
+	for i, input := range fs.Args() {
+		if runs[i], err = loadInput(ctx, input, opts); err != nil {
+			return err
+		}
+	}
+
+	var deltas []rateDelta
+	var regressions []string
+	changes := compareOutcomes(runs[0], runs[1], conv)
+	if *withStats {
+		deltas = compareRates(runs[0], runs[1], conv, *confidence)
+		for _, d := range deltas {
+			if d.Significant && d.Delta < 0 {
+				regressions = append(regressions, d.Difficulty)
+			}
+		}
+	} else {
+		for _, c := range changes {
+			if c.Regression {
+				regressions = append(regressions, c.Task)
+			}
+		}
+	}
+
+	switch {
+	case *format == diffFormatHTML:
+		page := diffPage{
+			Baseline:   diffRun{Input: fs.Arg(0), RunID: runs[0].RunID},
+			Current:    diffRun{Input: fs.Arg(1), RunID: runs[1].RunID},
+			Rates:      deltas,
+			Confidence: *confidence,
+			Tasks:      taskDetails(runs[0], runs[1], changes),
+		}
+		if err := writeDiffHTML(stdout, page); err != nil {
+			return err
+		}
+	case *format == diffFormatJSON:
+		var output interface{} = changes
+		if *withStats {
+			output = deltas
+		}
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			return err
+		}
+	case *withStats:
+		printRateDeltas(stdout, deltas, *confidence, useColor(colorMode, stdout))
+	default:
+		printChanges(stdout, changes, useColor(colorMode, stdout))
+	}
+
+	if len(regressions) == 0 {
+		return nil
+	}
+	if *withStats {
+		return gateError{code: exitCodeGateFailed, msg: "Significant pass-rate regressions: " + strings.Join(regressions, ", ")}
+	}
+	return gateError{code: exitCodeGateFailed, msg: fmt.Sprintf("%d tasks that passed in the baseline fail now", len(regressions))}
+}
+
+// compareOutcomes returns the tasks kept by the filters of conv whose
+// outcome, as conv reports it, differs between baseline and current, sorted
+// by name. A task failing or erroring in current after passing in baseline
+// is a regression.
+func compareOutcomes(baseline, current converter.TestRun, conv *converter.Converter) []taskChange {
+	changes := make(map[string]*taskChange)
+	for i, run := range []converter.TestRun{baseline, current} {
+		for _, result := range run.Results {
+			if result.ParseError() != nil || !conv.Keep(result) {
+				continue
+			}
+			if changes[result.TaskName] == nil {
+				changes[result.TaskName] = &taskChange{Task: result.TaskName}
+			}
+			c := changes[result.TaskName]
+			c.Difficulty = result.Difficulty
+			outcome := historyOutcome(conv.ConvertResult(result))
+			if i == 0 {
+				c.Baseline = outcome
+			} else {
+				c.Current = outcome
+			}
+		}
+	}
+	result := []taskChange{}
+	for _, c := range changes {
+		if c.Baseline == c.Current {
+			continue
+		}
+		c.Regression = c.Baseline == outcomePassed && (c.Current == outcomeFailure || c.Current == outcomeError)
+		result = append(result, *c)
+	}
+	slices.SortFunc(result, func(a, b taskChange) int { return strings.Compare(a.Task, b.Task) })
+	return result
+}
+
+// printChanges writes the tasks whose outcome changed as a table
+func printChanges(w io.Writer, changes []taskChange, color bool) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "No task changed outcome.")
+		return
+	}
+	rows := [][]cell{{{text: "Task"}, {text: "Difficulty"}, {text: "Baseline"}, {text: "Current"}, {text: ""}}}
+	for _, c := range changes {
+		flag := cell{}
+		if c.Regression {
+			flag = cell{text: "regression", color: ansiBold + ansiRed}
+		}
+		rows = append(rows, []cell{{text: c.Task}, {text: c.Difficulty},
+			{text: cmp.Or(c.Baseline, "-"), color: outcomeColor(c.Baseline)},
+			{text: cmp.Or(c.Current, "-"), color: outcomeColor(c.Current)}, flag})
+	}
+	writeTable(w, rows, color, false)
+}
+
+// compareRates compares the pass rates of baseline and current per
+// difficulty level, then for all tasks, at the given confidence level. Every
+// sample of a sampled task is a trial, and skipped tasks and samples, and
+// those the filters of conv drop, are left out.
+func compareRates(baseline, current converter.TestRun, conv *converter.Converter, confidence float64) []rateDelta {
+	var counts [2]map[string][2]int
+	for i, run := range []converter.TestRun{baseline, current} {
+		counts[i] = make(map[string][2]int)
+		for _, result := range run.Results {
+			if result.ParseError() != nil || !conv.Keep(result) {
+				continue
+			}
+			trials := result.Samples
+			if len(trials) == 0 {
+				trials = []converter.MCPTestResult{result}
+			}
+			difficulty := cmp.Or(result.Difficulty, converter.UnknownGroup)
+			for _, trial := range trials {
+				outcome := historyOutcome(conv.ConvertResult(trial))
+				if outcome == outcomeSkipped {
+					continue
+				}
+				for _, key := range []string{difficulty, ""} {
+					c := counts[i][key]
+					c[1]++
+					if outcome == outcomePassed {
+						c[0]++
+					}
+					counts[i][key] = c
+				}
+			}
+		}
+	}
+
+	difficulties := make([]string, 0, len(counts[0])+len(counts[1]))
+	for i := range counts {
+		for difficulty := range counts[i] {
+			if difficulty != "" && !slices.Contains(difficulties, difficulty) {
+				difficulties = append(difficulties, difficulty)
+			}
+		}
+	}
+	slices.SortFunc(difficulties, converter.CompareDifficulty)
+
+	z := math.Sqrt2 * math.Erfinv(confidence)
+	deltas := []rateDelta{}
+	for _, difficulty := range append(difficulties, "") {
+		before := wilsonInterval(counts[0][difficulty][0], counts[0][difficulty][1], z)
+		after := wilsonInterval(counts[1][difficulty][0], counts[1][difficulty][1], z)
+		d := rateDelta{Difficulty: cmp.Or(difficulty, "Total"), Baseline: before, Current: after, Delta: after.Rate - before.Rate}
+		if before.Trials > 0 && after.Trials > 0 {
+			// Newcombe's hybrid score interval of the difference of the
+			// two proportions
+			d.Lower = d.Delta - math.Hypot(after.Rate-after.Lower, before.Upper-before.Rate)
+			d.Upper = d.Delta + math.Hypot(after.Upper-after.Rate, before.Rate-before.Lower)
+			d.Significant = d.Lower > 0 || d.Upper < 0
+		}
+		deltas = append(deltas, d)
+	}
+	return deltas
+}
+
+// wilsonInterval returns the pass rate of passed out of trials with its
+// Wilson score interval for the normal quantile z
+func wilsonInterval(passed, trials int, z float64) passInterval {
+	interval := passInterval{Passed: passed, Trials: trials}
+	if trials == 0 {
+		return interval
+	}
+	n := float64(trials)
+	p := float64(passed) / n
+	denominator := 1 + z*z/n
+	center := (p + z*z/(2*n)) / denominator
+	half := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / denominator
+	interval.Rate = p
+	interval.Lower = max(0, center-half)
+	interval.Upper = min(1, center+half)
+	return interval
+}
+
+// formatPassRate formats a pass rate with its trials, e.g. "90.0% (18/20)"
+func formatPassRate(interval passInterval) string {
+	if interval.Trials == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%% (%d/%d)", interval.Rate*100, interval.Passed, interval.Trials)
+}
+
+// formatRateDelta formats the difference of the pass rates of d, e.g. "-10.0%"
+func formatRateDelta(d rateDelta) string {
+	return fmt.Sprintf("%+.1f%%", d.Delta*100)
+}
+
+// formatDeltaInterval formats the interval of the difference of the pass
+// rates of d, or "-" when one of the runs has no trials
+func formatDeltaInterval(d rateDelta) string {
+	if d.Baseline.Trials == 0 || d.Current.Trials == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("[%+.1f%%, %+.1f%%]", d.Lower*100, d.Upper*100)
+}
+
+// intervalHeader heads the intervals of the confidence level, e.g. "95% interval"
+func intervalHeader(confidence float64) string {
+	return fmt.Sprintf("%g%% interval", confidence*100)
+}
+
+// printRateDeltas writes the pass-rate comparison as a table
+func printRateDeltas(w io.Writer, deltas []rateDelta, confidence float64, color bool) {
+	rows := [][]cell{{{text: "Difficulty"}, {text: "Baseline"}, {text: "Current"}, {text: "Delta"},
+		{text: intervalHeader(confidence)}, {text: ""}}}
+	for _, d := range deltas {
+		flag, deltaColor := cell{}, ""
+		switch {
+		case d.Significant && d.Delta < 0:
+			flag, deltaColor = cell{text: "regression", color: ansiBold + ansiRed}, ansiRed
+		case d.Significant:
+			flag, deltaColor = cell{text: "improvement", color: ansiGreen}, ansiGreen
+		case d.Delta < 0:
+			deltaColor = ansiYellow
+		}
+		rows = append(rows, []cell{{text: d.Difficulty}, {text: formatPassRate(d.Baseline)}, {text: formatPassRate(d.Current)},
+			{text: formatRateDelta(d), color: deltaColor}, {text: formatDeltaInterval(d)}, flag})
+	}
+	writeTable(w, rows, color, true)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareOutcomes(t *testing.T) {
+	baseline := mustParse(t, `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false},
+		{"taskName":"c","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"gone","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+	current := mustParse(t, `[
+		{"taskName":"a","difficulty":"easy","taskPassed":false,"taskError":"boom"},
+		{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"c","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"new","taskPassed":false}
+	]`)
+	want := []taskChange{
+		{Task: "a", Difficulty: "easy", Baseline: "passed", Current: "error", Regression: true},
+		{Task: "b", Difficulty: "hard", Baseline: "failure", Current: "passed"},
+		{Task: "gone", Baseline: "passed"},
+		{Task: "new", Current: "error"},
+	}
+	changes := compareOutcomes(baseline, current, mustNew(t))
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("changes = %+v, want %+v", changes, want)
+	}
+
+	var out bytes.Buffer
+	printChanges(&out, changes, false)
+	wantTable := "  Task  Difficulty  Baseline  Current\n" +
+		"  a     easy        passed    error    regression\n" +
+		"  b     hard        failure   passed\n" +
+		"  gone              passed    -\n" +
+		"  new               -         error\n"
+	if out.String() != wantTable {
+		t.Errorf("changes =\n%s\nwant\n%s", out.String(), wantTable)
+	}
+
+	out.Reset()
+	printChanges(&out, changes, true)
+	for _, want := range []string{
+		"  a     easy        " + ansiGreen + "passed" + ansiReset + "    " + ansiRed + "error" + ansiReset + "    " + ansiBold + ansiRed + "regression" + ansiReset,
+		"  b     hard        " + ansiYellow + "failure" + ansiReset,
+		"  new               -         " + ansiRed + "error" + ansiReset,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("colored changes do not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestDiffConversionFlags(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+
+	dir := t.TempDir()
+	baseline := filepath.Join(dir, "baseline.json")
+	current := filepath.Join(dir, "current.json")
+	if err := os.WriteFile(baseline, []byte(`[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","taskPassed":true,"allAssertionsPassed":true}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(current, []byte(`[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"cleanupOutput":{"Success":false,"Error":"namespace stuck"}},
+		{"taskName":"b","taskPassed":false,"taskError":"boom"}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		flags []string
+		want  []string
+	}{
+		{name: "defaults", want: []string{"a", "b"}},
+		{name: "phase policy", flags: []string{"--phase-policy", "cleanup=warn"}, want: []string{"b"}},
+		{name: "task filter", flags: []string{"--exclude-task", "^b$"}, want: []string{"a"}},
+		{name: "both", flags: []string{"--phase-policy", "cleanup=ignore", "--include-task", "^a$"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out.Reset()
+			err := runCLI(context.Background(), append(append([]string{"diff", "--json"}, tt.flags...), baseline, current))
+			var gateErr gateError
+			if (len(tt.want) > 0) != errors.As(err, &gateErr) {
+				t.Errorf("diff error = %v, want a failed gate only with regressions", err)
+			}
+			var changes []taskChange
+			if err := json.Unmarshal(out.Bytes(), &changes); err != nil {
+				t.Fatalf("%v:\n%s", err, out.String())
+			}
+			var tasks []string
+			for _, c := range changes {
+				tasks = append(tasks, c.Task)
+			}
+			if !reflect.DeepEqual(tasks, tt.want) {
+				t.Errorf("changed tasks = %q, want %q", tasks, tt.want)
+			}
+		})
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	got := wilsonInterval(8, 10, 1.96)
+	if got.Rate != 0.8 || math.Abs(got.Lower-0.4902) > 1e-4 || math.Abs(got.Upper-0.9433) > 1e-4 {
+		t.Errorf("wilsonInterval(8, 10) = %+v, want 0.8 in [0.4902, 0.9433]", got)
+	}
+	if got := wilsonInterval(0, 0, 1.96); got != (passInterval{}) {
+		t.Errorf("wilsonInterval(0, 0) = %+v, want zero", got)
+	}
+}
+
+// sampledRun returns a run of one easy task sampled passed times out of n
+func sampledRun(t *testing.T, passed, n int) string {
+	t.Helper()
+	samples := make([]string, n)
+	for i := range samples {
+		samples[i] = fmt.Sprintf(`{"taskName":"a","taskPassed":true,"allAssertionsPassed":%t}`, i < passed)
+	}
+	return `[{"taskName":"a","difficulty":"easy","taskPassed":true,"samples":[` + strings.Join(samples, ",") + `]}]`
+}
+
+func TestDiffStats(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	baseline := write("baseline.json", sampledRun(t, 18, 20))
+	noisy := write("noisy.json", sampledRun(t, 16, 20))
+	broken := write("broken.json", sampledRun(t, 6, 20))
+
+	// A drop within the noise of 20 samples is no regression
+	if err := runCLI(context.Background(), []string{"diff", "--stats", baseline, noisy}); err != nil {
+		t.Fatalf("diff --stats of a noisy run: %v", err)
+	}
+	want := "  Difficulty       Baseline        Current   Delta      95% interval\n" +
+		"  easy        90.0% (18/20)  80.0% (16/20)  -10.0%  [-32.8%, +13.4%]\n" +
+		"  Total       90.0% (18/20)  80.0% (16/20)  -10.0%  [-32.8%, +13.4%]\n"
+	if out.String() != want {
+		t.Errorf("diff --stats =\n%s\nwant\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	err := runCLI(context.Background(), []string{"diff", "--stats", baseline, broken})
+	var gateErr gateError
+	if !errors.As(err, &gateErr) || gateErr.code != exitCodeGateFailed || err.Error() != "Significant pass-rate regressions: easy, Total" {
+		t.Errorf("diff --stats of a broken run error = %v, want significant regressions", err)
+	}
+	if !strings.Contains(out.String(), "regression") {
+		t.Errorf("diff --stats does not flag the regression:\n%s", out.String())
+	}
+
+	out.Reset()
+	runCLI(context.Background(), []string{"diff", "--stats", "--color", "always", baseline, broken})
+	if !strings.Contains(out.String(), ansiRed+"-60.0%"+ansiReset) || !strings.Contains(out.String(), ansiBold+ansiRed+"regression"+ansiReset) {
+		t.Errorf("diff --stats --color always does not color the regression:\n%q", out.String())
+	}
+
+	// Without --stats, a sampled task counts once, with the outcome of its samples
+	out.Reset()
+	if err := runCLI(context.Background(), []string{"diff", baseline, noisy}); err != nil || out.String() != "No task changed outcome.\n" {
+		t.Errorf("diff = %q, %v, want no change", out.String(), err)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"slices"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Output formats of diff
+const (
+	diffFormatText = "text"
+	diffFormatJSON = "json"
+	diffFormatHTML = "html"
+)
+
+// diffFormats lists the output formats of diff
+var diffFormats = []string{diffFormatText, diffFormatJSON, diffFormatHTML}
+
+// taskDetail is a task whose outcome changed, with its assertions whose
+// outcome changed too and, for regressions, its tool calls in both runs
+type taskDetail struct {
+	taskChange
+	Assertions []assertionChange
+	Calls      []callRow
+}
+
+// assertionChange is an assertion of a task whose outcome differs between
+// the two runs of diff
+type assertionChange struct {
+	Name string
+	// Baseline and Current are "passed" or "failed", or empty when the
+	// task of the run does not have the assertion
+	Baseline string
+	Current  string
+	// Message explains the outcome of the assertion in the current run
+	Message string
+}
+
+// callRow is a row of the side-by-side tool calls of a task: a call made in
+// both runs, one made in a single run, or a pair of calls made in place of
+// each other
+type callRow struct {
+	// Kind is "same", "removed", "added" or "changed"
+	Kind     string
+	Baseline string
+	Current  string
+}
+
+// diffRun describes one of the two runs of an HTML diff
+type diffRun struct {
+	Input string
+	RunID string
+}
+
+// diffPage is what diffTemplate lays out
+type diffPage struct {
+	Baseline diffRun
+	Current  diffRun
+	// Rates and Confidence are the comparison of diff --stats, if any
+	Rates      []rateDelta
+	Confidence float64
+	Tasks      []taskDetail
+}
+
+// taskDetails adds the changed assertions of each changed task and, for
+// regressions, the tool calls of both runs aligned side by side
+func taskDetails(baseline, current converter.TestRun, changes []taskChange) []taskDetail {
+	before, after := resultsByTask(baseline), resultsByTask(current)
+	details := make([]taskDetail, 0, len(changes))
+	for _, c := range changes {
+		d := taskDetail{taskChange: c, Assertions: compareAssertions(before[c.Task], after[c.Task])}
+		if c.Regression {
+			d.Calls = alignCalls(callNames(before[c.Task]), callNames(after[c.Task]))
+		}
+		details = append(details, d)
+	}
+	return details
+}
+
+// resultsByTask indexes the results of run by task name; like
+// compareOutcomes, the last result of a task wins
+func resultsByTask(run converter.TestRun) map[string]converter.MCPTestResult {
+	results := make(map[string]converter.MCPTestResult, len(run.Results))
+	for _, result := range run.Results {
+		if result.ParseError() == nil {
+			results[result.TaskName] = result
+		}
+	}
+	return results
+}
+
+// compareAssertions returns the assertions whose outcome differs between
+// the baseline and the current result of a task, sorted by name
+func compareAssertions(baseline, current converter.MCPTestResult) []assertionChange {
+	outcome := func(assertions map[string]converter.Assertion, name string) string {
+		assertion, ok := assertions[name]
+		switch {
+		case !ok:
+			return ""
+		case assertion.Passed:
+			return "passed"
+		}
+		return "failed"
+	}
+	var names []string
+	for _, assertions := range []map[string]converter.Assertion{baseline.AssertionResults, current.AssertionResults} {
+		for name := range assertions {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	var changes []assertionChange
+	for _, name := range names {
+		c := assertionChange{Name: name, Baseline: outcome(baseline.AssertionResults, name), Current: outcome(current.AssertionResults, name)}
+		if c.Baseline != c.Current {
+			c.Message = current.AssertionResults[name].Message
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// callNames names the tool calls of a result as server::tool, marking the
+// failed ones
+func callNames(result converter.MCPTestResult) []string {
+	names := make([]string, 0, len(result.CallHistory.ToolCalls))
+	for _, call := range result.CallHistory.ToolCalls {
+		name := call.Name
+		if call.ServerName != "" {
+			name = call.ServerName + "::" + name
+		}
+		if !call.Success {
+			name += " (failed)"
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// alignCalls lines up the tool calls of the two runs along their longest
+// common subsequence. A run of calls only in the baseline followed or
+// preceded by calls only in the current run is paired up row by row, so
+// that a call replaced by another shows on one row.
+func alignCalls(baseline, current []string) []callRow {
+	// common[i][j] is the length of the longest common subsequence of
+	// baseline[i:] and current[j:]
+	common := make([][]int, len(baseline)+1)
+	for i := range common {
+		common[i] = make([]int, len(current)+1)
+	}
+	for i := len(baseline) - 1; i >= 0; i-- {
+		for j := len(current) - 1; j >= 0; j-- {
+			if baseline[i] == current[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var rows []callRow
+	var removed, added []string
+	flush := func() {
+		for k := range max(len(removed), len(added)) {
+			row := callRow{Kind: "changed"}
+			switch {
+			case k >= len(added):
+				row = callRow{Kind: "removed", Baseline: removed[k]}
+			case k >= len(removed):
+				row = callRow{Kind: "added", Current: added[k]}
+			default:
+				row.Baseline, row.Current = removed[k], added[k]
+			}
+			rows = append(rows, row)
+		}
+		removed, added = nil, nil
+	}
+	i, j := 0, 0
+	for i < len(baseline) || j < len(current) {
+		switch {
+		case i < len(baseline) && j < len(current) && baseline[i] == current[j]:
+			flush()
+			rows = append(rows, callRow{Kind: "same", Baseline: baseline[i], Current: current[j]})
+			i, j = i+1, j+1
+		case j == len(current) || i < len(baseline) && common[i+1][j] >= common[i][j+1]:
+			removed = append(removed, baseline[i])
+			i++
+		default:
+			added = append(added, current[j])
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+// writeDiffHTML writes the comparison of two runs as a standalone HTML page
+func writeDiffHTML(w io.Writer, page diffPage) error {
+	return diffTemplate.Execute(w, page)
+}
+
+// diffTemplate lays out the HTML comparison of two runs: the pass rates with
+// --stats, the tasks whose outcome changed, then the details of each of
+// them, regressions expanded
+var diffTemplate = template.Must(template.New("diff").Funcs(template.FuncMap{
+	"passRate":       formatPassRate,
+	"rateDelta":      formatRateDelta,
+	"interval":       formatDeltaInterval,
+	"intervalHeader": intervalHeader,
+	"orDash": func(s string) string {
+		if s == "" {
+			return "-"
+		}
+		return s
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Run comparison</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+.passed { color: #080; } .failure, .error, .failed { color: #c00; } .skipped { color: #888; }
+.regression { color: #c00; font-weight: bold; } .improvement { color: #080; }
+.calls td { font-family: monospace; width: 50%; }
+tr.removed td.baseline { background: #fdd; } tr.added td.current { background: #dfd; } tr.changed td { background: #ffd; }
+</style>
+</head>
+<body>
+<h1>Run comparison</h1>
+<table>
+<tr><th></th><th>Baseline</th><th>Current</th></tr>
+<tr><td>Input</td><td>{{.Baseline.Input}}</td><td>{{.Current.Input}}</td></tr>
+{{- if or .Baseline.RunID .Current.RunID}}
+<tr><td>Run</td><td>{{orDash .Baseline.RunID}}</td><td>{{orDash .Current.RunID}}</td></tr>
+{{- end}}
+</table>
+{{- if .Rates}}
+<h2>Pass rates</h2>
+<table>
+<tr><th>Difficulty</th><th>Baseline</th><th>Current</th><th>Delta</th><th>{{intervalHeader .Confidence}}</th><th></th></tr>
+{{- range .Rates}}
+<tr><td>{{.Difficulty}}</td><td>{{passRate .Baseline}}</td><td>{{passRate .Current}}</td><td>{{rateDelta .}}</td><td>{{interval .}}</td>
+<td>{{if and .Significant (lt .Delta 0.0)}}<span class="regression">regression</span>{{else if .Significant}}<span class="improvement">improvement</span>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<h2>Status changes</h2>
+{{- if .Tasks}}
+<table>
+<tr><th>Task</th><th>Difficulty</th><th>Baseline</th><th>Current</th><th></th></tr>
+{{- range $i, $task := .Tasks}}
+<tr><td><a href="#task-{{$i}}">{{.Task}}</a></td><td>{{.Difficulty}}</td><td class="{{.Baseline}}">{{orDash .Baseline}}</td><td class="{{.Current}}">{{orDash .Current}}</td>
+<td>{{if .Regression}}<span class="regression">regression</span>{{end}}</td></tr>
+{{- end}}
+</table>
+{{- range $i, $task := .Tasks}}
+<details id="task-{{$i}}"{{if .Regression}} open{{end}}>
+<summary><strong>{{.Task}}</strong>: <span class="{{.Baseline}}">{{orDash .Baseline}}</span> &rarr; <span class="{{.Current}}">{{orDash .Current}}</span></summary>
+{{- if .Assertions}}
+<h4>Assertions</h4>
+<table>
+<tr><th>Assertion</th><th>Baseline</th><th>Current</th><th>Message</th></tr>
+{{- range .Assertions}}
+<tr><td>{{.Name}}</td><td class="{{.Baseline}}">{{orDash .Baseline}}</td><td class="{{.Current}}">{{orDash .Current}}</td><td>{{.Message}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No assertion changed outcome.</p>
+{{- end}}
+{{- if .Regression}}
+<h4>Tool calls</h4>
+{{- if .Calls}}
+<table class="calls">
+<tr><th>Baseline</th><th>Current</th></tr>
+{{- range .Calls}}
+<tr class="{{.Kind}}"><td class="baseline">{{.Baseline}}</td><td class="current">{{.Current}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>No tool calls in either run.</p>
+{{- end}}
+{{- end}}
+</details>
+{{- end}}
+{{- else}}
+<p>No task changed outcome.</p>
+{{- end}}
+</body>
+</html>
+`))
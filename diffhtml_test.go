@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlignCalls(t *testing.T) {
+	tests := []struct {
+		name              string
+		baseline, current []string
+		want              []callRow
+	}{
+		{name: "no calls"},
+		{
+			name:     "same calls",
+			baseline: []string{"kube::get", "kube::apply"},
+			current:  []string{"kube::get", "kube::apply"},
+			want:     []callRow{{Kind: "same", Baseline: "kube::get", Current: "kube::get"}, {Kind: "same", Baseline: "kube::apply", Current: "kube::apply"}},
+		},
+		{
+			name:     "replaced, missing and extra calls",
+			baseline: []string{"kube::get", "kube::apply", "kube::wait"},
+			current:  []string{"kube::get", "kube::delete", "kube::apply (failed)", "helm::list"},
+			want: []callRow{
+				{Kind: "same", Baseline: "kube::get", Current: "kube::get"},
+				{Kind: "changed", Baseline: "kube::apply", Current: "kube::delete"},
+				{Kind: "changed", Baseline: "kube::wait", Current: "kube::apply (failed)"},
+				{Kind: "added", Current: "helm::list"},
+			},
+		},
+		{
+			name:     "calls only in the baseline",
+			baseline: []string{"kube::get", "kube::apply"},
+			current:  []string{"kube::apply"},
+			want:     []callRow{{Kind: "removed", Baseline: "kube::get"}, {Kind: "same", Baseline: "kube::apply", Current: "kube::apply"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := alignCalls(tt.baseline, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("alignCalls() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskDetails(t *testing.T) {
+	baseline := mustParse(t, `[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,
+		 "assertionResults":{"pods-ready":{"passed":true},"called":{"passed":true},"removed":{"passed":true}},
+		 "callHistory":{"ToolCalls":[{"serverName":"kube","name":"get","success":true},{"serverName":"kube","name":"apply","success":true}]}},
+		{"taskName":"b","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"called":{"passed":false}}}
+	]`)
+	current := mustParse(t, `[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":false,
+		 "assertionResults":{"pods-ready":{"passed":false,"message":"0/3 pods ready"},"called":{"passed":true},"added":{"passed":true}},
+		 "callHistory":{"ToolCalls":[{"serverName":"kube","name":"get","success":false}]}},
+		{"taskName":"b","taskPassed":true,"allAssertionsPassed":true,"assertionResults":{"called":{"passed":true}},
+		 "callHistory":{"ToolCalls":[{"name":"get","success":true}]}}
+	]`)
+	details := taskDetails(baseline, current, compareOutcomes(baseline, current, mustNew(t)))
+	if len(details) != 2 {
+		t.Fatalf("details = %+v, want a and b", details)
+	}
+
+	wantAssertions := []assertionChange{
+		{Name: "added", Current: "passed"},
+		{Name: "pods-ready", Baseline: "passed", Current: "failed", Message: "0/3 pods ready"},
+		{Name: "removed", Baseline: "passed"},
+	}
+	if !reflect.DeepEqual(details[0].Assertions, wantAssertions) {
+		t.Errorf("assertions of a = %+v, want %+v", details[0].Assertions, wantAssertions)
+	}
+	wantCalls := []callRow{{Kind: "changed", Baseline: "kube::get", Current: "kube::get (failed)"}, {Kind: "removed", Baseline: "kube::apply"}}
+	if !reflect.DeepEqual(details[0].Calls, wantCalls) {
+		t.Errorf("calls of a = %+v, want %+v", details[0].Calls, wantCalls)
+	}
+	// Only regressions have their tool calls compared
+	if details[1].Regression || details[1].Calls != nil || len(details[1].Assertions) != 1 {
+		t.Errorf("details of b = %+v", details[1])
+	}
+}
+
+func TestDiffHTML(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	baseline := write("baseline.json", `{"runId":"run-1","results":[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,"assertionResults":{"pods-ready":{"passed":true}},
+		 "callHistory":{"ToolCalls":[{"serverName":"kube","name":"apply","success":true}]}},
+		{"taskName":"<b>","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false}]}`)
+	current := write("current.json", `{"runId":"run-2","results":[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false,"message":"0/3 pods ready"}},
+		 "callHistory":{"ToolCalls":[{"serverName":"kube","name":"delete","success":true}]}},
+		{"taskName":"<b>","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}]}`)
+
+	err := runCLI(context.Background(), []string{"diff", "--format", "html", baseline, current})
+	var gateErr gateError
+	if !errors.As(err, &gateErr) || gateErr.code != exitCodeGateFailed {
+		t.Errorf("diff --format html error = %v, want the regression to fail the gate", err)
+	}
+	page := out.String()
+	for _, want := range []string{
+		"<title>Run comparison</title>",
+		"<tr><td>Run</td><td>run-1</td><td>run-2</td></tr>",
+		`<tr><td><a href="#task-0">&lt;b&gt;</a></td><td>hard</td><td class="failure">failure</td><td class="passed">passed</td>`,
+		`<details id="task-1" open>`,
+		`<tr><td>pods-ready</td><td class="passed">passed</td><td class="failed">failed</td><td>0/3 pods ready</td></tr>`,
+		`<tr class="changed"><td class="baseline">kube::apply</td><td class="current">kube::delete</td></tr>`,
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML diff does not contain %q:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "Pass rates") {
+		t.Errorf("HTML diff without --stats has pass rates:\n%s", page)
+	}
+
+	out.Reset()
+	runCLI(context.Background(), []string{"diff", "--stats", "--format", "html", baseline, current})
+	if !strings.Contains(out.String(), "<th>95% interval</th>") || !strings.Contains(out.String(), `<a href="#task-1">a</a>`) {
+		t.Errorf("HTML diff --stats does not have both the pass rates and the status changes:\n%s", out.String())
+	}
+
+	for _, args := range [][]string{
+		{"diff", "--format", "pdf", baseline, current},
+		{"diff", "--json", "--format", "html", baseline, current},
+	} {
+		var usage usageError
+		if err := runCLI(context.Background(), args); !errors.As(err, &usage) {
+			t.Errorf("runCLI(%q) error = %v, want a usage error", args, err)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"maps"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Defaults of notify email
+const (
+	defaultSMTPPort        = 587
+	defaultSMTPPasswordEnv = "SMTP_PASSWORD"
+)
+
+// Values of --tls of notify email
+const (
+	smtpStartTLS = "starttls"
+	smtpTLS      = "tls"
+	smtpNoTLS    = "none"
+)
+
+var smtpTLSModes = []string{smtpStartTLS, smtpTLS, smtpNoTLS}
+
+// emailRouting is a --recipients file: the recipients of the digest of the
+// tasks of each difficulty level, and of the tasks with each tag
+type emailRouting struct {
+	Difficulty map[string][]string `yaml:"difficulty"`
+	Tags       map[string][]string `yaml:"tags"`
+}
+
+// smtpServer is the server notify email sends through
+type smtpServer struct {
+	host     string
+	port     int
+	tlsMode  string
+	user     string
+	password string
+}
+
+// notifyEmail implements notify email: it sends an HTML digest of the
+// results to --to, and of the tasks routed to them to the recipients of
+// --recipients, through an SMTP server
+func notifyEmail(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	server := smtpServer{}
+	fs.StringVar(&server.host, "smtp-host", "", "SMTP server to send through")
+	fs.IntVar(&server.port, "smtp-port", defaultSMTPPort, "port of the SMTP server")
+	fs.StringVar(&server.tlsMode, "tls", smtpStartTLS, "TLS mode: "+strings.Join(smtpTLSModes, ", ")+" for implicit TLS, usually on port 465")
+	fs.StringVar(&server.user, "user", "", "user to authenticate as, with the password in --password-env")
+	passwordEnv := fs.String("password-env", defaultSMTPPasswordEnv, "environment variable holding the password of --user")
+	from := fs.String("from", "", "sender address")
+	to := fs.String("to", "", "comma-separated recipients of the digest of every task")
+	recipientsFile := fs.String("recipients", "", "YAML file routing the digest of the tasks of a difficulty level or tag to recipients, under difficulty: and tags:")
+	subject := fs.String("subject", "", `subject of the emails (default "MCP checker: <passed> of <tasks> tasks passed")`)
+	onlyFailures := fs.Bool("only-failures", false, "only send the digests with failed or errored tasks")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	switch {
+	case server.host == "" || *from == "":
+		return newUsageError("notify email requires --smtp-host and --from")
+	case *to == "" && *recipientsFile == "":
+		return newUsageError("notify email requires --to or --recipients")
+	case !slices.Contains(smtpTLSModes, server.tlsMode):
+		return newUsageError("--tls must be one of %s", strings.Join(smtpTLSModes, ", "))
+	}
+	var routing emailRouting
+	if *recipientsFile != "" {
+		data, err := os.ReadFile(*recipientsFile)
+		if err != nil {
+			return newUsageError("invalid --recipients: %v", err)
+		}
+		if err := yaml.Unmarshal(data, &routing); err != nil {
+			return newUsageError("invalid --recipients %s: %v", *recipientsFile, err)
+		}
+	}
+	if server.user != "" {
+		if server.password, err = secretFromEnv("password-env", *passwordEnv); err != nil {
+			return err
+		}
+	}
+	conv, err := newPublishConverter()
+	if err != nil {
+		return err
+	}
+	_, tests, err := loadPublishedTests(ctx, fs.Args(), opts, conv)
+	if err != nil {
+		return err
+	}
+
+	digests := routeDigests(tests, splitList(*to), routing)
+	var sent int
+	for _, recipient := range slices.Sorted(maps.Keys(digests)) {
+		recipientTests := digests[recipient]
+		rows := summaryRows(recipientTests)
+		total := rows[len(rows)-1]
+		if *onlyFailures && total.failed+total.errored == 0 {
+			continue
+		}
+		body, err := renderDigest(recipientTests)
+		if err != nil {
+			return err
+		}
+		message := emailMessage(*from, recipient, cmp.Or(*subject, defaultPublishName+": "+passedTitle(total)), body, time.Now())
+		if err := server.send(ctx, *from, recipient, message); err != nil {
+			return fmt.Errorf("sending the digest to %s: %w", recipient, err)
+		}
+		sent++
+	}
+	slog.Info("sent digests", "emails", sent)
+	return nil
+}
+
+// routeDigests returns the tests of the digest of each recipient, in the
+// order of tests: every test for the recipients of to, and for those of
+// routing the tests of their difficulty levels and tags
+func routeDigests(tests []publishedTest, to []string, routing emailRouting) map[string][]publishedTest {
+	digests := make(map[string][]publishedTest)
+	for _, test := range tests {
+		recipients := slices.Clone(to)
+		recipients = append(recipients, routing.Difficulty[cmp.Or(test.Result.Difficulty, converter.UnknownGroup)]...)
+		for _, tag := range test.Result.Tags {
+			recipients = append(recipients, routing.Tags[tag]...)
+		}
+		slices.Sort(recipients)
+		for _, recipient := range slices.Compact(recipients) {
+			digests[recipient] = append(digests[recipient], test)
+		}
+	}
+	return digests
+}
+
+// digestTemplate lays out the HTML digest: the pass rate of each difficulty
+// level, then the failed and errored tasks with their failure
+var digestTemplate = template.Must(template.New("digest").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>{{.Title}}</h2>
+<table cellpadding="4" style="border-collapse: collapse">
+<tr><th align="left">Difficulty</th><th>Tests</th><th>Passed</th><th>Failed</th><th>Errors</th><th>Skipped</th><th>Pass rate</th></tr>
+{{- range .Rows}}
+<tr><td>{{.Name}}</td><td align="right">{{.Tests}}</td><td align="right">{{.Passed}}</td><td align="right">{{.Failed}}</td><td align="right">{{.Errors}}</td><td align="right">{{.Skipped}}</td><td align="right">{{.PassRate}}</td></tr>
+{{- end}}
+</table>
+{{- if .Failures}}
+<h3>Failing tasks</h3>
+{{- range .Failures}}
+<h4 style="color: #c00">{{.Task}} ({{.Outcome}})</h4>
+<p>{{.Message}}</p>
+{{- if .Details}}
+<pre style="background: #f6f8fa; padding: 8px; white-space: pre-wrap">{{.Details}}</pre>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// digestRow is a row of the summary table of the digest
+type digestRow struct {
+	Name                                   string
+	Tests, Passed, Failed, Errors, Skipped int
+	PassRate                               string
+}
+
+// newDigestRow returns the digestRow of the counts of row
+func newDigestRow(row summaryRow) digestRow {
+	return digestRow{Name: row.name, Tests: row.tests, Passed: row.passed, Failed: row.failed,
+		Errors: row.errored, Skipped: row.skipped, PassRate: fmt.Sprintf("%.1f%%", row.passRate()*100)}
+}
+
+// digestFailure is a failed or errored task of the digest
+type digestFailure struct {
+	Task, Outcome, Message, Details string
+}
+
+// renderDigest renders the HTML digest of tests
+func renderDigest(tests []publishedTest) (string, error) {
+	data := struct {
+		Title    string
+		Rows     []digestRow
+		Failures []digestFailure
+	}{}
+	rows := summaryRows(tests)
+	for _, row := range rows {
+		data.Rows = append(data.Rows, newDigestRow(row))
+	}
+	data.Title = defaultPublishName + ": " + passedTitle(rows[len(rows)-1])
+	for _, test := range tests {
+		if comment := test.failureComment(); comment != "" {
+			message, details, _ := strings.Cut(comment, "\n\n")
+			data.Failures = append(data.Failures, digestFailure{Task: test.Result.TaskName, Outcome: test.Outcome, Message: message, Details: details})
+		}
+	}
+	var html strings.Builder
+	if err := digestTemplate.Execute(&html, data); err != nil {
+		return "", err
+	}
+	return html.String(), nil
+}
+
+// emailMessage returns the email of the HTML body, quoted-printable so that
+// no line is too long for SMTP
+func emailMessage(from, to, subject, body string, date time.Time) []byte {
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\n", from, to,
+		mime.QEncoding.Encode("utf-8", subject), date.Format(time.RFC1123Z))
+	message.WriteString("Content-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+	writer := quotedprintable.NewWriter(&message)
+	writer.Write([]byte(body))
+	writer.Close()
+	return message.Bytes()
+}
+
+// send delivers message from from to the recipient to, authenticating
+// when a user is set
+func (s smtpServer) send(ctx context.Context, from, to string, message []byte) error {
+	address := net.JoinHostPort(s.host, strconv.Itoa(s.port))
+	dialer := &net.Dialer{Timeout: defaultHTTPTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	if s.tlsMode == smtpTLS {
+		conn = tls.Client(conn, &tls.Config{ServerName: s.host})
+	}
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if s.tlsMode == smtpStartTLS {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return err
+		}
+	}
+	if s.user != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(message); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"io"
+	"maps"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sentEmail is an email received by the fake SMTP server of the tests
+type sentEmail struct {
+	to      string
+	subject string
+	body    string
+}
+
+// startSMTPServer starts an SMTP server that accepts every email without
+// TLS or authentication, and returns its port and the emails it received
+func startSMTPServer(t *testing.T) (int, func() []sentEmail) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	var mu sync.Mutex
+	var emails []sentEmail
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			text := textproto.NewConn(conn)
+			text.PrintfLine("220 localhost ESMTP")
+			var to string
+			for {
+				line, err := text.ReadLine()
+				if err != nil {
+					break
+				}
+				verb, arg, _ := strings.Cut(line, " ")
+				switch strings.ToUpper(verb) {
+				case "EHLO", "HELO":
+					text.PrintfLine("250 localhost")
+				case "RCPT":
+					to = strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+					text.PrintfLine("250 OK")
+				case "DATA":
+					text.PrintfLine("354 go ahead")
+					data, _ := io.ReadAll(text.DotReader())
+					message, err := mail.ReadMessage(strings.NewReader(string(data)))
+					if err != nil {
+						t.Error(err)
+						text.PrintfLine("554 bad message")
+						continue
+					}
+					body, _ := io.ReadAll(quotedprintable.NewReader(message.Body))
+					mu.Lock()
+					emails = append(emails, sentEmail{to: to, subject: message.Header.Get("Subject"), body: string(body)})
+					mu.Unlock()
+					text.PrintfLine("250 OK")
+				case "QUIT":
+					text.PrintfLine("221 bye")
+				default:
+					text.PrintfLine("250 OK")
+				}
+			}
+			conn.Close()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port, func() []sentEmail {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(emails)
+	}
+}
+
+func TestNotifyEmail(t *testing.T) {
+	port, emails := startSMTPServer(t)
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	recipients := write("recipients.yaml", "difficulty:\n  easy: [easy@example.com]\n  hard: [hard@example.com]\ntags:\n  k8s: [k8s@example.com]\n")
+	input := write("results.json", `[
+		{"taskName":"a","difficulty":"easy","tags":["k8s"],"taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"hard","tags":["k8s"],"taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false}}},
+		{"taskName":"c","difficulty":"hard","taskPassed":false,"taskError":"<boom>"}
+	]`)
+	err := runCLI(context.Background(), []string{"notify", "email", "--smtp-host", "127.0.0.1", "--smtp-port", strconv.Itoa(port), "--tls", "none",
+		"--from", "ci@example.com", "--to", "all@example.com", "--recipients", recipients, "--only-failures", input})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// easy@example.com only has a task that passed
+	got := make(map[string]sentEmail)
+	for _, email := range emails() {
+		got[email.to] = email
+	}
+	if want := []string{"all@example.com", "hard@example.com", "k8s@example.com"}; !reflect.DeepEqual(slices.Sorted(maps.Keys(got)), want) {
+		t.Fatalf("emails = %+v, want emails to %q", got, want)
+	}
+	if subject := got["all@example.com"].subject; subject != "MCP checker: 1 of 3 tasks passed" {
+		t.Errorf("subject = %q", subject)
+	}
+	if subject := got["hard@example.com"].subject; subject != "MCP checker: 0 of 2 tasks passed" {
+		t.Errorf("subject of the hard digest = %q", subject)
+	}
+	k8s := got["k8s@example.com"].body
+	if !strings.Contains(k8s, "<h4 style=\"color: #c00\">b (failure)</h4>") || strings.Contains(k8s, ">c (") {
+		t.Errorf("digest of k8s = %s, want the failure of b only", k8s)
+	}
+	if all := got["all@example.com"].body; !strings.Contains(all, "&lt;boom&gt;") {
+		t.Errorf("digest = %s, want the escaped error of c", all)
+	}
+}
+
+func TestEmailMessage(t *testing.T) {
+	date := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	message := string(emailMessage("ci@example.com", "qa@example.com", "Résultats", "<p>"+strings.Repeat("x", 200)+"</p>", date))
+	for _, want := range []string{"Subject: =?utf-8?q?R=C3=A9sultats?=\r\n", "Date: Fri, 02 Jan 2026 03:04:05 +0000\r\n", "Content-Transfer-Encoding: quoted-printable\r\n"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("message = %q, want %q", message, want)
+		}
+	}
+	for _, line := range strings.Split(message, "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("line of %d characters, want quoted-printable lines", len(line))
+		}
+	}
+}
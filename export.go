@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Supported values for the --format flag of export
+const (
+	exportInflux  = "influx"
+	exportGrafana = "grafana-json"
+)
+
+var exportFormats = []string{exportInflux, exportGrafana}
+
+// allDifficulties tags the metrics of all tasks together
+const allDifficulties = "all"
+
+// metric is one time-stamped point of the export: a measurement with its
+// tags, in the order they are written, and its fields
+type metric struct {
+	name   string
+	tags   [][2]string
+	fields []metricField
+}
+
+// metricField is a field of a metric; integer fields are counts
+type metricField struct {
+	key     string
+	value   float64
+	integer bool
+}
+
+// runExport implements the export command
+func runExport(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	checkFormat := oneOf("format", exportFormats...)
+	format := addCheckedString(fs, "format", exportInflux, "output format: "+strings.Join(exportFormats, ", "), checkFormat)
+	output := fs.String("output", "", "write the metrics to this file, or an s3:// or gs:// URI, instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	if err := checkFormat(*format); err != nil {
+		return err
+	}
+	run, err := loadInputs(ctx, fs.Args(), opts)
+	if err != nil {
+		return err
+	}
+
+	metrics, err := collectMetrics(run)
+	if err != nil {
+		return err
+	}
+	at := runTime(run, time.Now)
+	var data []byte
+	if *format == exportGrafana {
+		if data, err = grafanaSeries(metrics, at); err != nil {
+			return err
+		}
+	} else {
+		data = []byte(influxLines(metrics, at))
+	}
+	return writeOutput(ctx, *output, data)
+}
+
+// runTime returns the start time of run or, when it has none or it is not
+// an RFC 3339 time, now
+func runTime(run converter.TestRun, now func() time.Time) time.Time {
+	if run.StartedAt != "" {
+		at, err := time.Parse(time.RFC3339Nano, run.StartedAt)
+		if err == nil {
+			return at
+		}
+		slog.Warn("startedAt is not an RFC 3339 time, exporting at the current time", "startedAt", run.StartedAt)
+	}
+	return now()
+}
+
+// collectMetrics returns the metrics of run: the outcome counts and pass
+// rate of each difficulty level and of all tasks, the mean phase durations
+// of each difficulty level, and the tool calls of each server and tool
+func collectMetrics(run converter.TestRun) ([]metric, error) {
+	conv, err := converter.New(converter.WithSuiteNameTemplate(groupNameTemplate),
+		converter.WithoutSystemOut(), converter.WithoutSystemErr())
+	if err != nil {
+		return nil, err
+	}
+	report, err := conv.Convert(run)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []metric
+	total := summaryRow{name: allDifficulties}
+	rows := []*summaryRow{}
+	for _, suite := range report.Suites {
+		row := &summaryRow{name: suite.Name}
+		for _, testCase := range suite.AllTestCases() {
+			row.add(testCase)
+			total.add(testCase)
+		}
+		rows = append(rows, row)
+	}
+	for _, row := range append(rows, &total) {
+		metrics = append(metrics, metric{name: "mcpchecker_tasks", tags: [][2]string{{"difficulty", row.name}}, fields: []metricField{
+			{key: "tests", value: float64(row.tests), integer: true},
+			{key: "passed", value: float64(row.passed), integer: true},
+			{key: "failed", value: float64(row.failed), integer: true},
+			{key: "errors", value: float64(row.errored), integer: true},
+			{key: "skipped", value: float64(row.skipped), integer: true},
+			{key: "pass_rate", value: row.passRate()},
+		}})
+	}
+
+	stats := computeStats(run, conv)
+	for _, p := range stats.Phases {
+		for _, phase := range converter.Phases {
+			if ms, ok := p.MeanMs[phase]; ok {
+				metrics = append(metrics, metric{name: "mcpchecker_phase_duration", tags: [][2]string{{"difficulty", p.Difficulty}, {"phase", phase}},
+					fields: []metricField{{key: "mean_ms", value: ms}}})
+			}
+		}
+	}
+	for _, t := range stats.Tools {
+		metrics = append(metrics, metric{name: "mcpchecker_tool_calls", tags: [][2]string{{"server", t.Server}, {"tool", t.Tool}}, fields: []metricField{
+			{key: "calls", value: float64(t.Calls), integer: true},
+			{key: "succeeded", value: float64(t.Succeeded), integer: true},
+			{key: "success_rate", value: t.Success},
+		}})
+	}
+	return metrics, nil
+}
+
+// influxEscaper escapes the commas, equal signs and spaces of tag keys and
+// values in the InfluxDB line protocol
+var influxEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+
+// influxLines renders metrics in the InfluxDB line protocol, time-stamped
+// at in nanoseconds. Empty tag values are left out, as the protocol
+// requires.
+func influxLines(metrics []metric, at time.Time) string {
+	var lines strings.Builder
+	for _, m := range metrics {
+		lines.WriteString(influxEscaper.Replace(m.name))
+		for _, tag := range m.tags {
+			if tag[1] != "" {
+				lines.WriteString("," + influxEscaper.Replace(tag[0]) + "=" + influxEscaper.Replace(tag[1]))
+			}
+		}
+		for i, field := range m.fields {
+			separator := ","
+			if i == 0 {
+				separator = " "
+			}
+			lines.WriteString(separator + influxEscaper.Replace(field.key) + "=" + formatMetricValue(field))
+		}
+		fmt.Fprintf(&lines, " %d\n", at.UnixNano())
+	}
+	return lines.String()
+}
+
+func formatMetricValue(field metricField) string {
+	if field.integer {
+		return strconv.FormatInt(int64(field.value), 10) + "i"
+	}
+	return strconv.FormatFloat(field.value, 'f', -1, 64)
+}
+
+// grafanaTarget is a time series in the response format of the Grafana
+// JSON datasource: datapoints are [value, unix milliseconds] pairs
+type grafanaTarget struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// grafanaSeries renders metrics as Grafana JSON datasource series, one per
+// field, named after the measurement, tag values and field, e.g.
+// "mcpchecker_tasks.easy.pass_rate"
+func grafanaSeries(metrics []metric, at time.Time) ([]byte, error) {
+	series := []grafanaTarget{}
+	for _, m := range metrics {
+		name := []string{m.name}
+		for _, tag := range m.tags {
+			if tag[1] != "" {
+				name = append(name, tag[1])
+			}
+		}
+		for _, field := range m.fields {
+			series = append(series, grafanaTarget{
+				Target:     strings.Join(append(name, field.key), "."),
+				Datapoints: [][2]float64{{field.value, float64(at.UnixMilli())}},
+			})
+		}
+	}
+	data, err := json.MarshalIndent(series, "", "  ")
+	return append(data, '\n'), err
+}
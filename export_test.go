@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const exportResults = `{"runId":"nightly","startedAt":"2026-01-02T03:04:05Z","results":[
+	{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+	 "setupOutput":{"Success":true,"DurationMs":1000},"agentOutput":{"Success":true,"DurationMs":3000},
+	 "callHistory":{"ToolCalls":[{"serverName":"kube","name":"pods_list","success":true},{"serverName":"kube","name":"pods_list","success":false}]}},
+	{"taskName":"b","difficulty":"hard","taskPassed":false,"taskError":"boom"},
+	{"taskName":"c","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}
+]}`
+
+func TestInfluxLines(t *testing.T) {
+	metrics, err := collectMetrics(mustParse(t, exportResults))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := influxLines(metrics, time.Unix(1, 5))
+	want := `mcpchecker_tasks,difficulty=easy tests=1i,passed=1i,failed=0i,errors=0i,skipped=0i,pass_rate=1 1000000005
+mcpchecker_tasks,difficulty=hard tests=2i,passed=1i,failed=0i,errors=1i,skipped=0i,pass_rate=0.5 1000000005
+mcpchecker_tasks,difficulty=all tests=3i,passed=2i,failed=0i,errors=1i,skipped=0i,pass_rate=0.6666666666666666 1000000005
+mcpchecker_phase_duration,difficulty=easy,phase=setup mean_ms=1000 1000000005
+mcpchecker_phase_duration,difficulty=easy,phase=agent mean_ms=3000 1000000005
+mcpchecker_tool_calls,server=kube,tool=pods_list calls=2i,succeeded=1i,success_rate=0.5 1000000005
+`
+	if got != want {
+		t.Errorf("influx lines =\n%s\nwant\n%s", got, want)
+	}
+
+	escaped := influxLines([]metric{{name: "m", tags: [][2]string{{"server", "my server,a=b"}, {"tool", ""}},
+		fields: []metricField{{key: "v", value: 1.5}}}}, time.Unix(0, 0))
+	if escaped != "m,server=my\\ server\\,a\\=b v=1.5 0\n" {
+		t.Errorf("escaped line = %q", escaped)
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	results := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(results, []byte(exportResults), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "metrics.txt")
+	if err := runCLI(context.Background(), []string{"export", "--output", output, results}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Points are time-stamped at the start of the run
+	if first, _, _ := strings.Cut(string(data), "\n"); !strings.HasSuffix(first, " 1767323045000000000") {
+		t.Errorf("first line = %q, want the startedAt of the run", first)
+	}
+
+	output = filepath.Join(dir, "metrics.json")
+	if err := runCLI(context.Background(), []string{"export", "--format", "grafana-json", "--output", output, results}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = os.ReadFile(output); err != nil {
+		t.Fatal(err)
+	}
+	var series []grafanaTarget
+	if err := json.Unmarshal(data, &series); err != nil {
+		t.Fatal(err)
+	}
+	targets := make(map[string][2]float64)
+	for _, s := range series {
+		targets[s.Target] = s.Datapoints[0]
+	}
+	for target, want := range map[string][2]float64{
+		"mcpchecker_tasks.hard.pass_rate":                   {0.5, 1767323045000},
+		"mcpchecker_phase_duration.easy.agent.mean_ms":      {3000, 1767323045000},
+		"mcpchecker_tool_calls.kube.pods_list.success_rate": {0.5, 1767323045000},
+	} {
+		if targets[target] != want {
+			t.Errorf("%s = %v, want %v", target, targets[target], want)
+		}
+	}
+}
+
+func TestRunTime(t *testing.T) {
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	run := mustParse(t, `{"startedAt":"2026-01-02T03:04:05.5Z","results":[]}`)
+	if got := runTime(run, clock); !got.Equal(time.Date(2026, 1, 2, 3, 4, 5, 5e8, time.UTC)) {
+		t.Errorf("runTime = %v, want the startedAt of the run", got)
+	}
+	for _, startedAt := range []string{"", "yesterday"} {
+		run.StartedAt = startedAt
+		if got := runTime(run, clock); !got.Equal(now) {
+			t.Errorf("runTime with startedAt %q = %v, want now", startedAt, got)
+		}
+	}
+}
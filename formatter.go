@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Report formats of the convert command: JUnit XML, or the output of an
+// external formatter named after the exec: prefix
+const (
+	formatJUnit      = "junit"
+	formatExecPrefix = "exec:"
+)
+
+// formatterPath returns the executable of an exec: report format, or ""
+// for JUnit XML
+func formatterPath(format string) (string, error) {
+	if format == formatJUnit {
+		return "", nil
+	}
+	path, ok := strings.CutPrefix(format, formatExecPrefix)
+	if !ok || path == "" {
+		return "", newUsageError("--format must be %s or %s/path/to/formatter, got %q", formatJUnit, formatExecPrefix, format)
+	}
+	return path, nil
+}
+
+// checkFormat checks the value of the --format flag of convert
+func checkFormat(format string) error {
+	_, err := formatterPath(format)
+	return err
+}
+
+// runFormatter pipes the run, as JSON in the envelope schema, to the stdin
+// of the formatter executable and returns what it writes to stdout. Like
+// the JUnit XML, the run is filtered and redacted by conv first. The
+// formatter inherits the environment, its stderr goes to the logs, and a
+// non-zero exit status fails the conversion.
+func runFormatter(ctx context.Context, path string, run converter.TestRun, conv *converter.Converter) ([]byte, error) {
+	input, err := conv.MarshalRun(run)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(input)
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = logOutput
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("formatter %s: %w", path, err)
+	}
+	return output.Bytes(), nil
+}
+
+// convertWithFormatter reads the inputs and writes what the formatter makes
+// of them to output. The run is still converted, for the gates to check the
+// JUnit XML they always do.
+func convertWithFormatter(ctx context.Context, inputs []string, output, formatter string, opts inputOptions, conv *converter.Converter) (converter.JUnitTestSuites, error) {
+	testRun, err := loadInputs(ctx, inputs, opts)
+	if err != nil {
+		return converter.JUnitTestSuites{}, err
+	}
+	if err := checkResults(testRun, opts); err != nil {
+		return converter.JUnitTestSuites{}, err
+	}
+	junitXML, err := convertRun(ctx, testRun, conv)
+	if err != nil {
+		return junitXML, err
+	}
+	report, err := runFormatter(ctx, formatter, testRun, conv)
+	if err != nil {
+		return junitXML, err
+	}
+	return junitXML, writeOutput(ctx, output, report)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeFormatter writes a shell script formatter to dir
+func writeFormatter(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("formatter scripts need a POSIX shell")
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFormatterPath(t *testing.T) {
+	tests := []struct {
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{format: "junit"},
+		{format: "exec:/opt/formatters/csv", want: "/opt/formatters/csv"},
+		{format: "exec:csv-formatter", want: "csv-formatter"},
+		{format: "exec:", wantErr: true},
+		{format: "csv", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := formatterPath(tt.format)
+		var usage usageError
+		if got != tt.want || (err != nil) != tt.wantErr || (err != nil && !errors.As(err, &usage)) {
+			t.Errorf("formatterPath(%q) = %q, %v, want %q", tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestExecFormat(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	results := `{"runId":"run-1","results":[` + resultA + `,{"task_name":"b","task_passed":false,"task_error":"boom"}]}`
+	if err := os.WriteFile(input, []byte(results), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The formatter echoes its input, with an argument from the environment
+	echo := writeFormatter(t, dir, "echo-formatter", `printf '%s\n' "$REPORT_TITLE"; cat`)
+	t.Setenv("REPORT_TITLE", "nightly")
+	output := filepath.Join(dir, "report.txt")
+
+	if err := runCLI(context.Background(), []string{"--format", "exec:" + echo, "--output", output, input}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	title, piped, _ := strings.Cut(string(data), "\n")
+	if title != "nightly" {
+		t.Errorf("formatter output starts with %q, want the environment to be passed on", title)
+	}
+	var run struct {
+		RunID   string `json:"runId"`
+		Results []struct {
+			TaskName  string `json:"taskName"`
+			TaskError string `json:"taskError"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal([]byte(piped), &run); err != nil {
+		t.Fatalf("formatter input is not JSON: %v\n%s", err, piped)
+	}
+	// Results of every schema version reach the formatter normalized
+	if run.RunID != "run-1" || len(run.Results) != 2 || run.Results[1].TaskName != "b" || run.Results[1].TaskError != "boom" {
+		t.Errorf("formatter input = %+v", run)
+	}
+
+	// The gates still check the converted results
+	err = runCLI(context.Background(), []string{"--format", "exec:" + echo, "--fail-on", "errors", "--output", output, input})
+	var gateErr gateError
+	if !errors.As(err, &gateErr) || gateErr.code != exitCodeTestsFailed {
+		t.Errorf("--fail-on with a formatter error = %v, want the gate to fail", err)
+	}
+
+	// The stderr of the formatter goes to the logs
+	var logs bytes.Buffer
+	previous := logOutput
+	logOutput = &logs
+	t.Cleanup(func() { logOutput = previous })
+	failing := writeFormatter(t, dir, "failing-formatter", "echo 'unsupported run' >&2\nexit 3\n")
+	err = runCLI(context.Background(), []string{"--format", "exec:" + failing, "--output", output, input})
+	if err == nil || !strings.Contains(err.Error(), "failing-formatter") || exitCode(err) != exitCodeConversion {
+		t.Errorf("failing formatter error = %v, want a conversion error", err)
+	}
+	if !strings.Contains(logs.String(), "unsupported run") {
+		t.Errorf("logs = %q, want the stderr of the formatter", logs.String())
+	}
+
+	// Filtered out tasks and redacted secrets never reach the formatter
+	secret := filepath.Join(dir, "secret.json")
+	results = `{"results":[` + resultA + `,{"taskName":"b","taskPassed":false,"allAssertionsPassed":false,
+		"taskOutput":"Authorization: Bearer abc123token","taskError":"token sk-live-42 rejected",
+		"callHistory":{"ToolCalls":[{"name":"login","success":true,"arguments":{"password":"hunter2"}}]}}]}`
+	if err := os.WriteFile(secret, []byte(results), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCLI(context.Background(), []string{"--format", "exec:" + echo, "--redact", `sk-live-\d+`, "--redact", "hunter2", "--include-task", "^b$", "--output", output, secret}); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = os.ReadFile(output); err != nil {
+		t.Fatal(err)
+	}
+	for _, leaked := range []string{"abc123token", "sk-live-42", "hunter2", `"taskName":"a"`} {
+		if strings.Contains(string(data), leaked) {
+			t.Errorf("formatter input contains %q:\n%s", leaked, data)
+		}
+	}
+	if !strings.Contains(string(data), "Bearer [REDACTED]") {
+		t.Errorf("formatter input does not mask the bearer token:\n%s", data)
+	}
+
+	for _, args := range [][]string{
+		{"--format", "csv", input},
+		{"--format", "exec:" + echo, "--watch", "--output", output, input},
+		{"--format", "exec:" + echo, "--bundle", filepath.Join(dir, "bundle"), input},
+	} {
+		var usage usageError
+		if err := runCLI(context.Background(), args); !errors.As(err, &usage) {
+			t.Errorf("runCLI(%q) error = %v, want a usage error", args, err)
+		}
+	}
+}
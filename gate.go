@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Supported values for the --fail-on flag
+const (
+	failOnFailures = "failures"
+	failOnErrors   = "errors"
+	failOnAny      = "any"
+	failOnNever    = "never"
+)
+
+// failOnValues lists the --fail-on values in the order shown by the help
+var failOnValues = []string{failOnFailures, failOnErrors, failOnAny, failOnNever}
+
+// GateOptions decides whether the test outcome fails the command once the
+// report has been written
+type GateOptions struct {
+	// FailOn is one of the --fail-on values; empty never fails
+	FailOn string
+	// MinPassRate maps a lower-case difficulty level to the minimum share
+	// of its tasks that must pass, from 0 to 1. The empty key applies to
+	// all tasks and "unknown" to tasks without a difficulty.
+	MinPassRate map[string]float64
+	// Baseline, when set, is the report of an earlier run; testcases that
+	// passed in it and fail in the report are regressions
+	Baseline *converter.JUnitTestSuites
+}
+
+// gateError reports a failed gate, with the exit status to use
+type gateError struct {
+	code int
+	msg  string
+}
+
+func (e gateError) Error() string {
+	return e.msg
+}
+
+// check returns a gateError when the report trips one of the gates, listing
+// every gate that failed. --fail-on takes precedence for the exit status.
+// Its counts include the suites imported from JUnit XML inputs, while pass
+// rates only cover the converted MCP checker results.
+func (g GateOptions) check(report converter.JUnitTestSuites) error {
+	var failed []string
+	code := 0
+
+	_, failures, errors := report.Totals()
+	var tripped bool
+	switch g.FailOn {
+	case failOnFailures:
+		tripped = failures > 0
+	case failOnErrors:
+		tripped = errors > 0
+	case failOnAny:
+		tripped = failures > 0 || errors > 0
+	}
+	if tripped {
+		failed = append(failed, fmt.Sprintf("Tests failed: %d failures, %d errors (--fail-on %s)", failures, errors, g.FailOn))
+		code = exitCodeTestsFailed
+	}
+
+	if len(g.MinPassRate) > 0 {
+		passed, total := passCounts(report)
+		for _, difficulty := range slices.Sorted(maps.Keys(g.MinPassRate)) {
+			minRate := g.MinPassRate[difficulty]
+			if total[difficulty] == 0 {
+				continue
+			}
+			rate := float64(passed[difficulty]) / float64(total[difficulty])
+			if rate >= minRate {
+				continue
+			}
+			scope := "all tasks"
+			if difficulty != "" {
+				scope = difficulty + " tasks"
+			}
+			failed = append(failed, fmt.Sprintf("Pass-rate gate failed: %s passed %.1f%% (%d/%d), below the minimum of %.1f%%",
+				scope, rate*100, passed[difficulty], total[difficulty], minRate*100))
+			if code == 0 {
+				code = exitCodeGateFailed
+			}
+		}
+	}
+
+	if g.Baseline != nil {
+		if regressions := findRegressions(*g.Baseline, report); len(regressions) > 0 {
+			failed = append(failed, fmt.Sprintf("Baseline regressions: %d tasks that passed in the baseline fail now:\n  %s",
+				len(regressions), strings.Join(regressions, "\n  ")))
+			if code == 0 {
+				code = exitCodeGateFailed
+			}
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	return gateError{code: code, msg: strings.Join(failed, "\n")}
+}
+
+// findRegressions returns the testcases of report that fail, with a failure
+// or an error, while a testcase of the same name passed in baseline, each
+// followed by its outcome, e.g. "create-pod (failure)". Tasks missing from
+// the baseline or failing in it too are not regressions.
+func findRegressions(baseline, report converter.JUnitTestSuites) []string {
+	passedBefore := make(map[string]bool)
+	for _, suite := range baseline.Suites {
+		for _, testCase := range suite.AllTestCases() {
+			passedBefore[testCase.Name] = testCase.Skipped == nil && testCase.Failure == nil && testCase.Error == nil
+		}
+	}
+	var regressions []string
+	for _, suite := range report.Suites {
+		for _, testCase := range suite.AllTestCases() {
+			if !passedBefore[testCase.Name] {
+				continue
+			}
+			switch {
+			case testCase.Error != nil:
+				regressions = append(regressions, testCase.Name+" (error)")
+			case testCase.Failure != nil:
+				regressions = append(regressions, testCase.Name+" (failure)")
+			}
+		}
+	}
+	return regressions
+}
+
+// loadBaseline converts the --baseline run with conv, so that its testcases
+// are named like those of the report
+func loadBaseline(ctx context.Context, path string, opts inputOptions, conv *converter.Converter) (*converter.JUnitTestSuites, error) {
+	if path == "" {
+		return nil, nil
+	}
+	run, err := loadInput(ctx, path, opts)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	report, err := conv.ConvertContext(ctx, run)
+	if err != nil {
+		return nil, fmt.Errorf("baseline: %w", err)
+	}
+	return &report, nil
+}
+
+// passCounts counts the passed and total converted testcases, overall under
+// the empty key and per lower-case difficulty level. Skipped testcases did
+// not run and count toward neither.
+func passCounts(report converter.JUnitTestSuites) (passed, total map[string]int) {
+	passed = make(map[string]int)
+	total = make(map[string]int)
+	for _, suite := range report.Suites {
+		for _, testCase := range suite.AllTestCases() {
+			difficulty := strings.ToLower(testCase.Difficulty())
+			if difficulty == "" {
+				difficulty = converter.UnknownGroup
+			}
+			if testCase.Skipped != nil {
+				continue
+			}
+			ok := testCase.Failure == nil && testCase.Error == nil
+			for _, key := range []string{"", difficulty} {
+				total[key]++
+				if ok {
+					passed[key]++
+				}
+			}
+		}
+	}
+	return passed, total
+}
+
+// parsePassRates parses a --min-pass-rate value: comma-separated rates,
+// either bare for all tasks or as difficulty=rate
+func parsePassRates(value string) (map[string]float64, error) {
+	items := splitList(value)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	rates := make(map[string]float64, len(items))
+	for _, item := range items {
+		difficulty, rateText, perDifficulty := strings.Cut(item, "=")
+		if !perDifficulty {
+			difficulty, rateText = "", item
+		}
+		difficulty = strings.ToLower(strings.TrimSpace(difficulty))
+		if perDifficulty && difficulty == "" {
+			return nil, newUsageError("invalid --min-pass-rate %q: missing difficulty before '='", item)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rateText), 64)
+		if err != nil || !(rate >= 0 && rate <= 1) {
+			return nil, newUsageError("invalid --min-pass-rate %q: rates must be numbers between 0 and 1", item)
+		}
+		if _, ok := rates[difficulty]; ok {
+			return nil, newUsageError("invalid --min-pass-rate %q: rate given twice", item)
+		}
+		rates[difficulty] = rate
+	}
+	return rates, nil
+}
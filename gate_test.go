@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestFailOn(t *testing.T) {
+	passed := mustConvert(t, mustParse(t, `[`+resultA+`]`))
+	failed := mustConvert(t, mustParse(t, `[`+resultA+`,{"taskName":"f","taskPassed":true,"allAssertionsPassed":false}]`))
+	errored := mustConvert(t, mustParse(t, `[`+resultA+`,`+resultB+`]`))
+
+	tests := []struct {
+		failOn string
+		report converter.JUnitTestSuites
+		want   bool
+	}{
+		{failOn: "", report: errored, want: false},
+		{failOn: failOnNever, report: errored, want: false},
+		{failOn: failOnFailures, report: passed, want: false},
+		{failOn: failOnFailures, report: failed, want: true},
+		{failOn: failOnFailures, report: errored, want: false},
+		{failOn: failOnErrors, report: failed, want: false},
+		{failOn: failOnErrors, report: errored, want: true},
+		{failOn: failOnAny, report: passed, want: false},
+		{failOn: failOnAny, report: failed, want: true},
+		{failOn: failOnAny, report: errored, want: true},
+	}
+
+	for _, tt := range tests {
+		err := GateOptions{FailOn: tt.failOn}.check(tt.report)
+		var gateErr gateError
+		if got := errors.As(err, &gateErr); got != tt.want {
+			t.Errorf("--fail-on %q: check() error = %v, want tripped %v", tt.failOn, err, tt.want)
+		} else if got && gateErr.code != exitCodeTestsFailed {
+			t.Errorf("--fail-on %q: exit code = %d, want %d", tt.failOn, gateErr.code, exitCodeTestsFailed)
+		}
+	}
+}
+
+func TestFailOnWritesReport(t *testing.T) {
+	captureHelp(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte("["+resultB+"]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, command := range []string{"convert", "merge"} {
+		output := filepath.Join(dir, command+".xml")
+		var gateErr gateError
+		if err := runCLI(context.Background(), []string{command, "--fail-on", "any", "--output", output, input}); !errors.As(err, &gateErr) {
+			t.Errorf("%s --fail-on any error = %v, want a gateError", command, err)
+		}
+		if _, err := os.Stat(output); err != nil {
+			t.Errorf("%s did not write the report before failing: %v", command, err)
+		}
+	}
+
+	var usageErr usageError
+	if err := runCLI(context.Background(), []string{"--fail-on", "sometimes", input}); !errors.As(err, &usageErr) {
+		t.Errorf("invalid --fail-on error = %v, want a usageError", err)
+	}
+}
+
+func TestMinPassRate(t *testing.T) {
+	// easy: 2 of 2 pass, hard: 1 of 2 pass and one skipped, unknown: 0 of 1 pass
+	report := mustConvert(t, mustParse(t, `[`+resultA+`,`+resultA+`,`+resultB+`,
+		{"taskName":"h","taskPassed":true,"difficulty":"Hard","allAssertionsPassed":true},
+		{"taskName":"s","taskSkipped":true,"difficulty":"hard"},
+		{"taskName":"u","taskPassed":true,"allAssertionsPassed":false}]`))
+
+	tests := []struct {
+		name        string
+		minPassRate string
+		wantCode    int
+		wantMsg     string
+	}{
+		{name: "overall met", minPassRate: "0.6"},
+		{name: "overall missed", minPassRate: "0.95", wantCode: exitCodeGateFailed, wantMsg: "all tasks passed 60.0% (3/5), below the minimum of 95.0%"},
+		{name: "per difficulty met", minPassRate: "easy=1.0,hard=0.5"},
+		{name: "per difficulty missed", minPassRate: "easy=1.0, HARD=0.8", wantCode: exitCodeGateFailed, wantMsg: "hard tasks passed 50.0% (1/2), below the minimum of 80.0%"},
+		{name: "unknown difficulty", minPassRate: "unknown=0.5", wantCode: exitCodeGateFailed, wantMsg: "unknown tasks passed 0.0% (0/1)"},
+		{name: "no tasks of that difficulty", minPassRate: "medium=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rates, err := parsePassRates(tt.minPassRate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = GateOptions{MinPassRate: rates}.check(report)
+			var gateErr gateError
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Errorf("check() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.As(err, &gateErr) || gateErr.code != tt.wantCode || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("check() error = %v, want exit code %d and %q", err, tt.wantCode, tt.wantMsg)
+			}
+		})
+	}
+
+	t.Run("fail-on takes precedence", func(t *testing.T) {
+		err := GateOptions{FailOn: failOnAny, MinPassRate: map[string]float64{"": 1}}.check(report)
+		var gateErr gateError
+		if !errors.As(err, &gateErr) || gateErr.code != exitCodeTestsFailed || !strings.Contains(err.Error(), "Pass-rate gate failed") {
+			t.Errorf("check() error = %v, want both gates reported with exit code %d", err, exitCodeTestsFailed)
+		}
+	})
+}
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	baseline := filepath.Join(dir, "baseline.json")
+	if err := os.WriteFile(baseline, []byte(`[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"c","taskPassed":true,"allAssertionsPassed":false},
+		{"taskName":"d","taskPassed":true,"allAssertionsPassed":true}
+	]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	current := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(current, []byte(`[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":false},
+		{"taskName":"b","taskPassed":false,"taskError":"boom"},
+		{"taskName":"c","taskPassed":true,"allAssertionsPassed":false},
+		{"taskName":"d","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"new","taskPassed":false}
+	]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "report.xml")
+	err := runCLI(context.Background(), []string{"--baseline", baseline, "--output", output, current})
+	var gateErr gateError
+	if !errors.As(err, &gateErr) || gateErr.code != exitCodeGateFailed {
+		t.Fatalf("--baseline error = %v, want a gateError with exit code %d", err, exitCodeGateFailed)
+	}
+	want := "Baseline regressions: 2 tasks that passed in the baseline fail now:\n  a (failure)\n  b (error)"
+	if err.Error() != want {
+		t.Errorf("--baseline error = %q, want %q", err.Error(), want)
+	}
+
+	// Failing tasks that were not passing before are no regressions
+	if err := runCLI(context.Background(), []string{"--baseline", current, "--output", output, current}); err != nil {
+		t.Errorf("--baseline of the run itself error = %v, want nil", err)
+	}
+	if err := runCLI(context.Background(), []string{"--baseline", filepath.Join(dir, "missing.json"), "--output", output, current}); err == nil || !strings.HasPrefix(err.Error(), "baseline: ") {
+		t.Errorf("missing --baseline error = %v, want a baseline error", err)
+	}
+}
+
+func TestParsePassRatesErrors(t *testing.T) {
+	for _, value := range []string{"high", "1.5", "-0.1", "NaN", "=0.5", "easy=x", "0.9,0.8", "easy=1,EASY=0.9"} {
+		var usageErr usageError
+		if _, err := parsePassRates(value); !errors.As(err, &usageErr) {
+			t.Errorf("parsePassRates(%q) error = %v, want a usageError", value, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Defaults of publish github-check
+const (
+	defaultGitHubAPIURL   = "https://api.github.com"
+	defaultGitHubTokenEnv = "GITHUB_TOKEN"
+)
+
+// Limits of the GitHub Checks API
+const (
+	// maxCheckAnnotations is the number of annotations a request may carry
+	maxCheckAnnotations = 50
+	// maxCheckText bounds the summary and the annotation messages
+	maxCheckText = 65535
+)
+
+// Conclusions of a check run
+const (
+	checkSuccess = "success"
+	checkNeutral = "neutral"
+	checkFailure = "failure"
+)
+
+// checkAnnotation points at the line of a file that a failure comes from
+type checkAnnotation struct {
+	Path      string `json:"path"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Level     string `json:"annotation_level"`
+	Title     string `json:"title"`
+	Message   string `json:"message"`
+}
+
+// checkOutput is the output of a check run
+type checkOutput struct {
+	Title       string            `json:"title"`
+	Summary     string            `json:"summary"`
+	Annotations []checkAnnotation `json:"annotations,omitempty"`
+}
+
+// publishGitHubCheck implements publish github-check: it creates a check run
+// on a commit with a summary of the results and an annotation on the task
+// file of every failure, concluded by the gates
+func publishGitHubCheck(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	gates := addGateFlags(fs)
+	repo := fs.String("repo", os.Getenv("GITHUB_REPOSITORY"), "repository of the commit, as owner/name (default $GITHUB_REPOSITORY)")
+	sha := fs.String("sha", os.Getenv("GITHUB_SHA"), "commit to create the check run on (default $GITHUB_SHA)")
+	apiURL := fs.String("api-url", cmp.Or(os.Getenv("GITHUB_API_URL"), defaultGitHubAPIURL), "GitHub API URL, for GitHub Enterprise Server (default $GITHUB_API_URL or https://api.github.com)")
+	tokenEnv := fs.String("token-env", defaultGitHubTokenEnv, "environment variable holding a GitHub token allowed to write checks")
+	name := fs.String("name", defaultPublishName, "name of the check run")
+	pathPrefix := fs.String("path-prefix", "", "strip this prefix from the task paths, e.g. the checkout directory, so that annotations point at repository files")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	if *repo == "" || *sha == "" {
+		return newUsageError("publish github-check requires --repo and --sha, or the GITHUB_REPOSITORY and GITHUB_SHA environment variables")
+	}
+	token, err := secretFromEnv("token-env", *tokenEnv)
+	if err != nil {
+		return err
+	}
+	conv, err := newPublishConverter(converter.WithPathPrefix(*pathPrefix))
+	if err != nil {
+		return err
+	}
+	gateOpts, err := gates.options(ctx, opts, conv)
+	if err != nil {
+		return err
+	}
+
+	run, tests, err := loadPublishedTests(ctx, fs.Args(), opts, conv)
+	if err != nil {
+		return err
+	}
+	report, err := conv.ConvertContext(ctx, run)
+	if err != nil {
+		return err
+	}
+	gateErr := gateOpts.check(report)
+	conclusion, output := checkRunOutput(tests, gateErr)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	header.Set("X-GitHub-Api-Version", "2022-11-28")
+	endpoint := fmt.Sprintf("%s/repos/%s/check-runs", strings.TrimSuffix(*apiURL, "/"), *repo)
+	annotations := output.Annotations
+	output.Annotations = annotations[:min(len(annotations), maxCheckAnnotations)]
+	body := map[string]interface{}{"name": *name, "head_sha": *sha, "status": "completed", "conclusion": conclusion, "output": output}
+	var checkRun struct {
+		ID      int64  `json:"id"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := callAPI(ctx, http.MethodPost, endpoint, header, body, &checkRun); err != nil {
+		return fmt.Errorf("creating the check run: %w", err)
+	}
+	// Further annotations are added by updating the check run
+	for start := maxCheckAnnotations; start < len(annotations); start += maxCheckAnnotations {
+		output.Annotations = annotations[start:min(len(annotations), start+maxCheckAnnotations)]
+		if err := callAPI(ctx, http.MethodPatch, fmt.Sprintf("%s/%d", endpoint, checkRun.ID), header,
+			map[string]interface{}{"output": output}, nil); err != nil {
+			return fmt.Errorf("annotating the check run: %w", err)
+		}
+	}
+	slog.Info("created check run", "url", checkRun.HTMLURL, "conclusion", conclusion, "annotations", len(annotations))
+	return gateErr
+}
+
+// checkRunOutput returns the conclusion and output of the check run of
+// tests: failure when a gate failed, with its message, neutral when tasks
+// failed without tripping a gate, success otherwise
+func checkRunOutput(tests []publishedTest, gateErr error) (string, checkOutput) {
+	var annotations []checkAnnotation
+	for _, test := range tests {
+		if comment := test.failureComment(); comment != "" && test.TestCase.File != "" {
+			line := max(test.TestCase.Line, 1)
+			annotations = append(annotations, checkAnnotation{Path: test.TestCase.File, StartLine: line, EndLine: line,
+				Level: "failure", Title: test.Result.TaskName, Message: truncateText(comment, maxCheckText)})
+		}
+	}
+	table, total := markdownSummary(tests)
+
+	var summary strings.Builder
+	var gate gateError
+	if errors.As(gateErr, &gate) {
+		fmt.Fprintf(&summary, "```\n%s\n```\n\n", gate.msg)
+	}
+	summary.WriteString(table)
+
+	conclusion := checkSuccess
+	switch {
+	case gateErr != nil:
+		conclusion = checkFailure
+	case total.failed+total.errored > 0:
+		conclusion = checkNeutral
+	}
+	return conclusion, checkOutput{Title: passedTitle(total), Summary: truncateText(summary.String(), maxCheckText), Annotations: annotations}
+}
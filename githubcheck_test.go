@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublishGitHubCheck(t *testing.T) {
+	type request struct {
+		method, path string
+		body         struct {
+			HeadSHA    string      `json:"head_sha"`
+			Conclusion string      `json:"conclusion"`
+			Output     checkOutput `json:"output"`
+		}
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gh-token" {
+			http.Error(w, "bad credentials", http.StatusUnauthorized)
+			return
+		}
+		req := request{method: r.Method, path: r.URL.Path}
+		if err := json.NewDecoder(r.Body).Decode(&req.body); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, req)
+		w.Write([]byte(`{"id":9,"html_url":"https://github.com/o/r/runs/9"}`))
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_TOKEN", "gh-token")
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	input := write("results.json", `{"results":[
+		{"taskName":"a","taskPath":"/work/tasks/a.yaml","taskLine":4,"difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","taskPath":"/work/tasks/b.yaml","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false}}}
+	]}`)
+	publish := func(extra ...string) error {
+		args := append([]string{"publish", "github-check", "--api-url", server.URL, "--repo", "o/r", "--sha", "abc123", "--path-prefix", "/work"}, extra...)
+		return runCLI(context.Background(), append(args, input))
+	}
+
+	// Failures without a gate conclude neutral
+	if err := publish(); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("requests = %+v, want 1", requests)
+	}
+	req := requests[0]
+	if req.method != http.MethodPost || req.path != "/repos/o/r/check-runs" || req.body.HeadSHA != "abc123" || req.body.Conclusion != checkNeutral {
+		t.Errorf("request = %s %s %+v", req.method, req.path, req.body)
+	}
+	if req.body.Output.Title != "1 of 2 tasks passed" {
+		t.Errorf("title = %q", req.body.Output.Title)
+	}
+	annotations := req.body.Output.Annotations
+	if len(annotations) != 1 || annotations[0].Path != "tasks/b.yaml" || annotations[0].StartLine != 1 || !strings.Contains(annotations[0].Message, "pods-ready") {
+		t.Errorf("annotations = %+v", annotations)
+	}
+
+	// A failed gate concludes failure and sets the exit status
+	requests = nil
+	err := publish("--fail-on", "any")
+	var gateErr gateError
+	if !errors.As(err, &gateErr) {
+		t.Fatalf("publish --fail-on any error = %v, want a gateError", err)
+	}
+	if len(requests) != 1 || requests[0].body.Conclusion != checkFailure || !strings.Contains(requests[0].body.Output.Summary, gateErr.msg) {
+		t.Errorf("requests = %+v, want a failure with the gate message", requests)
+	}
+
+	// Annotations past the first batch are added by updating the check run
+	var many []string
+	for i := range 55 {
+		many = append(many, fmt.Sprintf(`{"taskName":"t%d","taskPath":"tasks/t%d.yaml","taskPassed":false,"taskError":"boom"}`, i, i))
+	}
+	input = write("many.json", "["+strings.Join(many, ",")+"]")
+	requests = nil
+	if err := publish(); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 2 || len(requests[0].body.Output.Annotations) != maxCheckAnnotations ||
+		requests[1].method != http.MethodPatch || requests[1].path != "/repos/o/r/check-runs/9" || len(requests[1].body.Output.Annotations) != 5 {
+		t.Errorf("requests = %+v, want a POST with 50 annotations and a PATCH with 5", requests)
+	}
+
+	// API errors are reported with the response
+	t.Setenv("GITHUB_TOKEN", "wrong")
+	if err := publish(); err == nil || !strings.Contains(err.Error(), "401 Unauthorized: bad credentials") {
+		t.Errorf("publish with a wrong token error = %v, want the 401 response", err)
+	}
+}
+
+func TestCheckRunOutput(t *testing.T) {
+	conv := mustNew(t)
+	var tests []publishedTest
+	for i := range 120 {
+		result := mustParse(t, fmt.Sprintf(`[{"taskName":"t%d","taskPath":"tasks/t%d.yaml","taskPassed":true,"allAssertionsPassed":%t}]`, i, i, i%2 == 0)).Results[0]
+		testCase := conv.ConvertResult(result)
+		tests = append(tests, publishedTest{Result: result, TestCase: testCase, Outcome: historyOutcome(testCase)})
+	}
+	conclusion, output := checkRunOutput(tests, nil)
+	if conclusion != checkNeutral || output.Title != "60 of 120 tasks passed" || len(output.Annotations) != 60 {
+		t.Errorf("checkRunOutput = %s, %q with %d annotations", conclusion, output.Title, len(output.Annotations))
+	}
+	if !strings.Contains(output.Summary, "| Total | 120 | 60 | 60 | 0 | 0 | 50.0% |") {
+		t.Errorf("summary = %q, want the total row", output.Summary)
+	}
+
+	conclusion, _ = checkRunOutput(tests[:1], nil)
+	if conclusion != checkSuccess {
+		t.Errorf("conclusion of passing tasks = %s, want %s", conclusion, checkSuccess)
+	}
+	if got := truncateText(strings.Repeat("é", maxCheckText), maxCheckText); len(got) > maxCheckText || !strings.HasSuffix(got, "...") {
+		t.Errorf("truncateText length = %d", len(got))
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Defaults of publish gitlab-mr
+const (
+	defaultGitLabURL      = "https://gitlab.com"
+	defaultGitLabTokenEnv = "GITLAB_TOKEN"
+)
+
+// gitLabNotesPerPage is the page size of the merge request notes searched
+// for an earlier comment
+const gitLabNotesPerPage = 100
+
+// maxCommentFailures bounds the failures detailed in a merge request comment
+const maxCommentFailures = 20
+
+// gitLabNote is a comment of a merge request
+type gitLabNote struct {
+	ID   int64  `json:"id"`
+	Body string `json:"body"`
+}
+
+// publishGitLabMR implements publish gitlab-mr: it comments a summary of the
+// results on a merge request, compared with the results of its target branch
+// when given, and updates that comment on later runs
+func publishGitLabMR(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	baseURL := fs.String("url", cmp.Or(os.Getenv("CI_SERVER_URL"), defaultGitLabURL), "URL of the GitLab instance (default $CI_SERVER_URL or https://gitlab.com)")
+	project := fs.String("project", os.Getenv("CI_PROJECT_ID"), "ID or path of the project of the merge request (default $CI_PROJECT_ID)")
+	mergeRequest := fs.String("merge-request", os.Getenv("CI_MERGE_REQUEST_IID"), "IID of the merge request to comment on (default $CI_MERGE_REQUEST_IID)")
+	tokenEnv := fs.String("token-env", defaultGitLabTokenEnv, "environment variable holding a GitLab token allowed to comment, with the api scope")
+	name := fs.String("name", defaultPublishName, "title of the comment, which also tells apart the comments of several jobs")
+	baseline := fs.String("baseline", "", "results of the target branch to compare with; a missing file is skipped with a warning")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	if *project == "" || *mergeRequest == "" {
+		return newUsageError("publish gitlab-mr requires --project and --merge-request, or the CI_PROJECT_ID and CI_MERGE_REQUEST_IID environment variables of merge request pipelines")
+	}
+	token, err := secretFromEnv("token-env", *tokenEnv)
+	if err != nil {
+		return err
+	}
+	conv, err := newPublishConverter()
+	if err != nil {
+		return err
+	}
+
+	run, tests, err := loadPublishedTests(ctx, fs.Args(), opts, conv)
+	if err != nil {
+		return err
+	}
+	var changes []taskChange
+	if *baseline != "" {
+		baselineRun, err := loadInput(ctx, *baseline, opts)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			slog.Warn("no results of the target branch, commenting without comparison", "baseline", *baseline)
+		case err != nil:
+			return err
+		default:
+			changes = compareOutcomes(baselineRun, run, conv)
+		}
+	}
+	body := mergeRequestComment(*name, tests, changes)
+
+	header := http.Header{}
+	header.Set("PRIVATE-TOKEN", token)
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s/merge_requests/%s/notes", strings.TrimSuffix(*baseURL, "/"),
+		url.PathEscape(*project), url.PathEscape(*mergeRequest))
+	note, err := findGitLabNote(ctx, endpoint, header, commentMarker(*name))
+	if err != nil {
+		return fmt.Errorf("listing the merge request comments: %w", err)
+	}
+	payload := map[string]string{"body": body}
+	if note == nil {
+		if err := callAPI(ctx, http.MethodPost, endpoint, header, payload, nil); err != nil {
+			return fmt.Errorf("commenting on the merge request: %w", err)
+		}
+		slog.Info("commented on the merge request", "project", *project, "mergeRequest", *mergeRequest)
+		return nil
+	}
+	if err := callAPI(ctx, http.MethodPut, fmt.Sprintf("%s/%d", endpoint, note.ID), header, payload, nil); err != nil {
+		return fmt.Errorf("updating the merge request comment: %w", err)
+	}
+	slog.Info("updated the merge request comment", "project", *project, "mergeRequest", *mergeRequest, "note", note.ID)
+	return nil
+}
+
+// commentMarker is the hidden line that identifies the comments named name
+func commentMarker(name string) string {
+	return fmt.Sprintf("<!-- mcpchecker-junit-report: %s -->", strings.ReplaceAll(name, "--", "- -"))
+}
+
+// findGitLabNote returns the first note of the merge request notes endpoint
+// that contains marker, or nil when none does
+func findGitLabNote(ctx context.Context, endpoint string, header http.Header, marker string) (*gitLabNote, error) {
+	for page := 1; ; page++ {
+		var notes []gitLabNote
+		pageURL := fmt.Sprintf("%s?sort=asc&order_by=created_at&per_page=%d&page=%d", endpoint, gitLabNotesPerPage, page)
+		if err := callAPI(ctx, http.MethodGet, pageURL, header, nil, &notes); err != nil {
+			return nil, err
+		}
+		for _, note := range notes {
+			if strings.Contains(note.Body, marker) {
+				return &note, nil
+			}
+		}
+		if len(notes) < gitLabNotesPerPage {
+			return nil, nil
+		}
+	}
+}
+
+// mergeRequestComment renders the Markdown comment of the results: the
+// summary table of tests, the tasks whose outcome changed since the target
+// branch when changes is not nil, and the first failures
+func mergeRequestComment(name string, tests []publishedTest, changes []taskChange) string {
+	table, total := markdownSummary(tests)
+	var comment strings.Builder
+	fmt.Fprintf(&comment, "%s\n### %s: %s\n\n%s", commentMarker(name), name, passedTitle(total), table)
+
+	if changes != nil {
+		var regressions int
+		for _, c := range changes {
+			if c.Regression {
+				regressions++
+			}
+		}
+		comment.WriteString("\n#### Compared with the target branch\n\n")
+		if len(changes) == 0 {
+			comment.WriteString("No task changed outcome.\n")
+		} else {
+			fmt.Fprintf(&comment, "%d tasks changed outcome, %d of them regressions.\n\n| Task | Difficulty | Target branch | This branch | |\n|---|---|---|---|---|\n", len(changes), regressions)
+			for _, c := range changes {
+				flag := ""
+				if c.Regression {
+					flag = ":x: regression"
+				}
+				fmt.Fprintf(&comment, "| %s | %s | %s | %s | %s |\n", c.Task, c.Difficulty, cmp.Or(c.Baseline, "-"), cmp.Or(c.Current, "-"), flag)
+			}
+		}
+	}
+
+	var failures []publishedTest
+	for _, test := range tests {
+		if test.failureComment() != "" {
+			failures = append(failures, test)
+		}
+	}
+	if len(failures) == 0 {
+		return comment.String()
+	}
+	fmt.Fprintf(&comment, "\n<details><summary>%d failed tasks</summary>\n\n", len(failures))
+	for _, test := range failures[:min(len(failures), maxCommentFailures)] {
+		fmt.Fprintf(&comment, "**%s** (%s)\n\n```\n%s\n```\n\n", test.Result.TaskName, test.Outcome, test.failureComment())
+	}
+	if len(failures) > maxCommentFailures {
+		fmt.Fprintf(&comment, "and %d more.\n\n", len(failures)-maxCommentFailures)
+	}
+	comment.WriteString("</details>\n")
+	return comment.String()
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPublishGitLabMR(t *testing.T) {
+	notes := []gitLabNote{{ID: 1, Body: "LGTM"}}
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("PRIVATE-TOKEN") != "gl-token" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		requests = append(requests, r.Method+" "+r.URL.EscapedPath())
+		var body gitLabNote
+		if r.Method != http.MethodGet {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+		}
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(notes)
+		case http.MethodPost:
+			body.ID = int64(len(notes) + 1)
+			notes = append(notes, body)
+			json.NewEncoder(w).Encode(body)
+		case http.MethodPut:
+			notes[len(notes)-1].Body = body.Body
+			json.NewEncoder(w).Encode(notes[len(notes)-1])
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GITLAB_TOKEN", "gl-token")
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	baseline := write("main.json", `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+	input := write("results.json", `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false}}}
+	]`)
+	publish := func(baseline string) error {
+		return runCLI(context.Background(), []string{"publish", "gitlab-mr", "--url", server.URL, "--project", "group/project",
+			"--merge-request", "7", "--baseline", baseline, input})
+	}
+
+	// The first run adds a comment
+	if err := publish(baseline); err != nil {
+		t.Fatal(err)
+	}
+	endpoint := "/api/v4/projects/group%2Fproject/merge_requests/7/notes"
+	if want := []string{"GET " + endpoint, "POST " + endpoint}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+	if len(notes) != 2 {
+		t.Fatalf("notes = %+v, want the comment added", notes)
+	}
+	for _, want := range []string{"### MCP checker: 1 of 2 tasks passed", "| b | hard | passed | failure | :x: regression |", "pods-ready"} {
+		if !strings.Contains(notes[1].Body, want) {
+			t.Errorf("comment = %q, want %q", notes[1].Body, want)
+		}
+	}
+
+	// Later runs update it, and a missing baseline is skipped
+	requests = nil
+	if err := publish(filepath.Join(dir, "missing.json")); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"GET " + endpoint, "PUT " + endpoint + "/2"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+	if len(notes) != 2 || strings.Contains(notes[1].Body, "Compared with the target branch") {
+		t.Errorf("notes = %+v, want the comment updated without comparison", notes)
+	}
+
+	// API errors are reported with the response
+	t.Setenv("GITLAB_TOKEN", "wrong")
+	if err := publish(baseline); err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("publish with a wrong token error = %v, want the 401 response", err)
+	}
+}
+
+func TestFindGitLabNote(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Two full pages of other comments, then the one searched for
+		var notes []gitLabNote
+		switch r.URL.Query().Get("page") {
+		case "1", "2":
+			for i := range gitLabNotesPerPage {
+				notes = append(notes, gitLabNote{ID: int64(i), Body: "comment"})
+			}
+		case "3":
+			notes = []gitLabNote{{ID: 300, Body: commentMarker("nightly") + "\nresults"}}
+		}
+		json.NewEncoder(w).Encode(notes)
+	}))
+	defer server.Close()
+
+	note, err := findGitLabNote(context.Background(), server.URL, nil, commentMarker("nightly"))
+	if err != nil || note == nil || note.ID != 300 {
+		t.Errorf("findGitLabNote = %+v, %v, want note 300", note, err)
+	}
+	note, err = findGitLabNote(context.Background(), server.URL, nil, commentMarker("other"))
+	if err != nil || note != nil {
+		t.Errorf("findGitLabNote of another name = %+v, %v, want none", note, err)
+	}
+}
+
+func TestMergeRequestComment(t *testing.T) {
+	conv := mustNew(t)
+	var tests []publishedTest
+	for i := range maxCommentFailures + 2 {
+		result := mustParse(t, fmt.Sprintf(`[{"taskName":"t%d","taskPassed":false,"taskError":"boom"}]`, i)).Results[0]
+		testCase := conv.ConvertResult(result)
+		tests = append(tests, publishedTest{Result: result, TestCase: testCase, Outcome: historyOutcome(testCase)})
+	}
+	comment := mergeRequestComment("MCP checker", tests, []taskChange{})
+	for _, want := range []string{"<!-- mcpchecker-junit-report: MCP checker -->\n", "No task changed outcome.", "22 failed tasks", "**t19** (error)", "and 2 more."} {
+		if !strings.Contains(comment, want) {
+			t.Errorf("comment = %q, want %q", comment, want)
+		}
+	}
+	if strings.Contains(comment, "**t20**") {
+		t.Errorf("comment = %q, want at most %d failures", comment, maxCommentFailures)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+	converterv1 "github.com/jrangelramos/mcpchecker-junit-report/proto/converter/v1"
+)
+
+// converterServer implements the gRPC ConverterService
+type converterServer struct {
+	converterv1.UnimplementedConverterServiceServer
+	// maxInputSize caps the bytes of a stream, both as received and once
+	// decompressed
+	maxInputSize int64
+}
+
+// newGRPCServer returns a gRPC server with the ConverterService registered,
+// accepting streams of up to maxInputSize bytes
+func newGRPCServer(maxInputSize int64) *grpc.Server {
+	server := grpc.NewServer()
+	converterv1.RegisterConverterServiceServer(server, &converterServer{maxInputSize: maxInputSize})
+	return server
+}
+
+// errStreamTooLarge fails the pipe of a stream past maxInputSize
+var errStreamTooLarge = errors.New("stream too large")
+
+// Convert parses the chunks as they arrive, without touching the
+// filesystem, and returns the report when the stream ends. The parsed run is
+// held in memory, so a stream larger than maxInputSize, as received or once
+// decompressed, fails with RESOURCE_EXHAUSTED. The report is sent as a single
+// message: clients expecting reports larger than the 4 MiB gRPC receive
+// limit must raise it, e.g. with grpc.MaxCallRecvMsgSize.
+func (s *converterServer) Convert(stream grpc.ClientStreamingServer[converterv1.ResultChunk, converterv1.Report]) error {
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, converter.ErrEmptyInput.Error())
+	} else if err != nil {
+		return err
+	}
+
+	opts := converter.ParseOptions{
+		Format:      first.GetFormat(),
+		Strict:      first.GetStrict(),
+		Lenient:     first.GetLenient(),
+		ToolResults: defaultToolResultLimits,
+		MaxBytes:    s.maxInputSize,
+	}
+	if opts.Format == "" {
+		opts.Format = converter.FormatAuto
+	}
+
+	// tooLarge is set, before the pipe fails, once the chunks received
+	// exceed maxInputSize; not every decoder wraps the read error
+	var tooLarge atomic.Bool
+	reader, writer := io.Pipe()
+	go func() {
+		received := int64(0)
+		chunk := first
+		for {
+			if received += int64(len(chunk.GetData())); received > s.maxInputSize {
+				tooLarge.Store(true)
+				writer.CloseWithError(errStreamTooLarge)
+				return
+			}
+			if _, err := writer.Write(chunk.GetData()); err != nil {
+				return
+			}
+			var err error
+			chunk, err = stream.Recv()
+			if err == io.EOF {
+				writer.Close()
+				return
+			} else if err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+	}()
+
+	ctx := stream.Context()
+	run, err := converter.ParseContext(ctx, reader, opts)
+	// Unblock the receiving goroutine if parsing stopped before the end of the stream
+	reader.Close()
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		if tooLarge.Load() || errors.Is(err, converter.ErrInputTooLarge) {
+			return status.Errorf(codes.ResourceExhausted, "stream larger than %d bytes", s.maxInputSize)
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.InvalidArgument, "parsing stream: %v", err)
+	}
+
+	conv, err := converter.New()
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	junitXML, err := conv.ConvertContext(ctx, run)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		return status.Error(codes.Internal, err.Error())
+	}
+	data, err := conv.Render(junitXML)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	tests, failures, errors := junitXML.Totals()
+	report := &converterv1.Report{
+		JunitXml: data,
+		Tests:    int32(tests),
+		Failures: int32(failures),
+		Errors:   int32(errors),
+	}
+	for _, parseErr := range run.ParseErrors {
+		report.ParseErrors = append(report.ParseErrors, parseErr.Error())
+	}
+	return stream.SendAndClose(report)
+}
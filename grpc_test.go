@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+	converterv1 "github.com/jrangelramos/mcpchecker-junit-report/proto/converter/v1"
+)
+
+func newTestConverterClient(t *testing.T) converterv1.ConverterServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := newGRPCServer(1 << 10)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return converterv1.NewConverterServiceClient(conn)
+}
+
+func TestGRPCConvert(t *testing.T) {
+	client := newTestConverterClient(t)
+
+	tests := []struct {
+		name            string
+		chunks          []*converterv1.ResultChunk
+		wantCode        codes.Code
+		wantTests       int32
+		wantFailures    int32
+		wantErrors      int32
+		wantParseErrors int
+		wantXML         string
+	}{
+		{
+			name: "json array split across chunks",
+			chunks: []*converterv1.ResultChunk{
+				{Data: []byte("[" + resultA[:10])},
+				{Data: []byte(resultA[10:] + ",")},
+				{Data: []byte(resultB + "]")},
+			},
+			wantTests:  2,
+			wantErrors: 1,
+			wantXML:    `<testcase name="a" classname="tasks.a" file="/x/tasks/a/task.yaml">`,
+		},
+		{
+			name: "lenient json lines with explicit format",
+			chunks: []*converterv1.ResultChunk{
+				{Data: []byte(resultA + "\n{bad\n"), Format: converter.FormatNDJSON, Lenient: true},
+				{Data: []byte(resultC + "\n")},
+			},
+			wantTests:       3,
+			wantErrors:      1,
+			wantParseErrors: 1,
+		},
+		{
+			name:     "strict schema violation",
+			chunks:   []*converterv1.ResultChunk{{Data: []byte(`[{"taskName":"a"}]`), Strict: true}},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "empty stream",
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "stream too large",
+			chunks:   []*converterv1.ResultChunk{{Data: []byte("[" + strings.Repeat(resultA+",", 10))}, {Data: []byte(resultA + "]")}},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:     "yaml stream too large",
+			chunks:   []*converterv1.ResultChunk{{Data: []byte("- taskName: y\n"), Format: converter.FormatYAML}, {Data: []byte(strings.Repeat("#\n", 1<<10))}},
+			wantCode: codes.ResourceExhausted,
+		},
+		{
+			name:     "gzip bomb",
+			chunks:   []*converterv1.ResultChunk{{Data: []byte(gzipped(t, "["+strings.Repeat(" ", 256<<10)+resultA+"]"))}},
+			wantCode: codes.ResourceExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.Convert(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, chunk := range tt.chunks {
+				if err := stream.Send(chunk); err != nil {
+					t.Fatal(err)
+				}
+			}
+			report, err := stream.CloseAndRecv()
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("Convert() error = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert() error = %v", err)
+			}
+
+			if report.GetTests() != tt.wantTests || report.GetFailures() != tt.wantFailures || report.GetErrors() != tt.wantErrors {
+				t.Errorf("totals = %d/%d/%d, want %d/%d/%d", report.GetTests(), report.GetFailures(), report.GetErrors(), tt.wantTests, tt.wantFailures, tt.wantErrors)
+			}
+			if len(report.GetParseErrors()) != tt.wantParseErrors {
+				t.Errorf("parse errors = %v, want %d", report.GetParseErrors(), tt.wantParseErrors)
+			}
+			if !strings.HasPrefix(string(report.GetJunitXml()), "<?xml") || !strings.Contains(string(report.GetJunitXml()), tt.wantXML) {
+				t.Errorf("unexpected report:\n%s", report.GetJunitXml())
+			}
+		})
+	}
+}
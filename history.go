@@ -0,0 +1,368 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Outcomes of a task recorded in the history
+const (
+	outcomePassed  = "passed"
+	outcomeFailure = "failure"
+	outcomeError   = "error"
+	outcomeSkipped = "skipped"
+)
+
+// historySchema creates the tables of a history database: a row per run
+// added, in the order they were added, the outcome of each of its tasks and
+// the tool calls it made per server and tool
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	run_id TEXT NOT NULL,
+	started_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS outcomes (
+	run INTEGER NOT NULL REFERENCES runs(id),
+	task TEXT NOT NULL,
+	difficulty TEXT NOT NULL,
+	outcome TEXT NOT NULL,
+	PRIMARY KEY (run, task)
+);
+CREATE TABLE IF NOT EXISTS tool_calls (
+	run INTEGER NOT NULL REFERENCES runs(id),
+	server TEXT NOT NULL,
+	tool TEXT NOT NULL,
+	calls INTEGER NOT NULL,
+	succeeded INTEGER NOT NULL,
+	PRIMARY KEY (run, server, tool)
+);`
+
+// defaultHistoryRuns is how many of the latest runs history report covers
+const defaultHistoryRuns = 30
+
+// taskHistory is what history report tells of one task
+type taskHistory struct {
+	Task       string `json:"task"`
+	Difficulty string `json:"difficulty"`
+	// Runs counts the runs of the report that have the task, and Passed
+	// those in which it passed
+	Runs     int     `json:"runs"`
+	Passed   int     `json:"passed"`
+	PassRate float64 `json:"passRate"`
+	// LastOutcome is the outcome of the task in the latest run that has it
+	LastOutcome string `json:"lastOutcome"`
+	// LastPassed identifies the latest run the task passed in, over the
+	// whole history, and is nil when it never passed
+	LastPassed *historyRun `json:"lastPassed"`
+}
+
+// historyRun identifies a run of the history
+type historyRun struct {
+	RunID     string `json:"runId,omitempty"`
+	StartedAt string `json:"startedAt"`
+}
+
+// historyReport is the output of history report
+type historyReport struct {
+	Runs  int           `json:"runs"`
+	Tasks []taskHistory `json:"tasks"`
+}
+
+// runHistory implements the history command, whose first argument is the
+// action: add or report
+func runHistory(ctx context.Context, cmd *command, args []string) error {
+	var action string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	fs := cmd.flagSet()
+	dbPath := fs.String("db", "", "SQLite database file holding the history, created if missing")
+	switch action {
+	case "add":
+		inputs := addInputFlags(fs)
+		conversion := addConvertFlags(fs)
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		opts, err := inputs.options()
+		if err != nil {
+			return err
+		}
+		conv, err := conversion.newConverter()
+		if err != nil {
+			return err
+		}
+		db, err := openHistory(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		run, err := loadInputs(ctx, fs.Args(), opts)
+		if err != nil {
+			return err
+		}
+		return addHistory(ctx, db, run, conv, time.Now)
+	case "report":
+		task := fs.String("task", "", "only report this task, e.g. to tell when it last passed")
+		last := fs.Int("last", defaultHistoryRuns, "number of latest runs to compute pass rates over")
+		asJSON := fs.Bool("json", false, "print the report as JSON")
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		if *last < 1 {
+			return newUsageError("--last must be at least 1")
+		}
+		db, err := openHistory(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		report, err := reportHistory(ctx, db, *task, *last)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			encoder := json.NewEncoder(stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(report)
+		}
+		printHistory(stdout, report)
+		return nil
+	case "":
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		return newUsageError("history needs an action: add or report")
+	default:
+		return newUsageError("unknown history action %q: must be add or report", action)
+	}
+}
+
+// openHistory opens the history database at path, creating its tables
+func openHistory(path string) (*sql.DB, error) {
+	if path == "" {
+		return nil, newUsageError("--db is required")
+	}
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("history database %s: %w", path, err)
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("history database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+// addHistory records the outcome of every task of run kept by the filters of
+// conv, as conv reports it, and its tool calls, as a new run of the history,
+// started at the start time of run or, when it has none, now
+func addHistory(ctx context.Context, db *sql.DB, run converter.TestRun, conv *converter.Converter, now func() time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	startedAt := cmp.Or(run.StartedAt, now().UTC().Format(time.RFC3339))
+	inserted, err := tx.ExecContext(ctx, `INSERT INTO runs (run_id, started_at) VALUES (?, ?)`, run.RunID, startedAt)
+	if err != nil {
+		return fmt.Errorf("recording run: %w", err)
+	}
+	id, err := inserted.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("recording run: %w", err)
+	}
+	calls := make(map[[2]string]*callStats)
+	for _, result := range run.Results {
+		if result.ParseError() != nil || !conv.Keep(result) {
+			continue
+		}
+		for _, call := range result.CallHistory.ToolCalls {
+			key := [2]string{call.ServerName, call.Name}
+			if calls[key] == nil {
+				calls[key] = &callStats{}
+			}
+			calls[key].add(call.Success)
+		}
+		// A task repeated in the run keeps its last outcome
+		if _, err := tx.ExecContext(ctx, `INSERT OR REPLACE INTO outcomes (run, task, difficulty, outcome) VALUES (?, ?, ?, ?)`,
+			id, result.TaskName, result.Difficulty, historyOutcome(conv.ConvertResult(result))); err != nil {
+			return fmt.Errorf("recording task %s: %w", result.TaskName, err)
+		}
+	}
+	for key, stats := range calls {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO tool_calls (run, server, tool, calls, succeeded) VALUES (?, ?, ?, ?, ?)`,
+			id, key[0], key[1], stats.Calls, stats.Succeeded); err != nil {
+			return fmt.Errorf("recording calls of %s::%s: %w", key[0], key[1], err)
+		}
+	}
+	return tx.Commit()
+}
+
+// historyOutcome returns the outcome a testcase is recorded with
+func historyOutcome(testCase converter.JUnitTestCase) string {
+	switch {
+	case testCase.Skipped != nil:
+		return outcomeSkipped
+	case testCase.Error != nil:
+		return outcomeError
+	case testCase.Failure != nil:
+		return outcomeFailure
+	}
+	return outcomePassed
+}
+
+// reportHistory computes the pass rate of every task, or of task alone when
+// set, over the last runs of the history, along with the latest run each
+// passed in. Tasks are sorted by name.
+func reportHistory(ctx context.Context, db *sql.DB, task string, last int) (historyReport, error) {
+	report := historyReport{Tasks: []taskHistory{}}
+	rows, err := db.QueryContext(ctx, `
+		SELECT o.task, o.difficulty, o.outcome
+		FROM outcomes o
+		WHERE o.run IN (SELECT id FROM runs ORDER BY id DESC LIMIT ?) AND (? = '' OR o.task = ?)
+		ORDER BY o.run`, last, task, task)
+	if err != nil {
+		return report, fmt.Errorf("reading history: %w", err)
+	}
+	defer rows.Close()
+	tasks := make(map[string]*taskHistory)
+	for rows.Next() {
+		var name, difficulty, outcome string
+		if err := rows.Scan(&name, &difficulty, &outcome); err != nil {
+			return report, fmt.Errorf("reading history: %w", err)
+		}
+		if tasks[name] == nil {
+			tasks[name] = &taskHistory{Task: name}
+		}
+		t := tasks[name]
+		t.Difficulty, t.LastOutcome = difficulty, outcome
+		// Skipped runs count toward neither side, as in pass-rate gates
+		if outcome == outcomeSkipped {
+			continue
+		}
+		t.Runs++
+		if outcome == outcomePassed {
+			t.Passed++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("reading history: %w", err)
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT o.task, r.run_id, r.started_at
+		FROM outcomes o JOIN runs r ON r.id = o.run
+		WHERE o.run = (SELECT MAX(run) FROM outcomes WHERE task = o.task AND outcome = ?)`, outcomePassed)
+	if err != nil {
+		return report, fmt.Errorf("reading history: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var run historyRun
+		if err := rows.Scan(&name, &run.RunID, &run.StartedAt); err != nil {
+			return report, fmt.Errorf("reading history: %w", err)
+		}
+		if t := tasks[name]; t != nil {
+			t.LastPassed = &run
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, fmt.Errorf("reading history: %w", err)
+	}
+
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (SELECT id FROM runs ORDER BY id DESC LIMIT ?)`, last).Scan(&report.Runs); err != nil {
+		return report, fmt.Errorf("reading history: %w", err)
+	}
+	for _, t := range tasks {
+		if t.Runs > 0 {
+			t.PassRate = float64(t.Passed) / float64(t.Runs)
+		}
+		report.Tasks = append(report.Tasks, *t)
+	}
+	slices.SortFunc(report.Tasks, func(a, b taskHistory) int { return strings.Compare(a.Task, b.Task) })
+	return report, nil
+}
+
+// printHistory writes the history report as a table
+func printHistory(w io.Writer, report historyReport) {
+	fmt.Fprintf(w, "Runs: %d\n\n", report.Runs)
+	rows := [][]cell{{{text: "Task"}, {text: "Difficulty"}, {text: "Runs"}, {text: "Passed"}, {text: "Pass rate"}, {text: "Last"}, {text: "Last passed"}}}
+	for _, t := range report.Tasks {
+		rate, lastPassed := "-", "never"
+		if t.Runs > 0 {
+			rate = fmt.Sprintf("%.1f%%", t.PassRate*100)
+		}
+		if t.LastPassed != nil {
+			lastPassed = t.LastPassed.StartedAt
+			if t.LastPassed.RunID != "" {
+				lastPassed += " (" + t.LastPassed.RunID + ")"
+			}
+		}
+		rows = append(rows, []cell{{text: t.Task}, {text: t.Difficulty}, {text: fmt.Sprint(t.Runs)}, {text: fmt.Sprint(t.Passed)},
+			{text: rate}, {text: t.LastOutcome}, {text: lastPassed}})
+	}
+	writeTable(w, rows, false, false)
+}
+
+// historyCalls aggregates the tool calls of the last runs of the history
+// into a scoreboard
+func historyCalls(ctx context.Context, db *sql.DB, last int) (scoreboard, error) {
+	board := scoreboard{Servers: []scoreboardEntry{}, Tools: []scoreboardEntry{}}
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (SELECT id FROM runs ORDER BY id DESC LIMIT ?)`, last).Scan(&board.Runs); err != nil {
+		return board, fmt.Errorf("reading history: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT server, tool, COUNT(*), SUM(calls), SUM(succeeded)
+		FROM tool_calls
+		WHERE run IN (SELECT id FROM runs ORDER BY id DESC LIMIT ?)
+		GROUP BY server, tool`, last)
+	if err != nil {
+		return board, fmt.Errorf("reading history: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry scoreboardEntry
+		if err := rows.Scan(&entry.Server, &entry.Tool, &entry.Runs, &entry.Calls, &entry.Succeeded); err != nil {
+			return board, fmt.Errorf("reading history: %w", err)
+		}
+		board.Tools = append(board.Tools, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return board, fmt.Errorf("reading history: %w", err)
+	}
+
+	rows, err = db.QueryContext(ctx, `
+		SELECT server, COUNT(DISTINCT run), SUM(calls), SUM(succeeded)
+		FROM tool_calls
+		WHERE run IN (SELECT id FROM runs ORDER BY id DESC LIMIT ?)
+		GROUP BY server`, last)
+	if err != nil {
+		return board, fmt.Errorf("reading history: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry scoreboardEntry
+		if err := rows.Scan(&entry.Server, &entry.Runs, &entry.Calls, &entry.Succeeded); err != nil {
+			return board, fmt.Errorf("reading history: %w", err)
+		}
+		board.Servers = append(board.Servers, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return board, fmt.Errorf("reading history: %w", err)
+	}
+	board.rank()
+	return board, nil
+}
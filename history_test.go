@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+
+	dir := t.TempDir()
+	db := filepath.Join(dir, "runs.db")
+	runs := []string{
+		`{"runId":"run-1","startedAt":"2026-01-01T10:00:00Z","results":[
+			{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+			{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false}]}`,
+		`{"runId":"run-2","startedAt":"2026-01-02T10:00:00Z","results":[
+			{"taskName":"a","difficulty":"easy","taskPassed":false,"taskError":"boom"},
+			{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":false}]}`,
+		`{"runId":"run-3","startedAt":"2026-01-03T10:00:00Z","results":[
+			{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,"taskSkipped":true},
+			{"taskName":"c","difficulty":"medium","taskPassed":true,"allAssertionsPassed":true}]}`,
+	}
+	for i, run := range runs {
+		path := filepath.Join(dir, "run.json")
+		if err := os.WriteFile(path, []byte(run), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := runCLI(context.Background(), []string{"history", "add", "--db", db, path}); err != nil {
+			t.Fatalf("history add of run %d: %v", i+1, err)
+		}
+	}
+
+	if err := runCLI(context.Background(), []string{"history", "report", "--db", db, "--json"}); err != nil {
+		t.Fatal(err)
+	}
+	var report historyReport
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	want := historyReport{Runs: 3, Tasks: []taskHistory{
+		{Task: "a", Difficulty: "easy", Runs: 2, Passed: 1, PassRate: 0.5, LastOutcome: "skipped",
+			LastPassed: &historyRun{RunID: "run-1", StartedAt: "2026-01-01T10:00:00Z"}},
+		{Task: "b", Difficulty: "hard", Runs: 2, Passed: 0, LastOutcome: "failure"},
+		{Task: "c", Difficulty: "medium", Runs: 1, Passed: 1, PassRate: 1, LastOutcome: "passed",
+			LastPassed: &historyRun{RunID: "run-3", StartedAt: "2026-01-03T10:00:00Z"}},
+	}}
+	if !reflect.DeepEqual(report, want) {
+		t.Errorf("report = %+v, want %+v", report, want)
+	}
+
+	// The pass rates only cover the latest runs, while the last pass may
+	// be older
+	out.Reset()
+	if err := runCLI(context.Background(), []string{"history", "report", "--db", db, "--last", "1", "--task", "a"}); err != nil {
+		t.Fatal(err)
+	}
+	wantTable := "Runs: 1\n\n" +
+		"  Task  Difficulty  Runs  Passed  Pass rate  Last     Last passed\n" +
+		"  a     easy        0     0       -          skipped  2026-01-01T10:00:00Z (run-1)\n"
+	if out.String() != wantTable {
+		t.Errorf("report =\n%s\nwant\n%s", out.String(), wantTable)
+	}
+}
+
+func TestHistoryConversionFlags(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "run.json")
+	if err := os.WriteFile(input, []byte(`{"runId":"run-1","startedAt":"2026-01-01T10:00:00Z","results":[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,"cleanupOutput":{"Success":false,"Error":"namespace stuck"}},
+		{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		flags []string
+		want  map[string]string
+	}{
+		{name: "defaults", want: map[string]string{"a": outcomeError, "b": outcomePassed}},
+		{name: "phase policy", flags: []string{"--phase-policy", "cleanup=warn"}, want: map[string]string{"a": outcomePassed, "b": outcomePassed}},
+		{name: "task filter", flags: []string{"--difficulty", "hard"}, want: map[string]string{"b": outcomePassed}},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := filepath.Join(dir, fmt.Sprintf("runs-%d.db", i))
+			if err := runCLI(context.Background(), append(append([]string{"history", "add", "--db", db}, tt.flags...), input)); err != nil {
+				t.Fatal(err)
+			}
+			out.Reset()
+			if err := runCLI(context.Background(), []string{"history", "report", "--db", db, "--json"}); err != nil {
+				t.Fatal(err)
+			}
+			var report historyReport
+			if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, task := range report.Tasks {
+				got[task.Task] = task.LastOutcome
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outcomes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
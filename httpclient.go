@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Defaults for every HTTP(S) request: URL inputs, cloud storage, publishers
+// and notifications
+const (
+	defaultHTTPTimeout = 30 * time.Second
+	defaultHTTPRetries = 3
+)
+
+// maxRetryAfter caps the wait a Retry-After header asks for
+const maxRetryAfter = time.Minute
+
+// retryBackoff is the delay before the first retry, doubled on each further retry
+var retryBackoff = time.Second
+
+// httpOptions configures the HTTP client shared by every networked feature
+type httpOptions struct {
+	// Timeout bounds each attempt, including reading the response body
+	Timeout time.Duration
+	// Retries is the number of extra attempts after a failed request;
+	// a single attempt is always made
+	Retries int
+	// Proxy is the URL of the proxy of every request; when empty, the
+	// HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables apply
+	Proxy string
+	// CACert is a PEM file of CA certificates trusted in addition to the
+	// system ones, e.g. those of a TLS intercepting proxy
+	CACert string
+}
+
+// transport returns a transport going through the proxy and trusting the
+// CA bundle of the options
+func (o httpOptions) transport() (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if o.Proxy != "" {
+		proxy, err := url.Parse(o.Proxy)
+		if err != nil || proxy.Host == "" || !slices.Contains([]string{"http", "https", "socks5"}, proxy.Scheme) {
+			return nil, newUsageError("--proxy must be an http, https or socks5 URL, got %q", o.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if o.CACert != "" {
+		data, err := os.ReadFile(o.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no PEM certificates in %s", o.CACert)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	return transport, nil
+}
+
+// client returns an HTTP client retrying failed requests on the transport
+// of the options
+func (o httpOptions) client() (*http.Client, error) {
+	transport, err := o.transport()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: &retryTransport{base: transport, timeout: o.Timeout, retries: max(o.Retries, 0)}}, nil
+}
+
+// httpSettings and httpClient are the options and the client of every
+// HTTP(S) request, installed by setupHTTP
+var (
+	httpSettings = httpOptions{Timeout: defaultHTTPTimeout, Retries: defaultHTTPRetries}
+	httpClient   = &http.Client{Transport: &retryTransport{base: http.DefaultTransport, timeout: defaultHTTPTimeout, retries: defaultHTTPRetries}}
+)
+
+// networkFlagNames lists the flags registered by addNetworkFlags
+var networkFlagNames = map[string]bool{
+	"http-timeout": true,
+	"http-retries": true,
+	"proxy":        true,
+	"ca-cert":      true,
+}
+
+// addNetworkFlags registers the network flags, which every command accepts, on fs
+func addNetworkFlags(fs *flag.FlagSet) {
+	fs.Duration("http-timeout", defaultHTTPTimeout, "timeout for each attempt of an HTTP(S) request, including reading the response")
+	fs.Int("http-retries", defaultHTTPRetries, "retries with exponential backoff for failed HTTP(S) requests")
+	fs.String("proxy", "", "proxy URL for every HTTP(S) request (default from HTTPS_PROXY, HTTP_PROXY and NO_PROXY)")
+	fs.String("ca-cert", "", "PEM file of CA certificates to trust in addition to the system ones")
+}
+
+// setupHTTP installs the HTTP client configured by the network flags of fs,
+// if it has them
+func setupHTTP(fs *flag.FlagSet) error {
+	if fs.Lookup("http-timeout") == nil {
+		return nil
+	}
+	opts := httpOptions{
+		Timeout: fs.Lookup("http-timeout").Value.(flag.Getter).Get().(time.Duration),
+		Retries: fs.Lookup("http-retries").Value.(flag.Getter).Get().(int),
+		Proxy:   fs.Lookup("proxy").Value.String(),
+		CACert:  fs.Lookup("ca-cert").Value.String(),
+	}
+	if opts.Retries < 0 {
+		return newUsageError("--http-retries must not be negative")
+	}
+	if opts.Timeout <= 0 {
+		return newUsageError("--http-timeout must be positive")
+	}
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	httpSettings, httpClient = opts, client
+	return nil
+}
+
+// retryTransport bounds each attempt of a request with a timeout and retries
+// failed ones with exponential backoff. Network errors, 429 and 5xx responses
+// are retried for idempotent methods; other methods, which may have taken
+// effect, are only retried when the connection could not be made or the
+// server answered 429 or 503, and requests whose body cannot be replayed are
+// never retried.
+type retryTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+	retries int
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.attempt(req)
+		wait, retry := t.shouldRetry(req, resp, err)
+		if !retry || attempt == t.retries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		if resp != nil {
+			err = fmt.Errorf("unexpected status %s", resp.Status)
+			io.Copy(io.Discard, io.LimitReader(resp.Body, maxAPIErrorBody))
+			resp.Body.Close()
+		}
+		wait = max(wait, backoff)
+
+		slog.Warn("retrying request", "url", req.URL.Redacted(), "error", err, "backoff", wait)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+		backoff *= 2
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// attempt sends req once, bounding it with the timeout until its response
+// body is closed
+func (t *retryTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// shouldRetry reports whether the outcome of an attempt is retried, and how
+// long the server asked to wait before doing so
+func (t *retryTransport) shouldRetry(req *http.Request, resp *http.Response, err error) (time.Duration, bool) {
+	if req.Context().Err() != nil {
+		return 0, false
+	}
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions ||
+		req.Method == http.MethodPut || req.Method == http.MethodDelete
+	if err != nil {
+		var opErr *net.OpError
+		return 0, idempotent || (errors.As(err, &opErr) && (opErr.Op == "dial" || opErr.Op == "proxyconnect"))
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+		return retryAfter(resp), true
+	case resp.StatusCode >= 500:
+		return 0, idempotent
+	}
+	return 0, false
+}
+
+// retryAfter returns the wait given in seconds by the Retry-After header of
+// resp, capped to maxRetryAfter
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return min(time.Duration(seconds)*time.Second, maxRetryAfter)
+}
+
+// cancelBody releases the timeout of an attempt once its body is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useHTTPClient installs the client of opts for the duration of the test,
+// with retries a millisecond apart
+func useHTTPClient(t *testing.T, opts httpOptions) {
+	t.Helper()
+	client, err := opts.client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousSettings, previousClient, previousBackoff := httpSettings, httpClient, retryBackoff
+	httpSettings, httpClient, retryBackoff = opts, client, time.Millisecond
+	t.Cleanup(func() { httpSettings, httpClient, retryBackoff = previousSettings, previousClient, previousBackoff })
+}
+
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		statuses     []int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{name: "get retries server errors", method: http.MethodGet, statuses: []int{502, 500, 200}, wantStatus: 200, wantAttempts: 3},
+		{name: "post retries unavailable", method: http.MethodPost, statuses: []int{503, 429, 200}, wantStatus: 200, wantAttempts: 3},
+		{name: "post does not retry server errors", method: http.MethodPost, statuses: []int{500, 200}, wantStatus: 500, wantAttempts: 1},
+		{name: "gives up after retries", method: http.MethodPut, statuses: []int{503}, wantStatus: 503, wantAttempts: 4},
+		{name: "does not retry client errors", method: http.MethodGet, statuses: []int{401, 200}, wantStatus: 401, wantAttempts: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				// Every attempt sends the whole body
+				if body, _ := io.ReadAll(r.Body); r.Method != http.MethodGet && string(body) != "payload" {
+					t.Errorf("attempt %d sent %q", n, body)
+				}
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[min(int(n)-1, len(tt.statuses)-1)])
+			}))
+			defer server.Close()
+			useHTTPClient(t, httpOptions{Timeout: time.Second, Retries: 3})
+
+			var body io.Reader
+			if tt.method != http.MethodGet {
+				body = strings.NewReader("payload")
+			}
+			req, err := http.NewRequest(tt.method, server.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := httpClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportTimeout(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+	useHTTPClient(t, httpOptions{Timeout: 50 * time.Millisecond, Retries: 1})
+
+	// The first attempt times out, the second one gets the results
+	data, err := fetchURL(context.Background(), server.URL, RemoteOptions{})
+	if err != nil || string(data) != "[]" {
+		t.Errorf("fetchURL() = %q, %v", data, err)
+	}
+}
+
+func TestHTTPClientCACert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	useHTTPClient(t, httpOptions{Timeout: time.Second})
+	if _, err := fetchURL(context.Background(), server.URL, RemoteOptions{}); err == nil {
+		t.Fatal("fetching from a server with an untrusted certificate succeeded")
+	}
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caCert, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	useHTTPClient(t, httpOptions{Timeout: time.Second, CACert: caCert})
+	if _, err := fetchURL(context.Background(), server.URL, RemoteOptions{}); err != nil {
+		t.Errorf("fetching with --ca-cert: %v", err)
+	}
+
+	if _, err := (httpOptions{CACert: filepath.Join(t.TempDir(), "missing.pem")}).client(); err == nil {
+		t.Error("a missing CA bundle was accepted")
+	}
+	notPEM := filepath.Join(t.TempDir(), "ca.txt")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (httpOptions{CACert: notPEM}).client(); err == nil {
+		t.Error("a CA bundle without certificates was accepted")
+	}
+}
+
+func TestHTTPClientProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte("[]"))
+	}))
+	defer proxy.Close()
+
+	useHTTPClient(t, httpOptions{Timeout: time.Second, Proxy: proxy.URL})
+	if _, err := fetchURL(context.Background(), "http://results.example.com/run.json", RemoteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if proxied != "http://results.example.com/run.json" {
+		t.Errorf("proxy received %q", proxied)
+	}
+}
+
+func TestSetupHTTP(t *testing.T) {
+	previousSettings, previousClient := httpSettings, httpClient
+	t.Cleanup(func() { httpSettings, httpClient = previousSettings, previousClient })
+
+	cmd := findCommand(defaultCommand)
+	fs := cmd.flagSet()
+	if err := parseFlags(fs, []string{"--http-timeout", "5s", "--http-retries", "1", "--proxy", "http://proxy.example.com:3128"}); err != nil {
+		t.Fatal(err)
+	}
+	want := httpOptions{Timeout: 5 * time.Second, Retries: 1, Proxy: "http://proxy.example.com:3128"}
+	if httpSettings != want {
+		t.Errorf("settings = %+v, want %+v", httpSettings, want)
+	}
+	transport := httpClient.Transport.(*retryTransport)
+	if transport.timeout != want.Timeout || transport.retries != want.Retries {
+		t.Errorf("transport = %+v", transport)
+	}
+	req := httptest.NewRequest(http.MethodGet, "https://results.example.com", nil)
+	if proxy, err := transport.base.(*http.Transport).Proxy(req); err != nil || proxy.String() != want.Proxy {
+		t.Errorf("proxy = %v, %v, want %s", proxy, err, want.Proxy)
+	}
+}
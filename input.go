@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// inputOptions controls how the inputs are found, fetched and decoded
+type inputOptions struct {
+	converter.ParseOptions
+	// Remote configures fetching inputs given as HTTP(S) URLs
+	Remote RemoteOptions
+}
+
+// errNoResults is returned for inputs that hold no results unless
+// --allow-empty is given
+var errNoResults = errors.New("no results to report")
+
+// checkResults fails when run has neither results nor imported suites, or
+// only warns when opts allow empty inputs
+func checkResults(run converter.TestRun, opts inputOptions) error {
+	if len(run.Results) > 0 || len(run.ImportedSuites) > 0 || len(run.ParseErrors) > 0 {
+		return nil
+	}
+	if !opts.AllowEmpty {
+		return errNoResults
+	}
+	slog.Warn("the input has no results, writing an empty report")
+	return nil
+}
+
+// loadInputs parses and combines every input argument, reading stdin when
+// there are none
+func loadInputs(ctx context.Context, paths []string, opts inputOptions) (converter.TestRun, error) {
+	if len(paths) == 0 {
+		return loadInput(ctx, "", opts)
+	}
+	var run converter.TestRun
+	for _, path := range paths {
+		inputRun, err := loadInput(ctx, path, opts)
+		if err != nil {
+			return run, err
+		}
+		run.Merge(inputRun)
+	}
+	return run, nil
+}
+
+// loadInput parses results from a file, every results file in a directory or
+// a tar/zip archive, an HTTP(S) URL, an S3/GCS object, or stdin when path is
+// empty or "-", marking its errors as input errors
+func loadInput(ctx context.Context, path string, opts inputOptions) (converter.TestRun, error) {
+	run, err := readInput(ctx, path, opts)
+	if err != nil {
+		return run, inputError{err: err}
+	}
+	return run, nil
+}
+
+// readInput parses results from the input named by path, as loadInput does
+func readInput(ctx context.Context, path string, opts inputOptions) (converter.TestRun, error) {
+	if isURL(path) {
+		return loadURL(ctx, path, opts)
+	}
+	if isCloudURI(path) {
+		return loadCloudObject(ctx, path, opts)
+	}
+	if path == "" || path == "-" {
+		start := time.Now()
+		run, err := converter.ParseContext(ctx, os.Stdin, opts.ParseOptions)
+		if err != nil {
+			return run, fmt.Errorf("parsing stdin: %w", err)
+		}
+		run.SetSource("stdin")
+		logParsed("stdin", run, start)
+		return run, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return converter.TestRun{}, fmt.Errorf("opening file %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return loadDirectory(ctx, path, opts)
+	}
+	if isArchive(path) {
+		return loadArchive(ctx, path, opts)
+	}
+	return loadFile(ctx, path, opts)
+}
+
+func loadFile(ctx context.Context, filename string, opts inputOptions) (converter.TestRun, error) {
+	start := time.Now()
+	file, err := os.Open(filename)
+	if err != nil {
+		return converter.TestRun{}, fmt.Errorf("opening file %s: %w", filename, err)
+	}
+	defer file.Close()
+
+	if opts.Format == converter.FormatAuto {
+		opts.Format = inputFormatForFile(filename)
+	}
+
+	run, err := converter.ParseContext(ctx, file, opts.ParseOptions)
+	if err != nil {
+		return run, fmt.Errorf("parsing %s: %w", filename, err)
+	}
+	run.SetSource(filename)
+	logParsed(filename, run, start)
+	return run, nil
+}
+
+// loadDirectory parses every results file in dir, in name order, into a single run
+func loadDirectory(ctx context.Context, dir string, opts inputOptions) (converter.TestRun, error) {
+	var run converter.TestRun
+	found := false
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return run, fmt.Errorf("reading directory %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !isResultsFile(entry.Name()) {
+			continue
+		}
+		fileRun, err := loadFile(ctx, filepath.Join(dir, entry.Name()), opts)
+		if err != nil {
+			return run, err
+		}
+		run.Merge(fileRun)
+		found = true
+	}
+	if !found {
+		return run, fmt.Errorf("no results files found in %s", dir)
+	}
+	return run, nil
+}
+
+// isResultsFile reports whether a file name looks like a supported results file
+func isResultsFile(filename string) bool {
+	name := strings.TrimSuffix(strings.ToLower(filename), ".gz")
+	switch filepath.Ext(name) {
+	case ".json", ".ndjson", ".jsonl", ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// inputFormatForFile picks the input format implied by a file extension,
+// falling back to auto-detection
+func inputFormatForFile(filename string) string {
+	name := strings.TrimSuffix(strings.ToLower(filename), ".gz")
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		return converter.FormatYAML
+	case ".ndjson", ".jsonl":
+		return converter.FormatNDJSON
+	case ".xml":
+		return converter.FormatJUnit
+	default:
+		return converter.FormatAuto
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+const (
+	resultA = `{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true}`
+	resultB = `{"taskName":"b","taskPath":"/x/tasks/b/task.yaml","taskPassed":false,"difficulty":"hard","allAssertionsPassed":false}`
+	resultC = `{"taskName":"c","taskPassed":true,"difficulty":"medium","allAssertionsPassed":true}`
+)
+
+// autoInput reads inputs with the default flags
+var autoInput = inputOptions{ParseOptions: converter.ParseOptions{Format: converter.FormatAuto}}
+
+func taskNames(run converter.TestRun) []string {
+	names := make([]string, 0, len(run.Results))
+	for _, result := range run.Results {
+		names = append(names, result.TaskName)
+	}
+	return names
+}
+
+func gzipped(t *testing.T, data string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestInputFormatForFile(t *testing.T) {
+	tests := map[string]string{
+		"results.json":      converter.FormatAuto,
+		"results.json.gz":   converter.FormatAuto,
+		"results.yaml":      converter.FormatYAML,
+		"RESULTS.YML.GZ":    converter.FormatYAML,
+		"results.ndjson":    converter.FormatNDJSON,
+		"results.jsonl.gz":  converter.FormatNDJSON,
+		"results":           converter.FormatAuto,
+		"go-test.xml":       converter.FormatJUnit,
+		"dir.yaml/out.json": converter.FormatAuto,
+	}
+	for filename, want := range tests {
+		if got := inputFormatForFile(filename); got != want {
+			t.Errorf("inputFormatForFile(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
+
+func TestLoadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"1.json":        "[" + resultA + "]",
+		"2.yaml":        "taskName: b\n",
+		"3.ndjson":      resultC,
+		"notes.txt":     "not results",
+		"report.xml":    "<testsuites/>",
+		"4.jsonl.gz":    gzipped(t, `{"taskName":"d"}`),
+		"5.json.bak":    "[" + resultA + "]",
+		"nested/6.json": "[" + resultA + "]",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run, err := loadInput(context.Background(), dir, autoInput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(taskNames(run), ","); got != "a,b,c,d" {
+		t.Errorf("task names = %s, want a,b,c,d", got)
+	}
+
+	if _, err := loadInput(context.Background(), t.TempDir(), autoInput); err == nil {
+		t.Error("loading a directory without results files succeeded, want an error")
+	}
+}
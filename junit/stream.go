@@ -0,0 +1,257 @@
+// Package junit writes JUnit XML documents incrementally, one testcase at a
+// time, so that large reports never have to be held in memory.
+package junit
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Property is a name/value pair of the properties of a testsuite
+type Property struct {
+	Name  string `xml:"name,attr" json:"name"`
+	Value string `xml:"value,attr" json:"value"`
+}
+
+// Suite holds the attributes and properties of a testsuite element. The
+// counts are written in its start tag, before any testcase, so they must be
+// known up front.
+type Suite struct {
+	Name     string
+	Tests    int
+	Failures int
+	Errors   int
+	Skipped  int
+	// Time is the total time in seconds, left out when empty
+	Time      string
+	Timestamp string
+	// Properties are written in a properties element when not empty
+	Properties []Property
+}
+
+// Root holds the attributes of the testsuites element: the optional name
+// and the aggregates over every testsuite, which some consumers read
+// instead of summing the suites
+type Root struct {
+	Name     string
+	Tests    int
+	Failures int
+	Errors   int
+	Skipped  int
+	// Time is the total time in seconds, left out when empty
+	Time string
+	// Properties are written in a properties element when not empty
+	Properties []Property
+}
+
+// StreamWriter writes a testsuites document to an io.Writer as it is
+// produced: WriteSuiteStart, WriteTestCase for each testcase of the suite
+// and WriteSuiteEnd, for each suite, then Close. The XML header and the
+// testsuites element are written with the first suite, or by Close when
+// there is none. Call SetRoot before that to give the testsuites element
+// its attributes.
+//
+// Writes are not buffered beyond the encoder, so wrap slow writers in a
+// bufio.Writer. The first error is returned by every later call.
+type StreamWriter struct {
+	encoder *xml.Encoder
+	w       io.Writer
+	root    *Root
+	started bool
+	closed  bool
+	// suite is the open testsuite, if any, and written the number of
+	// testcases written to it so far
+	suite   *Suite
+	written int
+	err     error
+}
+
+// NewStreamWriter returns a StreamWriter writing to w, indenting nested
+// elements with indent or, when it is empty, writing the document on a
+// single line
+func NewStreamWriter(w io.Writer, indent string) *StreamWriter {
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", indent)
+	return &StreamWriter{encoder: encoder, w: w}
+}
+
+// SetRoot sets the attributes of the testsuites element. It must be called
+// before the first testsuite is written.
+func (s *StreamWriter) SetRoot(root Root) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.started {
+		return s.fail(errors.New("setting the testsuites attributes after they were written"))
+	}
+	s.root = &root
+	return nil
+}
+
+// WriteSuiteStart opens a testsuite element
+func (s *StreamWriter) WriteSuiteStart(suite Suite) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.closed {
+		return s.fail(errors.New("writing a testsuite after Close"))
+	}
+	if s.suite != nil {
+		return s.fail(fmt.Errorf("starting testsuite %q before the end of testsuite %q", suite.Name, s.suite.Name))
+	}
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: "testsuite"}, Attr: []xml.Attr{
+		{Name: xml.Name{Local: "name"}, Value: suite.Name},
+		{Name: xml.Name{Local: "tests"}, Value: strconv.Itoa(suite.Tests)},
+		{Name: xml.Name{Local: "failures"}, Value: strconv.Itoa(suite.Failures)},
+		{Name: xml.Name{Local: "errors"}, Value: strconv.Itoa(suite.Errors)},
+		{Name: xml.Name{Local: "skipped"}, Value: strconv.Itoa(suite.Skipped)},
+	}}
+	if suite.Time != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "time"}, Value: suite.Time})
+	}
+	if suite.Timestamp != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "timestamp"}, Value: suite.Timestamp})
+	}
+	if err := s.encoder.EncodeToken(start); err != nil {
+		return s.fail(err)
+	}
+	if err := s.writeProperties(suite.Properties); err != nil {
+		return err
+	}
+	s.suite = &suite
+	s.written = 0
+	return s.flush()
+}
+
+// WriteTestCase writes a testcase of the open testsuite. testCase is
+// marshaled with encoding/xml as a testcase element, whatever its type name,
+// so any struct with the usual JUnit attributes and elements will do.
+func (s *StreamWriter) WriteTestCase(testCase any) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.suite == nil {
+		return s.fail(errors.New("writing a testcase outside of a testsuite"))
+	}
+	if err := s.encoder.EncodeElement(testCase, xml.StartElement{Name: xml.Name{Local: "testcase"}}); err != nil {
+		return s.fail(fmt.Errorf("writing testcase: %w", err))
+	}
+	s.written++
+	return s.flush()
+}
+
+// WriteSuiteEnd closes the open testsuite. It fails when the number of
+// testcases written differs from the count given to WriteSuiteStart.
+func (s *StreamWriter) WriteSuiteEnd() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.suite == nil {
+		return s.fail(errors.New("ending a testsuite that was not started"))
+	}
+	if s.written != s.suite.Tests {
+		return s.fail(fmt.Errorf("testsuite %q declares %d tests but %d were written", s.suite.Name, s.suite.Tests, s.written))
+	}
+	if err := s.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "testsuite"}}); err != nil {
+		return s.fail(err)
+	}
+	s.suite = nil
+	return s.flush()
+}
+
+// Close ends the document. It does not close the underlying writer.
+func (s *StreamWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.closed {
+		return nil
+	}
+	if s.suite != nil {
+		return s.fail(fmt.Errorf("closing before the end of testsuite %q", s.suite.Name))
+	}
+	if err := s.start(); err != nil {
+		return err
+	}
+	if err := s.encoder.EncodeToken(xml.EndElement{Name: xml.Name{Local: "testsuites"}}); err != nil {
+		return s.fail(err)
+	}
+	if err := s.flush(); err != nil {
+		return err
+	}
+	s.closed = true
+	if _, err := io.WriteString(s.w, "\n"); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// start writes the XML header and opens the testsuites element, once
+func (s *StreamWriter) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	if _, err := io.WriteString(s.w, xml.Header); err != nil {
+		return s.fail(err)
+	}
+	start := xml.StartElement{Name: xml.Name{Local: "testsuites"}}
+	if root := s.root; root != nil {
+		if root.Name != "" {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "name"}, Value: root.Name})
+		}
+		start.Attr = append(start.Attr,
+			xml.Attr{Name: xml.Name{Local: "tests"}, Value: strconv.Itoa(root.Tests)},
+			xml.Attr{Name: xml.Name{Local: "failures"}, Value: strconv.Itoa(root.Failures)},
+			xml.Attr{Name: xml.Name{Local: "errors"}, Value: strconv.Itoa(root.Errors)},
+			xml.Attr{Name: xml.Name{Local: "skipped"}, Value: strconv.Itoa(root.Skipped)},
+		)
+		if root.Time != "" {
+			start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "time"}, Value: root.Time})
+		}
+	}
+	if err := s.encoder.EncodeToken(start); err != nil {
+		return s.fail(err)
+	}
+	if s.root != nil {
+		return s.writeProperties(s.root.Properties)
+	}
+	return nil
+}
+
+// writeProperties writes a properties element, unless there are none
+func (s *StreamWriter) writeProperties(properties []Property) error {
+	if len(properties) == 0 {
+		return nil
+	}
+	element := struct {
+		Properties []Property `xml:"property"`
+	}{properties}
+	if err := s.encoder.EncodeElement(element, xml.StartElement{Name: xml.Name{Local: "properties"}}); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// flush writes out what the encoder buffered
+func (s *StreamWriter) flush() error {
+	if err := s.encoder.Flush(); err != nil {
+		return s.fail(err)
+	}
+	return nil
+}
+
+// fail records the first error
+func (s *StreamWriter) fail(err error) error {
+	if s.err == nil {
+		s.err = err
+	}
+	return s.err
+}
@@ -0,0 +1,142 @@
+package junit_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+	"github.com/jrangelramos/mcpchecker-junit-report/junit"
+)
+
+// streamReport writes report with a StreamWriter
+func streamReport(t *testing.T, report converter.JUnitTestSuites, indent string) string {
+	t.Helper()
+	var out bytes.Buffer
+	w := junit.NewStreamWriter(&out, indent)
+	root := junit.Root{Name: report.Name, Tests: report.Tests, Failures: report.Failures,
+		Errors: report.Errors, Skipped: report.Skipped, Time: report.Time}
+	if report.Properties != nil {
+		root.Properties = report.Properties.Properties
+	}
+	if err := w.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	for _, suite := range report.Suites {
+		start := junit.Suite{Name: suite.Name, Tests: suite.Tests, Failures: suite.Failures,
+			Errors: suite.Errors, Skipped: suite.Skipped, Time: suite.Time, Timestamp: suite.Timestamp}
+		if suite.Properties != nil {
+			start.Properties = suite.Properties.Properties
+		}
+		if err := w.WriteSuiteStart(start); err != nil {
+			t.Fatal(err)
+		}
+		for _, testCase := range suite.TestCases {
+			if err := w.WriteTestCase(testCase); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.WriteSuiteEnd(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestStreamWriterMatchesRender(t *testing.T) {
+	run, err := converter.Parse(strings.NewReader(`{"runId":"r1","startedAt":"2025-03-01T10:00:00Z","results":[
+		{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","taskPassed":true,"allAssertionsPassed":true,"difficulty":"easy"},
+		{"taskName":"b","taskPassed":false,"taskError":"boom <&>","difficulty":"hard","agentOutput":{"Success":false,"DurationMs":1200}},
+		{"taskName":"c","taskPassed":true,"allAssertionsPassed":false,"difficulty":"hard",
+			"assertionResults":{"toolsUsed":{"passed":false,"reason":"missing"}}}
+	]}`), converter.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, indent := range []string{converter.DefaultIndent, "\t", ""} {
+		conv, err := converter.New(converter.WithIndent(indent), converter.WithReportName("nightly"),
+			converter.WithProperties(converter.JUnitProperty{Name: "ci", Value: "true"}), converter.WithReportProperties(converter.JUnitProperty{Name: "GIT_SHA", Value: "abc123"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := conv.Convert(run)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := conv.Render(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := streamReport(t, report, indent); got != string(want) {
+			t.Errorf("indent %q: streamed report differs from the rendered one:\n%s\nwant:\n%s", indent, got, want)
+		}
+	}
+}
+
+func TestStreamWriterEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := junit.NewStreamWriter(&out, "  ").Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := xml.Header + "<testsuites></testsuites>\n"; out.String() != want {
+		t.Errorf("empty document = %q, want %q", out.String(), want)
+	}
+}
+
+func TestStreamWriterMisuse(t *testing.T) {
+	type testCase struct {
+		Name string `xml:"name,attr"`
+	}
+
+	tests := []struct {
+		name  string
+		write func(w *junit.StreamWriter) error
+	}{
+		{name: "testcase outside a suite", write: func(w *junit.StreamWriter) error {
+			return w.WriteTestCase(testCase{Name: "a"})
+		}},
+		{name: "nested suites", write: func(w *junit.StreamWriter) error {
+			w.WriteSuiteStart(junit.Suite{Name: "outer"})
+			return w.WriteSuiteStart(junit.Suite{Name: "inner"})
+		}},
+		{name: "end without start", write: func(w *junit.StreamWriter) error {
+			return w.WriteSuiteEnd()
+		}},
+		{name: "wrong test count", write: func(w *junit.StreamWriter) error {
+			w.WriteSuiteStart(junit.Suite{Name: "s", Tests: 2})
+			w.WriteTestCase(testCase{Name: "a"})
+			return w.WriteSuiteEnd()
+		}},
+		{name: "close with an open suite", write: func(w *junit.StreamWriter) error {
+			w.WriteSuiteStart(junit.Suite{Name: "s"})
+			return w.Close()
+		}},
+		{name: "root after the first suite", write: func(w *junit.StreamWriter) error {
+			w.WriteSuiteStart(junit.Suite{Name: "s"})
+			w.WriteSuiteEnd()
+			return w.SetRoot(junit.Root{Tests: 0})
+		}},
+		{name: "suite after close", write: func(w *junit.StreamWriter) error {
+			w.Close()
+			return w.WriteSuiteStart(junit.Suite{Name: "s"})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := junit.NewStreamWriter(&bytes.Buffer{}, "")
+			err := tt.write(w)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if closeErr := w.Close(); closeErr != err {
+				t.Errorf("Close() = %v after %v, want the first error", closeErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package junit
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// junitXSD is the XML Schema of the documents Validate accepts
+//
+//go:embed junit.xsd
+var junitXSD []byte
+
+var junitSchema = mustCompileSchema(junitXSD)
+
+// Schema returns the embedded XML Schema that Validate checks documents against
+func Schema() []byte {
+	return bytes.Clone(junitXSD)
+}
+
+// ValidationError is the first violation of the JUnit schema found by Validate
+type ValidationError struct {
+	// Line is the line of the document the violation was found on
+	Line int
+	// Path locates the offending element, e.g.
+	// /testsuites/testsuite[@name="easy"]/testcase[@name="create-pod"], and
+	// is empty when the document has no root element
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("invalid JUnit XML: line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("invalid JUnit XML: line %d: %s: %s", e.Line, e.Path, e.Message)
+}
+
+// Validate checks that data is a well-formed JUnit XML document, rooted at
+// testsuites or testsuite, that matches the embedded schema. It returns a
+// *ValidationError locating the first violation, or nil when the document
+// is valid.
+func Validate(data []byte) error {
+	v := &validator{decoder: xml.NewDecoder(bytes.NewReader(data))}
+	var root *xml.StartElement
+	for root == nil {
+		token, err := v.decoder.Token()
+		if err == io.EOF {
+			return v.fail("", "document has no root element")
+		}
+		if err != nil {
+			return v.syntaxError("", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			root = &token
+		case xml.CharData:
+			if len(bytes.TrimSpace(token)) > 0 {
+				return v.fail("", "text before the root element")
+			}
+		}
+	}
+
+	path := "/" + elementStep(*root, nil)
+	decl, ok := junitSchema.elements[root.Name.Local]
+	if !ok {
+		return v.fail(path, fmt.Sprintf("root element <%s> is not one of %s", root.Name.Local, strings.Join(junitSchema.roots(), ", ")))
+	}
+	if err := v.element(*root, decl, path); err != nil {
+		return err
+	}
+
+	for {
+		token, err := v.decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return v.syntaxError("", err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			return v.fail("/"+elementStep(token, nil), "document has more than one root element")
+		case xml.CharData:
+			if len(bytes.TrimSpace(token)) > 0 {
+				return v.fail("", "text after the root element")
+			}
+		}
+	}
+}
+
+// validator walks a document, checking each element against its declaration
+type validator struct {
+	decoder *xml.Decoder
+}
+
+// element checks the attributes and content of the element that start
+// opens, up to its end tag
+func (v *validator) element(start xml.StartElement, decl *elementDecl, path string) error {
+	for _, attr := range start.Attr {
+		// Namespace declarations and qualified attributes, such as
+		// xsi:schemaLocation, are outside the schema
+		if attr.Name.Space != "" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		attrDecl, ok := decl.attrs[attr.Name.Local]
+		if !ok {
+			return v.fail(path, fmt.Sprintf("attribute %q is not allowed on <%s>", attr.Name.Local, decl.name))
+		}
+		if !validValue(attrDecl.typ, attr.Value) {
+			return v.fail(path, fmt.Sprintf("attribute %s=%q is not a valid %s", attr.Name.Local, attr.Value, attrDecl.typ))
+		}
+	}
+	for _, name := range decl.required {
+		if !slices.ContainsFunc(start.Attr, func(attr xml.Attr) bool { return attr.Name.Space == "" && attr.Name.Local == name }) {
+			return v.fail(path, fmt.Sprintf("<%s> is missing the required attribute %q", decl.name, name))
+		}
+	}
+
+	// children records the child elements, to be matched against the
+	// content model once they are all known
+	type child struct {
+		name, path string
+		line       int
+	}
+	var children []child
+	seen := make(map[string]int)
+	for {
+		token, err := v.decoder.Token()
+		if err != nil {
+			return v.syntaxError(path, err)
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			line, _ := v.decoder.InputPos()
+			childPath := path + "/" + elementStep(token, seen)
+			childDecl, ok := decl.children[token.Name.Local]
+			if !ok {
+				return v.fail(childPath, fmt.Sprintf("element <%s> is not allowed in <%s>", token.Name.Local, decl.name))
+			}
+			children = append(children, child{name: token.Name.Local, path: childPath, line: line})
+			if err := v.element(token, childDecl, childPath); err != nil {
+				return err
+			}
+		case xml.CharData:
+			if !decl.text && len(bytes.TrimSpace(token)) > 0 {
+				return v.fail(path, fmt.Sprintf("<%s> must not contain text", decl.name))
+			}
+		case xml.EndElement:
+			if decl.content == nil {
+				return nil
+			}
+			names := make([]string, len(children))
+			for i, c := range children {
+				names[i] = c.name
+			}
+			matched, missing, ok := decl.content.match(names, 0)
+			if !ok {
+				return v.fail(path, fmt.Sprintf("<%s> is missing a <%s> element", decl.name, missing))
+			}
+			if matched < len(children) {
+				c := children[matched]
+				return &ValidationError{Line: c.line, Path: c.path, Message: fmt.Sprintf("element <%s> is out of order or repeated in <%s>", c.name, decl.name)}
+			}
+			return nil
+		}
+	}
+}
+
+// fail returns a ValidationError at the current position of the decoder
+func (v *validator) fail(path, message string) error {
+	line, _ := v.decoder.InputPos()
+	return &ValidationError{Line: line, Path: path, Message: message}
+}
+
+// syntaxError reports a document that is not well-formed XML
+func (v *validator) syntaxError(path string, err error) error {
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return &ValidationError{Line: syntaxErr.Line, Path: path, Message: syntaxErr.Msg}
+	}
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return v.fail(path, err.Error())
+}
+
+// elementStep names an element in a Path by its name attribute or, when it
+// has none, by its position among the siblings of the same name counted in seen
+func elementStep(start xml.StartElement, seen map[string]int) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "name" {
+			return fmt.Sprintf("%s[@name=%q]", start.Name.Local, attr.Value)
+		}
+	}
+	if seen == nil {
+		return start.Name.Local
+	}
+	seen[start.Name.Local]++
+	return fmt.Sprintf("%s[%d]", start.Name.Local, seen[start.Name.Local])
+}
+
+var decimalValue = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// dateTimeLayouts are the forms of xs:dateTime, with and without a time zone
+var dateTimeLayouts = []string{"2006-01-02T15:04:05.999999999Z07:00", "2006-01-02T15:04:05.999999999"}
+
+// validValue reports whether value is a valid literal of the built-in type typ
+func validValue(typ, value string) bool {
+	switch typ {
+	case "xs:boolean":
+		return value == "true" || value == "false" || value == "1" || value == "0"
+	case "xs:decimal":
+		return decimalValue.MatchString(value)
+	case "xs:nonNegativeInteger":
+		_, err := strconv.ParseUint(value, 10, 64)
+		return err == nil
+	case "xs:positiveInteger":
+		n, err := strconv.ParseUint(value, 10, 64)
+		return err == nil && n > 0
+	case "xs:dateTime":
+		for _, layout := range dateTimeLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return true
+			}
+		}
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,172 @@
+package junit_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+	"github.com/jrangelramos/mcpchecker-junit-report/junit"
+)
+
+func TestValidateRenderedReport(t *testing.T) {
+	run, err := converter.Parse(strings.NewReader(`{"runId":"r1","startedAt":"2025-03-01T10:00:00Z","results":[
+		{"taskName":"a","taskPath":"/x/tasks/a/task.yaml","taskLine":3,"taskPassed":true,"allAssertionsPassed":true,"difficulty":"easy"},
+		{"taskName":"b","taskPassed":false,"taskError":"boom <&>","difficulty":"hard"},
+		{"taskName":"c","taskPassed":true,"allAssertionsPassed":false,"difficulty":"hard",
+			"assertionResults":{"toolsUsed":{"passed":false,"reason":"missing"}}}
+	]}`), converter.ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range [][]converter.Option{
+		nil,
+		{converter.WithIndent(""), converter.WithoutCDATA()},
+		{converter.WithNestedSuites(), converter.WithGroupBy(converter.GroupByNone)},
+		{converter.WithExplodedAssertions()},
+	} {
+		conv, err := converter.New(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := conv.Convert(run)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := conv.Render(report)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := junit.Validate(data); err != nil {
+			t.Errorf("Validate() error = %v\n%s", err, data)
+		}
+		// StreamWriter does not write nested suites
+		if len(report.Suites[0].Suites) == 0 {
+			streamed := streamReport(t, report, converter.DefaultIndent)
+			if err := junit.Validate([]byte(streamed)); err != nil {
+				t.Errorf("Validate() of the streamed report error = %v\n%s", err, streamed)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		doc      string
+		wantLine int
+		wantPath string
+		wantMsg  string
+	}{
+		"single testsuite root": {
+			doc: `<testsuite name="s" tests="1"><testcase name="a"><skipped/></testcase></testsuite>`,
+		},
+		"foreign attributes": {
+			doc: `<testsuites xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="junit.xsd"/>`,
+		},
+		"reruns and output": {
+			doc: `<testsuites><testsuite name="s" tests="1" time="1.5" timestamp="2025-03-01T10:00:00">
+				<testcase name="a" line="7"><failure type="Failed">x</failure>
+					<rerunFailure type="Failed"><stackTrace>y</stackTrace></rerunFailure><system-out>z</system-out>
+				</testcase></testsuite></testsuites>`,
+		},
+		"not well-formed": {
+			doc:      "<testsuites>\n<testsuite name=\"s\" tests=\"0\">\n</testsuites>",
+			wantLine: 3,
+			wantPath: `/testsuites/testsuite[@name="s"]`,
+			wantMsg:  "element <testsuite> closed by </testsuites>",
+		},
+		"empty document": {
+			doc:     "",
+			wantMsg: "document has no root element",
+		},
+		"unknown root": {
+			doc:      "<report/>",
+			wantPath: "/report",
+			wantMsg:  "root element <report> is not one of",
+		},
+		"unknown element": {
+			doc:      "<testsuites>\n<testsuite name=\"s\" tests=\"1\">\n<testcase name=\"a\">\n<fail/>\n</testcase></testsuite></testsuites>",
+			wantLine: 4,
+			wantPath: `/testsuites/testsuite[@name="s"]/testcase[@name="a"]/fail[1]`,
+			wantMsg:  "element <fail> is not allowed in <testcase>",
+		},
+		"unknown attribute": {
+			doc:      `<testsuites><testsuite name="s" tests="1" owner="me"/></testsuites>`,
+			wantPath: `/testsuites/testsuite[@name="s"]`,
+			wantMsg:  `attribute "owner" is not allowed on <testsuite>`,
+		},
+		"missing attribute": {
+			doc:      `<testsuites><testsuite tests="1"/></testsuites>`,
+			wantPath: `/testsuites/testsuite[1]`,
+			wantMsg:  `<testsuite> is missing the required attribute "name"`,
+		},
+		"invalid count": {
+			doc:      `<testsuites><testsuite name="s" tests="-1"/></testsuites>`,
+			wantPath: `/testsuites/testsuite[@name="s"]`,
+			wantMsg:  `attribute tests="-1" is not a valid xs:nonNegativeInteger`,
+		},
+		"invalid timestamp": {
+			doc:      `<testsuites><testsuite name="s" tests="0" timestamp="yesterday"/></testsuites>`,
+			wantPath: `/testsuites/testsuite[@name="s"]`,
+			wantMsg:  `attribute timestamp="yesterday" is not a valid xs:dateTime`,
+		},
+		"out of order": {
+			doc:      "<testsuites><testsuite name=\"s\" tests=\"1\"><testcase name=\"a\">\n<system-out/>\n<failure type=\"x\"/></testcase></testsuite></testsuites>",
+			wantLine: 3,
+			wantPath: `/testsuites/testsuite[@name="s"]/testcase[@name="a"]/failure[1]`,
+			wantMsg:  "element <failure> is out of order or repeated in <testcase>",
+		},
+		"repeated": {
+			doc:      `<testsuites><testsuite name="s" tests="1"><testcase name="a"><failure/><failure/></testcase></testsuite></testsuites>`,
+			wantPath: `/testsuites/testsuite[@name="s"]/testcase[@name="a"]/failure[2]`,
+			wantMsg:  "element <failure> is out of order or repeated in <testcase>",
+		},
+		"missing rerun type": {
+			doc:      `<testsuites><testsuite name="s" tests="1"><testcase name="a"><flakyFailure/></testcase></testsuite></testsuites>`,
+			wantPath: `/testsuites/testsuite[@name="s"]/testcase[@name="a"]/flakyFailure[1]`,
+			wantMsg:  `<flakyFailure> is missing the required attribute "type"`,
+		},
+		"text in element content": {
+			doc:      `<testsuites><testsuite name="s" tests="0">oops</testsuite></testsuites>`,
+			wantPath: `/testsuites/testsuite[@name="s"]`,
+			wantMsg:  "<testsuite> must not contain text",
+		},
+		"two roots": {
+			doc:      `<testsuites/><testsuites/>`,
+			wantPath: "/testsuites",
+			wantMsg:  "document has more than one root element",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := junit.Validate([]byte(tt.doc))
+			if tt.wantMsg == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var validationErr *junit.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("Validate() error = %v, want a *junit.ValidationError", err)
+			}
+			if tt.wantLine != 0 && validationErr.Line != tt.wantLine {
+				t.Errorf("Line = %d, want %d", validationErr.Line, tt.wantLine)
+			}
+			if validationErr.Path != tt.wantPath {
+				t.Errorf("Path = %q, want %q", validationErr.Path, tt.wantPath)
+			}
+			if !strings.Contains(validationErr.Message, tt.wantMsg) {
+				t.Errorf("Message = %q, want it to contain %q", validationErr.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	if schema := junit.Schema(); !strings.Contains(string(schema), `<xs:element name="testsuites">`) {
+		t.Errorf("Schema() does not declare testsuites:\n%s", schema)
+	}
+}
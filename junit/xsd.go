@@ -0,0 +1,289 @@
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// builtinTypes are the XML Schema types the embedded schema uses
+var builtinTypes = []string{"xs:string", "xs:boolean", "xs:decimal", "xs:nonNegativeInteger", "xs:positiveInteger", "xs:dateTime"}
+
+// schema is the compiled form of an XML Schema: its global elements
+type schema struct {
+	elements map[string]*elementDecl
+}
+
+// roots returns the names of the elements a document may be rooted at
+func (s *schema) roots() []string {
+	names := make([]string, 0, len(s.elements))
+	for name := range s.elements {
+		names = append(names, "<"+name+">")
+	}
+	slices.Sort(names)
+	return names
+}
+
+// elementDecl declares the attributes and content of an element
+type elementDecl struct {
+	name  string
+	attrs map[string]attributeDecl
+	// required are the names of the required attributes, sorted
+	required []string
+	// content is the model of the child elements, nil when there are none,
+	// and children the declarations of the elements it allows by name
+	content  *particle
+	children map[string]*elementDecl
+	// text allows character data: simple content or mixed content
+	text bool
+}
+
+type attributeDecl struct {
+	typ string
+}
+
+// particle is an element, sequence or choice of a content model, repeated
+// between min and max times, max being unbounded when negative
+type particle struct {
+	kind     string
+	decl     *elementDecl
+	items    []*particle
+	min, max int
+}
+
+// match consumes the elements of names matching p, from pos on, and returns
+// the position after them. It matches greedily, which the unique particle
+// attribution rule of XML Schema makes sound. When p needs an element that
+// is not there, it returns false and the name of that element.
+func (p *particle) match(names []string, pos int) (int, string, bool) {
+	for count := 0; p.max < 0 || count < p.max; count++ {
+		next, missing, ok := p.matchOnce(names, pos)
+		if !ok {
+			if count < p.min {
+				return pos, missing, false
+			}
+			break
+		}
+		if next == pos {
+			// An empty match repeats, satisfying minOccurs
+			break
+		}
+		pos = next
+	}
+	return pos, "", true
+}
+
+func (p *particle) matchOnce(names []string, pos int) (int, string, bool) {
+	switch p.kind {
+	case "element":
+		if pos < len(names) && names[pos] == p.decl.name {
+			return pos + 1, "", true
+		}
+		return pos, p.decl.name, false
+	case "sequence":
+		next := pos
+		for _, item := range p.items {
+			var missing string
+			var ok bool
+			if next, missing, ok = item.match(names, next); !ok {
+				return pos, missing, false
+			}
+		}
+		return next, "", true
+	default: // choice
+		var missing string
+		empty := false
+		for _, item := range p.items {
+			next, itemMissing, ok := item.match(names, pos)
+			switch {
+			case ok && next > pos:
+				return next, "", true
+			case ok:
+				empty = true
+			case missing == "":
+				missing = itemMissing
+			}
+		}
+		return pos, missing, empty
+	}
+}
+
+// xsdNode is an element of a schema document, read generically
+type xsdNode struct {
+	XMLName  xml.Name
+	Attrs    []xml.Attr `xml:",any,attr"`
+	Children []xsdNode  `xml:",any"`
+}
+
+func (n xsdNode) attr(name string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// schemaCompiler resolves the references between the declarations of a schema
+type schemaCompiler struct {
+	schema *schema
+	// types are the named complex types
+	types map[string]xsdNode
+}
+
+func mustCompileSchema(data []byte) *schema {
+	s, err := compileSchema(data)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded schema: %v", err))
+	}
+	return s
+}
+
+// compileSchema compiles the subset of XML Schema described in junit.xsd
+func compileSchema(data []byte) (*schema, error) {
+	var root xsdNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	c := &schemaCompiler{schema: &schema{elements: make(map[string]*elementDecl)}, types: make(map[string]xsdNode)}
+	var globals []xsdNode
+	for _, node := range root.Children {
+		switch node.XMLName.Local {
+		case "element":
+			// Declare every global element first, so that references,
+			// even recursive ones, resolve
+			c.schema.elements[node.attr("name")] = &elementDecl{name: node.attr("name")}
+			globals = append(globals, node)
+		case "complexType":
+			c.types[node.attr("name")] = node
+		default:
+			return nil, fmt.Errorf("unsupported schema component <%s>", node.XMLName.Local)
+		}
+	}
+	for _, node := range globals {
+		if err := c.define(c.schema.elements[node.attr("name")], node); err != nil {
+			return nil, err
+		}
+	}
+	return c.schema, nil
+}
+
+// define fills in decl from the type or the inline complex type of its element node
+func (c *schemaCompiler) define(decl *elementDecl, node xsdNode) error {
+	if typ := node.attr("type"); typ != "" {
+		if slices.Contains(builtinTypes, typ) {
+			decl.text = true
+			return nil
+		}
+		complexType, ok := c.types[typ]
+		if !ok {
+			return fmt.Errorf("element %s: unknown type %q", decl.name, typ)
+		}
+		return c.defineComplex(decl, complexType)
+	}
+	for _, child := range node.Children {
+		if child.XMLName.Local == "complexType" {
+			return c.defineComplex(decl, child)
+		}
+	}
+	return fmt.Errorf("element %s has no type", decl.name)
+}
+
+func (c *schemaCompiler) defineComplex(decl *elementDecl, node xsdNode) error {
+	decl.attrs = make(map[string]attributeDecl)
+	decl.children = make(map[string]*elementDecl)
+	decl.text = node.attr("mixed") == "true"
+	for _, child := range node.Children {
+		switch child.XMLName.Local {
+		case "sequence", "choice":
+			content, err := c.particle(decl, child)
+			if err != nil {
+				return err
+			}
+			decl.content = content
+		case "attribute":
+			if err := c.attribute(decl, child); err != nil {
+				return err
+			}
+		case "simpleContent":
+			decl.text = true
+			for _, extension := range child.Children {
+				for _, attr := range extension.Children {
+					if err := c.attribute(decl, attr); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			return fmt.Errorf("element %s: unsupported <%s>", decl.name, child.XMLName.Local)
+		}
+	}
+	slices.Sort(decl.required)
+	return nil
+}
+
+func (c *schemaCompiler) attribute(decl *elementDecl, node xsdNode) error {
+	name, typ := node.attr("name"), node.attr("type")
+	if !slices.Contains(builtinTypes, typ) {
+		return fmt.Errorf("attribute %s of %s: unsupported type %q", name, decl.name, typ)
+	}
+	decl.attrs[name] = attributeDecl{typ: typ}
+	if node.attr("use") == "required" {
+		decl.required = append(decl.required, name)
+	}
+	return nil
+}
+
+// particle compiles an element, sequence or choice of the content of parent
+func (c *schemaCompiler) particle(parent *elementDecl, node xsdNode) (*particle, error) {
+	p := &particle{kind: node.XMLName.Local, min: 1, max: 1}
+	if value := node.attr("minOccurs"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("element %s: minOccurs %q: %w", parent.name, value, err)
+		}
+		p.min = n
+	}
+	if value := node.attr("maxOccurs"); value == "unbounded" {
+		p.max = -1
+	} else if value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("element %s: maxOccurs %q: %w", parent.name, value, err)
+		}
+		p.max = n
+	}
+
+	switch p.kind {
+	case "element":
+		if ref := node.attr("ref"); ref != "" {
+			decl, ok := c.schema.elements[ref]
+			if !ok {
+				return nil, fmt.Errorf("element %s: unknown reference %q", parent.name, ref)
+			}
+			p.decl = decl
+		} else {
+			p.decl = &elementDecl{name: node.attr("name")}
+			if err := c.define(p.decl, node); err != nil {
+				return nil, err
+			}
+		}
+		if existing, ok := parent.children[p.decl.name]; ok && existing != p.decl {
+			return nil, fmt.Errorf("element %s declares <%s> twice", parent.name, p.decl.name)
+		}
+		parent.children[p.decl.name] = p.decl
+	case "sequence", "choice":
+		for _, item := range node.Children {
+			itemParticle, err := c.particle(parent, item)
+			if err != nil {
+				return nil, err
+			}
+			p.items = append(p.items, itemParticle)
+		}
+	default:
+		return nil, fmt.Errorf("element %s: unsupported <%s>", parent.name, p.kind)
+	}
+	return p, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Supported values for the --log-format flag
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
+var logFormatValues = []string{logFormatText, logFormatJSON}
+
+// logOutput receives the log records
+var logOutput io.Writer = os.Stderr
+
+// logFlagNames lists the flags registered by addLogFlags
+var logFlagNames = map[string]bool{
+	"verbose":    true,
+	"quiet":      true,
+	"log-format": true,
+	"color":      true,
+}
+
+// addLogFlags registers the logging flags, which every command accepts, on fs
+func addLogFlags(fs *flag.FlagSet) {
+	fs.Bool("verbose", false, "log debug details such as per-file parse timing and per-test conversion decisions")
+	fs.Bool("quiet", false, "only log errors")
+	fs.String("log-format", logFormatText, "log format on stderr: "+strings.Join(logFormatValues, " or "))
+	fs.String("color", colorAuto, "color the tables of summary and diff and the failed gates: "+strings.Join(colorValues, ", ")+"; auto colors terminals unless NO_COLOR is set")
+}
+
+// colorLogs is set when failed gates are written in color to logOutput,
+// which takes text logs on a terminal or --color always
+var colorLogs bool
+
+// setupLogging installs the default logger configured by the logging flags
+// of fs, if it has them
+func setupLogging(fs *flag.FlagSet) error {
+	if fs.Lookup("log-format") == nil {
+		return nil
+	}
+	verbose := fs.Lookup("verbose").Value.(flag.Getter).Get().(bool)
+	quiet := fs.Lookup("quiet").Value.(flag.Getter).Get().(bool)
+	format := fs.Lookup("log-format").Value.String()
+	color := fs.Lookup("color").Value.String()
+
+	if verbose && quiet {
+		return newUsageError("--verbose and --quiet cannot be combined")
+	}
+	if !slices.Contains(logFormatValues, format) {
+		return newUsageError("--log-format must be one of %s", strings.Join(logFormatValues, ", "))
+	}
+	if !slices.Contains(colorValues, color) {
+		return newUsageError("--color must be one of %s", strings.Join(colorValues, ", "))
+	}
+	colorMode = color
+	colorLogs = format == logFormatText && useColor(color, logOutput)
+
+	level := slog.LevelInfo
+	if verbose {
+		level = slog.LevelDebug
+	} else if quiet {
+		level = slog.LevelError
+	}
+	slog.SetDefault(newLogger(logOutput, format, level))
+	return nil
+}
+
+// newLogger returns a logger writing records of at least level to w
+func newLogger(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == logFormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// logParsed logs, at debug level, how long reading and parsing an input took
+func logParsed(source string, run converter.TestRun, start time.Time) {
+	slog.Debug("parsed input", "input", source, "results", len(run.Results),
+		"importedSuites", len(run.ImportedSuites), "duration", time.Since(start))
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previousOutput, previousLogger := logOutput, slog.Default()
+	logOutput = &buf
+	t.Cleanup(func() {
+		logOutput = previousOutput
+		slog.SetDefault(previousLogger)
+	})
+	return &buf
+}
+
+func TestLogging(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte("["+resultA+",\n{bad"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "report.xml")
+
+	tests := []struct {
+		name     string
+		args     []string
+		want     []string
+		dontWant []string
+	}{
+		{
+			name:     "default logs warnings",
+			args:     []string{"--lenient"},
+			want:     []string{`level=WARN msg="malformed entry reported as an errored testcase"`},
+			dontWant: []string{"level=DEBUG"},
+		},
+		{
+			name: "verbose logs parsing and conversion decisions",
+			args: []string{"--lenient", "--verbose"},
+			want: []string{
+				`level=DEBUG msg="parsed input" input=` + input + ` results=2`,
+				`level=DEBUG msg="converted task" task=a suite="MCP Checker Tests - easy" classname=tasks.a outcome=passed attempts=1`,
+				`task=parse-error-1 suite="MCP Checker Tests - unknown" classname="" outcome="error: ExecutionError"`,
+			},
+		},
+		{
+			name:     "quiet only logs errors",
+			args:     []string{"--lenient", "--quiet"},
+			dontWant: []string{"level=WARN"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLog(t)
+			if err := runCLI(context.Background(), append(tt.args, "--output", output, input)); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("logs do not contain %s:\n%s", want, logs)
+				}
+			}
+			for _, dontWant := range tt.dontWant {
+				if strings.Contains(logs.String(), dontWant) {
+					t.Errorf("logs contain %s:\n%s", dontWant, logs)
+				}
+			}
+		})
+	}
+}
+
+func TestLogFormatJSON(t *testing.T) {
+	logs := captureLog(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte("["+resultA+"]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCLI(context.Background(), []string{"--log-format", "json", "--verbose", "--output", filepath.Join(dir, "report.xml"), input}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	var record struct {
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+		Task  string `json:"task"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &record); err != nil {
+		t.Fatalf("log line is not JSON: %v\n%s", err, logs)
+	}
+	if record.Level != "DEBUG" || record.Msg != "converted task" || record.Task != "a" {
+		t.Errorf("unexpected log record %+v", record)
+	}
+}
+
+func TestLogFlagErrors(t *testing.T) {
+	captureLog(t)
+	captureHelp(t)
+	for _, args := range [][]string{
+		{"--verbose", "--quiet", "results.json"},
+		{"--log-format", "xml", "results.json"},
+	} {
+		var usageErr usageError
+		if err := runCLI(context.Background(), args); !errors.As(err, &usageErr) {
+			t.Errorf("runCLI(%q) error = %v, want a usageError", args, err)
+		}
+	}
+}
@@ -1,471 +1,202 @@
 package main
 
 import (
-	"encoding/json"
-	"encoding/xml"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
-	"strings"
-)
-
-// MCPTestResult represents a single test result from the MCP checker
-type MCPTestResult struct {
-	TaskName            string                 `json:"taskName"`
-	TaskPath            string                 `json:"taskPath"`
-	TaskPassed          bool                   `json:"taskPassed"`
-	TaskOutput          string                 `json:"taskOutput"`
-	TaskError           string                 `json:"taskError,omitempty"`
-	Difficulty          string                 `json:"difficulty"`
-	AssertionResults    map[string]Assertion   `json:"assertionResults"`
-	AllAssertionsPassed bool                   `json:"allAssertionsPassed"`
-	CallHistory         CallHistory            `json:"callHistory"`
-	SetupOutput         PhaseOutput            `json:"setupOutput"`
-	AgentOutput         PhaseOutput            `json:"agentOutput"`
-	VerifyOutput        PhaseOutput            `json:"verifyOutput"`
-	CleanupOutput       PhaseOutput            `json:"cleanupOutput"`
-}
-
-// Assertion represents an individual assertion result
-type Assertion struct {
-	Passed bool `json:"passed"`
-}
-
-// CallHistory represents the history of tool and resource calls
-type CallHistory struct {
-	ToolCalls     []ToolCall     `json:"ToolCalls"`
-	ResourceReads []ResourceRead `json:"ResourceReads"`
-}
-
-// ToolCall represents a single tool invocation
-type ToolCall struct {
-	ServerName string                 `json:"serverName"`
-	Success    bool                   `json:"success"`
-	Name       string                 `json:"name"`
-	Result     map[string]interface{} `json:"result"`
-}
-
-// ResourceRead represents a single resource read operation
-type ResourceRead struct {
-	ServerName string `json:"serverName"`
-	Success    bool   `json:"success"`
-	URI        string `json:"uri"`
-}
-
-// PhaseOutput represents output from a test phase
-type PhaseOutput struct {
-	Success bool   `json:"Success"`
-	Error   string `json:"Error"`
-}
+	"os/signal"
+	"path/filepath"
+	"syscall"
 
-// JUnit XML structures
-type JUnitTestSuites struct {
-	XMLName xml.Name `xml:"testsuites"`
-	Suites  []JUnitTestSuite
-}
-
-type JUnitTestSuite struct {
-	XMLName   xml.Name        `xml:"testsuite"`
-	Name      string          `xml:"name,attr"`
-	Tests     int             `xml:"tests,attr"`
-	Failures  int             `xml:"failures,attr"`
-	Errors    int             `xml:"errors,attr"`
-	Skipped   int             `xml:"skipped,attr"`
-	TestCases []JUnitTestCase `xml:"testcase"`
-}
-
-type JUnitTestCase struct {
-	Name      string         `xml:"name,attr"`
-	Classname string         `xml:"classname,attr"`
-	Failure   *JUnitFailure  `xml:"failure,omitempty"`
-	Error     *JUnitError    `xml:"error,omitempty"`
-	SystemOut string         `xml:"system-out,omitempty"`
-	SystemErr string         `xml:"system-err,omitempty"`
-}
-
-type JUnitFailure struct {
-	Message string `xml:"message,attr"`
-	Type    string `xml:"type,attr"`
-	Content string `xml:",chardata"`
-}
-
-type JUnitError struct {
-	Message string `xml:"message,attr"`
-	Type    string `xml:"type,attr"`
-	Content string `xml:",chardata"`
-}
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
 
 func main() {
-	var input io.Reader
-
-	// Check if a file argument is provided
-	if len(os.Args) > 1 {
-		filename := os.Args[1]
-		file, err := os.Open(filename)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error opening file %s: %v\n", filename, err)
-			os.Exit(1)
-		}
-		defer file.Close()
-		input = file
-	} else {
-		// Read from stdin
-		input = os.Stdin
-	}
-
-	// Read all input
-	data, err := io.ReadAll(input)
+	slog.SetDefault(newLogger(logOutput, logFormatText, slog.LevelInfo))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := runCLI(ctx, os.Args[1:])
+	stop()
+	exitOnError(err)
+}
+
+// runConvert implements the convert command, the default when no command is given
+func runConvert(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	conversion := addConvertFlags(fs)
+	gates := addGateFlags(fs)
+	output := fs.String("output", "", "write the report to this file, or an s3:// or gs:// URI, instead of stdout")
+	format := addCheckedString(fs, "format", formatJUnit, "report format: junit, or exec:/path/to/formatter to pipe the results as JSON to an executable and write its output", checkFormat)
+	watch := fs.Bool("watch", false, "regenerate the --output report whenever the input file or directory changes")
+	buffered := fs.Bool("buffered", false, "parse every result before converting them, instead of streaming a single JSON input result by result")
+	bundle := fs.String("bundle", "", "write the JUnit XML of every suite, an HTML report, summary.json and the attachments with their index to this directory, writing the report to stdout only with --output -")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	parseOpts, err := inputs.options()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading input: %v\n", err)
-		os.Exit(1)
+		return err
 	}
-
-	// Parse JSON
-	var testResults []MCPTestResult
-	if err := json.Unmarshal(data, &testResults); err != nil {
-		fmt.Fprintf(os.Stderr, "Error parsing JSON: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Convert to JUnit XML
-	junitXML := convertToJUnit(testResults)
-
-	// Output XML
-	output, err := xml.MarshalIndent(junitXML, "", "  ")
+	formatter, err := formatterPath(*format)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating XML: %v\n", err)
-		os.Exit(1)
+		return err
 	}
-
-	fmt.Println(xml.Header + string(output))
-}
-
-func convertToJUnit(results []MCPTestResult) JUnitTestSuites {
-	suites := JUnitTestSuites{}
-
-	// Group tests by difficulty
-	testsByDifficulty := make(map[string][]MCPTestResult)
-	for _, result := range results {
-		difficulty := result.Difficulty
-		if difficulty == "" {
-			difficulty = "unknown"
-		}
-		testsByDifficulty[difficulty] = append(testsByDifficulty[difficulty], result)
+	if formatter != "" && (*watch || *bundle != "") {
+		return newUsageError("--format %s cannot be combined with --watch or --bundle", *format)
 	}
-
-	// Create a test suite for each difficulty level
-	for difficulty, tests := range testsByDifficulty {
-		suite := JUnitTestSuite{
-			Name:      fmt.Sprintf("MCP Checker Tests - %s", difficulty),
-			Tests:     len(tests),
-			Failures:  0,
-			Errors:    0,
-			Skipped:   0,
-			TestCases: make([]JUnitTestCase, 0, len(tests)),
+	if *bundle != "" {
+		if *watch {
+			return newUsageError("--watch cannot be combined with --bundle")
 		}
-
-		for _, test := range tests {
-			testCase := convertTestCase(test)
-			suite.TestCases = append(suite.TestCases, testCase)
-
-			// Count failures and errors
-			if testCase.Failure != nil {
-				suite.Failures++
-			}
-			if testCase.Error != nil {
-				suite.Errors++
-			}
+		if err := prepareBundle(*bundle, conversion); err != nil {
+			return err
 		}
-
-		suites.Suites = append(suites.Suites, suite)
 	}
-
-	return suites
-}
-
-func convertTestCase(test MCPTestResult) JUnitTestCase {
-	testCase := JUnitTestCase{
-		Name:      test.TaskName,
-		Classname: extractClassname(test.TaskPath, test.Difficulty),
-		SystemOut: formatHumanReadableOutput(test),
+	conv, err := conversion.newConverter()
+	if err != nil {
+		return err
+	}
+	gateOpts, err := gates.options(ctx, parseOpts, conv)
+	if err != nil {
+		return err
 	}
 
-	// Determine if test failed and why
-	if !test.TaskPassed {
-		// Test execution failed
-		testCase.Error = &JUnitError{
-			Message: "Test execution failed",
-			Type:    "ExecutionError",
-			Content: test.TaskError,
-		}
-		if test.TaskError != "" {
-			testCase.SystemErr = test.TaskError
-		}
-	} else if !test.AllAssertionsPassed {
-		// Assertions failed
-		failedAssertions := getFailedAssertions(test.AssertionResults)
-		testCase.Failure = &JUnitFailure{
-			Message: fmt.Sprintf("Assertion failures: %s", strings.Join(failedAssertions, ", ")),
-			Type:    "AssertionFailure",
-			Content: buildFailureContent(test, failedAssertions),
+	if *watch {
+		input := fs.Arg(0)
+		if fs.NArg() != 1 || isURL(input) || isCloudURI(input) || *output == "" {
+			return newUsageError("--watch requires a single local input file or directory and --output")
 		}
+		return watchAndConvert(ctx, input, *output, parseOpts, conv)
 	}
 
-	// Check phase failures
-	phaseErrors := collectPhaseErrors(test)
-	if phaseErrors != "" {
-		if testCase.Error != nil {
-			testCase.Error.Content += "\n\nPhase Errors:\n" + phaseErrors
-		} else if testCase.Failure != nil {
-			testCase.Failure.Content += "\n\nPhase Errors:\n" + phaseErrors
-		} else {
-			// Phase failed but test reported as passed - treat as error
-			testCase.Error = &JUnitError{
-				Message: "Phase execution failed",
-				Type:    "PhaseError",
-				Content: phaseErrors,
-			}
-		}
-		if testCase.SystemErr == "" {
-			testCase.SystemErr = phaseErrors
-		} else {
-			testCase.SystemErr += "\n\n" + phaseErrors
+	if *bundle != "" {
+		junitXML, err := convertBundle(ctx, fs.Args(), *bundle, *output, parseOpts, conv)
+		if err != nil {
+			return err
 		}
+		return gateOpts.check(junitXML)
 	}
-
-	return testCase
-}
-
-func extractClassname(taskPath string, difficulty string) string {
-	if taskPath == "" {
-		return difficulty
+	var junitXML converter.JUnitTestSuites
+	if formatter != "" {
+		junitXML, err = convertWithFormatter(ctx, fs.Args(), *output, formatter, parseOpts, conv)
+	} else if !*buffered && streamable(fs.Args(), parseOpts, conv) {
+		junitXML, err = convertStream(ctx, fs.Args(), *output, parseOpts, conv)
+	} else {
+		junitXML, err = convert(ctx, fs.Args(), *output, parseOpts, conv)
 	}
-	// Extract meaningful parts from path
-	// e.g., "/home/.../tasks/create-function/create-function.yaml" -> "tasks.create-function"
-	parts := strings.Split(taskPath, "/")
-	for i, part := range parts {
-		if part == "tasks" && i+1 < len(parts) {
-			return fmt.Sprintf("tasks.%s", parts[i+1])
-		}
+	if err != nil {
+		return err
 	}
-	return difficulty
+	return gateOpts.check(junitXML)
 }
 
-func getFailedAssertions(assertions map[string]Assertion) []string {
-	var failed []string
-	for name, assertion := range assertions {
-		if !assertion.Passed {
-			failed = append(failed, name)
-		}
+// convert reads the inputs, converts it to JUnit XML and writes the report
+func convert(ctx context.Context, inputs []string, output string, opts inputOptions, conv *converter.Converter) (converter.JUnitTestSuites, error) {
+	testRun, err := loadInputs(ctx, inputs, opts)
+	if err != nil {
+		return converter.JUnitTestSuites{}, err
+	}
+	if err := checkResults(testRun, opts); err != nil {
+		return converter.JUnitTestSuites{}, err
 	}
-	return failed
+	return writeReport(ctx, testRun, output, conv)
 }
 
-func buildFailureContent(test MCPTestResult, failedAssertions []string) string {
-	var content strings.Builder
-
-	content.WriteString("Failed Assertions:\n")
-	for _, assertion := range failedAssertions {
-		content.WriteString(fmt.Sprintf("  - %s\n", assertion))
+// convertBundle reads the inputs and converts them into the artifacts of
+// the bundle directory dir, also writing the report to output when set
+func convertBundle(ctx context.Context, inputs []string, dir, output string, opts inputOptions, conv *converter.Converter) (converter.JUnitTestSuites, error) {
+	testRun, err := loadInputs(ctx, inputs, opts)
+	if err != nil {
+		return converter.JUnitTestSuites{}, err
 	}
-
-	if test.TaskError != "" {
-		content.WriteString("\nError Details:\n")
-		content.WriteString(test.TaskError)
+	if err := checkResults(testRun, opts); err != nil {
+		return converter.JUnitTestSuites{}, err
 	}
-
-	return content.String()
-}
-
-func collectPhaseErrors(test MCPTestResult) string {
-	var errors strings.Builder
-
-	if !test.SetupOutput.Success && test.SetupOutput.Error != "" {
-		errors.WriteString("Setup Phase Error:\n")
-		errors.WriteString(test.SetupOutput.Error)
-		errors.WriteString("\n\n")
+	var junitXML converter.JUnitTestSuites
+	if output != "" {
+		junitXML, err = writeReport(ctx, testRun, output, conv)
+	} else {
+		junitXML, err = convertRun(ctx, testRun, conv)
 	}
-
-	if !test.AgentOutput.Success && test.AgentOutput.Error != "" {
-		errors.WriteString("Agent Phase Error:\n")
-		errors.WriteString(test.AgentOutput.Error)
-		errors.WriteString("\n\n")
+	if err != nil {
+		return junitXML, err
 	}
-
-	if !test.VerifyOutput.Success && test.VerifyOutput.Error != "" {
-		errors.WriteString("Verify Phase Error:\n")
-		errors.WriteString(test.VerifyOutput.Error)
-		errors.WriteString("\n\n")
+	if err := writeBundle(ctx, dir, testRun, junitXML, conv); err != nil {
+		return junitXML, err
 	}
+	slog.Info("wrote bundle", "dir", dir)
+	return junitXML, nil
+}
 
-	if !test.CleanupOutput.Success && test.CleanupOutput.Error != "" {
-		errors.WriteString("Cleanup Phase Error:\n")
-		errors.WriteString(test.CleanupOutput.Error)
-		errors.WriteString("\n\n")
+// convertRun converts a parsed run to JUnit XML, warning about the entries
+// that could not be parsed
+func convertRun(ctx context.Context, testRun converter.TestRun, conv *converter.Converter) (converter.JUnitTestSuites, error) {
+	for _, parseErr := range testRun.ParseErrors {
+		slog.Warn("malformed entry reported as an errored testcase", "error", parseErr)
 	}
-
-	return strings.TrimSpace(errors.String())
+	return conv.ConvertContext(ctx, testRun)
 }
 
-func formatHumanReadableOutput(test MCPTestResult) string {
-	var output strings.Builder
-
-	// Header with test status
-	output.WriteString(fmt.Sprintf("Task: %s\n", test.TaskName))
-	output.WriteString(fmt.Sprintf("Path: %s\n", test.TaskPath))
-	output.WriteString(fmt.Sprintf("Difficulty: %s\n", test.Difficulty))
-
-	status := "PASSED"
-	if !test.TaskPassed {
-		status = "FAILED"
+// writeReport converts a parsed run to JUnit XML and writes it to output,
+// returning the converted document
+func writeReport(ctx context.Context, testRun converter.TestRun, output string, conv *converter.Converter) (converter.JUnitTestSuites, error) {
+	junitXML, err := convertRun(ctx, testRun, conv)
+	if err != nil {
+		return junitXML, err
 	}
-	output.WriteString(fmt.Sprintf("Status: %s\n", status))
-
-	// Assertions summary
-	passedCount := countPassedAssertions(test.AssertionResults)
-	totalCount := len(test.AssertionResults)
-	output.WriteString(fmt.Sprintf("Assertions: %d/%d passed\n", passedCount, totalCount))
-
-	// Call history summary
-	if test.CallHistory.ToolCalls != nil || test.CallHistory.ResourceReads != nil {
-		toolCount := len(test.CallHistory.ToolCalls)
-		resourceCount := len(test.CallHistory.ResourceReads)
-
-		toolsByServer := groupToolCallsByServer(test.CallHistory.ToolCalls)
-		var serverSummaries []string
-		for server, count := range toolsByServer {
-			serverSummaries = append(serverSummaries, fmt.Sprintf("%s:%d ok", server, count))
-		}
-
-		if toolCount > 0 || resourceCount > 0 {
-			output.WriteString(fmt.Sprintf("Call history: tools=%d", toolCount))
-			if len(serverSummaries) > 0 {
-				output.WriteString(fmt.Sprintf(" (%s)", strings.Join(serverSummaries, ", ")))
-			}
-			if resourceCount > 0 {
-				output.WriteString(fmt.Sprintf(" resources=%d", resourceCount))
-			}
-			output.WriteString("\n")
-		}
-
-		// Tool outputs
-		if len(test.CallHistory.ToolCalls) > 0 {
-			output.WriteString("  Tool output:\n")
-			for _, toolCall := range test.CallHistory.ToolCalls {
-				statusMarker := "ok"
-				if !toolCall.Success {
-					statusMarker = "failed"
-				}
-				output.WriteString(fmt.Sprintf("    • %s::%s (%s)\n", toolCall.ServerName, toolCall.Name, statusMarker))
-
-				// Extract structured content if available
-				if toolCall.Result != nil {
-					if structuredContent, ok := toolCall.Result["structuredContent"].(map[string]interface{}); ok {
-						if message, ok := structuredContent["message"].(string); ok && message != "" {
-							// Truncate long messages
-							if len(message) > 200 {
-								lines := strings.Split(message, "\n")
-								if len(lines) > 3 {
-									output.WriteString(fmt.Sprintf("      %s\n", strings.TrimSpace(lines[0])))
-									output.WriteString(fmt.Sprintf("      … (+%d lines)\n", len(lines)-1))
-								} else {
-									output.WriteString(fmt.Sprintf("      %s... (truncated)\n", message[:200]))
-								}
-							} else {
-								// Show full message for short outputs
-								formattedMsg := strings.ReplaceAll(strings.TrimSpace(message), "\n", "\n      ")
-								output.WriteString(fmt.Sprintf("      %s\n", formattedMsg))
-							}
-						}
-					}
-				}
-			}
-		}
+	report, err := conv.Render(junitXML)
+	if err != nil {
+		return junitXML, err
 	}
 
-	// Timeline (from taskOutput - split into bullet points)
-	if test.TaskOutput != "" {
-		output.WriteString("Timeline:\n")
+	return junitXML, writeOutput(ctx, output, report)
+}
 
-		// Split output into paragraphs/sentences
-		lines := strings.Split(test.TaskOutput, "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" {
-				continue
-			}
+// writeOutput writes the report to stdout, uploads it to S3/GCS, or atomically
+// replaces the output file so that readers never observe a partially written report
+func writeOutput(ctx context.Context, path string, data []byte) error {
+	return writeOutputWith(ctx, path, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
 
-			// Wrap long lines
-			if len(line) > 100 {
-				wrapped := wrapText(line, 100)
-				for i, wrappedLine := range wrapped {
-					if i == 0 {
-						output.WriteString(fmt.Sprintf("  - note: %s\n", wrappedLine))
-					} else {
-						output.WriteString(fmt.Sprintf("    %s\n", wrappedLine))
-					}
-				}
-			} else {
-				output.WriteString(fmt.Sprintf("  - note: %s\n", line))
-			}
-		}
+// writeOutputWith is writeOutput for reports written by write as they are
+// produced. Uploads are buffered in memory, since they need the whole object.
+func writeOutputWith(ctx context.Context, path string, write func(w io.Writer) error) error {
+	if path == "" || path == "-" {
+		return write(os.Stdout)
 	}
-
-	// Error details if test failed
-	if test.TaskError != "" {
-		output.WriteString("\nError:\n")
-		errorLines := strings.Split(test.TaskError, "\n")
-		for _, line := range errorLines {
-			if line != "" {
-				output.WriteString(fmt.Sprintf("  %s\n", line))
-			}
+	if isCloudURI(path) {
+		var data bytes.Buffer
+		if err := write(&data); err != nil {
+			return err
 		}
+		return writeCloudOutput(ctx, path, data.Bytes())
 	}
 
-	return output.String()
-}
-
-func countPassedAssertions(assertions map[string]Assertion) int {
-	count := 0
-	for _, assertion := range assertions {
-		if assertion.Passed {
-			count++
-		}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
 	}
-	return count
-}
+	defer os.Remove(tmp.Name())
 
-func groupToolCallsByServer(toolCalls []ToolCall) map[string]int {
-	groups := make(map[string]int)
-	for _, call := range toolCalls {
-		if call.Success {
-			groups[call.ServerName]++
-		}
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
 	}
-	return groups
-}
-
-func wrapText(text string, maxWidth int) []string {
-	var lines []string
-	words := strings.Fields(text)
-
-	if len(words) == 0 {
-		return lines
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
 	}
-
-	currentLine := words[0]
-	for _, word := range words[1:] {
-		if len(currentLine)+1+len(word) <= maxWidth {
-			currentLine += " " + word
-		} else {
-			lines = append(lines, currentLine)
-			currentLine = word
-		}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
 	}
-
-	if currentLine != "" {
-		lines = append(lines, currentLine)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
 	}
-
-	return lines
+	return nil
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+	"github.com/jrangelramos/mcpchecker-junit-report/junit"
+)
+
+func TestWriteReport(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "report.xml")
+	if err := os.WriteFile(output, []byte("stale"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := writeReport(context.Background(), mustParse(t, `[`+resultA+`,`+resultB+`]`), output, mustNew(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tests, _, errors := report.Totals(); tests != 2 || errors != 1 {
+		t.Errorf("report totals = %d tests, %d errors, want 2, 1", tests, errors)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") || !strings.Contains(string(data), `<testcase name="b"`) {
+		t.Errorf("report file was not replaced:\n%s", data)
+	}
+	info, err := os.Stat(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("report mode = %v, want 0644", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(filepath.Dir(output))
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestWriteReportCheck(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "report.xml")
+	run := mustParse(t, `[`+resultA+`]`)
+	run.ImportedSuites = []converter.ImportedSuite{{
+		XMLName: xml.Name{Local: "testsuite"},
+		Attrs:   []xml.Attr{{Name: xml.Name{Local: "name"}, Value: "legacy"}, {Name: xml.Name{Local: "tests"}, Value: "1"}},
+		Inner:   `<testcase name="x"><bogus/></testcase>`,
+	}}
+
+	_, err := writeReport(context.Background(), run, output, mustNew(t, converter.WithCheck()))
+	var validationErr *junit.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("writeReport() error = %v, want a *junit.ValidationError", err)
+	}
+	if want := `/testsuites/testsuite[@name="legacy"]/testcase[@name="x"]/bogus[1]`; validationErr.Path != want {
+		t.Errorf("error path = %q, want %q", validationErr.Path, want)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("invalid report was written: %v", err)
+	}
+
+	if _, err := writeReport(context.Background(), mustParse(t, `[`+resultA+`]`), output, mustNew(t, converter.WithCheck())); err != nil {
+		t.Errorf("writeReport() of a valid report error = %v", err)
+	}
+}
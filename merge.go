@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// runMerge implements the merge command, which combines several runs into
+// one report, treating results from later runs as reruns of earlier ones
+func runMerge(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	conversion := addConvertFlags(fs)
+	gates := addGateFlags(fs)
+	output := fs.String("output", "", "write the report to this file, or an s3:// or gs:// URI, instead of stdout")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	parseOpts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	conv, err := conversion.newConverter()
+	if err != nil {
+		return err
+	}
+	gateOpts, err := gates.options(ctx, parseOpts, conv)
+	if err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return newUsageError("merge requires at least one input")
+	}
+
+	runs := make([]converter.TestRun, 0, fs.NArg())
+	for _, input := range fs.Args() {
+		run, err := loadInput(ctx, input, parseOpts)
+		if err != nil {
+			return err
+		}
+		runs = append(runs, run)
+	}
+
+	merged := converter.MergeReruns(runs)
+	if err := checkResults(merged, parseOpts); err != nil {
+		return err
+	}
+	junitXML, err := writeReport(ctx, merged, *output, conv)
+	if err != nil {
+		return err
+	}
+	return gateOpts.check(junitXML)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func mustNew(t *testing.T, opts ...converter.Option) *converter.Converter {
+	t.Helper()
+	conv, err := converter.New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conv
+}
+
+func mustConvert(t *testing.T, run converter.TestRun, opts ...converter.Option) converter.JUnitTestSuites {
+	t.Helper()
+	report, err := mustNew(t, opts...).Convert(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func mustParse(t *testing.T, input string) converter.TestRun {
+	t.Helper()
+	run, err := converter.Parse(strings.NewReader(input), converter.ParseOptions{Format: converter.FormatAuto})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
@@ -0,0 +1,16 @@
+package main
+
+import "context"
+
+// notifyTargets lists the targets of the notify command
+var notifyTargets = []commandTarget{
+	{name: "email", run: notifyEmail},
+	{name: "pagerduty", run: notifyPagerDuty},
+	{name: "opsgenie", run: notifyOpsgenie},
+}
+
+// runNotify implements the notify command, whose first argument is the
+// target to notify
+func runNotify(ctx context.Context, cmd *command, args []string) error {
+	return runTarget(ctx, cmd, notifyTargets, args)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Defaults of notify opsgenie
+const (
+	defaultOpsgenieAPIURL    = "https://api.opsgenie.com"
+	defaultOpsgenieAPIKeyEnv = "OPSGENIE_API_KEY"
+)
+
+// Limits of the Opsgenie Alert API
+const (
+	maxOpsgenieMessage     = 130
+	maxOpsgenieAlias       = 512
+	maxOpsgenieDescription = 15000
+)
+
+// opsgeniePriorities lists the values of --priority of notify opsgenie
+var opsgeniePriorities = []string{"P1", "P2", "P3", "P4", "P5"}
+
+// opsgenieAlert is an alert of the Opsgenie Alert API
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Source      string            `json:"source,omitempty"`
+	Priority    string            `json:"priority,omitempty"`
+}
+
+// notifyOpsgenie implements notify opsgenie: it creates an Opsgenie alert
+// when a gate fails, and closes it when they pass. The alias of the alert is
+// the dedup key of the pipeline, so that failures that repeat from one night
+// to the next add up on one open alert.
+func notifyOpsgenie(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	alerts := addAlertFlags(fs)
+	apiURL := fs.String("api-url", defaultOpsgenieAPIURL, "Opsgenie API URL, e.g. https://api.eu.opsgenie.com for the EU instance")
+	apiKeyEnv := fs.String("api-key-env", defaultOpsgenieAPIKeyEnv, "environment variable holding the key of an Opsgenie API integration")
+	priority := fs.String("priority", "P3", "priority of the alert: "+strings.Join(opsgeniePriorities, ", "))
+	tags := fs.String("tags", "", "comma-separated tags of the alert")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if !slices.Contains(opsgeniePriorities, *priority) {
+		return newUsageError("--priority must be one of %s", strings.Join(opsgeniePriorities, ", "))
+	}
+	if len(*alerts.dedupKey) > maxOpsgenieAlias {
+		return newUsageError("--dedup-key must not be longer than %d characters", maxOpsgenieAlias)
+	}
+	apiKey, err := secretFromEnv("api-key-env", *apiKeyEnv)
+	if err != nil {
+		return err
+	}
+	alert, err := alerts.evaluate(ctx, "opsgenie", fs.Args())
+	if err != nil {
+		return err
+	}
+
+	header := http.Header{}
+	header.Set("Authorization", "GenieKey "+apiKey)
+	endpoint := strings.TrimSuffix(*apiURL, "/") + "/v2/alerts"
+	switch {
+	case alert.gateErr != nil:
+		body := opsgenieAlertOf(alert, *priority, splitList(*tags))
+		if err := callAPI(ctx, http.MethodPost, endpoint, header, body, nil); err != nil {
+			return fmt.Errorf("creating the Opsgenie alert: %w", err)
+		}
+		slog.Info("created Opsgenie alert", "alias", alert.dedupKey)
+	case *alerts.resolve:
+		endpoint += "/" + url.PathEscape(alert.dedupKey) + "/close?identifierType=alias"
+		body := map[string]string{"source": defaultPublishName, "note": "Gates passed: " + passedTitle(alert.total)}
+		if err := callAPI(ctx, http.MethodPost, endpoint, header, body, nil); err != nil {
+			return fmt.Errorf("closing the Opsgenie alert: %w", err)
+		}
+		slog.Info("closed Opsgenie alert", "alias", alert.dedupKey)
+	default:
+		slog.Info("gates passed")
+	}
+	return alert.gateErr
+}
+
+// opsgenieAlertOf returns the Opsgenie alert of the failed gates of alert
+func opsgenieAlertOf(alert gateAlert, priority string, tags []string) opsgenieAlert {
+	details := map[string]string{
+		"passed": strconv.Itoa(alert.total.passed),
+		"tasks":  strconv.Itoa(alert.total.tests - alert.total.skipped),
+	}
+	if alert.runURL != "" {
+		details["run"] = alert.runURL
+	}
+	return opsgenieAlert{
+		Message:     truncateText(alert.summary(), maxOpsgenieMessage),
+		Alias:       alert.dedupKey,
+		Description: truncateText(alert.description(), maxOpsgenieDescription),
+		Details:     details,
+		Tags:        tags,
+		Source:      defaultPublishName,
+		Priority:    priority,
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNotifyOpsgenie(t *testing.T) {
+	type request struct {
+		path  string
+		alert opsgenieAlert
+	}
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "GenieKey og-key" {
+			http.Error(w, `{"message":"Could not authenticate"}`, http.StatusUnauthorized)
+			return
+		}
+		req := request{path: r.URL.RequestURI()}
+		if err := json.NewDecoder(r.Body).Decode(&req.alert); err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, req)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Setenv("OPSGENIE_API_KEY", "og-key")
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	baseline := write("baseline.json", `[{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true}]`)
+	failing := write("failing.json", `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"hard","taskPassed":false,"taskError":"boom"}
+	]`)
+	notify := func(input string) error {
+		return runCLI(context.Background(), []string{"notify", "opsgenie", "--api-url", server.URL + "/", "--baseline", baseline,
+			"--dedup-key", "mcpchecker/o/r nightly", "--run-url", "", "--tags", "mcp, evals", input})
+	}
+
+	var gateErr gateError
+	if err := notify(failing); !errors.As(err, &gateErr) || gateErr.code != exitCodeGateFailed {
+		t.Fatalf("notify error = %v, want the regression", err)
+	}
+	if len(requests) != 1 || requests[0].path != "/v2/alerts" {
+		t.Fatalf("requests = %+v, want an alert", requests)
+	}
+	alert := requests[0].alert
+	if alert.Alias != "mcpchecker/o/r nightly" || alert.Priority != "P3" || !reflect.DeepEqual(alert.Tags, []string{"mcp", "evals"}) ||
+		alert.Message != "MCP checker gate failed: 1 of 2 tasks passed" {
+		t.Errorf("alert = %+v", alert)
+	}
+	if !strings.Contains(alert.Description, "Baseline regressions") || !strings.Contains(alert.Description, "Failing tasks:\n  b (error)") {
+		t.Errorf("description = %q, want the regression and failing task", alert.Description)
+	}
+
+	if err := notify(baseline); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/v2/alerts/mcpchecker%2Fo%2Fr%20nightly/close?identifierType=alias"; len(requests) != 2 || requests[1].path != want {
+		t.Errorf("requests = %+v, want a close of %s", requests, want)
+	}
+
+	t.Setenv("OPSGENIE_API_KEY", "wrong")
+	if err := notify(failing); err == nil || !strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("notify with a wrong key error = %v, want the 401 response", err)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// Defaults of publish otel
+const (
+	defaultOTelServiceName = "mcpchecker"
+	otelScopeName          = "github.com/jrangelramos/mcpchecker-junit-report"
+	otelTracesPath         = "/v1/traces"
+)
+
+// Span kinds and status codes of OTLP
+const (
+	otlpKindInternal = 1
+	otlpKindClient   = 3
+	otlpStatusOK     = 1
+	otlpStatusError  = 2
+)
+
+// otelTraceIDPattern matches the trace IDs of results that spans can join
+var otelTraceIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{32}$`)
+
+// otlpSpan is a span in the OTLP JSON encoding; times are Unix nanoseconds
+type otlpSpan struct {
+	TraceID      string          `json:"traceId"`
+	SpanID       string          `json:"spanId"`
+	ParentSpanID string          `json:"parentSpanId,omitempty"`
+	Name         string          `json:"name"`
+	Kind         int             `json:"kind"`
+	Start        uint64          `json:"startTimeUnixNano,string"`
+	End          uint64          `json:"endTimeUnixNano,string"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+	Events       []otlpEvent     `json:"events,omitempty"`
+	Links        []otlpLink      `json:"links,omitempty"`
+	Status       otlpStatus      `json:"status"`
+}
+
+// otlpAttribute is a key with a value of the AnyValue oneof, e.g.
+// {"stringValue": "easy"}
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpEvent struct {
+	Time       uint64          `json:"timeUnixNano,string"`
+	Name       string          `json:"name"`
+	Attributes []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func stringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]interface{}{"stringValue": value}}
+}
+
+func boolAttribute(key string, value bool) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]interface{}{"boolValue": value}}
+}
+
+// intAttribute encodes value as a string, as OTLP JSON does for 64-bit
+// integers
+func intAttribute(key string, value int) otlpAttribute {
+	return otlpAttribute{Key: key, Value: map[string]interface{}{"intValue": strconv.Itoa(value)}}
+}
+
+// publishOTel implements publish otel: it sends the tests of the results as
+// spans over OTLP/HTTP, with child spans per phase and tool call and an
+// event per assertion
+func publishOTel(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	endpoint := fs.String("endpoint", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "OTLP/HTTP endpoint of the collector, e.g. http://localhost:4318 (default $OTEL_EXPORTER_OTLP_ENDPOINT)")
+	serviceName := fs.String("service-name", cmp.Or(os.Getenv("OTEL_SERVICE_NAME"), defaultOTelServiceName), "service.name of the spans (default $OTEL_SERVICE_NAME or mcpchecker)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	if *endpoint == "" {
+		return newUsageError("publish otel requires --endpoint or the OTEL_EXPORTER_OTLP_ENDPOINT environment variable")
+	}
+	header, err := parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		return err
+	}
+	conv, err := newPublishConverter()
+	if err != nil {
+		return err
+	}
+	run, tests, err := loadPublishedTests(ctx, fs.Args(), opts, conv)
+	if err != nil {
+		return err
+	}
+
+	spans := otelSpans(run, tests, runTime(run, time.Now), randomID)
+	body := map[string]interface{}{"resourceSpans": []interface{}{map[string]interface{}{
+		"resource":   map[string]interface{}{"attributes": []otlpAttribute{stringAttribute("service.name", *serviceName)}},
+		"scopeSpans": []interface{}{map[string]interface{}{"scope": map[string]string{"name": otelScopeName, "version": currentBuildInfo().Version}, "spans": spans}},
+	}}}
+	tracesURL := strings.TrimSuffix(*endpoint, "/")
+	if !strings.HasSuffix(tracesURL, otelTracesPath) {
+		tracesURL += otelTracesPath
+	}
+	var response struct {
+		PartialSuccess struct {
+			RejectedSpans string `json:"rejectedSpans"`
+			ErrorMessage  string `json:"errorMessage"`
+		} `json:"partialSuccess"`
+	}
+	if err := callAPI(ctx, http.MethodPost, tracesURL, header, body, &response); err != nil {
+		return fmt.Errorf("exporting spans: %w", err)
+	}
+	if rejected := response.PartialSuccess; rejected.RejectedSpans != "" && rejected.RejectedSpans != "0" {
+		slog.Warn("the collector rejected spans", "rejected", rejected.RejectedSpans, "error", rejected.ErrorMessage)
+	}
+	slog.Info("exported spans", "endpoint", tracesURL, "spans", len(spans), "trace", spans[0].TraceID)
+	return nil
+}
+
+// parseOTLPHeaders parses the comma-separated key=value pairs of
+// OTEL_EXPORTER_OTLP_HEADERS, whose values are URL-encoded
+func parseOTLPHeaders(value string) (http.Header, error) {
+	header := http.Header{}
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		key, encoded, ok := strings.Cut(pair, "=")
+		decoded, err := url.QueryUnescape(strings.TrimSpace(encoded))
+		if !ok || strings.TrimSpace(key) == "" || err != nil {
+			return nil, newUsageError("invalid OTEL_EXPORTER_OTLP_HEADERS: %q is not a key=value pair", pair)
+		}
+		header.Set(strings.TrimSpace(key), decoded)
+	}
+	return header, nil
+}
+
+// randomID returns n random bytes in hex, the encoding of trace and span
+// IDs in OTLP JSON
+func randomID(n int) string {
+	id := make([]byte, n)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// otelSpans returns the spans of a run starting at start: a root span of the
+// run with a child per test, and under each test a span per phase that
+// reported a duration or error, with the tool calls under the agent phase.
+// Results only report durations, so tests, phases and tool calls are laid
+// out one after the other. Tests with a trace ID of their own, e.g. that of
+// the agent, join that trace with a link to the run instead. newID returns
+// IDs of n bytes.
+func otelSpans(run converter.TestRun, tests []publishedTest, start time.Time, newID func(n int) string) []otlpSpan {
+	root := otlpSpan{TraceID: newID(16), SpanID: newID(8), Name: "mcpchecker run", Kind: otlpKindInternal,
+		Start: unixNano(start), Attributes: []otlpAttribute{intAttribute("mcpchecker.tasks", len(tests))}}
+	if run.RunID != "" {
+		root.Attributes = append(root.Attributes, stringAttribute("mcpchecker.run_id", run.RunID))
+	}
+	spans := []otlpSpan{root}
+	at := start
+	for _, test := range tests {
+		testSpan := otlpSpan{TraceID: root.TraceID, SpanID: newID(8), ParentSpanID: root.SpanID,
+			Name: test.Result.TaskName, Kind: otlpKindInternal, Start: unixNano(at), Attributes: []otlpAttribute{
+				stringAttribute("mcpchecker.task.name", test.Result.TaskName),
+				stringAttribute("mcpchecker.task.outcome", test.Outcome),
+			}}
+		if otelTraceIDPattern.MatchString(test.Result.TraceID) && strings.Trim(test.Result.TraceID, "0") != "" {
+			testSpan.TraceID, testSpan.ParentSpanID = strings.ToLower(test.Result.TraceID), ""
+			testSpan.Links = []otlpLink{{TraceID: root.TraceID, SpanID: root.SpanID}}
+		}
+		for _, attribute := range [][2]string{{"mcpchecker.task.difficulty", test.Result.Difficulty},
+			{"mcpchecker.task.path", test.TestCase.File}, {"mcpchecker.prompt_id", test.Result.PromptID}} {
+			if attribute[1] != "" {
+				testSpan.Attributes = append(testSpan.Attributes, stringAttribute(attribute[0], attribute[1]))
+			}
+		}
+		switch {
+		case test.TestCase.Error != nil:
+			testSpan.Status = otlpStatus{Code: otlpStatusError, Message: test.TestCase.Error.Message}
+		case test.TestCase.Failure != nil:
+			testSpan.Status = otlpStatus{Code: otlpStatusError, Message: test.TestCase.Failure.Message}
+		case test.Outcome == outcomePassed:
+			testSpan.Status = otlpStatus{Code: otlpStatusOK}
+		}
+
+		var children []otlpSpan
+		var assertionsAt time.Time
+		for _, phase := range converter.Phases {
+			output := test.Result.Phase(phase)
+			calls := test.Result.CallHistory.ToolCalls
+			if phase != converter.PhaseAgent {
+				calls = nil
+			}
+			if output.DurationMs == 0 && output.Error == "" && len(calls) == 0 {
+				continue
+			}
+			phaseSpan := otlpSpan{TraceID: testSpan.TraceID, SpanID: newID(8), ParentSpanID: testSpan.SpanID, Name: phase,
+				Kind: otlpKindInternal, Start: unixNano(at), Attributes: []otlpAttribute{stringAttribute("mcpchecker.phase", phase)}}
+			if output.Error != "" {
+				phaseSpan.Status = otlpStatus{Code: otlpStatusError, Message: redactBuiltin(output.Error)}
+			}
+			children = append(children, phaseSpan)
+			phaseIndex := len(children) - 1
+			end := at.Add(milliseconds(output.DurationMs))
+			callAt := at
+			for _, call := range calls {
+				callSpan := otlpSpan{TraceID: testSpan.TraceID, SpanID: newID(8), ParentSpanID: phaseSpan.SpanID,
+					Name: "tools/call " + call.Name, Kind: otlpKindClient, Start: unixNano(callAt), Attributes: []otlpAttribute{
+						stringAttribute("mcp.method.name", "tools/call"),
+						stringAttribute("gen_ai.tool.name", call.Name),
+						stringAttribute("mcpchecker.server", call.ServerName),
+					}}
+				callAt = callAt.Add(milliseconds(call.DurationMs))
+				callSpan.End = unixNano(callAt)
+				if !call.Success {
+					callSpan.Status = otlpStatus{Code: otlpStatusError}
+				}
+				children = append(children, callSpan)
+			}
+			// Tool calls may add up to more than the reported duration
+			at = end
+			if callAt.After(end) {
+				at = callAt
+			}
+			children[phaseIndex].End = unixNano(at)
+			if phase == converter.PhaseVerify {
+				assertionsAt = at
+			}
+		}
+		// Assertions are checked by the verify phase, when reported
+		if assertionsAt.IsZero() {
+			assertionsAt = at
+		}
+		for _, name := range slices.Sorted(maps.Keys(test.Result.AssertionResults)) {
+			assertion := test.Result.AssertionResults[name]
+			event := otlpEvent{Time: unixNano(assertionsAt), Name: "assertion", Attributes: []otlpAttribute{
+				stringAttribute("mcpchecker.assertion.name", name),
+				boolAttribute("mcpchecker.assertion.passed", assertion.Passed),
+			}}
+			if assertion.Message != "" {
+				event.Attributes = append(event.Attributes, stringAttribute("mcpchecker.assertion.message", redactBuiltin(assertion.Message)))
+			}
+			testSpan.Events = append(testSpan.Events, event)
+		}
+		testSpan.End = unixNano(at)
+		spans = append(append(spans, testSpan), children...)
+	}
+	spans[0].End = unixNano(at)
+	return spans
+}
+
+// unixNano returns t in Unix nanoseconds, as OTLP times are
+func unixNano(t time.Time) uint64 {
+	return uint64(t.UnixNano())
+}
+
+func milliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// redactBuiltin masks the secrets matched by the built-in redactions, for
+// the texts sent as is rather than through a testcase
+func redactBuiltin(text string) string {
+	for _, redaction := range converter.BuiltinRedactions {
+		text = redaction.Pattern.ReplaceAllString(text, redaction.Replacement)
+	}
+	return text
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPublishOTel(t *testing.T) {
+	var body struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []otlpAttribute `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []otlpSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Api-Key") != "s3cr3t" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "api-key=s3cr3t")
+
+	input := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(input, []byte(`{"startedAt":"2026-01-02T03:04:05Z","results":[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"agentOutput":{"Success":true,"DurationMs":1500}}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCLI(context.Background(), []string{"publish", "otel", "--endpoint", server.URL + "/", "--service-name", "evals", input}); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.ResourceSpans) != 1 || len(body.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("body = %+v, want one resource and scope", body)
+	}
+	if attributes := body.ResourceSpans[0].Resource.Attributes; len(attributes) != 1 || attributes[0].Value["stringValue"] != "evals" {
+		t.Errorf("resource attributes = %+v, want service.name evals", attributes)
+	}
+	spans := body.ResourceSpans[0].ScopeSpans[0].Spans
+	start := uint64(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano())
+	if len(spans) != 3 || spans[0].Start != start || spans[0].End != start+uint64(1500*time.Millisecond) {
+		t.Errorf("spans = %+v, want the run, task and agent phase spans over 1.5s", spans)
+	}
+
+	if err := runCLI(context.Background(), []string{"publish", "otel", "--endpoint", server.URL + "/other", input}); err == nil ||
+		!strings.Contains(err.Error(), "400 Bad Request") {
+		t.Errorf("publish to another path error = %v, want the 400 response", err)
+	}
+}
+
+func TestOTelSpans(t *testing.T) {
+	run := mustParse(t, `{"runId":"nightly","results":[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":false,
+			"assertionResults":{"pods-ready":{"passed":false,"message":"token Bearer abc123"},"created":{"passed":true}},
+			"setupOutput":{"Success":true,"DurationMs":100},
+			"agentOutput":{"Success":true,"DurationMs":50},
+			"verifyOutput":{"Success":true,"DurationMs":20},
+			"callHistory":{"ToolCalls":[
+				{"serverName":"k8s","name":"pods_list","success":true,"durationMs":40},
+				{"serverName":"k8s","name":"pods_get","success":false,"durationMs":30}
+			]}},
+		{"taskName":"b","traceId":"0AF7651916CD43DD8448EB211C80319C","taskPassed":true,"allAssertionsPassed":true}
+	]}`)
+	conv := mustNewPublishConverter(t)
+	var tests []publishedTest
+	for _, result := range run.Results {
+		testCase := conv.ConvertResult(result)
+		tests = append(tests, publishedTest{Result: result, TestCase: testCase, Outcome: historyOutcome(testCase)})
+	}
+	var ids int
+	newID := func(n int) string {
+		ids++
+		return fmt.Sprintf("%0*d", 2*n, ids)
+	}
+	start := time.Unix(1000, 0)
+	ms := func(n int) uint64 { return uint64(start.Add(time.Duration(n) * time.Millisecond).UnixNano()) }
+
+	spans := otelSpans(run, tests, start, newID)
+	type span struct {
+		name, parent string
+		start, end   uint64
+		status       int
+	}
+	want := []span{
+		{"mcpchecker run", "", ms(0), ms(190), 0},
+		{"a", "0000000000000002", ms(0), ms(190), otlpStatusError},
+		{"setup", "0000000000000003", ms(0), ms(100), 0},
+		{"agent", "0000000000000003", ms(100), ms(170), 0},
+		{"tools/call pods_list", "0000000000000005", ms(100), ms(140), 0},
+		{"tools/call pods_get", "0000000000000005", ms(140), ms(170), otlpStatusError},
+		{"verify", "0000000000000003", ms(170), ms(190), 0},
+		{"b", "", ms(190), ms(190), otlpStatusOK},
+	}
+	if len(spans) != len(want) {
+		t.Fatalf("spans = %+v, want %d", spans, len(want))
+	}
+	for i, s := range spans {
+		if got := (span{s.Name, s.ParentSpanID, s.Start, s.End, s.Status.Code}); got != want[i] {
+			t.Errorf("span %d = %+v, want %+v", i, got, want[i])
+		}
+	}
+	if spans[1].TraceID != spans[0].TraceID || spans[2].TraceID != spans[0].TraceID {
+		t.Errorf("trace IDs = %s, %s, %s, want the run trace", spans[0].TraceID, spans[1].TraceID, spans[2].TraceID)
+	}
+
+	events := spans[1].Events
+	if len(events) != 2 || events[0].Time != ms(190) || events[1].Attributes[0].Value["stringValue"] != "pods-ready" {
+		t.Fatalf("events = %+v, want the assertions at the end of verify", events)
+	}
+	if message := events[1].Attributes[2].Value["stringValue"]; message != "token Bearer [REDACTED]" {
+		t.Errorf("assertion message = %v, want it redacted", message)
+	}
+
+	// A task with a trace ID of its own joins it, linked to the run
+	b := spans[7]
+	if b.TraceID != "0af7651916cd43dd8448eb211c80319c" || len(b.Links) != 1 || b.Links[0].SpanID != spans[0].SpanID {
+		t.Errorf("span of b = %+v, want it in its own trace, linked to the run", b)
+	}
+}
+
+func TestParseOTLPHeaders(t *testing.T) {
+	header, err := parseOTLPHeaders("api-key=abc, x-scope=team%20a,")
+	if err != nil || header.Get("Api-Key") != "abc" || header.Get("X-Scope") != "team a" {
+		t.Errorf("parseOTLPHeaders = %v, %v", header, err)
+	}
+	for _, value := range []string{"api-key", "=abc", "key=%zz"} {
+		if _, err := parseOTLPHeaders(value); err == nil {
+			t.Errorf("parseOTLPHeaders(%q) succeeded, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Defaults of notify pagerduty
+const (
+	defaultPagerDutyEventsURL     = "https://events.pagerduty.com/v2/enqueue"
+	defaultPagerDutyRoutingKeyEnv = "PAGERDUTY_ROUTING_KEY"
+)
+
+// pagerDutySeverities lists the values of --severity of notify pagerduty
+var pagerDutySeverities = []string{"critical", "error", "warning", "info"}
+
+// pagerDutyEvent is an event of the PagerDuty Events API v2
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Client      string            `json:"client,omitempty"`
+	ClientURL   string            `json:"client_url,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+// pagerDutyPayload describes the alert of a trigger event
+type pagerDutyPayload struct {
+	Summary       string                 `json:"summary"`
+	Source        string                 `json:"source"`
+	Severity      string                 `json:"severity"`
+	Timestamp     string                 `json:"timestamp,omitempty"`
+	Component     string                 `json:"component,omitempty"`
+	Class         string                 `json:"class,omitempty"`
+	CustomDetails map[string]interface{} `json:"custom_details,omitempty"`
+}
+
+// pagerDutyLink is a link shown on the incident
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// notifyPagerDuty implements notify pagerduty: it triggers an alert through
+// the PagerDuty Events API when a gate fails, and resolves it when they
+// pass. Every run of a pipeline shares the dedup key of the alert, so that
+// failures that repeat from one night to the next update one incident.
+func notifyPagerDuty(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	alerts := addAlertFlags(fs)
+	eventsURL := fs.String("events-url", defaultPagerDutyEventsURL, "URL of the PagerDuty Events API v2")
+	routingKeyEnv := fs.String("routing-key-env", defaultPagerDutyRoutingKeyEnv, "environment variable holding the integration key of the PagerDuty service")
+	severity := fs.String("severity", "error", "severity of the alert: "+strings.Join(pagerDutySeverities, ", "))
+	source := fs.String("source", "", "system the alert is about (default --dedup-key)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if !slices.Contains(pagerDutySeverities, *severity) {
+		return newUsageError("--severity must be one of %s", strings.Join(pagerDutySeverities, ", "))
+	}
+	routingKey, err := secretFromEnv("routing-key-env", *routingKeyEnv)
+	if err != nil {
+		return err
+	}
+	alert, err := alerts.evaluate(ctx, "pagerduty", fs.Args())
+	if err != nil {
+		return err
+	}
+
+	event := pagerDutyEvent{RoutingKey: routingKey, EventAction: "resolve", DedupKey: alert.dedupKey}
+	if alert.gateErr != nil {
+		event = pagerDutyTrigger(alert, routingKey, *severity, *source, time.Now())
+	} else if !*alerts.resolve {
+		slog.Info("gates passed")
+		return nil
+	}
+	if err := callAPI(ctx, http.MethodPost, *eventsURL, http.Header{}, event, nil); err != nil {
+		return fmt.Errorf("sending the PagerDuty event: %w", err)
+	}
+	slog.Info("sent PagerDuty event", "action", event.EventAction, "dedup_key", event.DedupKey)
+	return alert.gateErr
+}
+
+// pagerDutyTrigger returns the trigger event of the failed gates of alert
+func pagerDutyTrigger(alert gateAlert, routingKey, severity, source string, now time.Time) pagerDutyEvent {
+	details := map[string]interface{}{
+		"gates":  alert.gateErr.Error(),
+		"passed": alert.total.passed,
+		"tasks":  alert.total.tests - alert.total.skipped,
+	}
+	if tasks := alert.failingTasks(); len(tasks) > 0 {
+		details["failing_tasks"] = tasks
+	}
+	event := pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "trigger",
+		DedupKey:    alert.dedupKey,
+		Payload: &pagerDutyPayload{
+			Summary:       alert.summary(),
+			Source:        cmp.Or(source, alert.dedupKey),
+			Severity:      severity,
+			Timestamp:     now.UTC().Format(time.RFC3339),
+			Component:     "mcpchecker",
+			Class:         "quality gate",
+			CustomDetails: details,
+		},
+		Client: defaultPublishName,
+	}
+	if alert.runURL != "" {
+		event.ClientURL = alert.runURL
+		event.Links = []pagerDutyLink{{Href: alert.runURL, Text: "CI run"}}
+	}
+	return event
+}
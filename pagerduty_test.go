@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifyPagerDuty(t *testing.T) {
+	var events []pagerDutyEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event pagerDutyEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Error(err)
+		}
+		if event.RoutingKey != "pd-key" {
+			http.Error(w, `{"status":"invalid event","message":"Event object is invalid"}`, http.StatusBadRequest)
+			return
+		}
+		events = append(events, event)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+	t.Setenv("PAGERDUTY_ROUTING_KEY", "pd-key")
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	failing := write("failing.json", `[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true},
+		{"taskName":"b","difficulty":"hard","taskPassed":false,"taskError":"boom"}
+	]`)
+	passing := write("passing.json", `[{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true}]`)
+	notify := func(input string, extra ...string) error {
+		args := append([]string{"notify", "pagerduty", "--events-url", server.URL, "--min-pass-rate", "0.9",
+			"--dedup-key", "mcpchecker/o/r/nightly", "--run-url", "https://ci.example.com/runs/7"}, extra...)
+		return runCLI(context.Background(), append(args, input))
+	}
+
+	var gateErr gateError
+	if err := notify(failing); !errors.As(err, &gateErr) || gateErr.code != exitCodeGateFailed {
+		t.Fatalf("notify error = %v, want the failed gate", err)
+	}
+	if len(events) != 1 || events[0].EventAction != "trigger" || events[0].DedupKey != "mcpchecker/o/r/nightly" {
+		t.Fatalf("events = %+v, want a trigger of the dedup key", events)
+	}
+	trigger := events[0]
+	if trigger.Payload.Summary != "MCP checker gate failed: 1 of 2 tasks passed" || trigger.Payload.Severity != "error" ||
+		trigger.Payload.Source != "mcpchecker/o/r/nightly" || trigger.ClientURL != "https://ci.example.com/runs/7" {
+		t.Errorf("trigger = %+v, payload %+v", trigger, trigger.Payload)
+	}
+	if gates, _ := trigger.Payload.CustomDetails["gates"].(string); !strings.Contains(gates, "Pass-rate gate failed") {
+		t.Errorf("custom details = %v, want the failed gate", trigger.Payload.CustomDetails)
+	}
+
+	// Passing gates resolve the alert, unless --resolve=false
+	if err := notify(passing); err != nil {
+		t.Fatal(err)
+	}
+	if err := notify(passing, "--resolve=false"); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[1].EventAction != "resolve" || events[1].DedupKey != "mcpchecker/o/r/nightly" || events[1].Payload != nil {
+		t.Errorf("events = %+v, want one resolve of the dedup key", events)
+	}
+
+	var usageErr usageError
+	if err := runCLI(context.Background(), []string{"notify", "pagerduty", "--events-url", server.URL, failing}); !errors.As(err, &usageErr) {
+		t.Errorf("notify without a gate error = %v, want a usageError", err)
+	}
+	t.Setenv("PAGERDUTY_ROUTING_KEY", "wrong")
+	if err := notify(failing); err == nil || !strings.Contains(err.Error(), "400 Bad Request") {
+		t.Errorf("notify with a wrong key error = %v, want the 400 response", err)
+	}
+}
+
+func TestPagerDutyTrigger(t *testing.T) {
+	alert := gateAlert{dedupKey: "mcpchecker", gateErr: gateError{code: exitCodeTestsFailed, msg: "Tests failed"}, total: summaryRow{tests: 3, passed: 2, skipped: 1}}
+	event := pagerDutyTrigger(alert, "key", "critical", "nightly-evals", time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600)))
+	if event.Payload.Source != "nightly-evals" || event.Payload.Timestamp != "2026-01-02T02:04:05Z" || event.Payload.Summary != "MCP checker gate failed: 2 of 2 tasks passed" {
+		t.Errorf("payload = %+v", event.Payload)
+	}
+	if event.Links != nil || event.Payload.CustomDetails["failing_tasks"] != nil {
+		t.Errorf("event = %+v, want no links or failing tasks", event)
+	}
+}
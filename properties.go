@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// propertyList is a repeatable key=value flag
+type propertyList []converter.JUnitProperty
+
+func (p *propertyList) String() string {
+	if p == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*p))
+	for _, property := range *p {
+		pairs = append(pairs, property.Name+"="+property.Value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (p *propertyList) Set(value string) error {
+	name, propertyValue, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(name) == "" {
+		return errors.New("expected key=value")
+	}
+	*p = append(*p, converter.JUnitProperty{Name: strings.TrimSpace(name), Value: propertyValue})
+	return nil
+}
+
+// propertiesFromEnv returns a property for every environment variable whose
+// name starts with prefix, named after the rest of the variable name and
+// sorted by name
+func propertiesFromEnv(prefix string) []converter.JUnitProperty {
+	if prefix == "" {
+		return nil
+	}
+	var properties []converter.JUnitProperty
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+			properties = append(properties, converter.JUnitProperty{Name: key, Value: value})
+		}
+	}
+	slices.SortFunc(properties, func(a, b converter.JUnitProperty) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return properties
+}
+
+// capturedEnv returns a property for each of the named environment
+// variables, in order and named after the variable, warning about and
+// leaving out those that are not set
+func capturedEnv(names []string) []converter.JUnitProperty {
+	var properties []converter.JUnitProperty
+	for _, name := range names {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			slog.Warn("--capture-env variable is not set", "name", name)
+			continue
+		}
+		properties = append(properties, converter.JUnitProperty{Name: name, Value: value})
+	}
+	return properties
+}
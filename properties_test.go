@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestPropertyList(t *testing.T) {
+	var properties propertyList
+	for _, value := range []string{"model=gpt-5", "runUrl=https://ci/run?id=1", "empty="} {
+		if err := properties.Set(value); err != nil {
+			t.Fatalf("Set(%q) error = %v", value, err)
+		}
+	}
+	want := propertyList{{Name: "model", Value: "gpt-5"}, {Name: "runUrl", Value: "https://ci/run?id=1"}, {Name: "empty", Value: ""}}
+	if !reflect.DeepEqual(properties, want) {
+		t.Errorf("properties = %+v, want %+v", properties, want)
+	}
+
+	for _, value := range []string{"model", "=gpt-5"} {
+		if err := properties.Set(value); err == nil {
+			t.Errorf("Set(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestPropertiesFromEnv(t *testing.T) {
+	t.Setenv("REPORT_SHA", "abc123")
+	t.Setenv("REPORT_MODEL", "gpt-5")
+	t.Setenv("REPORT_", "ignored")
+
+	want := []converter.JUnitProperty{{Name: "MODEL", Value: "gpt-5"}, {Name: "SHA", Value: "abc123"}}
+	if got := propertiesFromEnv("REPORT_"); !reflect.DeepEqual(got, want) {
+		t.Errorf("propertiesFromEnv() = %+v, want %+v", got, want)
+	}
+	if got := propertiesFromEnv(""); got != nil {
+		t.Errorf("propertiesFromEnv(\"\") = %+v, want nil", got)
+	}
+}
+
+func TestPropertyFlags(t *testing.T) {
+	captureHelp(t)
+	t.Setenv("REPORT_SHA", "abc123")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte(`{"runId":"r1","results":[`+resultA+`,`+resultB+`]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "report.xml")
+
+	args := []string{"--property", "model=gpt-5", "--property", "mcpchecker=0.4.0", "--properties-from-env", "REPORT_", "--output", output, input}
+	if err := runCLI(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	properties := `<properties>
+      <property name="runId" value="r1"></property>
+      <property name="SHA" value="abc123"></property>
+      <property name="model" value="gpt-5"></property>
+      <property name="mcpchecker" value="0.4.0"></property>
+    </properties>`
+	if got := strings.Count(string(report), properties); got != 2 {
+		t.Errorf("found the properties on %d suites, want 2:\n%s", got, report)
+	}
+
+	var flagErr flagError
+	if err := runCLI(context.Background(), []string{"--property", "model", input}); !errors.As(err, &flagErr) {
+		t.Errorf("invalid --property error = %v, want a flagError", err)
+	}
+}
+
+func TestCaptureEnv(t *testing.T) {
+	logs := captureLog(t)
+	t.Setenv("GIT_SHA", "abc123")
+	t.Setenv("MODEL_NAME", "gpt-5")
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(input, []byte(`{"runId":"r1","results":[`+resultA+`,`+resultB+`]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "report.xml")
+
+	args := []string{"--capture-env", "MODEL_NAME,GIT_SHA,MCP_VERSION", "--property", "ci=true", "--check", "--output", output, input}
+	if err := runCLI(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportProperties := `<testsuites tests="2" failures="0" errors="1" skipped="0">
+  <properties>
+    <property name="MODEL_NAME" value="gpt-5"></property>
+    <property name="GIT_SHA" value="abc123"></property>
+  </properties>`
+	if !strings.Contains(string(report), reportProperties) {
+		t.Errorf("testsuites element does not hold the captured properties:\n%s", report)
+	}
+	suiteProperties := `<properties>
+      <property name="runId" value="r1"></property>
+      <property name="MODEL_NAME" value="gpt-5"></property>
+      <property name="GIT_SHA" value="abc123"></property>
+      <property name="ci" value="true"></property>
+    </properties>`
+	if got := strings.Count(string(report), suiteProperties); got != 2 {
+		t.Errorf("found the captured properties on %d suites, want 2:\n%s", got, report)
+	}
+	if !strings.Contains(logs.String(), "MCP_VERSION") {
+		t.Errorf("no warning about the unset MCP_VERSION:\n%s", logs)
+	}
+}
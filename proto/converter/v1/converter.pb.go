@@ -0,0 +1,247 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: converter/v1/converter.proto
+
+package converterv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ResultChunk carries a piece of the results input. The options are read
+// from the first chunk only.
+type ResultChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Raw input bytes: JSON, JSON Lines, YAML or JUnit XML, optionally gzip-compressed.
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Input format, one of "auto" (the default when empty), "json", "ndjson", "yaml" or "junit".
+	Format string `protobuf:"bytes,2,opt,name=format,proto3" json:"format,omitempty"`
+	// Validate every result against the embedded schema.
+	Strict bool `protobuf:"varint,3,opt,name=strict,proto3" json:"strict,omitempty"`
+	// Report malformed entries as errored "parse-error-N" testcases instead of failing.
+	Lenient       bool `protobuf:"varint,4,opt,name=lenient,proto3" json:"lenient,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultChunk) Reset() {
+	*x = ResultChunk{}
+	mi := &file_converter_v1_converter_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultChunk) ProtoMessage() {}
+
+func (x *ResultChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultChunk.ProtoReflect.Descriptor instead.
+func (*ResultChunk) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ResultChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *ResultChunk) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ResultChunk) GetStrict() bool {
+	if x != nil {
+		return x.Strict
+	}
+	return false
+}
+
+func (x *ResultChunk) GetLenient() bool {
+	if x != nil {
+		return x.Lenient
+	}
+	return false
+}
+
+// Report is the converted JUnit XML document with its totals. It is sent as
+// a single message, so clients expecting reports larger than the 4 MiB gRPC
+// default must raise their receive limit.
+type Report struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The JUnit XML document, including the XML header.
+	JunitXml []byte `protobuf:"bytes,1,opt,name=junit_xml,json=junitXml,proto3" json:"junit_xml,omitempty"`
+	Tests    int32  `protobuf:"varint,2,opt,name=tests,proto3" json:"tests,omitempty"`
+	Failures int32  `protobuf:"varint,3,opt,name=failures,proto3" json:"failures,omitempty"`
+	Errors   int32  `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`
+	// One message per malformed entry kept in lenient mode.
+	ParseErrors   []string `protobuf:"bytes,5,rep,name=parse_errors,json=parseErrors,proto3" json:"parse_errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Report) Reset() {
+	*x = Report{}
+	mi := &file_converter_v1_converter_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Report) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Report) ProtoMessage() {}
+
+func (x *Report) ProtoReflect() protoreflect.Message {
+	mi := &file_converter_v1_converter_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Report.ProtoReflect.Descriptor instead.
+func (*Report) Descriptor() ([]byte, []int) {
+	return file_converter_v1_converter_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Report) GetJunitXml() []byte {
+	if x != nil {
+		return x.JunitXml
+	}
+	return nil
+}
+
+func (x *Report) GetTests() int32 {
+	if x != nil {
+		return x.Tests
+	}
+	return 0
+}
+
+func (x *Report) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *Report) GetErrors() int32 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *Report) GetParseErrors() []string {
+	if x != nil {
+		return x.ParseErrors
+	}
+	return nil
+}
+
+var File_converter_v1_converter_proto protoreflect.FileDescriptor
+
+const file_converter_v1_converter_proto_rawDesc = "" +
+	"\n" +
+	"\x1cconverter/v1/converter.proto\x12\x1dmcpchecker.junit.converter.v1\"k\n" +
+	"\vResultChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x16\n" +
+	"\x06format\x18\x02 \x01(\tR\x06format\x12\x16\n" +
+	"\x06strict\x18\x03 \x01(\bR\x06strict\x12\x18\n" +
+	"\alenient\x18\x04 \x01(\bR\alenient\"\x92\x01\n" +
+	"\x06Report\x12\x1b\n" +
+	"\tjunit_xml\x18\x01 \x01(\fR\bjunitXml\x12\x14\n" +
+	"\x05tests\x18\x02 \x01(\x05R\x05tests\x12\x1a\n" +
+	"\bfailures\x18\x03 \x01(\x05R\bfailures\x12\x16\n" +
+	"\x06errors\x18\x04 \x01(\x05R\x06errors\x12!\n" +
+	"\fparse_errors\x18\x05 \x03(\tR\vparseErrors2r\n" +
+	"\x10ConverterService\x12^\n" +
+	"\aConvert\x12*.mcpchecker.junit.converter.v1.ResultChunk\x1a%.mcpchecker.junit.converter.v1.Report(\x01BPZNgithub.com/jrangelramos/mcpchecker-junit-report/proto/converter/v1;converterv1b\x06proto3"
+
+var (
+	file_converter_v1_converter_proto_rawDescOnce sync.Once
+	file_converter_v1_converter_proto_rawDescData []byte
+)
+
+func file_converter_v1_converter_proto_rawDescGZIP() []byte {
+	file_converter_v1_converter_proto_rawDescOnce.Do(func() {
+		file_converter_v1_converter_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_converter_v1_converter_proto_rawDesc), len(file_converter_v1_converter_proto_rawDesc)))
+	})
+	return file_converter_v1_converter_proto_rawDescData
+}
+
+var file_converter_v1_converter_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_converter_v1_converter_proto_goTypes = []any{
+	(*ResultChunk)(nil), // 0: mcpchecker.junit.converter.v1.ResultChunk
+	(*Report)(nil),      // 1: mcpchecker.junit.converter.v1.Report
+}
+var file_converter_v1_converter_proto_depIdxs = []int32{
+	0, // 0: mcpchecker.junit.converter.v1.ConverterService.Convert:input_type -> mcpchecker.junit.converter.v1.ResultChunk
+	1, // 1: mcpchecker.junit.converter.v1.ConverterService.Convert:output_type -> mcpchecker.junit.converter.v1.Report
+	1, // [1:2] is the sub-list for method output_type
+	0, // [0:1] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_converter_v1_converter_proto_init() }
+func file_converter_v1_converter_proto_init() {
+	if File_converter_v1_converter_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_converter_v1_converter_proto_rawDesc), len(file_converter_v1_converter_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_converter_v1_converter_proto_goTypes,
+		DependencyIndexes: file_converter_v1_converter_proto_depIdxs,
+		MessageInfos:      file_converter_v1_converter_proto_msgTypes,
+	}.Build()
+	File_converter_v1_converter_proto = out.File
+	file_converter_v1_converter_proto_goTypes = nil
+	file_converter_v1_converter_proto_depIdxs = nil
+}
@@ -0,0 +1,124 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             (unknown)
+// source: converter/v1/converter.proto
+
+package converterv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ConverterService_Convert_FullMethodName = "/mcpchecker.junit.converter.v1.ConverterService/Convert"
+)
+
+// ConverterServiceClient is the client API for ConverterService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ConverterService converts MCP checker results to JUnit XML.
+type ConverterServiceClient interface {
+	// Convert reads the results streamed in chunks and returns the report once
+	// the client closes the stream. The chunks are concatenated in order and
+	// parsed exactly like a file given to the CLI.
+	Convert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ResultChunk, Report], error)
+}
+
+type converterServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConverterServiceClient(cc grpc.ClientConnInterface) ConverterServiceClient {
+	return &converterServiceClient{cc}
+}
+
+func (c *converterServiceClient) Convert(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[ResultChunk, Report], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ConverterService_ServiceDesc.Streams[0], ConverterService_Convert_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ResultChunk, Report]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_ConvertClient = grpc.ClientStreamingClient[ResultChunk, Report]
+
+// ConverterServiceServer is the server API for ConverterService service.
+// All implementations must embed UnimplementedConverterServiceServer
+// for forward compatibility.
+//
+// ConverterService converts MCP checker results to JUnit XML.
+type ConverterServiceServer interface {
+	// Convert reads the results streamed in chunks and returns the report once
+	// the client closes the stream. The chunks are concatenated in order and
+	// parsed exactly like a file given to the CLI.
+	Convert(grpc.ClientStreamingServer[ResultChunk, Report]) error
+	mustEmbedUnimplementedConverterServiceServer()
+}
+
+// UnimplementedConverterServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConverterServiceServer struct{}
+
+func (UnimplementedConverterServiceServer) Convert(grpc.ClientStreamingServer[ResultChunk, Report]) error {
+	return status.Error(codes.Unimplemented, "method Convert not implemented")
+}
+func (UnimplementedConverterServiceServer) mustEmbedUnimplementedConverterServiceServer() {}
+func (UnimplementedConverterServiceServer) testEmbeddedByValue()                          {}
+
+// UnsafeConverterServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConverterServiceServer will
+// result in compilation errors.
+type UnsafeConverterServiceServer interface {
+	mustEmbedUnimplementedConverterServiceServer()
+}
+
+func RegisterConverterServiceServer(s grpc.ServiceRegistrar, srv ConverterServiceServer) {
+	// If the following call panics, it indicates UnimplementedConverterServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConverterService_ServiceDesc, srv)
+}
+
+func _ConverterService_Convert_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ConverterServiceServer).Convert(&grpc.GenericServerStream[ResultChunk, Report]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ConverterService_ConvertServer = grpc.ClientStreamingServer[ResultChunk, Report]
+
+// ConverterService_ServiceDesc is the grpc.ServiceDesc for ConverterService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConverterService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mcpchecker.junit.converter.v1.ConverterService",
+	HandlerType: (*ConverterServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Convert",
+			Handler:       _ConverterService_Convert_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "converter/v1/converter.proto",
+}
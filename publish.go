@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// defaultPublishName names the check runs and comments of the results
+const defaultPublishName = "MCP checker"
+
+// commandTarget is a service that a command such as publish sends results
+// to, named by the first argument of the command
+type commandTarget struct {
+	name string
+	// run registers the flags of the target on fs, parses args with them
+	// and sends the results
+	run func(ctx context.Context, fs *flag.FlagSet, args []string) error
+}
+
+// publishTargets lists the targets of the publish command
+var publishTargets = []commandTarget{
+	{name: "testrail", run: publishTestRail},
+	{name: "xray", run: publishXray},
+	{name: "github-check", run: publishGitHubCheck},
+	{name: "gitlab-mr", run: publishGitLabMR},
+	{name: "otel", run: publishOTel},
+	{name: "datadog", run: publishDatadog},
+	{name: "pushgateway", run: publishPushgateway},
+}
+
+// targetNames returns the names of targets, for messages
+func targetNames(targets []commandTarget) string {
+	names := make([]string, len(targets))
+	for i, target := range targets {
+		names[i] = target.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// runTarget runs the one of targets named by the first argument of cmd
+func runTarget(ctx context.Context, cmd *command, targets []commandTarget, args []string) error {
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	fs := cmd.flagSet()
+	for _, target := range targets {
+		if target.name == name {
+			if err := target.run(ctx, fs, args); err != nil {
+				return publishError{err: err}
+			}
+			return nil
+		}
+	}
+	if name == "" {
+		if err := parseFlags(fs, args); err != nil {
+			return err
+		}
+		return newUsageError("%s needs a target: %s", cmd.name, targetNames(targets))
+	}
+	return newUsageError("unknown %s target %q: must be one of %s", cmd.name, name, targetNames(targets))
+}
+
+// runPublish implements the publish command, whose first argument is the
+// target to publish to
+func runPublish(ctx context.Context, cmd *command, args []string) error {
+	return runTarget(ctx, cmd, publishTargets, args)
+}
+
+// publishedTest is a result along with its testcase, the outcome reported
+// for it
+type publishedTest struct {
+	Result   converter.MCPTestResult
+	TestCase converter.JUnitTestCase
+	// Outcome is one of the outcomes recorded by history
+	Outcome string
+}
+
+// newPublishConverter returns the converter of the published tests, which
+// masks secrets in the messages sent to the target with the built-in
+// redactions, along with the extra options
+func newPublishConverter(extra ...converter.Option) (*converter.Converter, error) {
+	return converter.New(append([]converter.Option{converter.WithoutSystemOut(), converter.WithoutSystemErr()}, extra...)...)
+}
+
+// loadPublishedTests loads the results of the inputs and converts each to
+// a testcase with conv. Parse errors are left out.
+func loadPublishedTests(ctx context.Context, inputs []string, opts inputOptions, conv *converter.Converter) (converter.TestRun, []publishedTest, error) {
+	run, err := loadInputs(ctx, inputs, opts)
+	if err != nil {
+		return run, nil, err
+	}
+	var tests []publishedTest
+	for _, result := range run.Results {
+		if result.ParseError() != nil {
+			continue
+		}
+		testCase := conv.ConvertResult(result)
+		tests = append(tests, publishedTest{Result: result, TestCase: testCase, Outcome: historyOutcome(testCase)})
+	}
+	return run, tests, nil
+}
+
+// failureComment describes why a test failed or errored: the message of
+// its failure or error followed by its details, with the failed assertions
+// and phase errors. It is empty for tests that passed or were skipped.
+func (t publishedTest) failureComment() string {
+	var message, content string
+	switch {
+	case t.TestCase.Error != nil:
+		message, content = t.TestCase.Error.Message, t.TestCase.Error.Content
+	case t.TestCase.Failure != nil:
+		message, content = t.TestCase.Failure.Message, t.TestCase.Failure.Content
+	default:
+		return ""
+	}
+	if content = strings.TrimSpace(content); content == "" {
+		return message
+	}
+	return message + "\n\n" + content
+}
+
+// duration returns the time the task took, adding up its phases
+func (t publishedTest) duration() time.Duration {
+	var ms float64
+	for _, phase := range t.Result.PhaseDurations() {
+		ms += phase
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// summaryRows returns the outcome counts of each difficulty level of tests,
+// in order, followed by those of all tests, named "Total"
+func summaryRows(tests []publishedTest) []summaryRow {
+	testCases := make([]converter.JUnitTestCase, len(tests))
+	for i, test := range tests {
+		testCases[i] = test.TestCase
+	}
+	return difficultyRows(testCases)
+}
+
+// difficultyRows returns the outcome counts of each difficulty level of
+// testCases, in order, followed by those of all of them, named "Total"
+func difficultyRows(testCases []converter.JUnitTestCase) []summaryRow {
+	rows := make(map[string]*summaryRow)
+	total := summaryRow{name: "Total"}
+	for _, testCase := range testCases {
+		difficulty := cmp.Or(testCase.Difficulty(), converter.UnknownGroup)
+		if rows[difficulty] == nil {
+			rows[difficulty] = &summaryRow{name: difficulty}
+		}
+		rows[difficulty].add(testCase)
+		total.add(testCase)
+	}
+	var sorted []summaryRow
+	for _, difficulty := range slices.SortedFunc(maps.Keys(rows), converter.CompareDifficulty) {
+		sorted = append(sorted, *rows[difficulty])
+	}
+	return append(sorted, total)
+}
+
+// markdownSummary returns the summaryRows of tests as a Markdown table,
+// along with the counts of all tests
+func markdownSummary(tests []publishedTest) (string, summaryRow) {
+	rows := summaryRows(tests)
+	var table strings.Builder
+	table.WriteString("| Difficulty | Tests | Passed | Failed | Errors | Skipped | Pass rate |\n|---|---:|---:|---:|---:|---:|---:|\n")
+	for _, row := range rows {
+		fmt.Fprintf(&table, "| %s | %d | %d | %d | %d | %d | %.1f%% |\n", row.name, row.tests, row.passed, row.failed, row.errored, row.skipped, row.passRate()*100)
+	}
+	return table.String(), rows[len(rows)-1]
+}
+
+// passedTitle headlines the counts of the published tests, e.g.
+// "18 of 20 tasks passed"; skipped tasks are left out
+func passedTitle(total summaryRow) string {
+	return fmt.Sprintf("%d of %d tasks passed", total.passed, total.tests-total.skipped)
+}
+
+// truncateText cuts text to limit bytes, the length a target accepts,
+// ending it with "..." when it is cut
+func truncateText(text string, limit int) string {
+	if len(text) <= limit {
+		return text
+	}
+	return strings.ToValidUTF8(text[:limit-3], "") + "..."
+}
+
+// loadTaskMapping reads a YAML or JSON file mapping task names to the IDs
+// or keys of the tests of a target, e.g. "create-pod: C1042"
+func loadTaskMapping(flagName, filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, newUsageError("invalid --%s: %v", flagName, err)
+	}
+	mapping := make(map[string]string)
+	if err := yaml.Unmarshal(data, &mapping); err != nil {
+		return nil, newUsageError("invalid --%s %s: %v", flagName, filename, err)
+	}
+	return mapping, nil
+}
+
+// maxAPIErrorBody bounds the part of an error response quoted in errors
+const maxAPIErrorBody = 512
+
+// callAPI sends body, when not nil, as JSON with method to endpoint, and
+// decodes the JSON response into out, when not nil. Statuses other than
+// 2xx fail with the start of the response body.
+func callAPI(ctx context.Context, method, endpoint string, header http.Header, body, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	return sendAPI(ctx, method, endpoint, header, "application/json", data, out)
+}
+
+// sendAPI sends data, when not nil, with method to endpoint as contentType,
+// and decodes the JSON response into out like callAPI
+func sendAPI(ctx context.Context, method, endpoint string, header http.Header, contentType string, data []byte, out interface{}) error {
+	var reader io.Reader
+	if data != nil {
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, maxAPIErrorBody))
+		return fmt.Errorf("%s %s: unexpected status %s: %s", method, endpoint, resp.Status, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: decoding response: %w", method, endpoint, err)
+	}
+	return nil
+}
+
+// basicAuth returns the credentials of HTTP basic authentication
+func basicAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
+
+// secretFromEnv returns the value of the environment variable named by the
+// flagName flag, failing when it is unset
+func secretFromEnv(flagName, name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", newUsageError("the %s environment variable, set with --%s, is empty", name, flagName)
+	}
+	return value, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestPublishedTests(t *testing.T) {
+	dir := t.TempDir()
+	results := filepath.Join(dir, "results.json")
+	if err := os.WriteFile(results, []byte(`[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,
+		 "setupOutput":{"Success":true,"DurationMs":1500},"agentOutput":{"Success":true,"DurationMs":2000}},
+		{"taskName":"b","taskPassed":true,"allAssertionsPassed":false,
+		 "assertionResults":{"pods-ready":{"passed":false,"message":"0/3 ready"}}},
+		{"taskName":"c","taskPassed":false,"taskError":"Authorization: Bearer s3cr3t-t0ken rejected"}
+	]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, tests, err := loadPublishedTests(context.Background(), []string{results}, inputOptions{}, mustNewPublishConverter(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tests) != 3 {
+		t.Fatalf("got %d tests, want 3", len(tests))
+	}
+	if tests[0].Outcome != outcomePassed || tests[0].failureComment() != "" || tests[0].duration() != 3500*time.Millisecond {
+		t.Errorf("passed test = %s, %q, %v", tests[0].Outcome, tests[0].failureComment(), tests[0].duration())
+	}
+	if comment := tests[1].failureComment(); tests[1].Outcome != outcomeFailure || !strings.Contains(comment, "pods-ready: 0/3 ready") {
+		t.Errorf("failed test = %s, %q", tests[1].Outcome, comment)
+	}
+	// Secrets are masked before leaving the machine
+	if comment := tests[2].failureComment(); tests[2].Outcome != outcomeError || strings.Contains(comment, "s3cr3t") {
+		t.Errorf("errored test = %s, %q", tests[2].Outcome, comment)
+	}
+}
+
+func TestLoadTaskMapping(t *testing.T) {
+	dir := t.TempDir()
+	mapping := filepath.Join(dir, "mapping.yaml")
+	if err := os.WriteFile(mapping, []byte("create-pod: C1042\nscale: 7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := loadTaskMapping("mapping", mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["create-pod"] != "C1042" || got["scale"] != "7" {
+		t.Errorf("mapping = %v", got)
+	}
+
+	if err := os.WriteFile(mapping, []byte("- a\n- b\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTaskMapping("mapping", mapping); err == nil {
+		t.Error("loadTaskMapping of a list succeeded, want an error")
+	}
+}
+
+func mustNewPublishConverter(t *testing.T) *converter.Converter {
+	t.Helper()
+	conv, err := newPublishConverter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conv
+}
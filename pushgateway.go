@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Defaults of publish pushgateway
+const (
+	defaultPushgatewayJob         = "mcpchecker"
+	defaultPushgatewayPasswordEnv = "PUSHGATEWAY_PASSWORD"
+	// prometheusTextFormat is the content type of the text exposition format
+	prometheusTextFormat = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// prometheusEscaper escapes label values in the text exposition format
+var prometheusEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// publishPushgateway implements publish pushgateway: it pushes the metrics
+// of export to the group of --job on a Prometheus Pushgateway, replacing
+// those of the previous run
+func publishPushgateway(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	baseURL := fs.String("url", "", "URL of the Pushgateway, e.g. http://pushgateway:9091")
+	job := fs.String("job", defaultPushgatewayJob, "job label of the pushed metrics")
+	instance := fs.String("instance", "", "instance label grouping the metrics with the job, e.g. the benchmark or branch, so that pushes of several instances do not replace each other")
+	user := fs.String("user", "", "user of HTTP basic authentication, with the password in --password-env")
+	passwordEnv := fs.String("password-env", defaultPushgatewayPasswordEnv, "environment variable holding the password of --user")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	if *baseURL == "" || *job == "" {
+		return newUsageError("publish pushgateway requires --url and --job")
+	}
+	header := http.Header{}
+	if *user != "" {
+		password, err := secretFromEnv("password-env", *passwordEnv)
+		if err != nil {
+			return err
+		}
+		header.Set("Authorization", "Basic "+basicAuth(*user, password))
+	}
+	run, err := loadInputs(ctx, fs.Args(), opts)
+	if err != nil {
+		return err
+	}
+	metrics, err := collectMetrics(run)
+	if err != nil {
+		return err
+	}
+	at := runTime(run, time.Now)
+	metrics = append(metrics, metric{name: "mcpchecker_run", fields: []metricField{{key: "timestamp_seconds", value: float64(at.Unix()), integer: true}}})
+
+	endpoint := strings.TrimSuffix(*baseURL, "/") + "/metrics/job/" + url.PathEscape(*job)
+	if *instance != "" {
+		endpoint += "/instance/" + url.PathEscape(*instance)
+	}
+	// PUT replaces every metric of the group, so that tasks and tools
+	// missing from this run do not linger
+	if err := sendAPI(ctx, http.MethodPut, endpoint, header, prometheusTextFormat, []byte(prometheusText(metrics)), nil); err != nil {
+		return fmt.Errorf("pushing metrics: %w", err)
+	}
+	slog.Info("pushed metrics", "url", endpoint, "metrics", len(metrics))
+	return nil
+}
+
+// prometheusText renders metrics in the Prometheus text exposition format,
+// as gauges named after the measurement and field, e.g.
+// mcpchecker_tasks_pass_rate{difficulty="easy"}, with their tags as labels.
+// Empty tag values are left out.
+func prometheusText(metrics []metric) string {
+	// Samples of a metric family must be written together
+	var names []string
+	samples := make(map[string][]string)
+	for _, m := range metrics {
+		var labels []string
+		for _, tag := range m.tags {
+			if tag[1] != "" {
+				labels = append(labels, fmt.Sprintf(`%s="%s"`, tag[0], prometheusEscaper.Replace(tag[1])))
+			}
+		}
+		var labelSet string
+		if len(labels) > 0 {
+			labelSet = "{" + strings.Join(labels, ",") + "}"
+		}
+		for _, field := range m.fields {
+			name := m.name + "_" + field.key
+			if samples[name] == nil {
+				names = append(names, name)
+			}
+			value := strconv.FormatFloat(field.value, 'g', -1, 64)
+			if field.integer {
+				value = strconv.FormatInt(int64(field.value), 10)
+			}
+			samples[name] = append(samples[name], name+labelSet+" "+value)
+		}
+	}
+	var text strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&text, "# TYPE %s gauge\n%s\n", name, strings.Join(samples[name], "\n"))
+	}
+	return text.String()
+}
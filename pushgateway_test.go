@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPublishPushgateway(t *testing.T) {
+	var request, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "ci" || password != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		request = r.Method + " " + r.URL.EscapedPath() + " " + r.Header.Get("Content-Type")
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	}))
+	defer server.Close()
+	t.Setenv("PUSHGATEWAY_PASSWORD", "secret")
+
+	input := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(input, []byte(`{"startedAt":"2026-01-02T03:04:05Z","results":[
+		{"taskName":"a","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+			"callHistory":{"ToolCalls":[{"serverName":"k8s","name":"pods_list","success":true}]}},
+		{"taskName":"b","difficulty":"easy","taskPassed":false,"taskError":"boom"}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := runCLI(context.Background(), []string{"publish", "pushgateway", "--url", server.URL + "/", "--instance", "release/1.2",
+		"--user", "ci", input})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "PUT /metrics/job/mcpchecker/instance/release%2F1.2 " + prometheusTextFormat; request != want {
+		t.Errorf("request = %q, want %q", request, want)
+	}
+	for _, want := range []string{
+		"# TYPE mcpchecker_tasks_passed gauge\nmcpchecker_tasks_passed{difficulty=\"easy\"} 1\nmcpchecker_tasks_passed{difficulty=\"all\"} 1\n",
+		"mcpchecker_tasks_pass_rate{difficulty=\"easy\"} 0.5\n",
+		"mcpchecker_tool_calls_calls{server=\"k8s\",tool=\"pods_list\"} 1\n",
+		"mcpchecker_run_timestamp_seconds 1767323045\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body = %s\nwant it to contain %q", body, want)
+		}
+	}
+
+	if err := runCLI(context.Background(), []string{"publish", "pushgateway", "--url", server.URL, input}); err == nil ||
+		!strings.Contains(err.Error(), "401 Unauthorized") {
+		t.Errorf("publish without --user error = %v, want the 401 response", err)
+	}
+}
+
+func TestPrometheusText(t *testing.T) {
+	metrics := []metric{
+		{name: "m", tags: [][2]string{{"tool", `say "hi"\n`}, {"server", ""}}, fields: []metricField{{key: "calls", value: 2, integer: true}, {key: "rate", value: 0.25}}},
+		{name: "m", tags: [][2]string{{"tool", "b"}}, fields: []metricField{{key: "calls", value: 1e6, integer: true}}},
+	}
+	want := "# TYPE m_calls gauge\nm_calls{tool=\"say \\\"hi\\\"\\\\n\"} 2\nm_calls{tool=\"b\"} 1000000\n# TYPE m_rate gauge\nm_rate{tool=\"say \\\"hi\\\"\\\\n\"} 0.25\n"
+	if got := prometheusText(metrics); got != want {
+		t.Errorf("prometheusText =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// redactionList is a repeatable regular expression flag
+type redactionList []converter.Redaction
+
+func (r *redactionList) String() string {
+	if r == nil {
+		return ""
+	}
+	patterns := make([]string, 0, len(*r))
+	for _, redaction := range *r {
+		patterns = append(patterns, redaction.Pattern.String())
+	}
+	return strings.Join(patterns, ",")
+}
+
+func (r *redactionList) Set(value string) error {
+	if value == "" {
+		return errors.New("empty regular expression")
+	}
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	*r = append(*r, converter.Redaction{Pattern: re, Replacement: converter.RedactedText})
+	return nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestRedactionList(t *testing.T) {
+	var redactions redactionList
+	if err := redactions.Set(`password=\S+`); err != nil {
+		t.Fatal(err)
+	}
+	if got := redactions[0].Pattern.ReplaceAllString("login password=hunter2 ok", redactions[0].Replacement); got != "login [REDACTED] ok" {
+		t.Errorf("redacted = %q", got)
+	}
+	for _, value := range []string{"", "("} {
+		if err := redactions.Set(value); err == nil {
+			t.Errorf("Set(%q) succeeded, want an error", value)
+		}
+	}
+}
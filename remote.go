@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// defaultHTTPTokenEnv names the environment variable holding the bearer
+// token of HTTP(S) inputs
+const defaultHTTPTokenEnv = "MCPJUNIT_HTTP_TOKEN"
+
+// RemoteOptions controls how results are fetched from an HTTP(S) URL; the
+// timeouts, retries, proxy and CA certificates are those of httpClient
+type RemoteOptions struct {
+	// TokenEnv names the environment variable holding a bearer token
+	TokenEnv string
+}
+
+// isURL reports whether an input argument is an HTTP(S) URL rather than a path
+func isURL(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
+}
+
+// loadURL downloads and parses the results at rawURL
+func loadURL(ctx context.Context, rawURL string, opts inputOptions) (converter.TestRun, error) {
+	start := time.Now()
+	data, err := fetchURL(ctx, rawURL, opts.Remote)
+	if err != nil {
+		return converter.TestRun{}, fmt.Errorf("fetching %s: %w", rawURL, err)
+	}
+
+	if opts.Format == converter.FormatAuto {
+		if parsed, err := url.Parse(rawURL); err == nil {
+			opts.Format = inputFormatForFile(parsed.Path)
+		}
+	}
+
+	run, err := converter.ParseContext(ctx, bytes.NewReader(data), opts.ParseOptions)
+	if err != nil {
+		return run, fmt.Errorf("parsing %s: %w", rawURL, err)
+	}
+	run.SetSource(rawURL)
+	logParsed(rawURL, run, start)
+	return run, nil
+}
+
+// fetchURL performs a GET with the shared client, which retries network
+// errors, 429 and 5xx responses
+func fetchURL(ctx context.Context, rawURL string, opts RemoteOptions) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if opts.TokenEnv != "" {
+		if token := os.Getenv(opts.TokenEnv); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestFetchURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retries      int
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{name: "success", statuses: []int{200}, retries: 3, wantAttempts: 1},
+		{name: "retries server errors", statuses: []int{500, 503, 200}, retries: 3, wantAttempts: 3},
+		{name: "retries rate limiting", statuses: []int{429, 200}, retries: 1, wantAttempts: 2},
+		{name: "gives up after retries", statuses: []int{500, 500, 500}, retries: 2, wantErr: true, wantAttempts: 3},
+		{name: "does not retry client errors", statuses: []int{404, 200}, retries: 3, wantErr: true, wantAttempts: 1},
+		{name: "no retries", statuses: []int{500, 200}, retries: 0, wantErr: true, wantAttempts: 1},
+		{name: "negative retries still make one attempt", statuses: []int{200}, retries: -1, wantAttempts: 1},
+		{name: "negative retries report the failure", statuses: []int{500}, retries: -1, wantErr: true, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				status := tt.statuses[min(int(n)-1, len(tt.statuses)-1)]
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte("[" + resultA + "]"))
+				}
+			}))
+			defer server.Close()
+
+			useHTTPClient(t, httpOptions{Timeout: time.Second, Retries: tt.retries})
+			data, err := fetchURL(context.Background(), server.URL+"/results.json", RemoteOptions{})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("fetchURL() succeeded, want an error")
+				}
+			} else if err != nil {
+				t.Fatalf("fetchURL() error = %v", err)
+			} else if !strings.Contains(string(data), `"taskName":"a"`) {
+				t.Errorf("fetchURL() = %q", data)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("made %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestFetchURLBearerToken(t *testing.T) {
+	t.Setenv("TEST_MCPJUNIT_TOKEN", "secret")
+
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	if _, err := fetchURL(context.Background(), server.URL, RemoteOptions{TokenEnv: "TEST_MCPJUNIT_TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+	if got != "Bearer secret" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+	}
+}
+
+func TestFetchURLCancel(t *testing.T) {
+	useHTTPClient(t, httpOptions{Timeout: time.Second, Retries: 3})
+	retryBackoff = time.Hour
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := fetchURL(ctx, server.URL, RemoteOptions{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("fetchURL() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("fetchURL() waited %v for a retry after the deadline", elapsed)
+	}
+}
+
+func TestLoadURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("- taskName: a\n- taskName: b\n"))
+	}))
+	defer server.Close()
+
+	// The format is picked from the URL path extension
+	run, err := loadInput(context.Background(), server.URL+"/run/results.yaml?token=x", inputOptions{ParseOptions: converter.ParseOptions{Format: converter.FormatAuto}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(taskNames(run), ","); got != "a,b" {
+		t.Errorf("task names = %s, want a,b", got)
+	}
+}
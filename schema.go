@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// runSchema implements the schema command, which prints the embedded JSON
+// Schema of a result
+func runSchema(_ context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	version := fs.Int("schema-version", 1, "schema version to print: 1 (camelCase fields) or 2 (snake_case fields)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return newUsageError("schema takes no arguments")
+	}
+
+	schema, err := converter.Schema(*version)
+	if err != nil {
+		return newUsageError("--schema-version must be 1 or 2")
+	}
+	_, err = stdout.Write(schema)
+	return err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSchemaCommand(t *testing.T) {
+	var buf bytes.Buffer
+	previous := stdout
+	stdout = &buf
+	defer func() { stdout = previous }()
+
+	for _, tt := range []struct {
+		args      []string
+		wantTitle string
+	}{
+		{args: []string{"schema"}, wantTitle: "MCPTestResult"},
+		{args: []string{"schema", "--schema-version", "2"}, wantTitle: "MCPTestResult v2"},
+	} {
+		buf.Reset()
+		if err := runCLI(context.Background(), tt.args); err != nil {
+			t.Fatalf("runCLI(%q) error = %v", tt.args, err)
+		}
+		var schema struct {
+			Title string `json:"title"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &schema); err != nil {
+			t.Fatalf("runCLI(%q) printed invalid JSON: %v", tt.args, err)
+		}
+		if schema.Title != tt.wantTitle {
+			t.Errorf("runCLI(%q) printed the schema titled %q, want %q", tt.args, schema.Title, tt.wantTitle)
+		}
+	}
+
+	var usageErr usageError
+	if err := runCLI(context.Background(), []string{"schema", "--schema-version", "3"}); !errors.As(err, &usageErr) {
+		t.Errorf("unknown schema version error = %v, want a usageError", err)
+	}
+}
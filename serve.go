@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+	"google.golang.org/grpc"
+)
+
+// Defaults for the serve subcommand
+const (
+	defaultServeAddr    = ":8080"
+	defaultMaxBodySize  = 32 << 20
+	serveShutdownPeriod = 10 * time.Second
+)
+
+// Response media types offered by POST /convert
+const (
+	mediaTypeXML  = "application/xml"
+	mediaTypeJSON = "application/json"
+)
+
+// runServe implements the serve command, an HTTP server converting
+// results posted to /convert, optionally alongside the gRPC ConverterService
+func runServe(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	addr := fs.String("addr", defaultServeAddr, "address to listen on")
+	grpcAddr := fs.String("grpc-addr", "", "also serve the gRPC ConverterService on this address")
+	maxBodySize := fs.Int64("max-body-size", defaultMaxBodySize, "maximum accepted request body or gRPC stream size in bytes, before and after decompression")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newServeHandler(*maxBodySize),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 2)
+	go func() {
+		slog.Info("listening", "addr", *addr)
+		errCh <- server.ListenAndServe()
+	}()
+
+	var grpcServer *grpc.Server
+	if *grpcAddr != "" {
+		listener, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return err
+		}
+		grpcServer = newGRPCServer(*maxBodySize)
+		go func() {
+			slog.Info("serving gRPC", "addr", *grpcAddr)
+			errCh <- grpcServer.Serve(listener)
+		}()
+	}
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownPeriod)
+	defer cancel()
+	return server.Shutdown(shutdownCtx)
+}
+
+// newServeHandler returns the handler of the serve subcommand
+func newServeHandler(maxBodySize int64) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintln(w, "ok")
+	})
+	mux.HandleFunc("POST /convert", func(w http.ResponseWriter, r *http.Request) {
+		handleConvert(w, r, maxBodySize)
+	})
+	return mux
+}
+
+// handleConvert converts the results in the request body. The input format
+// comes from the "format" query parameter or the Content-Type, "strict" and
+// "lenient" and "group-by" mirror the command-line flags, and the response format is
+// negotiated from the Accept header.
+func handleConvert(w http.ResponseWriter, r *http.Request, maxBodySize int64) {
+	mediaType := negotiateMediaType(r.Header.Get("Accept"))
+	if mediaType == "" {
+		http.Error(w, "supported response types: application/xml, text/xml, application/json", http.StatusNotAcceptable)
+		return
+	}
+
+	query := r.URL.Query()
+	opts := converter.ParseOptions{Format: query.Get("format"), ToolResults: defaultToolResultLimits, MaxBytes: maxBodySize}
+	if opts.Format == "" {
+		opts.Format = inputFormatForContentType(r.Header.Get("Content-Type"))
+	}
+	var err error
+	if opts.Strict, err = queryBool(query.Get("strict")); err != nil {
+		http.Error(w, "invalid strict parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if opts.Lenient, err = queryBool(query.Get("lenient")); err != nil {
+		http.Error(w, "invalid lenient parameter: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	var convertOpts []converter.Option
+	if groupBy := query.Get("group-by"); groupBy != "" {
+		if !slices.Contains(converter.GroupByValues, groupBy) {
+			http.Error(w, "invalid group-by parameter: must be one of "+strings.Join(converter.GroupByValues, ", "), http.StatusBadRequest)
+			return
+		}
+		convertOpts = append(convertOpts, converter.WithGroupBy(groupBy))
+	}
+	conv, err := converter.New(convertOpts...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	run, err := converter.ParseContext(r.Context(), http.MaxBytesReader(w, r.Body, maxBodySize), opts)
+	if err != nil {
+		// Both the body and, for gzip bodies, what it inflates to are capped
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, converter.ErrInputTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Error parsing request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	report, err := conv.ConvertContext(r.Context(), run)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var body []byte
+	if mediaType == mediaTypeJSON {
+		body, err = json.MarshalIndent(report, "", "  ")
+		body = append(body, '\n')
+	} else {
+		body, err = conv.Render(report)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.Header().Set("X-Parse-Errors", strconv.Itoa(len(run.ParseErrors)))
+	w.Write(body)
+}
+
+// negotiateMediaType picks the response type for an Accept header, or returns
+// "" when neither XML nor JSON is acceptable. Explicit types win over
+// wildcards, which select XML; quality values other than q=0 are not ranked.
+func negotiateMediaType(accept string) string {
+	if strings.TrimSpace(accept) == "" {
+		return mediaTypeXML
+	}
+
+	var xmlAccepted, jsonAccepted, wildcard bool
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case "application/xml", "text/xml":
+			xmlAccepted = true
+		case "application/json":
+			jsonAccepted = true
+		case "application/*", "text/*", "*/*":
+			wildcard = true
+		}
+	}
+	switch {
+	case xmlAccepted:
+		return mediaTypeXML
+	case jsonAccepted:
+		return mediaTypeJSON
+	case wildcard:
+		return mediaTypeXML
+	default:
+		return ""
+	}
+}
+
+// inputFormatForContentType maps a request Content-Type to an input format
+func inputFormatForContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines":
+		return converter.FormatNDJSON
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return converter.FormatYAML
+	case "application/xml", "text/xml":
+		return converter.FormatJUnit
+	default:
+		return converter.FormatAuto
+	}
+}
+
+func queryBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
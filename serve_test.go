@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeConvert(t *testing.T) {
+	server := httptest.NewServer(newServeHandler(1 << 10))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		query           string
+		contentType     string
+		accept          string
+		body            string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+		wantParseErrors string
+	}{
+		{
+			name:            "json array to xml",
+			body:            "[" + resultA + "]",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/xml; charset=utf-8",
+			wantBody:        `<testcase name="a" classname="tasks.a" file="/x/tasks/a/task.yaml">`,
+			wantParseErrors: "0",
+		},
+		{
+			name:            "json requested",
+			accept:          "application/json",
+			body:            "[" + resultB + "]",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `"name": "MCP Checker Tests - hard"`,
+		},
+		{
+			name:            "wildcard selects xml",
+			accept:          "text/html, */*;q=0.8",
+			body:            resultA,
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/xml; charset=utf-8",
+		},
+		{
+			name:        "yaml by content type",
+			contentType: "application/yaml",
+			body:        "- taskName: y\n  taskPassed: true\n",
+			wantStatus:  http.StatusOK,
+			wantBody:    `name="y"`,
+		},
+		{
+			name:            "lenient query parameter",
+			query:           "?lenient=true",
+			contentType:     "application/x-ndjson",
+			body:            resultA + "\n{bad\n",
+			wantStatus:      http.StatusOK,
+			wantBody:        `name="parse-error-1"`,
+			wantParseErrors: "1",
+		},
+		{
+			name:       "strict query parameter",
+			query:      "?strict=1",
+			body:       `[{"taskName":"a"}]`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "result 0: $.taskPassed: required field is missing",
+		},
+		{
+			name:       "group-by query parameter",
+			query:      "?group-by=none",
+			body:       "[" + resultA + "," + resultB + "]",
+			wantStatus: http.StatusOK,
+			wantBody:   `<testsuite name="MCP Checker Tests" tests="2"`,
+		},
+		{name: "invalid group-by value", query: "?group-by=owner", body: resultA, wantStatus: http.StatusBadRequest},
+		{name: "invalid strict value", query: "?strict=maybe", body: resultA, wantStatus: http.StatusBadRequest},
+		{name: "unsupported format", query: "?format=csv", body: resultA, wantStatus: http.StatusBadRequest},
+		{name: "empty body", body: "", wantStatus: http.StatusBadRequest, wantBody: "input is empty"},
+		{name: "not acceptable", accept: "text/html", body: resultA, wantStatus: http.StatusNotAcceptable},
+		{name: "too large", body: "[" + strings.Repeat(resultA+",", 20) + resultA + "]", wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "gzip body", body: gzipped(t, "["+resultA+"]"), wantStatus: http.StatusOK, wantBody: `<testcase name="a"`},
+		// A few hundred bytes inflating to 256 KiB
+		{name: "gzip bomb", body: gzipped(t, "["+strings.Repeat(" ", 256<<10)+resultA+"]"), wantStatus: http.StatusRequestEntityTooLarge, wantBody: "input too large"},
+		{
+			name:        "lenient gzip bomb",
+			query:       "?lenient=true",
+			contentType: "application/x-ndjson",
+			body:        gzipped(t, resultA+"\n"+strings.Repeat("\n", 256<<10)),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "yaml gzip bomb",
+			contentType: "application/yaml",
+			body:        gzipped(t, "- taskName: y\n  taskPassed: true\n"+strings.Repeat("#\n", 128<<10)),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/convert"+tt.query, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantContentType != "" && resp.Header.Get("Content-Type") != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", resp.Header.Get("Content-Type"), tt.wantContentType)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body does not contain %q:\n%s", tt.wantBody, body)
+			}
+			if tt.wantParseErrors != "" && resp.Header.Get("X-Parse-Errors") != tt.wantParseErrors {
+				t.Errorf("X-Parse-Errors = %q, want %q", resp.Header.Get("X-Parse-Errors"), tt.wantParseErrors)
+			}
+			if tt.wantContentType == "application/json; charset=utf-8" && !json.Valid(body) {
+				t.Errorf("body is not valid JSON:\n%s", body)
+			}
+		})
+	}
+}
+
+func TestServeHealthz(t *testing.T) {
+	server := httptest.NewServer(newServeHandler(defaultMaxBodySize))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /healthz status = %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/convert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /convert status = %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestNegotiateMediaType(t *testing.T) {
+	tests := map[string]string{
+		"":                                      mediaTypeXML,
+		"application/json":                      mediaTypeJSON,
+		"application/json, */*":                 mediaTypeJSON,
+		"text/xml, application/json":            mediaTypeXML,
+		"application/xml;q=0, application/json": mediaTypeJSON,
+		"*/*":                                   mediaTypeXML,
+		"text/html":                             "",
+	}
+	for accept, want := range tests {
+		if got := negotiateMediaType(accept); got != want {
+			t.Errorf("negotiateMediaType(%q) = %q, want %q", accept, got, want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !js
+
+package main
+
+// The history database is SQLite, through a pure Go driver that needs no
+// cgo, so that release builds with CGO_ENABLED=0 keep the history command.
+// The driver does not build for WebAssembly, where opening a history
+// database fails with an unknown driver error.
+import _ "modernc.org/sqlite"
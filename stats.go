@@ -0,0 +1,397 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// callStats counts calls, or reads, and how many of them succeeded
+type callStats struct {
+	Calls     int     `json:"calls"`
+	Succeeded int     `json:"succeeded"`
+	Success   float64 `json:"successRate"`
+}
+
+func (s *callStats) add(success bool) {
+	s.Calls++
+	if success {
+		s.Succeeded++
+	}
+	s.Success = float64(s.Succeeded) / float64(s.Calls)
+}
+
+// serverStats aggregates the calls and resource reads of one MCP server
+type serverStats struct {
+	Server        string    `json:"server"`
+	ToolCalls     callStats `json:"toolCalls"`
+	ResourceReads callStats `json:"resourceReads"`
+}
+
+// toolStats aggregates the calls of one tool and the outcome of the tasks
+// that called it
+type toolStats struct {
+	Server string `json:"server"`
+	Tool   string `json:"tool"`
+	callStats
+	// Tasks and FailedTasks count the tasks that called the tool at least
+	// once, and how many of them failed or errored
+	Tasks       int     `json:"tasks"`
+	FailedTasks int     `json:"failedTasks"`
+	FailureRate float64 `json:"taskFailureRate"`
+	// Lift compares FailureRate to the failure rate of all tasks: above 1,
+	// tasks calling the tool fail more often than the others
+	Lift float64 `json:"lift"`
+}
+
+// resourceStats aggregates the resource reads under one URI prefix
+type resourceStats struct {
+	Prefix string `json:"prefix"`
+	callStats
+}
+
+// phaseStats breaks down the time the tasks of one difficulty level spent
+// in each phase, over the tasks that reported phase durations
+type phaseStats struct {
+	Difficulty string `json:"difficulty"`
+	Tasks      int    `json:"tasks"`
+	// MeanMs is the mean duration of each phase per task, in milliseconds
+	MeanMs map[string]float64 `json:"meanMs"`
+	// Dominant is the phase the tasks spent the most time in, and Share
+	// its part of their total time
+	Dominant string  `json:"dominantPhase"`
+	Share    float64 `json:"dominantShare"`
+}
+
+// scoreboardEntry aggregates the tool calls of one MCP server, or of one of
+// its tools, over the runs of the history
+type scoreboardEntry struct {
+	Server string `json:"server"`
+	Tool   string `json:"tool,omitempty"`
+	// Runs counts the runs that called the server or tool
+	Runs int `json:"runs"`
+	callStats
+}
+
+// scoreboard ranks servers and tools by the success rate of their tool
+// calls over the last runs of the history
+type scoreboard struct {
+	Runs    int               `json:"runs"`
+	Servers []scoreboardEntry `json:"servers"`
+	Tools   []scoreboardEntry `json:"tools"`
+}
+
+// rank computes the success rates and sorts the servers and tools from the
+// least to the most reliable, the most called first when tied
+func (b *scoreboard) rank() {
+	for _, entries := range [][]scoreboardEntry{b.Servers, b.Tools} {
+		for i := range entries {
+			if entries[i].Calls > 0 {
+				entries[i].Success = float64(entries[i].Succeeded) / float64(entries[i].Calls)
+			}
+		}
+		slices.SortFunc(entries, func(a, b scoreboardEntry) int {
+			return cmp.Or(cmp.Compare(a.Success, b.Success), cmp.Compare(b.Calls, a.Calls),
+				cmp.Compare(a.Server, b.Server), cmp.Compare(a.Tool, b.Tool))
+		})
+	}
+}
+
+// callHistoryStats is the output of the stats command
+type callHistoryStats struct {
+	Tasks       int             `json:"tasks"`
+	FailedTasks int             `json:"failedTasks"`
+	Servers     []serverStats   `json:"servers"`
+	Tools       []toolStats     `json:"tools"`
+	Resources   []resourceStats `json:"resources"`
+	Phases      []phaseStats    `json:"phases"`
+	// History is the scoreboard of the history database given with --db
+	History *scoreboard `json:"history,omitempty"`
+}
+
+// runStats implements the stats command
+func runStats(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	asJSON := fs.Bool("json", false, "print the statistics as JSON")
+	dbPath := fs.String("db", "", "history database, as written by history add, to add a scoreboard of the servers and tools over its runs")
+	last := fs.Int("last", defaultHistoryRuns, "number of latest runs of the history the scoreboard covers")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *last < 1 {
+		return newUsageError("--last must be at least 1")
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	run, err := loadInputs(ctx, fs.Args(), opts)
+	if err != nil {
+		return err
+	}
+
+	conv, err := converter.New(converter.WithoutSystemOut(), converter.WithoutSystemErr())
+	if err != nil {
+		return err
+	}
+	stats := computeStats(run, conv)
+	if *dbPath != "" {
+		db, err := openHistory(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		board, err := historyCalls(ctx, db, *last)
+		if err != nil {
+			return err
+		}
+		stats.History = &board
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(stats)
+	}
+	printStats(stdout, stats)
+	return nil
+}
+
+// computeStats aggregates the call history of every result, with conv
+// deciding which tasks failed. Servers and tools are sorted by number of
+// calls, resources by number of reads.
+func computeStats(run converter.TestRun, conv *converter.Converter) callHistoryStats {
+	stats := callHistoryStats{Servers: []serverStats{}, Tools: []toolStats{}, Resources: []resourceStats{}, Phases: []phaseStats{}}
+	servers := make(map[string]*serverStats)
+	phases := make(map[string]*phaseStats)
+	tools := make(map[[2]string]*toolStats)
+	resources := make(map[string]*resourceStats)
+
+	server := func(name string) *serverStats {
+		if servers[name] == nil {
+			servers[name] = &serverStats{Server: name}
+		}
+		return servers[name]
+	}
+
+	for _, result := range run.Results {
+		if result.ParseError() != nil {
+			continue
+		}
+		testCase := conv.ConvertResult(result)
+		failed := testCase.Failure != nil || testCase.Error != nil
+		stats.Tasks++
+		if failed {
+			stats.FailedTasks++
+		}
+
+		used := make(map[[2]string]bool)
+		for _, call := range result.CallHistory.ToolCalls {
+			server(call.ServerName).ToolCalls.add(call.Success)
+			key := [2]string{call.ServerName, call.Name}
+			if tools[key] == nil {
+				tools[key] = &toolStats{Server: call.ServerName, Tool: call.Name}
+			}
+			tools[key].add(call.Success)
+			if !used[key] {
+				used[key] = true
+				tools[key].Tasks++
+				if failed {
+					tools[key].FailedTasks++
+				}
+			}
+		}
+		for _, read := range result.CallHistory.ResourceReads {
+			server(read.ServerName).ResourceReads.add(read.Success)
+			prefix := uriPrefix(read.URI)
+			if resources[prefix] == nil {
+				resources[prefix] = &resourceStats{Prefix: prefix}
+			}
+			resources[prefix].add(read.Success)
+		}
+
+		if durations := result.PhaseDurations(); len(durations) > 0 {
+			difficulty := cmp.Or(result.Difficulty, converter.UnknownGroup)
+			if phases[difficulty] == nil {
+				phases[difficulty] = &phaseStats{Difficulty: difficulty, MeanMs: make(map[string]float64)}
+			}
+			phases[difficulty].Tasks++
+			for phase, ms := range durations {
+				phases[difficulty].MeanMs[phase] += ms
+			}
+		}
+	}
+
+	overallFailureRate := 0.0
+	if stats.Tasks > 0 {
+		overallFailureRate = float64(stats.FailedTasks) / float64(stats.Tasks)
+	}
+	for _, s := range servers {
+		stats.Servers = append(stats.Servers, *s)
+	}
+	for _, t := range tools {
+		t.FailureRate = float64(t.FailedTasks) / float64(t.Tasks)
+		if overallFailureRate > 0 {
+			t.Lift = t.FailureRate / overallFailureRate
+		}
+		stats.Tools = append(stats.Tools, *t)
+	}
+	for _, r := range resources {
+		stats.Resources = append(stats.Resources, *r)
+	}
+	for _, p := range phases {
+		// MeanMs holds the totals until now
+		var total float64
+		for _, phase := range converter.Phases {
+			total += p.MeanMs[phase]
+			if p.MeanMs[phase] > p.MeanMs[p.Dominant] {
+				p.Dominant = phase
+			}
+		}
+		p.Share = p.MeanMs[p.Dominant] / total
+		for phase := range p.MeanMs {
+			p.MeanMs[phase] /= float64(p.Tasks)
+		}
+		stats.Phases = append(stats.Phases, *p)
+	}
+
+	slices.SortFunc(stats.Servers, func(a, b serverStats) int {
+		return cmp.Or(cmp.Compare(b.ToolCalls.Calls+b.ResourceReads.Calls, a.ToolCalls.Calls+a.ResourceReads.Calls), cmp.Compare(a.Server, b.Server))
+	})
+	slices.SortFunc(stats.Tools, func(a, b toolStats) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), cmp.Compare(a.Server, b.Server), cmp.Compare(a.Tool, b.Tool))
+	})
+	slices.SortFunc(stats.Resources, func(a, b resourceStats) int {
+		return cmp.Or(cmp.Compare(b.Calls, a.Calls), cmp.Compare(a.Prefix, b.Prefix))
+	})
+	slices.SortFunc(stats.Phases, func(a, b phaseStats) int {
+		return converter.CompareDifficulty(a.Difficulty, b.Difficulty)
+	})
+	return stats
+}
+
+// uriPrefix groups resource URIs by scheme and host, e.g. "k8s://pods" for
+// "k8s://pods/default/web", or by first path segment for URIs without a host
+func uriPrefix(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || uri == "" {
+		return uri
+	}
+	if parsed.Host != "" {
+		return parsed.Scheme + "://" + parsed.Host
+	}
+	path := strings.TrimPrefix(parsed.Opaque+parsed.Path, "/")
+	first, _, _ := strings.Cut(path, "/")
+	if parsed.Scheme != "" {
+		return parsed.Scheme + ":" + first
+	}
+	return first
+}
+
+// printStats writes the statistics as tables
+func printStats(w io.Writer, stats callHistoryStats) {
+	percent := func(s callStats) string {
+		if s.Calls == 0 {
+			return "-"
+		}
+		return fmt.Sprintf("%.1f%%", s.Success*100)
+	}
+	fmt.Fprintf(w, "Tasks: %d, failed: %d\n", stats.Tasks, stats.FailedTasks)
+
+	fmt.Fprintln(w, "\nServers:")
+	rows := [][]cell{{{text: "Server"}, {text: "Tool calls"}, {text: "Success"}, {text: "Resource reads"}, {text: "Success"}}}
+	for _, s := range stats.Servers {
+		rows = append(rows, []cell{{text: s.Server}, {text: fmt.Sprint(s.ToolCalls.Calls)}, {text: percent(s.ToolCalls)},
+			{text: fmt.Sprint(s.ResourceReads.Calls)}, {text: percent(s.ResourceReads)}})
+	}
+	writeTable(w, rows, false, true)
+
+	fmt.Fprintln(w, "\nTools:")
+	rows = [][]cell{{{text: "Tool"}, {text: "Calls"}, {text: "Success"}, {text: "Tasks"}, {text: "Failed tasks"}, {text: "Lift"}}}
+	for _, t := range stats.Tools {
+		rows = append(rows, []cell{{text: t.Server + "::" + t.Tool}, {text: fmt.Sprint(t.Calls)}, {text: percent(t.callStats)},
+			{text: fmt.Sprint(t.Tasks)}, {text: fmt.Sprint(t.FailedTasks)}, {text: fmt.Sprintf("%.2f", t.Lift)}})
+	}
+	writeTable(w, rows, false, true)
+
+	if len(stats.Resources) > 0 {
+		fmt.Fprintln(w, "\nResources:")
+		rows = [][]cell{{{text: "URI prefix"}, {text: "Reads"}, {text: "Success"}}}
+		for _, r := range stats.Resources {
+			rows = append(rows, []cell{{text: r.Prefix}, {text: fmt.Sprint(r.Calls)}, {text: percent(r.callStats)}})
+		}
+		writeTable(w, rows, false, true)
+	}
+
+	if len(stats.Phases) > 0 {
+		fmt.Fprintln(w, "\nPhase durations (mean per task):")
+		rows = [][]cell{{{text: "Difficulty"}, {text: "Tasks"}}}
+		for _, phase := range converter.Phases {
+			rows[0] = append(rows[0], cell{text: strings.ToUpper(phase[:1]) + phase[1:]})
+		}
+		rows[0] = append(rows[0], cell{text: "Dominant"})
+		for _, p := range stats.Phases {
+			row := []cell{{text: p.Difficulty}, {text: fmt.Sprint(p.Tasks)}}
+			for _, phase := range converter.Phases {
+				mean := "-"
+				if ms, ok := p.MeanMs[phase]; ok {
+					mean = time.Duration(ms * float64(time.Millisecond)).Round(time.Millisecond).String()
+				}
+				row = append(row, cell{text: mean})
+			}
+			rows = append(rows, append(row, cell{text: fmt.Sprintf("%s (%.1f%%)", p.Dominant, p.Share*100)}))
+		}
+		writeTable(w, rows, false, true)
+	}
+
+	if stats.History != nil {
+		printScoreboard(w, *stats.History)
+	}
+
+	var correlated []toolStats
+	for _, t := range stats.Tools {
+		if t.FailedTasks > 0 {
+			correlated = append(correlated, t)
+		}
+	}
+	if len(correlated) == 0 {
+		return
+	}
+	slices.SortStableFunc(correlated, func(a, b toolStats) int {
+		return cmp.Or(cmp.Compare(b.Lift, a.Lift), cmp.Compare(b.FailedTasks, a.FailedTasks))
+	})
+	fmt.Fprintln(w, "\nTools most correlated with failed tasks:")
+	rows = [][]cell{{{text: "Tool"}, {text: "Failed tasks"}, {text: "Task failure rate"}, {text: "Lift"}}}
+	for _, t := range correlated {
+		rows = append(rows, []cell{{text: t.Server + "::" + t.Tool}, {text: fmt.Sprintf("%d/%d", t.FailedTasks, t.Tasks)},
+			{text: fmt.Sprintf("%.1f%%", t.FailureRate*100)}, {text: fmt.Sprintf("%.2f", t.Lift)}})
+	}
+	writeTable(w, rows, false, true)
+}
+
+// printScoreboard writes the servers and tools of board, the least reliable first
+func printScoreboard(w io.Writer, board scoreboard) {
+	fmt.Fprintf(w, "\nScoreboard over the last %d runs:\n", board.Runs)
+	rows := [][]cell{{{text: "Server"}, {text: "Runs"}, {text: "Tool calls"}, {text: "Success"}}}
+	for _, s := range board.Servers {
+		rows = append(rows, []cell{{text: s.Server}, {text: fmt.Sprint(s.Runs)}, {text: fmt.Sprint(s.Calls)},
+			{text: fmt.Sprintf("%.1f%%", s.Success*100)}})
+	}
+	writeTable(w, rows, false, true)
+	fmt.Fprintln(w)
+	rows = [][]cell{{{text: "Tool"}, {text: "Runs"}, {text: "Calls"}, {text: "Success"}}}
+	for _, t := range board.Tools {
+		rows = append(rows, []cell{{text: t.Server + "::" + t.Tool}, {text: fmt.Sprint(t.Runs)}, {text: fmt.Sprint(t.Calls)},
+			{text: fmt.Sprintf("%.1f%%", t.Success*100)}})
+	}
+	writeTable(w, rows, false, true)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const statsResults = `[
+	{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"callHistory":{
+		"ToolCalls":[{"serverName":"kube","name":"pods_list","success":true},{"serverName":"kube","name":"pods_list","success":true}],
+		"ResourceReads":[{"serverName":"kube","uri":"k8s://pods/default/web","success":true}]}},
+	{"taskName":"b","taskPassed":false,"callHistory":{
+		"ToolCalls":[{"serverName":"kube","name":"pods_delete","success":false},{"serverName":"kube","name":"pods_list","success":true}],
+		"ResourceReads":[{"serverName":"docs","uri":"file:///docs/a.md","success":false},{"serverName":"kube","uri":"k8s://pods/default/db","success":false}]}}
+]`
+
+func TestComputeStats(t *testing.T) {
+	stats := computeStats(mustParse(t, statsResults), mustNew(t))
+	if stats.Tasks != 2 || stats.FailedTasks != 1 {
+		t.Errorf("tasks = %d, failed = %d, want 2, 1", stats.Tasks, stats.FailedTasks)
+	}
+
+	if len(stats.Servers) != 2 || stats.Servers[0].Server != "kube" {
+		t.Fatalf("servers = %+v, want kube first", stats.Servers)
+	}
+	kube := stats.Servers[0]
+	if kube.ToolCalls != (callStats{Calls: 4, Succeeded: 3, Success: 0.75}) || kube.ResourceReads.Calls != 2 {
+		t.Errorf("kube = %+v", kube)
+	}
+
+	if len(stats.Tools) != 2 {
+		t.Fatalf("tools = %+v, want 2", stats.Tools)
+	}
+	list, del := stats.Tools[0], stats.Tools[1]
+	if list.Tool != "pods_list" || list.Calls != 3 || list.Tasks != 2 || list.FailedTasks != 1 || list.Lift != 1 {
+		t.Errorf("pods_list = %+v", list)
+	}
+	if del.Tool != "pods_delete" || del.Success != 0 || del.FailureRate != 1 || del.Lift != 2 {
+		t.Errorf("pods_delete = %+v", del)
+	}
+
+	var prefixes []string
+	for _, r := range stats.Resources {
+		prefixes = append(prefixes, r.Prefix)
+	}
+	if got := strings.Join(prefixes, ","); got != "k8s://pods,file:docs" {
+		t.Errorf("resource prefixes = %s, want k8s://pods,file:docs", got)
+	}
+}
+
+func TestURIPrefix(t *testing.T) {
+	for uri, want := range map[string]string{
+		"k8s://pods/default/web": "k8s://pods",
+		"file:///docs/a.md":      "file:docs",
+		"urn:isbn:0451450523":    "urn:isbn:0451450523",
+		"notes/today.txt":        "notes",
+		"":                       "",
+	} {
+		if got := uriPrefix(uri); got != want {
+			t.Errorf("uriPrefix(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	var out bytes.Buffer
+	printStats(&out, computeStats(mustParse(t, statsResults), mustNew(t)))
+	for _, want := range []string{
+		"Tasks: 2, failed: 1\n",
+		"  docs             0        -               1     0.0%\n",
+		"  kube::pods_delete      1     0.0%      1             1  2.00\n",
+		"Tools most correlated with failed tasks:\n  Tool               Failed tasks  Task failure rate  Lift\n  kube::pods_delete           1/1             100.0%  2.00\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("stats do not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestStatsPhaseDurations(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"a","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true,
+		 "setupOutput":{"Success":true,"DurationMs":1000},"agentOutput":{"Success":true,"DurationMs":8000},"verifyOutput":{"Success":true,"DurationMs":1000}},
+		{"taskName":"b","difficulty":"hard","taskPassed":true,"allAssertionsPassed":true,
+		 "setupOutput":{"Success":true,"DurationMs":3000},"agentOutput":{"Success":true,"DurationMs":4000}},
+		{"taskName":"c","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true,
+		 "setupOutput":{"Success":true,"DurationMs":6000},"agentOutput":{"Success":true,"DurationMs":2000}},
+		{"taskName":"d","difficulty":"easy","taskPassed":true,"allAssertionsPassed":true}
+	]`)
+	stats := computeStats(run, mustNew(t))
+	want := []phaseStats{
+		{Difficulty: "easy", Tasks: 1, MeanMs: map[string]float64{"setup": 6000, "agent": 2000}, Dominant: "setup", Share: 0.75},
+		{Difficulty: "hard", Tasks: 2, MeanMs: map[string]float64{"setup": 2000, "agent": 6000, "verify": 500}, Dominant: "agent", Share: 12.0 / 17},
+	}
+	if !reflect.DeepEqual(stats.Phases, want) {
+		t.Errorf("phases = %+v, want %+v", stats.Phases, want)
+	}
+
+	var out bytes.Buffer
+	printStats(&out, stats)
+	for _, want := range []string{
+		"Phase durations (mean per task):\n",
+		"  Difficulty  Tasks  Setup  Agent  Verify  Cleanup       Dominant\n",
+		"  easy            1     6s     2s       -        -  setup (75.0%)\n",
+		"  hard            2     2s     6s   500ms        -  agent (70.6%)\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("stats do not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestStatsScoreboard(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+
+	dir := t.TempDir()
+	db := filepath.Join(dir, "runs.db")
+	runs := []string{
+		`[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"callHistory":{"ToolCalls":[
+			{"serverName":"kube","name":"pods_list","success":true},{"serverName":"docs","name":"search","success":true}]}}]`,
+		`[{"taskName":"a","taskPassed":false,"callHistory":{"ToolCalls":[
+			{"serverName":"kube","name":"pods_list","success":false},{"serverName":"kube","name":"pods_delete","success":false},
+			{"serverName":"kube","name":"pods_list","success":true}]}}]`,
+	}
+	path := filepath.Join(dir, "results.json")
+	for i, run := range runs {
+		if err := os.WriteFile(path, []byte(run), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := runCLI(context.Background(), []string{"history", "add", "--db", db, path}); err != nil {
+			t.Fatalf("history add of run %d: %v", i+1, err)
+		}
+	}
+
+	if err := runCLI(context.Background(), []string{"stats", "--db", db, "--json", path}); err != nil {
+		t.Fatal(err)
+	}
+	var stats callHistoryStats
+	if err := json.Unmarshal(out.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	want := &scoreboard{Runs: 2,
+		Servers: []scoreboardEntry{
+			{Server: "kube", Runs: 2, callStats: callStats{Calls: 4, Succeeded: 2, Success: 0.5}},
+			{Server: "docs", Runs: 1, callStats: callStats{Calls: 1, Succeeded: 1, Success: 1}},
+		},
+		Tools: []scoreboardEntry{
+			{Server: "kube", Tool: "pods_delete", Runs: 1, callStats: callStats{Calls: 1}},
+			{Server: "kube", Tool: "pods_list", Runs: 2, callStats: callStats{Calls: 3, Succeeded: 2, Success: 2.0 / 3}},
+			{Server: "docs", Tool: "search", Runs: 1, callStats: callStats{Calls: 1, Succeeded: 1, Success: 1}},
+		},
+	}
+	if !reflect.DeepEqual(stats.History, want) {
+		t.Errorf("history = %+v, want %+v", stats.History, want)
+	}
+
+	out.Reset()
+	if err := runCLI(context.Background(), []string{"stats", "--db", db, "--last", "1", path}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Scoreboard over the last 1 runs:\n  Server  Runs  Tool calls  Success\n  kube       1           3    33.3%\n\n",
+		"  kube::pods_delete     1      1     0.0%\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("stats do not contain %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "docs::search") {
+		t.Errorf("stats over the last run contain docs::search:\n%s", out.String())
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// streamFormats lists the input formats a ResultIterator reads
+var streamFormats = []string{converter.FormatAuto, converter.FormatJSON, converter.FormatNDJSON}
+
+// streamable reports whether convert can stream the inputs result by
+// result: a single local results file, or stdin, in JSON, with neither the
+// parse nor the conversion options that need every result at once
+func streamable(inputs []string, opts inputOptions, conv *converter.Converter) bool {
+	if len(inputs) > 1 || opts.Strict || opts.Lenient || !conv.Streamable() {
+		return false
+	}
+	format := opts.Format
+	if len(inputs) == 1 && inputs[0] != "-" {
+		input := inputs[0]
+		if isURL(input) || isCloudURI(input) || isArchive(input) {
+			return false
+		}
+		// Directories and missing files are left to the buffered path
+		if info, err := os.Stat(input); err != nil || info.IsDir() {
+			return false
+		}
+		if format == converter.FormatAuto {
+			format = inputFormatForFile(input)
+		}
+	}
+	return slices.Contains(streamFormats, format)
+}
+
+// convertStream converts the results of a single input, or stdin, as they
+// are read, writing the report to output, so that the memory it takes does
+// not grow with the input. Inputs that turn out to be JUnit XML are
+// converted the buffered way.
+func convertStream(ctx context.Context, inputs []string, output string, opts inputOptions, conv *converter.Converter) (converter.JUnitTestSuites, error) {
+	start := time.Now()
+	source, input := "stdin", io.Reader(os.Stdin)
+	if len(inputs) == 1 && inputs[0] != "-" {
+		file, err := os.Open(inputs[0])
+		if err != nil {
+			return converter.JUnitTestSuites{}, inputError{err: fmt.Errorf("opening file %s: %w", inputs[0], err)}
+		}
+		defer file.Close()
+		source, input = inputs[0], file
+	}
+
+	reader, err := converter.MaybeDecompress(bufio.NewReader(input))
+	if err != nil {
+		return converter.JUnitTestSuites{}, inputError{err: fmt.Errorf("parsing %s: %w", source, err)}
+	}
+	if format, err := converter.DetectFormat(reader); err == nil && format == converter.FormatJUnit {
+		run, err := converter.ParseContext(ctx, reader, opts.ParseOptions)
+		if err != nil {
+			return converter.JUnitTestSuites{}, inputError{err: fmt.Errorf("parsing %s: %w", source, err)}
+		}
+		run.SetSource(source)
+		logParsed(source, run, start)
+		if err := checkResults(run, opts); err != nil {
+			return converter.JUnitTestSuites{}, err
+		}
+		return writeReport(ctx, run, output, conv)
+	}
+
+	iterator := converter.NewResultIterator(reader)
+	iterator.SetToolResultLimits(opts.ToolResults)
+	results := &sourceResults{ResultIterator: iterator, source: source, opts: opts, start: start}
+	var junitXML converter.JUnitTestSuites
+	err = writeOutputWith(ctx, output, func(w io.Writer) error {
+		var err error
+		junitXML, err = conv.ConvertStream(ctx, results, w)
+		return err
+	})
+	return junitXML, err
+}
+
+// sourceResults iterates over the results of an input, recording it as
+// their source and failing on an input without results, like loadInput and
+// checkResults do for parsed inputs
+type sourceResults struct {
+	*converter.ResultIterator
+	source string
+	opts   inputOptions
+	start  time.Time
+	count  int
+}
+
+// Next returns the next result of the input
+func (r *sourceResults) Next() (converter.MCPTestResult, error) {
+	result, err := r.ResultIterator.Next()
+	if errors.Is(err, converter.ErrEmptyInput) && r.opts.AllowEmpty {
+		err = io.EOF
+	}
+	if err == io.EOF && r.count == 0 {
+		if !r.opts.AllowEmpty {
+			return result, errNoResults
+		}
+		slog.Warn("the input has no results, writing an empty report")
+	}
+	if err == io.EOF {
+		slog.Debug("parsed input", "input", r.source, "results", r.count, "duration", time.Since(r.start))
+		return result, err
+	} else if err != nil {
+		return result, inputError{err: fmt.Errorf("parsing %s: %w", r.source, err)}
+	}
+	r.count++
+	if result.SourceFile == "" {
+		result.SourceFile = r.source
+	}
+	return result, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestConvertStreamMatchesBuffered(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name  string
+		input string
+		args  []string
+	}{
+		{name: "envelope", input: write("envelope.json", `{"results":[`+resultB+`,`+resultA+`],"runId":"run-1"}`), args: []string{"--group-by", "file"}},
+		{name: "json lines", input: write("results.jsonl", resultA+"\n"+resultB+"\n"+resultA+"\n"), args: []string{"--on-duplicate", "merge"}},
+		{name: "junit xml without an extension", input: write("results", `<testsuites><testsuite name="legacy" tests="1"><testcase name="x"/></testsuite></testsuites>`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			convert := func(args ...string) []byte {
+				t.Helper()
+				output := filepath.Join(t.TempDir(), "report.xml")
+				args = append(append(args, tt.args...), "--output", output, tt.input)
+				if err := runCLI(context.Background(), args); err != nil {
+					t.Fatal(err)
+				}
+				data, err := os.ReadFile(output)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return data
+			}
+			if got, want := convert(), convert("--buffered"); !bytes.Equal(got, want) {
+				t.Errorf("streamed report differs from the buffered one\ngot:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+
+	// Gates see the outcomes of the streamed report
+	err := runCLI(context.Background(), []string{"--fail-on", "errors", "--output", filepath.Join(dir, "report.xml"), filepath.Join(dir, "envelope.json")})
+	var gateErr gateError
+	if !errors.As(err, &gateErr) {
+		t.Errorf("err = %v, want a gate error", err)
+	}
+
+	// An input without results fails before anything is written
+	output := filepath.Join(dir, "empty.xml")
+	if err := runCLI(context.Background(), []string{"--output", output, write("empty.json", "[]")}); !errors.Is(err, errNoResults) {
+		t.Errorf("err = %v, want %v", err, errNoResults)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("report of an empty input was written: %v", err)
+	}
+	if err := runCLI(context.Background(), []string{"--allow-empty", "--output", output, write("blank.json", "\n")}); err != nil {
+		t.Errorf("converting an empty input with --allow-empty: %v", err)
+	}
+}
+
+func TestStreamable(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "results.json")
+	xmlFile := filepath.Join(dir, "results.xml")
+	for _, path := range []string{file, xmlFile} {
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conv := mustNew(t)
+	tests := []struct {
+		name   string
+		inputs []string
+		opts   converter.ParseOptions
+		conv   *converter.Converter
+		want   bool
+	}{
+		{name: "stdin", want: true},
+		{name: "json file", inputs: []string{file}, want: true},
+		{name: "several files", inputs: []string{file, file}},
+		{name: "directory", inputs: []string{dir}},
+		{name: "missing file", inputs: []string{filepath.Join(dir, "missing.json")}},
+		{name: "url", inputs: []string{"https://example.com/results.json"}},
+		{name: "archive", inputs: []string{filepath.Join(dir, "results.tar.gz")}},
+		{name: "yaml stdin", opts: converter.ParseOptions{Format: converter.FormatYAML}},
+		{name: "junit file", inputs: []string{xmlFile}},
+		{name: "strict", inputs: []string{file}, opts: converter.ParseOptions{Strict: true}},
+		{name: "lenient", opts: converter.ParseOptions{Lenient: true}},
+		{name: "nested suites", inputs: []string{file}, conv: mustNew(t, converter.WithNestedSuites())},
+		{name: "schema check", inputs: []string{file}, conv: mustNew(t, converter.WithCheck())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.Format == "" {
+				tt.opts.Format = converter.FormatAuto
+			}
+			if tt.conv == nil {
+				tt.conv = conv
+			}
+			if got := streamable(tt.inputs, inputOptions{ParseOptions: tt.opts}, tt.conv); got != tt.want {
+				t.Errorf("streamable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
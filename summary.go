@@ -0,0 +1,262 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// runSummary implements the summary command
+func runSummary(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+
+	run, err := loadInputs(ctx, fs.Args(), opts)
+	if err != nil {
+		return err
+	}
+	return printSummary(stdout, run, useColor(colorMode, stdout))
+}
+
+// summaryRow holds the counts of one difficulty level, or of all tasks
+type summaryRow struct {
+	name                                    string
+	tests, passed, failed, errored, skipped int
+	// tokens and cost add up the LLM usage the results recorded
+	tokens int
+	cost   float64
+}
+
+func (r *summaryRow) add(testCase converter.JUnitTestCase) {
+	r.tests++
+	switch {
+	case testCase.Error != nil:
+		r.errored++
+	case testCase.Failure != nil:
+		r.failed++
+	case testCase.Skipped != nil:
+		r.skipped++
+	default:
+		r.passed++
+	}
+}
+
+// addUsage adds the token usage and cost of result
+func (r *summaryRow) addUsage(result converter.MCPTestResult) {
+	if result.TokenUsage != nil {
+		r.tokens += result.TokenUsage.Sum()
+	}
+	r.cost += result.CostUSD
+}
+
+// passRate is the share of the tasks that ran, leaving out skipped ones, that passed
+func (r summaryRow) passRate() float64 {
+	if r.tests == r.skipped {
+		return 0
+	}
+	return float64(r.passed) / float64(r.tests-r.skipped)
+}
+
+// cell is a table cell with an optional ANSI color
+type cell struct {
+	text, color string
+}
+
+// writeTable writes rows of cells in aligned columns separated by two
+// spaces. The first column is aligned left, and so are the others unless
+// alignRight is set. Colors are applied after padding so that they do not
+// affect the alignment.
+func writeTable(w io.Writer, rows [][]cell, color, alignRight bool) {
+	var widths []int
+	for _, row := range rows {
+		for i, c := range row {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[i] = max(widths[i], utf8.RuneCountInString(c.text))
+		}
+	}
+	for _, row := range rows {
+		var line strings.Builder
+		for i, c := range row {
+			padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c.text))
+			text := c.text
+			if color && c.color != "" {
+				text = c.color + text + ansiReset
+			}
+			if i > 0 && alignRight {
+				line.WriteString("  " + padding + text)
+			} else {
+				line.WriteString("  " + text + padding)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(line.String(), " "))
+	}
+}
+
+// groupNameTemplate names each suite after its difficulty level alone
+var groupNameTemplate = template.Must(converter.ParseNameTemplate("group", "{{.Group}}"))
+
+// printSummary writes a table of the results per difficulty level, followed
+// by the failing tasks with their failed assertions or error, and the
+// assertions that failed in the most tasks
+func printSummary(w io.Writer, run converter.TestRun, color bool) error {
+	conv, err := converter.New(converter.WithSuiteNameTemplate(groupNameTemplate), converter.WithRedactions(),
+		converter.WithoutSystemOut(), converter.WithoutSystemErr())
+	if err != nil {
+		return err
+	}
+	report, err := conv.Convert(run)
+	if err != nil {
+		return err
+	}
+	results := make(map[string]converter.MCPTestResult, len(run.Results))
+	for _, result := range run.Results {
+		results[result.TaskName] = result
+	}
+
+	if environment := run.Environment.Properties(); len(environment) > 0 {
+		settings := make([]string, len(environment))
+		for i, property := range environment {
+			settings[i] = property.Name + "=" + property.Value
+		}
+		fmt.Fprintf(w, "Environment: %s\n\n", strings.Join(settings, " "))
+	}
+
+	// The usage columns are only shown for results that record it
+	usage := slices.ContainsFunc(run.Results, func(result converter.MCPTestResult) bool {
+		return result.TokenUsage != nil || result.CostUSD > 0
+	})
+	rows := [][]cell{{
+		{text: "Difficulty", color: ansiBold}, {text: "Tests"}, {text: "Passed"},
+		{text: "Failed"}, {text: "Errors"}, {text: "Skipped"}, {text: "Pass rate"},
+	}}
+	if usage {
+		rows[0] = append(rows[0], cell{text: "Tokens"}, cell{text: "Cost"})
+	}
+	total := summaryRow{name: "Total"}
+	var failing [][]cell
+	// assertions maps the name of every failed assertion to the tasks it failed in
+	assertions := make(map[string][]string)
+	for _, suite := range report.Suites {
+		row := summaryRow{name: suite.Name}
+		for _, testCase := range suite.AllTestCases() {
+			result := results[testCase.Name]
+			row.add(testCase)
+			total.add(testCase)
+			row.addUsage(result)
+			total.addUsage(result)
+
+			switch {
+			case testCase.Error != nil:
+				failing = append(failing, []cell{{text: result.TaskName}, {text: suite.Name},
+					{text: "error:", color: ansiRed}, {text: firstLine(result.TaskError, testCase.Error.Message)}})
+			case testCase.Failure != nil:
+				failing = append(failing, []cell{{text: result.TaskName}, {text: suite.Name},
+					{text: "failed:", color: ansiYellow}, {text: strings.Join(result.FailedAssertions(), ", ")}})
+				for _, name := range result.FailedAssertions() {
+					assertions[name] = append(assertions[name], result.TaskName)
+				}
+			}
+		}
+		rows = append(rows, row.cells(usage))
+	}
+	totalRow := total.cells(usage)
+	totalRow[0].color = ansiBold
+	rows = append(rows, totalRow)
+	writeTable(w, rows, color, true)
+
+	if len(failing) == 0 {
+		return nil
+	}
+	header := "Failing tasks:"
+	if color {
+		header = ansiBold + header + ansiReset
+	}
+	fmt.Fprintf(w, "\n%s\n", header)
+	writeTable(w, failing, color, false)
+
+	if len(assertions) == 0 {
+		return nil
+	}
+	header = "Top failing assertions:"
+	if color {
+		header = ansiBold + header + ansiReset
+	}
+	fmt.Fprintf(w, "\n%s\n", header)
+	writeTable(w, topFailingAssertions(assertions), color, false)
+	return nil
+}
+
+// maxTopAssertions bounds the assertions listed under Top failing assertions
+const maxTopAssertions = 10
+
+// topAssertionNames returns the names of the maxTopAssertions assertions
+// that failed in the most tasks, by name when tied
+func topAssertionNames(assertions map[string][]string) []string {
+	names := slices.Collect(maps.Keys(assertions))
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(assertions[b]), len(assertions[a])), strings.Compare(a, b))
+	})
+	return names[:min(len(names), maxTopAssertions)]
+}
+
+// topFailingAssertions returns the table rows of the assertions that failed
+// in the most tasks, with their count and tasks
+func topFailingAssertions(assertions map[string][]string) [][]cell {
+	var rows [][]cell
+	for _, name := range topAssertionNames(assertions) {
+		rows = append(rows, []cell{{text: name}, {text: fmt.Sprint(len(assertions[name])), color: ansiYellow},
+			{text: strings.Join(assertions[name], ", ")}})
+	}
+	return rows
+}
+
+// cells returns the table row of r, colored by outcome, with the usage
+// columns when usage is set
+func (r summaryRow) cells(usage bool) []cell {
+	count := func(n int, color string) cell {
+		if n == 0 {
+			color = ""
+		}
+		return cell{text: fmt.Sprint(n), color: color}
+	}
+	rate := cell{text: fmt.Sprintf("%.1f%%", r.passRate()*100), color: ansiYellow}
+	if r.passed == r.tests-r.skipped {
+		rate.color = ansiGreen
+	} else if r.passed == 0 {
+		rate.color = ansiRed
+	}
+	cells := []cell{{text: r.name}, {text: fmt.Sprint(r.tests)}, count(r.passed, ansiGreen),
+		count(r.failed, ansiYellow), count(r.errored, ansiRed), count(r.skipped, ""), rate}
+	if usage {
+		cells = append(cells, cell{text: fmt.Sprint(r.tokens)}, cell{text: "$" + converter.FormatCost(r.cost)})
+	}
+	return cells
+}
+
+// firstLine returns the first non-empty line of text, or fallback when there is none
+func firstLine(text, fallback string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return fallback
+}
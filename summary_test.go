@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPrintSummary(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"create-pod","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true},
+		{"taskName":"scale","taskPassed":true,"difficulty":"medium","allAssertionsPassed":false,"assertionResults":{"b":{"passed":false},"a":{"passed":false}}},
+		{"taskName":"upgrade-cluster","taskPassed":false,"difficulty":"hard","taskError":"\ntimeout waiting\nmore"},
+		{"taskName":"x","taskPassed":true,"difficulty":"hard","allAssertionsPassed":true},
+		{"taskName":"y","taskSkipped":true,"skipReason":"no sampling support","difficulty":"hard"}
+	]`)
+
+	var out bytes.Buffer
+	if err := printSummary(&out, run, false); err != nil {
+		t.Fatal(err)
+	}
+	want := `  Difficulty  Tests  Passed  Failed  Errors  Skipped  Pass rate
+  easy            1       1       0       0        0     100.0%
+  medium          1       0       1       0        0       0.0%
+  hard            3       1       0       1        1      50.0%
+  Total           5       2       1       1        1      50.0%
+
+Failing tasks:
+  scale            medium  failed:  a, b
+  upgrade-cluster  hard    error:   timeout waiting
+
+Top failing assertions:
+  a  1  scale
+  b  1  scale
+`
+	if out.String() != want {
+		t.Errorf("summary:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	out.Reset()
+	if err := printSummary(&out, run, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"  " + ansiBold + "Difficulty" + ansiReset + "  Tests",
+		"  easy            1       " + ansiGreen + "1" + ansiReset + "       0",
+		ansiRed + "error:" + ansiReset + "   timeout waiting",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("colored summary does not contain %q:\n%s", want, out.String())
+		}
+	}
+}
+
+func TestPrintSummaryUsageAndEnvironment(t *testing.T) {
+	run := mustParse(t, `{"environment":{"model":"gpt-5","temperature":0.2},"results":[
+		{"taskName":"a","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,
+			"tokenUsage":{"prompt":1000,"completion":200,"total":1200},"costUSD":0.012},
+		{"taskName":"b","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,
+			"tokenUsage":{"prompt":300,"completion":50},"costUSD":0.0035},
+		{"taskName":"c","taskPassed":true,"difficulty":"hard","allAssertionsPassed":true}
+	]}`)
+
+	var out bytes.Buffer
+	if err := printSummary(&out, run, false); err != nil {
+		t.Fatal(err)
+	}
+	want := `Environment: model=gpt-5 temperature=0.2
+
+  Difficulty  Tests  Passed  Failed  Errors  Skipped  Pass rate  Tokens     Cost
+  easy            2       2       0       0        0     100.0%    1550  $0.0155
+  hard            1       1       0       0        0     100.0%       0  $0.0000
+  Total           3       3       0       0        0     100.0%    1550  $0.0155
+`
+	if out.String() != want {
+		t.Errorf("summary:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestTopFailingAssertions(t *testing.T) {
+	run := mustParse(t, `[
+		{"taskName":"t1","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false},"logs":{"passed":false}}},
+		{"taskName":"t2","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false},"logs":{"passed":true}}},
+		{"taskName":"t3","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false},"events":{"passed":false}}},
+		{"taskName":"t4","taskPassed":false,"taskError":"boom","assertionResults":{"events":{"passed":false}}}
+	]`)
+
+	var out bytes.Buffer
+	if err := printSummary(&out, run, false); err != nil {
+		t.Fatal(err)
+	}
+	// Assertions of errored tasks are left out, ties are sorted by name
+	want := `
+Top failing assertions:
+  pods-ready  3  t1, t2, t3
+  events      1  t3
+  logs        1  t1
+`
+	if !strings.HasSuffix(out.String(), want) {
+		t.Errorf("summary:\n%s\nwant it to end with:\n%s", out.String(), want)
+	}
+
+	assertions := make(map[string][]string)
+	for i := range maxTopAssertions + 2 {
+		assertions[fmt.Sprintf("a%02d", i)] = []string{"t"}
+	}
+	if rows := topFailingAssertions(assertions); len(rows) != maxTopAssertions || rows[0][0].text != "a00" {
+		t.Errorf("topFailingAssertions returned %d rows starting at %q, want %d starting at a00", len(rows), rows[0][0].text, maxTopAssertions)
+	}
+}
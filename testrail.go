@@ -0,0 +1,186 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultTestRailKeyEnv holds the TestRail API key by default
+const defaultTestRailKeyEnv = "TESTRAIL_API_KEY"
+
+// TestRail result statuses
+const (
+	testRailPassed  = 1
+	testRailBlocked = 2
+	testRailFailed  = 5
+)
+
+// testRailResult is a result of add_results_for_cases
+type testRailResult struct {
+	CaseID   int    `json:"case_id"`
+	StatusID int    `json:"status_id"`
+	Comment  string `json:"comment,omitempty"`
+	Elapsed  string `json:"elapsed,omitempty"`
+}
+
+// publishTestRail implements publish testrail: it posts the outcome of the
+// tasks mapped to TestRail cases to a run, created unless --run-id is given
+func publishTestRail(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	baseURL := fs.String("url", "", "URL of the TestRail instance, e.g. https://example.testrail.io")
+	user := fs.String("user", "", "TestRail user, usually an email address")
+	keyEnv := fs.String("api-key-env", defaultTestRailKeyEnv, "environment variable holding the TestRail API key")
+	mappingFile := fs.String("mapping", "", "YAML file mapping task names to TestRail case IDs, e.g. create-pod: C1042")
+	runID := fs.Int("run-id", 0, "TestRail run to add the results to")
+	projectID := fs.Int("project-id", 0, "TestRail project to create a run in, when --run-id is not given")
+	suiteID := fs.Int("suite-id", 0, "test suite of the created run, for projects with several suites")
+	runName := fs.String("run-name", "", "name of the created run (default the runId or start time of the results)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	switch {
+	case *baseURL == "" || *user == "" || *mappingFile == "":
+		return newUsageError("publish testrail requires --url, --user and --mapping")
+	case (*runID == 0) == (*projectID == 0):
+		return newUsageError("publish testrail requires either --run-id or --project-id")
+	}
+	key, err := secretFromEnv("api-key-env", *keyEnv)
+	if err != nil {
+		return err
+	}
+	mapping, err := loadTaskMapping("mapping", *mappingFile)
+	if err != nil {
+		return err
+	}
+	cases, err := testRailCaseIDs(mapping)
+	if err != nil {
+		return err
+	}
+
+	conv, err := newPublishConverter()
+	if err != nil {
+		return err
+	}
+	run, tests, err := loadPublishedTests(ctx, fs.Args(), opts, conv)
+	if err != nil {
+		return err
+	}
+	results, unmapped := testRailResults(tests, cases)
+	if len(unmapped) > 0 {
+		slog.Warn("tasks without a TestRail case in --mapping are not published", "tasks", strings.Join(unmapped, ", "))
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no task of the results is mapped to a TestRail case")
+	}
+
+	api := testRailAPI{baseURL: strings.TrimSuffix(*baseURL, "/"), header: http.Header{}}
+	api.header.Set("Authorization", "Basic "+basicAuth(*user, key))
+	if *runID == 0 {
+		name := cmp.Or(*runName, run.RunID, run.StartedAt, "mcpchecker "+time.Now().UTC().Format(time.RFC3339))
+		if *runID, err = api.addRun(ctx, *projectID, *suiteID, name, results); err != nil {
+			return err
+		}
+	}
+	if err := api.addResults(ctx, *runID, results); err != nil {
+		return err
+	}
+	slog.Info("published results to TestRail", "run", *runID, "results", len(results))
+	return nil
+}
+
+// testRailCaseIDs parses the case IDs of a --mapping file, with or without
+// their C prefix
+func testRailCaseIDs(mapping map[string]string) (map[string]int, error) {
+	cases := make(map[string]int, len(mapping))
+	for task, value := range mapping {
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "C"))
+		if err != nil || id <= 0 {
+			return nil, newUsageError("invalid --mapping: case ID %q of task %s is not a number like C1042", value, task)
+		}
+		cases[task] = id
+	}
+	return cases, nil
+}
+
+// testRailResults returns the results of the tests mapped to a case:
+// passed, failed, or blocked for errors, with the failure as comment.
+// Skipped tests are left out, and unmapped returns the tasks without a case.
+func testRailResults(tests []publishedTest, cases map[string]int) (results []testRailResult, unmapped []string) {
+	for _, test := range tests {
+		id, ok := cases[test.Result.TaskName]
+		if !ok {
+			unmapped = append(unmapped, test.Result.TaskName)
+			continue
+		}
+		result := testRailResult{CaseID: id, Comment: test.failureComment()}
+		switch test.Outcome {
+		case outcomeSkipped:
+			continue
+		case outcomePassed:
+			result.StatusID = testRailPassed
+		case outcomeError:
+			result.StatusID = testRailBlocked
+		default:
+			result.StatusID = testRailFailed
+		}
+		// TestRail rejects elapsed times under a second
+		if elapsed := test.duration().Round(time.Second); elapsed > 0 {
+			result.Elapsed = fmt.Sprintf("%ds", int(elapsed.Seconds()))
+		}
+		results = append(results, result)
+	}
+	return results, unmapped
+}
+
+// testRailAPI calls the TestRail API v2 of an instance
+type testRailAPI struct {
+	baseURL string
+	header  http.Header
+}
+
+func (api testRailAPI) endpoint(method string, id int) string {
+	return fmt.Sprintf("%s/index.php?/api/v2/%s/%d", api.baseURL, method, id)
+}
+
+// addRun creates a run of the cases of results and returns its ID
+func (api testRailAPI) addRun(ctx context.Context, projectID, suiteID int, name string, results []testRailResult) (int, error) {
+	body := struct {
+		SuiteID    int    `json:"suite_id,omitempty"`
+		Name       string `json:"name"`
+		IncludeAll bool   `json:"include_all"`
+		CaseIDs    []int  `json:"case_ids"`
+	}{SuiteID: suiteID, Name: name}
+	for _, result := range results {
+		body.CaseIDs = append(body.CaseIDs, result.CaseID)
+	}
+	var run struct {
+		ID int `json:"id"`
+	}
+	if err := callAPI(ctx, http.MethodPost, api.endpoint("add_run", projectID), api.header, body, &run); err != nil {
+		return 0, fmt.Errorf("creating TestRail run: %w", err)
+	}
+	return run.ID, nil
+}
+
+// addResults adds results to the run
+func (api testRailAPI) addResults(ctx context.Context, runID int, results []testRailResult) error {
+	body := struct {
+		Results []testRailResult `json:"results"`
+	}{results}
+	if err := callAPI(ctx, http.MethodPost, api.endpoint("add_results_for_cases", runID), api.header, body, nil); err != nil {
+		return fmt.Errorf("adding TestRail results: %w", err)
+	}
+	return nil
+}
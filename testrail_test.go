@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPublishTestRail(t *testing.T) {
+	var requests []string
+	var results []testRailResult
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RawQuery)
+		if user, key, ok := r.BasicAuth(); !ok || user != "qa@example.com" || key != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.RawQuery {
+		case "/api/v2/add_run/3":
+			var body struct {
+				Name    string `json:"name"`
+				CaseIDs []int  `json:"case_ids"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name != "nightly-42" || !reflect.DeepEqual(body.CaseIDs, []int{1, 2, 3}) {
+				t.Errorf("add_run body = %+v, %v", body, err)
+			}
+			w.Write([]byte(`{"id":77}`))
+		case "/api/v2/add_results_for_cases/77":
+			var body struct {
+				Results []testRailResult `json:"results"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Error(err)
+			}
+			results = body.Results
+			w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("TESTRAIL_API_KEY", "secret")
+
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	mapping := write("mapping.yaml", "a: C1\nb: C2\nc: 3\nskipped: C4\n")
+	input := write("results.json", `{"runId":"nightly-42","results":[
+		{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"agentOutput":{"Success":true,"DurationMs":61400}},
+		{"taskName":"b","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false}}},
+		{"taskName":"c","taskPassed":false,"taskError":"boom"},
+		{"taskName":"skipped","taskSkipped":true},
+		{"taskName":"unmapped","taskPassed":true,"allAssertionsPassed":true}
+	]}`)
+
+	err := runCLI(context.Background(), []string{"publish", "testrail", "--url", server.URL + "/", "--user", "qa@example.com",
+		"--mapping", mapping, "--project-id", "3", input})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/api/v2/add_run/3", "/api/v2/add_results_for_cases/77"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %+v, want 3", results)
+	}
+	if results[0] != (testRailResult{CaseID: 1, StatusID: testRailPassed, Elapsed: "61s"}) {
+		t.Errorf("passed result = %+v", results[0])
+	}
+	if results[1].StatusID != testRailFailed || !strings.Contains(results[1].Comment, "pods-ready") {
+		t.Errorf("failed result = %+v", results[1])
+	}
+	if results[2].StatusID != testRailBlocked || !strings.Contains(results[2].Comment, "boom") {
+		t.Errorf("errored result = %+v", results[2])
+	}
+
+	// An existing run only gets the results
+	requests = nil
+	if err := runCLI(context.Background(), []string{"publish", "testrail", "--url", server.URL, "--user", "qa@example.com",
+		"--mapping", mapping, "--run-id", "77", input}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"/api/v2/add_results_for_cases/77"}; !reflect.DeepEqual(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+
+	// API errors are reported with the response
+	err = runCLI(context.Background(), []string{"publish", "testrail", "--url", server.URL, "--user", "other",
+		"--mapping", mapping, "--run-id", "77", input})
+	if err == nil || !strings.Contains(err.Error(), "401 Unauthorized: unauthorized") {
+		t.Errorf("publish with a wrong user error = %v, want the 401 response", err)
+	}
+}
+
+func TestTestRailCaseIDs(t *testing.T) {
+	cases, err := testRailCaseIDs(map[string]string{"a": "C12", "b": " c7 ", "c": "9"})
+	if err != nil || !reflect.DeepEqual(cases, map[string]int{"a": 12, "b": 7, "c": 9}) {
+		t.Errorf("testRailCaseIDs = %v, %v", cases, err)
+	}
+	for _, id := range []string{"", "C", "case-1", "C-1"} {
+		if _, err := testRailCaseIDs(map[string]string{"a": id}); err == nil {
+			t.Errorf("testRailCaseIDs(%q) succeeded, want an error", id)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// knownDifficulties lists the difficulty levels mcpchecker assigns to tasks
+var knownDifficulties = []string{"easy", "medium", "hard"}
+
+// validationProblem is an issue found by the validate command
+type validationProblem struct {
+	// Source is the input the problem was found in
+	Source string
+	// Index is the position of the result in its input, or -1 for problems
+	// that are not tied to one result
+	Index   int
+	Message string
+}
+
+func (p validationProblem) String() string {
+	if p.Index < 0 {
+		return fmt.Sprintf("%s: %s", p.Source, p.Message)
+	}
+	return fmt.Sprintf("%s: result %d: %s", p.Source, p.Index, p.Message)
+}
+
+// runValidate implements the validate command
+func runValidate(ctx context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	inputs := addInputFlags(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	// Collect every problem instead of stopping at the first one
+	opts.Strict, opts.Lenient = true, true
+
+	run, err := loadInputs(ctx, fs.Args(), opts)
+	if err != nil {
+		return err
+	}
+	problems := validateRun(run)
+	printProblems(stdout, run, problems)
+	if len(problems) > 0 {
+		return inputError{err: fmt.Errorf("validation failed: %d problems found", len(problems))}
+	}
+	return nil
+}
+
+// validateRun checks parsed results for the problems the converter tolerates
+// but that usually point at a broken run: malformed or schema-violating
+// entries, empty or duplicate task names, unknown difficulties and missing
+// assertion maps
+func validateRun(run converter.TestRun) []validationProblem {
+	var problems []validationProblem
+	firstSeen := make(map[string]string)
+	positions := make(map[string]int)
+
+	for _, result := range run.Results {
+		source := result.SourceFile
+		index := positions[source]
+		positions[source]++
+
+		add := func(format string, args ...interface{}) {
+			problems = append(problems, validationProblem{Source: source, Index: index, Message: fmt.Sprintf(format, args...)})
+		}
+
+		if result.ParseError() != nil {
+			var schemaErrs converter.SchemaErrors
+			if !errors.As(result.ParseError(), &schemaErrs) {
+				add("%v", result.ParseError())
+				continue
+			}
+			for _, schemaErr := range schemaErrs {
+				add("%s: %s", schemaErr.Path, schemaErr.Message)
+			}
+			continue
+		}
+
+		if result.TaskName == "" {
+			add("task name is empty")
+		} else if first, ok := firstSeen[result.TaskName]; ok {
+			add("duplicate task name %q, first seen in %s", result.TaskName, first)
+		} else {
+			firstSeen[result.TaskName] = fmt.Sprintf("%s result %d", source, index)
+		}
+
+		if result.Difficulty == "" {
+			add("difficulty is empty")
+		} else if !slices.Contains(knownDifficulties, result.Difficulty) {
+			add("unknown difficulty %q, expected one of %s", result.Difficulty, strings.Join(knownDifficulties, ", "))
+		}
+
+		if result.AssertionResults == nil {
+			add("assertionResults is missing")
+		}
+	}
+	return problems
+}
+
+// printProblems writes one line per problem followed by a summary
+func printProblems(w io.Writer, run converter.TestRun, problems []validationProblem) {
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+	if len(problems) == 0 {
+		fmt.Fprintf(w, "OK: %d results, no problems found\n", len(run.Results))
+		return
+	}
+	fmt.Fprintf(w, "%d problems found in %d results\n", len(problems), len(run.Results))
+}
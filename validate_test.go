@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestValidateRun(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	second := filepath.Join(dir, "second.ndjson")
+	files := map[string]string{
+		first: `[
+			{"taskName":"a","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,"assertionResults":{}},
+			{"taskName":"","taskPassed":true,"difficulty":"expert","allAssertionsPassed":true,"assertionResults":{}},
+			{"taskName":"c","taskPassed":"yes","difficulty":"easy","allAssertionsPassed":true,"assertionResults":{}}
+		]`,
+		second: `{"taskName":"a","taskPassed":true,"difficulty":"","allAssertionsPassed":true}` + "\n{bad\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run, err := loadInputs(context.Background(), []string{first, second}, inputOptions{ParseOptions: converter.ParseOptions{Format: converter.FormatAuto, Strict: true, Lenient: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, problem := range validateRun(run) {
+		got = append(got, problem.String())
+	}
+	want := []string{
+		first + `: result 1: task name is empty`,
+		first + `: result 1: unknown difficulty "expert", expected one of easy, medium, hard`,
+		first + `: result 2: $.taskPassed: expected boolean, got string`,
+		second + `: result 0: duplicate task name "a", first seen in ` + first + ` result 0`,
+		second + `: result 0: difficulty is empty`,
+		second + `: result 0: assertionResults is missing`,
+		second + `: result 1: $: invalid character 'b' looking for beginning of object key string`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateCommand(t *testing.T) {
+	var out bytes.Buffer
+	previous := stdout
+	stdout = &out
+	t.Cleanup(func() { stdout = previous })
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(valid, []byte(`[{"taskName":"a","taskPassed":true,"difficulty":"easy","allAssertionsPassed":true,"assertionResults":{}}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(invalid, []byte("["+resultA+"]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := runCLI(context.Background(), []string{"validate", valid}); err != nil {
+		t.Errorf("validate of a valid file error = %v", err)
+	}
+	if !strings.Contains(out.String(), "OK: 1 results, no problems found") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runCLI(context.Background(), []string{"validate", invalid}); err == nil || !strings.Contains(err.Error(), "validation failed: 1 problems found") {
+		t.Errorf("validate of an invalid file error = %v", err)
+	}
+	if !strings.Contains(out.String(), "result 0: assertionResults is missing") {
+		t.Errorf("unexpected output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, injected at build time with
+//
+//	-ldflags "-X main.version=... -X main.commit=... -X main.buildDate=..."
+//
+// Builds without ldflags fall back to the module version and VCS stamp
+// recorded by the Go toolchain.
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// buildInfo describes the running binary
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// currentBuildInfo returns the injected build metadata, completed from the
+// information embedded by the Go toolchain
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if embedded, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && embedded.Main.Version != "" && embedded.Main.Version != "(devel)" {
+			info.Version = embedded.Main.Version
+		}
+		for _, setting := range embedded.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+	return info
+}
+
+func (info buildInfo) String() string {
+	return fmt.Sprintf("%s %s (commit %s, built %s, %s)", programName(), info.Version, info.Commit, info.BuildDate, info.GoVersion)
+}
+
+// printVersion writes the build metadata as text or JSON
+func printVersion(w io.Writer, asJSON bool) error {
+	info := currentBuildInfo()
+	if !asJSON {
+		_, err := fmt.Fprintln(w, info)
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(info)
+}
+
+// runVersion implements the version command
+func runVersion(_ context.Context, cmd *command, args []string) error {
+	fs := cmd.flagSet()
+	asJSON := fs.Bool("json", false, "print the build metadata as JSON")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return printVersion(stdout, *asJSON)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "v1.2.3", "abc1234", "2026-01-02T03:04:05Z"
+
+	var buf bytes.Buffer
+	previous := stdout
+	stdout = &buf
+	defer func() { stdout = previous }()
+
+	tests := []struct {
+		name  string
+		args  []string
+		check func(t *testing.T, out string)
+	}{
+		{
+			name: "--version flag",
+			args: []string{"--version"},
+			check: func(t *testing.T, out string) {
+				if !strings.Contains(out, " v1.2.3 (commit abc1234, built 2026-01-02T03:04:05Z, go") {
+					t.Errorf("unexpected version line %q", out)
+				}
+			},
+		},
+		{
+			name: "version command",
+			args: []string{"version"},
+			check: func(t *testing.T, out string) {
+				if !strings.Contains(out, "v1.2.3") {
+					t.Errorf("unexpected version line %q", out)
+				}
+			},
+		},
+		{
+			name: "version --json",
+			args: []string{"version", "--json"},
+			check: func(t *testing.T, out string) {
+				var info buildInfo
+				if err := json.Unmarshal([]byte(out), &info); err != nil {
+					t.Fatalf("invalid JSON %q: %v", out, err)
+				}
+				if info.Version != "v1.2.3" || info.Commit != "abc1234" || info.BuildDate != "2026-01-02T03:04:05Z" || info.GoVersion == "" {
+					t.Errorf("build info = %+v", info)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			if err := runCLI(context.Background(), tt.args); err != nil {
+				t.Fatalf("runCLI() error = %v", err)
+			}
+			tt.check(t, buf.String())
+		})
+	}
+}
+
+func TestVersionDefaults(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "", "", ""
+
+	info := currentBuildInfo()
+	if info.Version == "" || info.Commit == "" || info.BuildDate == "" {
+		t.Errorf("build info has empty fields: %+v", info)
+	}
+}
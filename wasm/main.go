@@ -0,0 +1,64 @@
+//go:build js && wasm
+
+// Command wasm exposes the converter to JavaScript, so that reports can be
+// built in the browser without a backend. Loading the module defines a
+// global function
+//
+//	convert(results: string): string
+//
+// which takes results in any input format the command line auto-detects and
+// returns the JUnit XML report, or an Error when the input cannot be parsed.
+package main
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func main() {
+	register()
+	// Keep the exported function callable for the lifetime of the page
+	select {}
+}
+
+// register defines the global convert function
+func register() {
+	js.Global().Set("convert", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) != 1 || args[0].Type() != js.TypeString {
+			return jsError("convert expects the results as a single string")
+		}
+		report, err := convert(args[0].String())
+		if err != nil {
+			return jsError(err.Error())
+		}
+		return report
+	}))
+}
+
+// convert turns results into a JUnit XML report with the default options
+func convert(results string) (string, error) {
+	run, err := converter.Parse(strings.NewReader(results), converter.ParseOptions{Format: converter.FormatAuto})
+	if err != nil {
+		return "", err
+	}
+	conv, err := converter.New()
+	if err != nil {
+		return "", err
+	}
+	report, err := conv.Convert(run)
+	if err != nil {
+		return "", err
+	}
+	data, err := conv.Render(report)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// jsError returns a JavaScript Error with message
+func jsError(message string) js.Value {
+	return js.Global().Get("Error").New(message)
+}
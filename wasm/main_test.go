@@ -0,0 +1,42 @@
+//go:build js && wasm
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"syscall/js"
+	"testing"
+
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+func TestConvert(t *testing.T) {
+	report, err := convert(`[{"taskName":"a","taskPassed":true,"allAssertionsPassed":true,"difficulty":"easy"}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(report, "<?xml") || !strings.Contains(report, `<testcase name="a"`) {
+		t.Errorf("convert() =\n%s", report)
+	}
+
+	if _, err := convert(""); !errors.Is(err, converter.ErrEmptyInput) {
+		t.Errorf("convert(\"\") error = %v, want %v", err, converter.ErrEmptyInput)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	register()
+	convertFunc := js.Global().Get("convert")
+
+	report := convertFunc.Invoke(`{"taskName":"a","taskPassed":false,"taskError":"boom"}`)
+	if report.Type() != js.TypeString || !strings.Contains(report.String(), `errors="1"`) {
+		t.Errorf("convert() = %v", report)
+	}
+
+	for _, args := range [][]any{{}, {42}, {"[{"}} {
+		if got := convertFunc.Invoke(args...); !got.InstanceOf(js.Global().Get("Error")) {
+			t.Errorf("convert(%v) = %v, want an Error", args, got)
+		}
+	}
+}
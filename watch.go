@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jrangelramos/mcpchecker-junit-report/converter"
+)
+
+// watchDebounce batches the burst of events that a single append usually produces
+const watchDebounce = 250 * time.Millisecond
+
+// watchAndConvert writes the output report and regenerates it whenever the
+// input file or directory changes, until ctx is done
+func watchAndConvert(ctx context.Context, input, output string, opts inputOptions, conv *converter.Converter) error {
+	info, err := os.Stat(input)
+	if err != nil {
+		return fmt.Errorf("opening file %s: %w", input, err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("starting watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch the parent directory of a single file so that writers replacing
+	// the file by renaming over it are still followed
+	dir := input
+	if !info.IsDir() {
+		dir = filepath.Dir(input)
+	}
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("watching %s: %w", dir, err)
+	}
+
+	relevant := func(name string) bool {
+		if info.IsDir() {
+			return isResultsFile(name)
+		}
+		return filepath.Clean(name) == filepath.Clean(input)
+	}
+
+	regenerate := func() {
+		if _, err := convert(ctx, []string{input}, output, opts, conv); err != nil {
+			// Results are often mid-write, keep the last good report
+			slog.Warn("keeping previous report", "error", err)
+			return
+		}
+		slog.Info("report written", "output", output)
+	}
+
+	regenerate()
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Chmod) || !relevant(event.Name) {
+				continue
+			}
+			debounce.Reset(watchDebounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("watch error", "error", err)
+		case <-debounce.C:
+			regenerate()
+		}
+	}
+}
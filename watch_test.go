@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForReport polls the output until it contains want testcases
+func waitForReport(t *testing.T, output string, want int) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		data, err := os.ReadFile(output)
+		if err == nil && strings.Count(string(data), "<testcase ") == want {
+			return string(data)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	data, _ := os.ReadFile(output)
+	t.Fatalf("report never reached %d testcases:\n%s", want, data)
+	return ""
+}
+
+func TestWatchAndConvert(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results")
+	if err := os.Mkdir(input, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(dir, "report.xml")
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(input, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("1.json", "["+resultA+"]")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- watchAndConvert(ctx, input, output, autoInput, mustNew(t))
+	}()
+
+	waitForReport(t, output, 1)
+
+	// A burst of writes is picked up once the debounce settles
+	write("2.ndjson", resultB+"\n")
+	write("2.ndjson", resultB+"\n"+resultC+"\n")
+	report := waitForReport(t, output, 3)
+	if !strings.Contains(report, `name="c"`) {
+		t.Errorf("report is missing the appended result:\n%s", report)
+	}
+
+	// A half-written file keeps the previous report
+	write("3.json", "["+resultA[:10])
+	time.Sleep(3 * watchDebounce)
+	waitForReport(t, output, 3)
+
+	// Files that are not results are ignored
+	write("notes.txt", "hello")
+	write("3.json", "["+resultA+"]")
+	waitForReport(t, output, 4)
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("watchAndConvert() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watchAndConvert() did not stop after cancellation")
+	}
+}
+
+func TestWatchAndConvertSingleFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.ndjson")
+	output := filepath.Join(dir, "report.xml")
+	if err := os.WriteFile(input, []byte(resultA+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchAndConvert(ctx, input, output, autoInput, mustNew(t))
+
+	waitForReport(t, output, 1)
+
+	// Replacing the file by renaming over it is still followed
+	tmp := filepath.Join(dir, "results.tmp")
+	if err := os.WriteFile(tmp, []byte(resultA+"\n"+resultB+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, input); err != nil {
+		t.Fatal(err)
+	}
+	waitForReport(t, output, 2)
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Environment variables holding the Xray Cloud API key by default
+const (
+	defaultXrayClientIDEnv     = "XRAY_CLIENT_ID"
+	defaultXrayClientSecretEnv = "XRAY_CLIENT_SECRET"
+)
+
+// Xray test run statuses
+const (
+	xrayPassed = "PASSED"
+	xrayFailed = "FAILED"
+	xrayTodo   = "TODO"
+)
+
+// jiraKeyPattern matches a Jira issue key, e.g. PROJ-123
+var jiraKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]+-[1-9][0-9]*$`)
+
+// xrayImport is the Xray JSON format of a test execution
+type xrayImport struct {
+	TestExecutionKey string     `json:"testExecutionKey,omitempty"`
+	Info             *xrayInfo  `json:"info,omitempty"`
+	Tests            []xrayTest `json:"tests"`
+}
+
+// xrayInfo describes the test execution created by an import
+type xrayInfo struct {
+	Project     string `json:"project,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+	StartDate   string `json:"startDate,omitempty"`
+	TestPlanKey string `json:"testPlanKey,omitempty"`
+}
+
+// xrayTest is the run of one test of an execution
+type xrayTest struct {
+	TestKey string `json:"testKey"`
+	Status  string `json:"status"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// publishXray implements publish xray: it writes the outcome of the tasks
+// with a Jira test key in the Xray JSON format, or imports it into Xray Cloud
+func publishXray(ctx context.Context, fs *flag.FlagSet, args []string) error {
+	inputs := addInputFlags(fs)
+	mappingFile := fs.String("mapping", "", "YAML file mapping task names to Jira test keys, e.g. create-pod: PROJ-123, for tasks without a key as tag")
+	project := fs.String("project", "", "Jira project of the created test execution")
+	execution := fs.String("test-execution", "", "existing test execution to add the results to, e.g. PROJ-900")
+	testPlan := fs.String("test-plan", "", "test plan to link the created test execution to")
+	summary := fs.String("summary", "", "summary of the created test execution (default the runId or start time of the results)")
+	output := fs.String("output", "", "write the import to this file, or an s3:// or gs:// URI, instead of stdout")
+	baseURL := fs.String("url", "", "import into this Xray Cloud API, e.g. https://xray.cloud.getxray.app, instead of writing the import")
+	idEnv := fs.String("client-id-env", defaultXrayClientIDEnv, "environment variable holding the Xray Cloud client ID")
+	secretEnv := fs.String("client-secret-env", defaultXrayClientSecretEnv, "environment variable holding the Xray Cloud client secret")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	opts, err := inputs.options()
+	if err != nil {
+		return err
+	}
+	switch {
+	case *project == "" && *execution == "":
+		return newUsageError("publish xray requires --project or --test-execution")
+	case *output != "" && *baseURL != "":
+		return newUsageError("--output and --url cannot be combined")
+	}
+	mapping := map[string]string{}
+	if *mappingFile != "" {
+		if mapping, err = loadTaskMapping("mapping", *mappingFile); err != nil {
+			return err
+		}
+	}
+
+	conv, err := newPublishConverter()
+	if err != nil {
+		return err
+	}
+	run, tests, err := loadPublishedTests(ctx, fs.Args(), opts, conv)
+	if err != nil {
+		return err
+	}
+	xrayTests, unmapped := xrayTestRuns(tests, mapping)
+	if len(unmapped) > 0 {
+		slog.Warn("tasks without a Jira test key are not published", "tasks", strings.Join(unmapped, ", "))
+	}
+	if len(xrayTests) == 0 {
+		return fmt.Errorf("no task of the results has a Jira test key")
+	}
+	doc := xrayImport{TestExecutionKey: *execution, Tests: xrayTests}
+	if *execution == "" {
+		doc.Info = &xrayInfo{
+			Project:     *project,
+			Summary:     cmp.Or(*summary, run.RunID, run.StartedAt, "MCP checker results"),
+			StartDate:   run.StartedAt,
+			TestPlanKey: *testPlan,
+		}
+	}
+
+	if *baseURL == "" {
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeOutput(ctx, *output, append(data, '\n'))
+	}
+	clientID, err := secretFromEnv("client-id-env", *idEnv)
+	if err != nil {
+		return err
+	}
+	clientSecret, err := secretFromEnv("client-secret-env", *secretEnv)
+	if err != nil {
+		return err
+	}
+	key, err := importXray(ctx, strings.TrimSuffix(*baseURL, "/"), clientID, clientSecret, doc)
+	if err != nil {
+		return err
+	}
+	slog.Info("imported results into Xray", "testExecution", key, "tests", len(xrayTests))
+	return nil
+}
+
+// xrayTestRuns returns the test runs of the tests with a Jira key, from
+// mapping or else their first tag that is a Jira key: passed, failed for
+// failures and errors, with the failure as comment, or to do when skipped.
+// unmapped returns the tasks without a key.
+func xrayTestRuns(tests []publishedTest, mapping map[string]string) (runs []xrayTest, unmapped []string) {
+	for _, test := range tests {
+		key := mapping[test.Result.TaskName]
+		if key == "" {
+			if i := slices.IndexFunc(test.Result.Tags, jiraKeyPattern.MatchString); i >= 0 {
+				key = test.Result.Tags[i]
+			}
+		}
+		if key == "" {
+			unmapped = append(unmapped, test.Result.TaskName)
+			continue
+		}
+		run := xrayTest{TestKey: key, Status: xrayFailed, Comment: test.failureComment()}
+		switch test.Outcome {
+		case outcomePassed:
+			run.Status = xrayPassed
+		case outcomeSkipped:
+			run.Status = xrayTodo
+			run.Comment = test.TestCase.Skipped.Message
+		}
+		runs = append(runs, run)
+	}
+	return runs, unmapped
+}
+
+// importXray authenticates with the client credentials of an Xray Cloud
+// API key, imports doc and returns the key of its test execution
+func importXray(ctx context.Context, baseURL, clientID, clientSecret string, doc xrayImport) (string, error) {
+	credentials := map[string]string{"client_id": clientID, "client_secret": clientSecret}
+	var token string
+	if err := callAPI(ctx, http.MethodPost, baseURL+"/api/v2/authenticate", nil, credentials, &token); err != nil {
+		return "", fmt.Errorf("authenticating with Xray: %w", err)
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	var execution struct {
+		Key string `json:"key"`
+	}
+	if err := callAPI(ctx, http.MethodPost, baseURL+"/api/v2/import/execution", header, doc, &execution); err != nil {
+		return "", fmt.Errorf("importing into Xray: %w", err)
+	}
+	return execution.Key, nil
+}
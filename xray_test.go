@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const xrayResults = `{"runId":"nightly-42","startedAt":"2026-01-02T03:04:05Z","results":[
+	{"taskName":"a","tags":["smoke","PROJ-1"],"taskPassed":true,"allAssertionsPassed":true},
+	{"taskName":"b","taskPassed":true,"allAssertionsPassed":false,"assertionResults":{"pods-ready":{"passed":false}}},
+	{"taskName":"c","tags":["PROJ-3"],"taskSkipped":true,"skipReason":"no cluster"},
+	{"taskName":"unmapped","tags":["smoke"],"taskPassed":true,"allAssertionsPassed":true}
+]}`
+
+func TestPublishXray(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "results.json")
+	mapping := filepath.Join(dir, "mapping.yaml")
+	if err := os.WriteFile(input, []byte(xrayResults), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(mapping, []byte("b: PROJ-2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(dir, "xray.json")
+	if err := runCLI(context.Background(), []string{"publish", "xray", "--project", "PROJ", "--test-plan", "PROJ-100",
+		"--mapping", mapping, "--output", output, input}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc xrayImport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	wantInfo := &xrayInfo{Project: "PROJ", Summary: "nightly-42", StartDate: "2026-01-02T03:04:05Z", TestPlanKey: "PROJ-100"}
+	if !reflect.DeepEqual(doc.Info, wantInfo) || doc.TestExecutionKey != "" {
+		t.Errorf("info = %+v, execution = %q, want %+v", doc.Info, doc.TestExecutionKey, wantInfo)
+	}
+	if len(doc.Tests) != 3 {
+		t.Fatalf("tests = %+v, want 3", doc.Tests)
+	}
+	if doc.Tests[0] != (xrayTest{TestKey: "PROJ-1", Status: xrayPassed}) {
+		t.Errorf("tagged test = %+v", doc.Tests[0])
+	}
+	if doc.Tests[1].TestKey != "PROJ-2" || doc.Tests[1].Status != xrayFailed || !strings.Contains(doc.Tests[1].Comment, "pods-ready") {
+		t.Errorf("mapped test = %+v", doc.Tests[1])
+	}
+	if doc.Tests[2] != (xrayTest{TestKey: "PROJ-3", Status: xrayTodo, Comment: "no cluster"}) {
+		t.Errorf("skipped test = %+v", doc.Tests[2])
+	}
+}
+
+func TestImportXray(t *testing.T) {
+	var imported xrayImport
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2/authenticate":
+			var credentials map[string]string
+			json.NewDecoder(r.Body).Decode(&credentials)
+			if credentials["client_id"] != "id" || credentials["client_secret"] != "secret" {
+				http.Error(w, `{"error":"bad credentials"}`, http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`"t0ken"`))
+		case "/api/v2/import/execution":
+			if r.Header.Get("Authorization") != "Bearer t0ken" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			json.NewDecoder(r.Body).Decode(&imported)
+			w.Write([]byte(`{"id":"1","key":"PROJ-901","self":"https://example.atlassian.net/rest/api/2/issue/1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("XRAY_CLIENT_ID", "id")
+	t.Setenv("XRAY_CLIENT_SECRET", "secret")
+
+	input := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(input, []byte(xrayResults), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runCLI(context.Background(), []string{"publish", "xray", "--test-execution", "PROJ-900", "--url", server.URL, input}); err != nil {
+		t.Fatal(err)
+	}
+	if imported.TestExecutionKey != "PROJ-900" || imported.Info != nil || len(imported.Tests) != 2 {
+		t.Errorf("imported = %+v, want the 2 tagged tests added to PROJ-900", imported)
+	}
+
+	t.Setenv("XRAY_CLIENT_SECRET", "wrong")
+	err := runCLI(context.Background(), []string{"publish", "xray", "--test-execution", "PROJ-900", "--url", server.URL, input})
+	if err == nil || !strings.Contains(err.Error(), "bad credentials") {
+		t.Errorf("import with a wrong secret error = %v, want the authentication error", err)
+	}
+}